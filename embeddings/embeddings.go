@@ -0,0 +1,141 @@
+// Package embeddings provides utilities for preparing embedding vectors for
+// storage: normalization, Matryoshka truncation and int8 quantization.
+package embeddings
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrDimensionMismatch is returned when vectors of different lengths are
+// compared
+var ErrDimensionMismatch = errors.New("embeddings: vectors have different dimensions")
+
+// Normalize returns a copy of v scaled to unit L2 norm. A zero vector is
+// returned unchanged.
+func Normalize(v []float32) []float32 {
+	out := make([]float32, len(v))
+	norm := Norm(v)
+	if norm == 0 {
+		copy(out, v)
+		return out
+	}
+
+	for i, x := range v {
+		out[i] = float32(float64(x) / norm)
+	}
+	return out
+}
+
+// Norm returns the L2 norm of v
+func Norm(v []float32) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
+
+// CosineSimilarity returns the cosine of the angle between a and b, or 0 if
+// either is a zero vector
+func CosineSimilarity(a, b []float32) (float64, error) {
+	if len(a) != len(b) {
+		return 0, ErrDimensionMismatch
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0, nil
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB)), nil
+}
+
+// Truncate shortens a Matryoshka embedding to its first dims dimensions and
+// renormalizes it. Only models trained with Matryoshka representation
+// learning (for example OpenAI's text-embedding-3 family) keep their quality
+// when truncated this way.
+func Truncate(v []float32, dims int) ([]float32, error) {
+	if dims <= 0 || dims > len(v) {
+		return nil, fmt.Errorf("embeddings: cannot truncate %d dimensions to %d", len(v), dims)
+	}
+	return Normalize(v[:dims]), nil
+}
+
+// Int8Vector is an embedding quantized to int8. Each value approximates the
+// original value divided by Scale.
+type Int8Vector struct {
+	Values []int8
+	Scale  float32
+}
+
+// QuantizationLoss estimates the accuracy lost by quantizing a vector
+type QuantizationLoss struct {
+	// MaxAbsError is the largest absolute difference between an original
+	// value and its dequantized counterpart
+	MaxAbsError float64
+
+	// RMSE is the root-mean-square error over all values
+	RMSE float64
+
+	// CosineSimilarity between the original and the dequantized vector;
+	// 1 means no loss in direction (0 for a zero vector)
+	CosineSimilarity float64
+}
+
+// Quantize converts v to int8 using symmetric scalar quantization, mapping
+// the largest absolute value to ±127, and reports the resulting loss
+func Quantize(v []float32) (Int8Vector, QuantizationLoss) {
+	var maxAbs float64
+	for _, x := range v {
+		maxAbs = math.Max(maxAbs, math.Abs(float64(x)))
+	}
+
+	q := Int8Vector{Values: make([]int8, len(v))}
+	if maxAbs > 0 {
+		q.Scale = float32(maxAbs / math.MaxInt8)
+		for i, x := range v {
+			q.Values[i] = int8(math.Round(float64(x) / float64(q.Scale)))
+		}
+	}
+
+	loss, _ := Loss(v, q)
+	return q, loss
+}
+
+// Dequantize converts q back to float32 values
+func (q Int8Vector) Dequantize() []float32 {
+	out := make([]float32, len(q.Values))
+	for i, x := range q.Values {
+		out[i] = float32(x) * q.Scale
+	}
+	return out
+}
+
+// Loss measures how closely q approximates original
+func Loss(original []float32, q Int8Vector) (QuantizationLoss, error) {
+	if len(original) != len(q.Values) {
+		return QuantizationLoss{}, ErrDimensionMismatch
+	}
+
+	restored := q.Dequantize()
+
+	var loss QuantizationLoss
+	var sumSquares float64
+	for i := range original {
+		diff := math.Abs(float64(original[i]) - float64(restored[i]))
+		loss.MaxAbsError = math.Max(loss.MaxAbsError, diff)
+		sumSquares += diff * diff
+	}
+	if len(original) > 0 {
+		loss.RMSE = math.Sqrt(sumSquares / float64(len(original)))
+	}
+
+	loss.CosineSimilarity, _ = CosineSimilarity(original, restored)
+	return loss, nil
+}
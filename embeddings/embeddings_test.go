@@ -0,0 +1,129 @@
+package embeddings
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func approxEqual(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		v    []float32
+		want []float32
+	}{
+		{name: "3-4-5", v: []float32{3, 4}, want: []float32{0.6, 0.8}},
+		{name: "already unit", v: []float32{0, 1, 0}, want: []float32{0, 1, 0}},
+		{name: "zero vector", v: []float32{0, 0}, want: []float32{0, 0}},
+		{name: "empty", v: []float32{}, want: []float32{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Normalize(tt.v)
+			if len(got) != len(tt.want) {
+				t.Fatalf("len = %d, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if !approxEqual(float64(got[i]), float64(tt.want[i]), 1e-6) {
+					t.Errorf("Normalize()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    []float32
+		want    float64
+		wantErr error
+	}{
+		{name: "identical", a: []float32{1, 2, 3}, b: []float32{1, 2, 3}, want: 1},
+		{name: "scaled", a: []float32{1, 2}, b: []float32{2, 4}, want: 1},
+		{name: "orthogonal", a: []float32{1, 0}, b: []float32{0, 1}, want: 0},
+		{name: "opposite", a: []float32{1, 0}, b: []float32{-1, 0}, want: -1},
+		{name: "zero vector", a: []float32{0, 0}, b: []float32{1, 0}, want: 0},
+		{name: "mismatch", a: []float32{1}, b: []float32{1, 0}, wantErr: ErrDimensionMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CosineSimilarity(tt.a, tt.b)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CosineSimilarity() error = %v, want %v", err, tt.wantErr)
+			}
+			if !approxEqual(got, tt.want, 1e-9) {
+				t.Errorf("CosineSimilarity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	got, err := Truncate([]float32{3, 4, 12}, 2)
+	if err != nil {
+		t.Fatalf("Truncate() error = %v", err)
+	}
+	if len(got) != 2 || !approxEqual(float64(got[0]), 0.6, 1e-6) || !approxEqual(float64(got[1]), 0.8, 1e-6) {
+		t.Errorf("Truncate() = %v, want [0.6 0.8]", got)
+	}
+
+	for _, dims := range []int{0, -1, 4} {
+		if _, err := Truncate([]float32{3, 4, 12}, dims); err == nil {
+			t.Errorf("Truncate(%d) error = nil, want error", dims)
+		}
+	}
+}
+
+func TestQuantize(t *testing.T) {
+	v := []float32{0.5, -0.25, 0.125, -1, 0.333}
+
+	q, loss := Quantize(v)
+
+	if q.Values[3] != -127 {
+		t.Errorf("largest magnitude quantized to %d, want -127", q.Values[3])
+	}
+	if !approxEqual(float64(q.Scale), 1.0/127, 1e-9) {
+		t.Errorf("Scale = %v, want 1/127", q.Scale)
+	}
+
+	restored := q.Dequantize()
+	for i := range v {
+		if !approxEqual(float64(restored[i]), float64(v[i]), float64(q.Scale)/2+1e-7) {
+			t.Errorf("Dequantize()[%d] = %v, want %v within half a step", i, restored[i], v[i])
+		}
+	}
+
+	if loss.MaxAbsError > float64(q.Scale)/2+1e-7 {
+		t.Errorf("MaxAbsError = %v, want at most half a step", loss.MaxAbsError)
+	}
+	if loss.RMSE <= 0 || loss.RMSE > loss.MaxAbsError {
+		t.Errorf("RMSE = %v, want in (0, MaxAbsError]", loss.RMSE)
+	}
+	if loss.CosineSimilarity < 0.9999 {
+		t.Errorf("CosineSimilarity = %v, want close to 1", loss.CosineSimilarity)
+	}
+}
+
+func TestQuantize_ZeroVector(t *testing.T) {
+	q, loss := Quantize([]float32{0, 0, 0})
+	if q.Scale != 0 || len(q.Values) != 3 {
+		t.Errorf("Quantize() = %+v, want three zero values with zero scale", q)
+	}
+	if loss.MaxAbsError != 0 || loss.RMSE != 0 {
+		t.Errorf("loss = %+v, want no error", loss)
+	}
+}
+
+func TestLoss_Mismatch(t *testing.T) {
+	q, _ := Quantize([]float32{1, 2})
+	if _, err := Loss([]float32{1}, q); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Loss() error = %v, want ErrDimensionMismatch", err)
+	}
+}
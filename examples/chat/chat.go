@@ -100,11 +100,12 @@ func runCustomizedCompletion(client *llm.OpenAIClient) error {
 	defer cancel()
 
 	fmt.Println("\nCustomized Completion Example:")
+	temperature := float32(0.8) // Higher temperature for more creative responses
 	resp, err := client.Complete(ctx, &llm.CompletionRequest{
 		Model:       "gpt-4",
 		Prompt:      "Generate a creative name for a tech startup.",
-		MaxTokens:   20,            // Limit response length
-		Temperature: 0.8,           // Higher temperature for more creative responses
+		MaxTokens:   20, // Limit response length
+		Temperature: &temperature,
 		Stop:        []string{"."}, // Stop at the first period
 	})
 	if err != nil {
@@ -4,135 +4,61 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
-	"strings"
 	"testing"
 
-	"github.com/aiwizzard/gollm/llm"
+	"github.com/aiwizzard/gollm/llmtest"
 )
 
 func TestRunBasicCompletion(t *testing.T) {
-	// Create a mock server
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Verify request
-		if r.Method != http.MethodPost {
-			t.Errorf("Method = %v, want POST", r.Method)
-		}
-		if !strings.HasSuffix(r.URL.Path, "/chat/completions") {
-			t.Errorf("Path = %v, want /chat/completions", r.URL.Path)
-		}
-
-		// Return a mock response
-		w.Write([]byte(`{
-			"choices": [
-				{
-					"message": {
-						"content": "Quantum computing uses quantum mechanics to perform complex calculations exponentially faster than classical computers."
-					},
-					"finish_reason": "stop"
-				}
-			],
-			"model": "gpt-4"
-		}`))
-	}))
-	defer server.Close()
-
-	// Create a client with the mock server
-	client := llm.NewOpenAIClient(llm.OpenAIConfig{
-		APIKey:  "test-key",
-		BaseURL: server.URL,
+	server := llmtest.NewServer(llmtest.Response{
+		Content: "Quantum computing uses quantum mechanics to perform complex calculations exponentially faster than classical computers.",
 	})
+	defer server.Close()
 
 	// Run the example
-	err := runBasicCompletion(client)
+	err := runBasicCompletion(server.Client())
 	if err != nil {
 		t.Errorf("runBasicCompletion() error = %v", err)
 	}
 }
 
 func TestRunStreamingCompletion(t *testing.T) {
-	// Create a mock server
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Verify request
-		if r.Method != http.MethodPost {
-			t.Errorf("Method = %v, want POST", r.Method)
-		}
-		if !strings.HasSuffix(r.URL.Path, "/chat/completions") {
-			t.Errorf("Path = %v, want /chat/completions", r.URL.Path)
-		}
-
-		// Return a mock streaming response
-		flusher, ok := w.(http.Flusher)
-		if !ok {
-			t.Fatal("Streaming not supported")
-		}
-
-		responses := []string{
-			"data: {\"choices\":[{\"delta\":{\"content\":\"Code flows like water\"}}]}\n\n",
-			"data: {\"choices\":[{\"delta\":{\"content\":\"\\nBugs hide in shadows\"}}]}\n\n",
-			"data: {\"choices\":[{\"delta\":{\"content\":\"\\nDebugger brings light\"}}]}\n\n",
-			"data: [DONE]\n\n",
-		}
-
-		for _, resp := range responses {
-			_, err := w.Write([]byte(resp))
-			if err != nil {
-				t.Fatal(err)
-			}
-			flusher.Flush()
-		}
-	}))
-	defer server.Close()
-
-	// Create a client with the mock server
-	client := llm.NewOpenAIClient(llm.OpenAIConfig{
-		APIKey:  "test-key",
-		BaseURL: server.URL,
+	server := llmtest.NewServer(llmtest.Response{
+		Chunks: []string{
+			"Code flows like water",
+			"\nBugs hide in shadows",
+			"\nDebugger brings light",
+		},
 	})
+	defer server.Close()
 
 	// Run the example
-	err := runStreamingCompletion(client)
+	err := runStreamingCompletion(server.Client())
 	if err != nil {
 		t.Errorf("runStreamingCompletion() error = %v", err)
 	}
+
+	if req, ok := server.LastRequest(); !ok || !req.Stream {
+		t.Errorf("LastRequest() = %+v, want a streaming request", req)
+	}
 }
 
 func TestRunCustomizedCompletion(t *testing.T) {
-	// Create a mock server
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Verify request
-		if r.Method != http.MethodPost {
-			t.Errorf("Method = %v, want POST", r.Method)
-		}
-		if !strings.HasSuffix(r.URL.Path, "/chat/completions") {
-			t.Errorf("Path = %v, want /chat/completions", r.URL.Path)
-		}
-
-		// Return a mock response
-		w.Write([]byte(`{
-			"choices": [
-				{
-					"message": {
-						"content": "QuantumLeap"
-					},
-					"finish_reason": "stop"
-				}
-			],
-			"model": "gpt-4"
-		}`))
-	}))
-	defer server.Close()
-
-	// Create a client with the mock server
-	client := llm.NewOpenAIClient(llm.OpenAIConfig{
-		APIKey:  "test-key",
-		BaseURL: server.URL,
+	server := llmtest.NewServer(llmtest.Response{
+		Content: "QuantumLeap",
 	})
+	defer server.Close()
 
 	// Run the example
-	err := runCustomizedCompletion(client)
+	err := runCustomizedCompletion(server.Client())
 	if err != nil {
 		t.Errorf("runCustomizedCompletion() error = %v", err)
 	}
+
+	req, _ := server.LastRequest()
+	if req.MaxTokens != 20 || req.Temperature != 0.8 {
+		t.Errorf("MaxTokens = %v, Temperature = %v, want 20 and 0.8", req.MaxTokens, req.Temperature)
+	}
 }
 
 func TestRunExample(t *testing.T) {
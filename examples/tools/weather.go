@@ -2,19 +2,19 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"time"
 
 	"github.com/aiwizzard/gollm/llm"
+	"github.com/aiwizzard/gollm/tools"
 )
 
 // WeatherParams represents the parameters for the getWeather function
 type WeatherParams struct {
-	Location string `json:"location"`
-	Unit     string `json:"unit"`
+	Location string `json:"location" description:"The city and state, e.g., San Francisco, CA"`
+	Unit     string `json:"unit" enum:"C,F"`
 }
 
 // GetWeather simulates getting weather data
@@ -23,6 +23,16 @@ func GetWeather(location, unit string) string {
 	return fmt.Sprintf("The weather in %s is 22°%s", location, unit)
 }
 
+// weatherTool lets the model call GetWeather; its parameter schema is
+// derived from WeatherParams and the arguments are decoded into it
+var weatherTool = tools.New("get_weather", "Get the current weather in a given location",
+	func(ctx context.Context, params WeatherParams) (string, error) {
+		return GetWeather(params.Location, params.Unit), nil
+	})
+
+// registry holds the tools the model may call
+var registry = tools.NewRegistry(weatherTool)
+
 // RunExample demonstrates how to use tools with the LLM
 func RunExample() error {
 	// Get API key from environment variable
@@ -43,33 +53,13 @@ func RunExample() error {
 	})
 
 	// Define the tool (function) that the model can use
-	weatherTool := llm.Tool{
-		Type: "function",
-		Function: llm.Function{
-			Name:        "get_weather",
-			Description: "Get the current weather in a given location",
-			Parameters: map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"location": map[string]interface{}{
-						"type":        "string",
-						"description": "The city and state, e.g., San Francisco, CA",
-					},
-					"unit": map[string]interface{}{
-						"type": "string",
-						"enum": []string{"C", "F"},
-					},
-				},
-				"required": []string{"location", "unit"},
-			},
-		},
-	}
+	definition := weatherTool.Definition()
 
-	if err := runNonStreamingExample(client, weatherTool); err != nil {
+	if err := runNonStreamingExample(client, definition); err != nil {
 		return fmt.Errorf("non-streaming example failed: %w", err)
 	}
 
-	if err := runStreamingExample(client, weatherTool); err != nil {
+	if err := runStreamingExample(client, definition); err != nil {
 		return fmt.Errorf("streaming example failed: %w", err)
 	}
 
@@ -80,40 +70,29 @@ func runNonStreamingExample(client *llm.OpenAIClient, weatherTool llm.Tool) erro
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// Make a request that will trigger the tool
-	resp, err := client.Complete(ctx, &llm.CompletionRequest{
-		Model:  "gpt-4",
-		Prompt: "What's the weather like in London? Please use Celsius.",
-		Tools:  []llm.Tool{weatherTool},
+	// The session keeps the conversation so the model sees its own tool calls
+	// alongside their results
+	session := llm.NewChatSession(client, llm.CompletionRequest{
+		Model: "gpt-4",
+		Tools: []llm.Tool{weatherTool},
 	})
+
+	// Make a request that will trigger the tool
+	resp, err := session.Send(ctx, "What's the weather like in London? Please use Celsius.")
 	if err != nil {
 		return fmt.Errorf("completion request failed: %w", err)
 	}
 
 	// Handle tool calls if any
 	if len(resp.ToolCalls) > 0 {
-		// Process each tool call
-		for _, call := range resp.ToolCalls {
-			if call.Function.Name == "get_weather" {
-				// Parse the arguments
-				var params WeatherParams
-				if err := json.Unmarshal([]byte(call.Function.Arguments), &params); err != nil {
-					return fmt.Errorf("failed to parse weather parameters: %w", err)
-				}
-
-				// Call the actual function
-				result := GetWeather(params.Location, params.Unit)
-
-				// Send the result back to the model
-				resp, err = client.Complete(ctx, &llm.CompletionRequest{
-					Model: "gpt-4",
-					Prompt: fmt.Sprintf("The weather function returned: %s. "+
-						"Please provide a natural response to the user's question.", result),
-				})
-				if err != nil {
-					return fmt.Errorf("follow-up completion request failed: %w", err)
-				}
-			}
+		if err := handleToolCalls(ctx, session, resp.ToolCalls); err != nil {
+			return err
+		}
+
+		// Send the results back to the model
+		resp, err = session.Send(ctx, "")
+		if err != nil {
+			return fmt.Errorf("follow-up completion request failed: %w", err)
 		}
 	}
 
@@ -125,73 +104,56 @@ func runStreamingExample(client *llm.OpenAIClient, weatherTool llm.Tool) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	fmt.Println("\nStreaming example:")
-	stream, err := client.CompleteStream(ctx, &llm.CompletionRequest{
-		Model:  "gpt-4",
-		Prompt: "What's the weather like in Paris? Please use Fahrenheit.",
-		Tools:  []llm.Tool{weatherTool},
+	session := llm.NewChatSession(client, llm.CompletionRequest{
+		Model: "gpt-4",
+		Tools: []llm.Tool{weatherTool},
 	})
+
+	fmt.Println("\nStreaming example:")
+	if err := printStream(session.SendStream(ctx, "What's the weather like in Paris? Please use Fahrenheit.")); err != nil {
+		return err
+	}
+
+	// The session has assembled the streamed tool calls into the reply
+	history := session.Messages()
+	toolCalls := history[len(history)-1].ToolCalls
+	if len(toolCalls) == 0 {
+		return nil
+	}
+
+	if err := handleToolCalls(ctx, session, toolCalls); err != nil {
+		return err
+	}
+	return printStream(session.SendStream(ctx, ""))
+}
+
+// handleToolCalls runs the requested tools concurrently and adds their
+// results to the session, to be sent back in a single follow-up turn
+func handleToolCalls(ctx context.Context, session *llm.ChatSession, toolCalls []llm.ToolCall) error {
+	results := registry.CallAll(ctx, toolCalls, 4)
+	session.AddMessages(tools.Messages(results)...)
+	return nil
+}
+
+// printStream prints the content of a streaming response as it arrives
+func printStream(stream llm.CompletionStream, err error) error {
 	if err != nil {
 		return fmt.Errorf("streaming request failed: %w", err)
 	}
 	defer stream.Close()
 
-	// Process the streaming response
-	var toolCalls []llm.ToolCall
 	for {
 		chunk, err := stream.Recv()
 		if err != nil {
 			if err == io.EOF {
-				break
+				return nil
 			}
 			return fmt.Errorf("error receiving stream: %w", err)
 		}
 
-		// Accumulate tool calls
-		if len(chunk.ToolCalls) > 0 {
-			toolCalls = append(toolCalls, chunk.ToolCalls...)
-		}
-
 		// Print content if any
 		if chunk.Content != "" {
 			fmt.Print(chunk.Content)
 		}
 	}
-
-	// Handle tool calls from streaming response
-	if len(toolCalls) > 0 {
-		for _, call := range toolCalls {
-			if call.Function.Name == "get_weather" {
-				var params WeatherParams
-				if err := json.Unmarshal([]byte(call.Function.Arguments), &params); err != nil {
-					return fmt.Errorf("failed to parse weather parameters: %w", err)
-				}
-
-				result := GetWeather(params.Location, params.Unit)
-
-				stream, err = client.CompleteStream(ctx, &llm.CompletionRequest{
-					Model: "gpt-4",
-					Prompt: fmt.Sprintf("The weather function returned: %s. "+
-						"Please provide a natural response to the user's question.", result),
-				})
-				if err != nil {
-					return fmt.Errorf("follow-up streaming request failed: %w", err)
-				}
-				defer stream.Close()
-
-				for {
-					chunk, err := stream.Recv()
-					if err != nil {
-						if err == io.EOF {
-							break
-						}
-						return fmt.Errorf("error receiving stream: %w", err)
-					}
-					fmt.Print(chunk.Content)
-				}
-			}
-		}
-	}
-
-	return nil
 }
@@ -84,12 +84,13 @@ func TestRunExample(t *testing.T) {
 					// Parse the request to check if it's the first or second call
 					var req struct {
 						Messages []struct {
+							Role    string `json:"role"`
 							Content string `json:"content"`
 						} `json:"messages"`
 					}
 					json.NewDecoder(r.Body).Decode(&req)
 
-					if strings.Contains(req.Messages[0].Content, "weather function returned") {
+					if req.Messages[len(req.Messages)-1].Role == "tool" {
 						// Second call - return final response
 						w.Write([]byte(`{
 							"choices": [
@@ -0,0 +1,166 @@
+// Package feedback records user ratings and corrections for LLM responses,
+// keyed by the provider-assigned CompletionResponse.ID.
+package feedback
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrMissingResponseID is returned when feedback is recorded without the ID
+// of the response it refers to
+var ErrMissingResponseID = errors.New("feedback: response ID is required")
+
+// ErrEmptyFilter is returned when Purge is called with a filter selecting
+// every entry without setting Filter.All
+var ErrEmptyFilter = errors.New("feedback: purge filter is empty, set All to purge every entry")
+
+// Feedback is a single user judgement about a response
+type Feedback struct {
+	// ResponseID is the CompletionResponse.ID the feedback refers to
+	ResponseID string `json:"response_id"`
+
+	// Rating is an application-defined score, e.g. +1/-1 for thumbs up/down
+	Rating int `json:"rating"`
+
+	// Correction is the answer the user would have expected (optional)
+	Correction string `json:"correction,omitempty"`
+
+	// Comment is free-form text left by the user (optional)
+	Comment string `json:"comment,omitempty"`
+
+	// Metadata holds application data such as user or session IDs (optional)
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// CreatedAt is when the feedback was recorded (defaults to now)
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Filter selects feedback entries in a query. Zero-valued fields match everything.
+type Filter struct {
+	ResponseID string
+	MinRating  *int
+	MaxRating  *int
+	Since      time.Time
+	Until      time.Time
+
+	// Metadata matches entries having all of these metadata values, e.g.
+	// {"user": "u-42"} to select everything recorded for one user
+	Metadata map[string]string
+
+	// All confirms that a filter without other fields selects every entry.
+	// Purge refuses such a filter unless All is set; Query ignores it.
+	All bool
+}
+
+// Store persists feedback and makes it queryable
+type Store interface {
+	// Record stores a feedback entry
+	Record(ctx context.Context, fb Feedback) error
+
+	// Query returns the entries matching the filter, oldest first
+	Query(ctx context.Context, filter Filter) ([]Feedback, error)
+
+	// Purge deletes the entries matching the filter, for example to honour
+	// a deletion request, and returns how many were deleted. It fails with
+	// ErrEmptyFilter if the filter selects every entry without setting All.
+	Purge(ctx context.Context, filter Filter) (int, error)
+}
+
+// MemoryStore is an in-process Store, safe for concurrent use
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries []Feedback
+}
+
+// NewMemoryStore creates an empty in-memory feedback store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Record implements the Store interface
+func (s *MemoryStore) Record(ctx context.Context, fb Feedback) error {
+	if fb.ResponseID == "" {
+		return ErrMissingResponseID
+	}
+	if fb.CreatedAt.IsZero() {
+		fb.CreatedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, fb)
+	return nil
+}
+
+// Query implements the Store interface
+func (s *MemoryStore) Query(ctx context.Context, filter Filter) ([]Feedback, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matches []Feedback
+	for _, fb := range s.entries {
+		if filter.matches(fb) {
+			matches = append(matches, fb)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].CreatedAt.Before(matches[j].CreatedAt)
+	})
+	return matches, nil
+}
+
+// Purge implements the Store interface
+func (s *MemoryStore) Purge(ctx context.Context, filter Filter) (int, error) {
+	if filter.empty() && !filter.All {
+		return 0, ErrEmptyFilter
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.entries[:0]
+	for _, fb := range s.entries {
+		if !filter.matches(fb) {
+			kept = append(kept, fb)
+		}
+	}
+	purged := len(s.entries) - len(kept)
+	clear(s.entries[len(kept):])
+	s.entries = kept
+	return purged, nil
+}
+
+// empty reports whether the filter has no criteria, matching every entry
+func (f Filter) empty() bool {
+	return f.ResponseID == "" && f.MinRating == nil && f.MaxRating == nil &&
+		f.Since.IsZero() && f.Until.IsZero() && len(f.Metadata) == 0
+}
+
+func (f Filter) matches(fb Feedback) bool {
+	if f.ResponseID != "" && fb.ResponseID != f.ResponseID {
+		return false
+	}
+	if f.MinRating != nil && fb.Rating < *f.MinRating {
+		return false
+	}
+	if f.MaxRating != nil && fb.Rating > *f.MaxRating {
+		return false
+	}
+	if !f.Since.IsZero() && fb.CreatedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !fb.CreatedAt.Before(f.Until) {
+		return false
+	}
+	for key, value := range f.Metadata {
+		if got, ok := fb.Metadata[key]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+package feedback
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_Record(t *testing.T) {
+	store := NewMemoryStore()
+
+	err := store.Record(context.Background(), Feedback{Rating: 1})
+	if !errors.Is(err, ErrMissingResponseID) {
+		t.Errorf("Record() error = %v, want %v", err, ErrMissingResponseID)
+	}
+
+	if err := store.Record(context.Background(), Feedback{ResponseID: "resp-1", Rating: 1}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	got, err := store.Query(context.Background(), Filter{ResponseID: "resp-1"})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Got %d entries, want 1", len(got))
+	}
+	if got[0].CreatedAt.IsZero() {
+		t.Error("CreatedAt was not set")
+	}
+}
+
+func TestMemoryStore_Query(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []Feedback{
+		{ResponseID: "resp-1", Rating: 1, CreatedAt: base.Add(2 * time.Hour)},
+		{ResponseID: "resp-2", Rating: -1, Correction: "Paris", CreatedAt: base},
+		{ResponseID: "resp-1", Rating: -1, CreatedAt: base.Add(time.Hour)},
+	}
+
+	store := NewMemoryStore()
+	for _, fb := range entries {
+		if err := store.Record(context.Background(), fb); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	positive := 1
+	negative := -1
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{
+			name:   "all entries oldest first",
+			filter: Filter{},
+			want:   []string{"resp-2", "resp-1", "resp-1"},
+		},
+		{
+			name:   "by response ID",
+			filter: Filter{ResponseID: "resp-2"},
+			want:   []string{"resp-2"},
+		},
+		{
+			name:   "thumbs up only",
+			filter: Filter{MinRating: &positive},
+			want:   []string{"resp-1"},
+		},
+		{
+			name:   "thumbs down only",
+			filter: Filter{MaxRating: &negative},
+			want:   []string{"resp-2", "resp-1"},
+		},
+		{
+			name:   "time window",
+			filter: Filter{Since: base.Add(time.Hour), Until: base.Add(2 * time.Hour)},
+			want:   []string{"resp-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Query(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Got %d entries, want %d", len(got), len(tt.want))
+			}
+			for i, fb := range got {
+				if fb.ResponseID != tt.want[i] {
+					t.Errorf("Entry %d ResponseID = %v, want %v", i, fb.ResponseID, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStore_Purge(t *testing.T) {
+	store := NewMemoryStore()
+	for _, fb := range []Feedback{
+		{ResponseID: "resp-1", Metadata: map[string]string{"user": "u-1"}},
+		{ResponseID: "resp-2", Metadata: map[string]string{"user": "u-2"}},
+		{ResponseID: "resp-3", Metadata: map[string]string{"user": "u-1", "session": "s-1"}},
+		{ResponseID: "resp-4"},
+	} {
+		if err := store.Record(context.Background(), fb); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	purged, err := store.Purge(context.Background(), Filter{Metadata: map[string]string{"user": "u-1"}})
+	if err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("Purge() = %d, want 2", purged)
+	}
+
+	got, err := store.Query(context.Background(), Filter{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(got) != 2 || got[0].ResponseID != "resp-2" || got[1].ResponseID != "resp-4" {
+		t.Errorf("Remaining entries = %+v, want resp-2 and resp-4", got)
+	}
+
+	for _, filter := range []Filter{{}, {Metadata: map[string]string{}}} {
+		if _, err := store.Purge(context.Background(), filter); !errors.Is(err, ErrEmptyFilter) {
+			t.Errorf("Purge(%+v) error = %v, want %v", filter, err, ErrEmptyFilter)
+		}
+	}
+	if got, _ := store.Query(context.Background(), Filter{}); len(got) != 2 {
+		t.Errorf("Remaining entries = %+v, want the empty filters refused", got)
+	}
+
+	purged, err = store.Purge(context.Background(), Filter{All: true})
+	if err != nil || purged != 2 {
+		t.Errorf("Purge(All) = %d, %v, want 2", purged, err)
+	}
+}
@@ -0,0 +1,58 @@
+package feedback
+
+import (
+	"context"
+	"time"
+)
+
+const defaultJanitorInterval = time.Hour
+
+// Retention configures how long a store keeps feedback
+type Retention struct {
+	// MaxAge is how long entries are kept after CreatedAt; zero keeps them
+	// forever
+	MaxAge time.Duration
+
+	// Interval is how often RunJanitor applies the policy (optional,
+	// defaults to one hour)
+	Interval time.Duration
+
+	// OnError is called with errors from purging; the janitor keeps running
+	// (optional)
+	OnError func(error)
+}
+
+// Apply deletes the entries of store older than MaxAge at now and returns
+// how many were deleted
+func (r Retention) Apply(ctx context.Context, store Store, now time.Time) (int, error) {
+	if r.MaxAge <= 0 {
+		return 0, nil
+	}
+	return store.Purge(ctx, Filter{Until: now.Add(-r.MaxAge)})
+}
+
+// RunJanitor applies the retention policy to store immediately and then
+// every Interval until ctx is done, returning ctx.Err(). It is meant to run
+// in its own goroutine:
+//
+//	go feedback.RunJanitor(ctx, store, feedback.Retention{MaxAge: 90 * 24 * time.Hour})
+func RunJanitor(ctx context.Context, store Store, retention Retention) error {
+	if retention.Interval <= 0 {
+		retention.Interval = defaultJanitorInterval
+	}
+
+	ticker := time.NewTicker(retention.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := retention.Apply(ctx, store, time.Now()); err != nil && retention.OnError != nil {
+			retention.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
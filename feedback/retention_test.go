@@ -0,0 +1,85 @@
+package feedback
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetention_Apply(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		maxAge time.Duration
+		want   []string
+	}{
+		{name: "no max age", want: []string{"old", "recent", "new"}},
+		{name: "old entries deleted", maxAge: 30 * 24 * time.Hour, want: []string{"recent", "new"}},
+		{name: "short max age", maxAge: time.Hour, want: []string{"new"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			for _, fb := range []Feedback{
+				{ResponseID: "old", CreatedAt: now.Add(-90 * 24 * time.Hour)},
+				{ResponseID: "recent", CreatedAt: now.Add(-24 * time.Hour)},
+				{ResponseID: "new", CreatedAt: now.Add(-time.Minute)},
+			} {
+				if err := store.Record(context.Background(), fb); err != nil {
+					t.Fatalf("Record() error = %v", err)
+				}
+			}
+
+			purged, err := Retention{MaxAge: tt.maxAge}.Apply(context.Background(), store, now)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if purged != 3-len(tt.want) {
+				t.Errorf("Apply() = %d, want %d", purged, 3-len(tt.want))
+			}
+
+			got, _ := store.Query(context.Background(), Filter{})
+			if len(got) != len(tt.want) {
+				t.Fatalf("Got %d entries, want %d", len(got), len(tt.want))
+			}
+			for i, fb := range got {
+				if fb.ResponseID != tt.want[i] {
+					t.Errorf("Entry %d = %q, want %q", i, fb.ResponseID, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRunJanitor(t *testing.T) {
+	store := NewMemoryStore()
+	if err := store.Record(context.Background(), Feedback{ResponseID: "old", CreatedAt: time.Now().Add(-48 * time.Hour)}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- RunJanitor(ctx, store, Retention{MaxAge: 24 * time.Hour, Interval: time.Millisecond})
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		got, _ := store.Query(context.Background(), Filter{})
+		if len(got) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Janitor did not purge the old entry")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("RunJanitor() error = %v, want %v", err, context.Canceled)
+	}
+}
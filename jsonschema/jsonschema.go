@@ -0,0 +1,387 @@
+// Package jsonschema validates JSON values against JSON schemas, such as the
+// parameters of tools and the schemas of structured output. The tools, llm
+// and llmtest packages share it so that a value is judged the same way
+// wherever it is checked.
+//
+// The keywords type, enum, const, format (date-time only), properties,
+// required, additionalProperties, items, minItems, maxItems, minLength,
+// maxLength, minimum, maximum, exclusiveMinimum, exclusiveMaximum, allOf,
+// anyOf and oneOf are checked. Other keywords, such as $ref, are ignored, so
+// schemas using them are validated leniently; for the same reason oneOf is
+// checked like anyOf. Integers are numbers without a fractional part, so 1.0
+// is an integer, and a property set to null is treated as absent unless it
+// is required, as encoding/json and OpenAI's strict mode do.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Problem is a part of a value that does not match a schema
+type Problem struct {
+	// Path locates the offending value: property names joined by dots and
+	// array indexes in brackets, such as "address.city" or "tags[1]", or
+	// empty for the validated value itself
+	Path string
+
+	// Message describes the problem, e.g. "must be a string"
+	Message string
+}
+
+// Validate checks value against schema and returns the problems found,
+// required properties first and then in the order of the value. The value
+// is decoded JSON, with numbers as float64 or json.Number. The schema is
+// raw JSON given as json.RawMessage or []byte, or any value encoding to a
+// JSON schema, such as a map or a tools.Schema; an error is returned if it
+// is neither an object nor a boolean.
+func Validate(schema, value any) ([]Problem, error) {
+	decoded, err := decodeSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	return validate(decoded, value, ""), nil
+}
+
+func decodeSchema(schema any) (any, error) {
+	var data []byte
+	switch s := schema.(type) {
+	case json.RawMessage:
+		data = s
+	case []byte:
+		data = s
+	default:
+		var err error
+		if data, err = json.Marshal(schema); err != nil {
+			return nil, fmt.Errorf("jsonschema: cannot encode schema: %w", err)
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("jsonschema: invalid schema: %w", err)
+	}
+	switch decoded.(type) {
+	case map[string]any, bool:
+		return decoded, nil
+	}
+	return nil, errors.New("jsonschema: schema must be an object or a boolean")
+}
+
+// validate checks value against a decoded schema
+func validate(schema, value any, path string) []Problem {
+	s, ok := schema.(map[string]any)
+	if !ok {
+		if schema == false {
+			return []Problem{{path, "is not allowed"}}
+		}
+		return nil
+	}
+
+	if types := typesOf(s["type"]); len(types) > 0 && !hasType(value, types) {
+		message := "must be " + describeTypes(types)
+		if value == nil {
+			message += ", got null"
+		}
+		return []Problem{{path, message}}
+	}
+
+	var problems []Problem
+	if enum, ok := s["enum"].([]any); ok && !contains(enum, value) {
+		problems = append(problems, Problem{path, "must be one of " + encodeList(enum)})
+	}
+	if constant, ok := s["const"]; ok && !equal(constant, value) {
+		problems = append(problems, Problem{path, "must be " + encode(constant)})
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		problems = append(problems, validateObject(s, value, path)...)
+	case []any:
+		problems = append(problems, validateArray(s, value, path)...)
+	case string:
+		problems = append(problems, validateString(s, value, path)...)
+	case float64, json.Number:
+		problems = append(problems, validateNumber(s, value, path)...)
+	}
+
+	if all, ok := s["allOf"].([]any); ok {
+		for _, sub := range all {
+			problems = append(problems, validate(sub, value, path)...)
+		}
+	}
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		if alternatives, ok := s[keyword].([]any); ok && !matchesAny(alternatives, value, path) {
+			problems = append(problems, Problem{path, "must match one of the allowed schemas"})
+		}
+	}
+	return problems
+}
+
+func validateObject(s map[string]any, object map[string]any, path string) []Problem {
+	var problems []Problem
+	required := make(map[string]bool)
+	if names, ok := s["required"].([]any); ok {
+		for _, name := range names {
+			name, ok := name.(string)
+			if !ok {
+				continue
+			}
+			required[name] = true
+			if _, ok := object[name]; !ok {
+				problems = append(problems, Problem{join(path, name), "missing required property"})
+			}
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	properties, _ := s["properties"].(map[string]any)
+	for _, name := range names {
+		value := object[name]
+		if value == nil && !required[name] {
+			continue
+		}
+		if property, ok := properties[name]; ok {
+			problems = append(problems, validate(property, value, join(path, name))...)
+			continue
+		}
+		if additional, ok := s["additionalProperties"]; ok {
+			problems = append(problems, validateAdditional(additional, value, join(path, name))...)
+		}
+	}
+	return problems
+}
+
+func validateAdditional(additional, value any, path string) []Problem {
+	if additional == false {
+		return []Problem{{path, "unknown property"}}
+	}
+	return validate(additional, value, path)
+}
+
+func validateArray(s map[string]any, array []any, path string) []Problem {
+	var problems []Problem
+	if n, ok := integer(s["minItems"]); ok && int64(len(array)) < n {
+		problems = append(problems, Problem{path, fmt.Sprintf("must have at least %d items", n)})
+	}
+	if n, ok := integer(s["maxItems"]); ok && int64(len(array)) > n {
+		problems = append(problems, Problem{path, fmt.Sprintf("must have at most %d items", n)})
+	}
+	if items, ok := s["items"]; ok {
+		for i, item := range array {
+			problems = append(problems, validate(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return problems
+}
+
+func validateString(s map[string]any, value, path string) []Problem {
+	var problems []Problem
+	length := int64(utf8.RuneCountInString(value))
+	if n, ok := integer(s["minLength"]); ok && length < n {
+		problems = append(problems, Problem{path, fmt.Sprintf("must be at least %d characters long", n)})
+	}
+	if n, ok := integer(s["maxLength"]); ok && length > n {
+		problems = append(problems, Problem{path, fmt.Sprintf("must be at most %d characters long", n)})
+	}
+	if s["format"] == "date-time" {
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			problems = append(problems, Problem{path, "must be an RFC 3339 date-time"})
+		}
+	}
+	return problems
+}
+
+func validateNumber(s map[string]any, value any, path string) []Problem {
+	n, _ := number(value)
+	bounds := []struct {
+		keyword string
+		fails   func(cmp int) bool
+		message string
+	}{
+		{"minimum", func(cmp int) bool { return cmp < 0 }, "must be at least "},
+		{"maximum", func(cmp int) bool { return cmp > 0 }, "must be at most "},
+		{"exclusiveMinimum", func(cmp int) bool { return cmp <= 0 }, "must be greater than "},
+		{"exclusiveMaximum", func(cmp int) bool { return cmp >= 0 }, "must be less than "},
+	}
+
+	var problems []Problem
+	for _, bound := range bounds {
+		limit, ok := number(s[bound.keyword])
+		if ok && bound.fails(n.Cmp(limit)) {
+			problems = append(problems, Problem{path, bound.message + encode(s[bound.keyword])})
+		}
+	}
+	return problems
+}
+
+func matchesAny(alternatives []any, value any, path string) bool {
+	for _, alternative := range alternatives {
+		if len(validate(alternative, value, path)) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// typesOf returns the types allowed by a type keyword, a string or an array
+// of strings
+func typesOf(keyword any) []string {
+	switch keyword := keyword.(type) {
+	case string:
+		return []string{keyword}
+	case []any:
+		var types []string
+		for _, t := range keyword {
+			if t, ok := t.(string); ok {
+				types = append(types, t)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func hasType(value any, types []string) bool {
+	for _, t := range types {
+		var ok bool
+		switch t {
+		case "null":
+			ok = value == nil
+		case "boolean":
+			_, ok = value.(bool)
+		case "string":
+			_, ok = value.(string)
+		case "number":
+			_, ok = number(value)
+		case "integer":
+			n, isNumber := number(value)
+			ok = isNumber && n.IsInt()
+		case "array":
+			_, ok = value.([]any)
+		case "object":
+			_, ok = value.(map[string]any)
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func describeTypes(types []string) string {
+	described := make([]string, len(types))
+	for i, t := range types {
+		switch t {
+		case "null":
+			described[i] = "null"
+		case "object", "array", "integer":
+			described[i] = "an " + t
+		default:
+			described[i] = "a " + t
+		}
+	}
+	return strings.Join(described, " or ")
+}
+
+// number returns the exact value of a JSON number
+func number(value any) (*big.Rat, bool) {
+	switch value := value.(type) {
+	case json.Number:
+		return new(big.Rat).SetString(string(value))
+	case float64:
+		if n := new(big.Rat); n.SetFloat64(value) != nil {
+			return n, true
+		}
+	}
+	return nil, false
+}
+
+// integer returns the value of a keyword taking a non-negative integer
+func integer(keyword any) (int64, bool) {
+	n, ok := number(keyword)
+	if !ok || !n.IsInt() || !n.Num().IsInt64() {
+		return 0, false
+	}
+	return n.Num().Int64(), true
+}
+
+func contains(values []any, value any) bool {
+	for _, v := range values {
+		if equal(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal reports whether two JSON values are equal, comparing numbers by
+// value whatever their representation
+func equal(a, b any) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x.Cmp(y) == 0
+	}
+
+	switch a := a.(type) {
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			other, ok := b[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func encode(value any) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+func encodeList(values []any) string {
+	encoded := make([]string, len(values))
+	for i, value := range values {
+		encoded[i] = encode(value)
+	}
+	return strings.Join(encoded, ", ")
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,145 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	schema := json.RawMessage(`{
+		"type": "object",
+		"required": ["name", "tags"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 1, "maxLength": 5},
+			"age": {"type": "integer", "minimum": 0, "maximum": 150},
+			"score": {"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 1},
+			"nickname": {"type": ["string", "null"]},
+			"role": {"enum": ["admin", "user", 1, {"level": 2}, [1, 2]]},
+			"kind": {"const": "person"},
+			"born": {"type": "string", "format": "date-time"},
+			"tags": {"type": "array", "minItems": 1, "maxItems": 2, "items": {"type": "string"}},
+			"contact": {"anyOf": [{"type": "string"}, {"type": "object", "required": ["email"]}]},
+			"id": {"allOf": [{"type": "string"}, {"minLength": 3}]},
+			"extra": {"$ref": "#/definitions/extra"}
+		}
+	}`)
+	valid := `"name": "Ada", "tags": ["math"]`
+
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{name: "valid", value: `{` + valid + `}`},
+		{
+			name: "valid optional properties",
+			value: `{` + valid + `, "age": 36.0, "score": 0.5, "nickname": null, "role": {"level": 2.0},
+				"kind": "person", "born": "1815-12-10T00:00:00Z", "contact": {"email": "ada@example.com"},
+				"id": "a-1", "extra": [true]}`,
+		},
+		{name: "null optional property", value: `{` + valid + `, "age": null, "unknown": null}`},
+		{
+			name:  "missing required",
+			value: `{"name": null}`,
+			want:  []string{"tags: missing required property", "name: must be a string, got null"},
+		},
+		{
+			name:  "wrong types",
+			value: `{` + valid + `, "age": 36.5, "nickname": 1, "score": "high"}`,
+			want:  []string{"age: must be an integer", "nickname: must be a string or null", "score: must be a number"},
+		},
+		{
+			name:  "bounds",
+			value: `{"name": "", "tags": [], "age": 200, "score": 1}`,
+			want: []string{
+				"age: must be at most 150",
+				"name: must be at least 1 characters long",
+				"score: must be less than 1",
+				"tags: must have at least 1 items",
+			},
+		},
+		{
+			name:  "enum and const",
+			value: `{` + valid + `, "role": {"level": 3}, "kind": "robot"}`,
+			want:  []string{`kind: must be "person"`, `role: must be one of "admin", "user", 1, {"level":2}, [1,2]`},
+		},
+		{
+			name:  "nested",
+			value: `{"name": "Ada Lovelace", "tags": ["a", 1, "c"], "born": "yesterday", "contact": {}, "id": "a"}`,
+			want: []string{
+				"born: must be an RFC 3339 date-time",
+				"contact: must match one of the allowed schemas",
+				"id: must be at least 3 characters long",
+				"name: must be at most 5 characters long",
+				"tags: must have at most 2 items",
+				"tags[1]: must be a string",
+			},
+		},
+		{name: "unknown property", value: `{` + valid + `, "email": "ada@example.com"}`, want: []string{"email: unknown property"}},
+		{name: "not an object", value: `["Ada"]`, want: []string{": must be an object"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, useNumber := range []bool{false, true} {
+				decoder := json.NewDecoder(strings.NewReader(tt.value))
+				if useNumber {
+					decoder.UseNumber()
+				}
+				var value any
+				if err := decoder.Decode(&value); err != nil {
+					t.Fatal(err)
+				}
+
+				problems, err := Validate(schema, value)
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				var got []string
+				for _, problem := range problems {
+					got = append(got, problem.Path+": "+problem.Message)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Validate(UseNumber: %t) = %q, want %q", useNumber, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate_Schemas(t *testing.T) {
+	type object struct {
+		Type     string   `json:"type"`
+		Required []string `json:"required"`
+	}
+
+	tests := []struct {
+		name    string
+		schema  any
+		value   any
+		want    int
+		wantErr bool
+	}{
+		{name: "Go value", schema: object{Type: "object", Required: []string{"a"}}, value: map[string]any{}, want: 1},
+		{name: "map", schema: map[string]any{"enum": []int{1, 2}}, value: 2.0},
+		{name: "true", schema: true, value: "anything"},
+		{name: "false", schema: []byte(`false`), value: "anything", want: 1},
+		{name: "invalid JSON", schema: json.RawMessage(`{"type":`), wantErr: true},
+		{name: "not a schema", schema: "object", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems, err := Validate(tt.schema, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if len(problems) != tt.want {
+				t.Errorf("Validate() = %v, want %d problems", problems, tt.want)
+			}
+		})
+	}
+}
@@ -9,89 +9,542 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
 )
 
 const (
-	anthropicAPIEndpoint = "https://api.anthropic.com/v1/messages"
+	defaultAnthropicBaseURL    = "https://api.anthropic.com/v1"
+	defaultAnthropicAPIVersion = "2023-06-01"
 )
 
+// AnthropicConfig contains configuration for the Anthropic client
+type AnthropicConfig struct {
+	// APIKey is your Anthropic API key
+	APIKey string
+
+	// Credentials supplies the API key for each request in place of APIKey
+	// (optional)
+	Credentials CredentialProvider
+
+	// BaseURL is the base URL for Anthropic API (optional, defaults to https://api.anthropic.com/v1)
+	BaseURL string
+
+	// Timeout is the timeout for API requests (optional, defaults to 30 seconds)
+	Timeout time.Duration
+
+	// HTTPClient is a custom HTTP client (optional)
+	HTTPClient *http.Client
+
+	// APIVersion is sent as the anthropic-version header (optional, defaults
+	// to 2023-06-01)
+	APIVersion string
+
+	// RetryConfig contains retry configuration (optional)
+	RetryConfig *RetryConfig
+
+	// DefaultModel is used for requests that do not set Model (optional)
+	DefaultModel string
+
+	// DefaultMaxTokens is used for requests that do not set MaxTokens, which
+	// Anthropic requires (optional, defaults to a limit every model of the
+	// requested family supports, see defaultAnthropicMaxTokens)
+	DefaultMaxTokens int
+}
+
 // AnthropicClient implements the LLMProvider interface for Anthropic
 type AnthropicClient struct {
-	apiKey     string
+	config     AnthropicConfig
 	httpClient *http.Client
 }
 
-// NewAnthropicClient creates a new Anthropic client
-func NewAnthropicClient(apiKey string) *AnthropicClient {
+// NewAnthropicClientWithConfig creates a new Anthropic client with the given
+// configuration
+func NewAnthropicClientWithConfig(config AnthropicConfig) *AnthropicClient {
+	if config.BaseURL == "" {
+		config.BaseURL = defaultAnthropicBaseURL
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{
+			Timeout: config.Timeout,
+		}
+	}
+
+	if config.APIVersion == "" {
+		config.APIVersion = defaultAnthropicAPIVersion
+	}
+
+	if config.RetryConfig == nil {
+		config.RetryConfig = defaultRetryConfig()
+	}
+
 	return &AnthropicClient{
-		apiKey:     apiKey,
-		httpClient: &http.Client{},
+		config:     config,
+		httpClient: config.HTTPClient,
 	}
 }
 
+// NewAnthropicClient creates a new Anthropic client with just an API key
+func NewAnthropicClient(apiKey string) *AnthropicClient {
+	return NewAnthropicClientWithConfig(AnthropicConfig{
+		APIKey: apiKey,
+	})
+}
+
+// newHTTPRequest creates a request to path below BaseURL with the
+// authentication and version headers set
+func (c *AnthropicClient) newHTTPRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	endpoint := strings.TrimRight(c.config.BaseURL, "/") + path
+	httpReq, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	apiKey, err := resolveAPIKey(ctx, c.config.Credentials, c.config.APIKey)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("x-api-key", apiKey)
+	httpReq.Header.Set("anthropic-version", c.config.APIVersion)
+	return httpReq, nil
+}
+
+// maxTokens resolves the max_tokens of a request for model, which Anthropic
+// rejects when missing. With extended thinking, the thinking budget counts
+// towards max_tokens, so a default leaves room for the answer on top of it.
+func (c *AnthropicClient) maxTokens(req *CompletionRequest, model string) int {
+	if req.MaxTokens > 0 {
+		return req.MaxTokens
+	}
+
+	maxTokens := c.config.DefaultMaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultAnthropicMaxTokens(model)
+	}
+	return req.ThinkingBudget + maxTokens
+}
+
+// defaultAnthropicMaxTokens returns the output limit of the models before
+// Claude 3.5, and 8192 for Claude 3.5 and later, which allow at least that
+// much; longer outputs must be requested explicitly
+func defaultAnthropicMaxTokens(model string) int {
+	for _, prefix := range []string{"claude-instant", "claude-2", "claude-3-opus", "claude-3-sonnet", "claude-3-haiku"} {
+		if strings.HasPrefix(model, prefix) {
+			return 4096
+		}
+	}
+	return 8192
+}
+
+// defaultModel returns the model of requests that do not set one
+func (c *AnthropicClient) defaultModel() string {
+	return c.config.DefaultModel
+}
+
+// model resolves the model of req, falling back to DefaultModel
+func (c *AnthropicClient) model(req *CompletionRequest) (string, error) {
+	if req.Model != "" {
+		return req.Model, nil
+	}
+	if c.config.DefaultModel != "" {
+		return c.config.DefaultModel, nil
+	}
+	return "", ErrModelRequired
+}
+
 type anthropicRequest struct {
 	Model       string    `json:"model"`
+	System      string    `json:"system,omitempty"`
 	Messages    []message `json:"messages"`
-	MaxTokens   int       `json:"max_tokens,omitempty"`
-	Temperature float32   `json:"temperature,omitempty"`
+	MaxTokens   int       `json:"max_tokens"`
+	Temperature *float32  `json:"temperature,omitempty"`
+	TopP        float32   `json:"top_p,omitempty"`
 	Stream      bool      `json:"stream,omitempty"`
+
+	Thinking *anthropicThinking `json:"thinking,omitempty"`
+	Metadata *anthropicMetadata `json:"metadata,omitempty"`
+
+	Tools      []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice *anthropicToolChoice `json:"tool_choice,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
 }
 
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+type anthropicMetadata struct {
+	UserID string `json:"user_id"`
+}
+
+type anthropicThinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+// message is a message of the Messages API. Content is either a string or,
+// for tool calls and results, a list of anthropicBlock.
 type message struct {
 	Role    string `json:"role"`
-	Content string `json:"content"`
+	Content any    `json:"content"`
+}
+
+// anthropicBlock is a content block sent in a message: text, an image, a
+// tool_use block replaying a tool call of the assistant, or a tool_result
+// block answering one
+type anthropicBlock struct {
+	Type   string                `json:"type"`
+	Text   string                `json:"text,omitempty"`
+	Source *anthropicImageSource `json:"source,omitempty"`
+
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// Content of a tool_result block is a string or, with parts, a list of
+	// anthropicBlock
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   any    `json:"content,omitempty"`
+
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Data      string `json:"data,omitempty"`
+}
+
+// newAnthropicRequest converts a CompletionRequest into the Messages API
+// format. Anthropic takes the system prompt as a top-level field rather than a
+// message, so system messages are joined into it, and tool results are sent
+// as user turns.
+func newAnthropicRequest(req *CompletionRequest, model string, stream bool) anthropicRequest {
+	anthropicReq := anthropicRequest{
+		Model:       model,
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		Stream:      stream,
+	}
+	if req.ThinkingBudget > 0 {
+		anthropicReq.Thinking = &anthropicThinking{Type: "enabled", BudgetTokens: req.ThinkingBudget}
+	}
+	if req.User != "" {
+		anthropicReq.Metadata = &anthropicMetadata{UserID: req.User}
+	}
+	for _, tool := range req.Tools {
+		schema := tool.Function.Parameters
+		if schema == nil {
+			schema = map[string]any{"type": "object"}
+		}
+		anthropicReq.Tools = append(anthropicReq.Tools, anthropicTool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: schema,
+		})
+	}
+	if len(req.Tools) > 0 {
+		anthropicReq.ToolChoice = newAnthropicToolChoice(req.ToolChoice)
+	}
+
+	var system []string
+	for _, msg := range req.messages() {
+		switch msg.Role {
+		case RoleSystem:
+			system = append(system, msg.Content)
+		case RoleTool:
+			anthropicReq.Messages = appendToolResult(anthropicReq.Messages, msg)
+		case RoleAssistant:
+			anthropicReq.Messages = append(anthropicReq.Messages, message{Role: msg.Role, Content: assistantContent(msg)})
+		default:
+			anthropicReq.Messages = append(anthropicReq.Messages, message{Role: msg.Role, Content: anthropicMessageContent(msg)})
+		}
+	}
+	anthropicReq.System = strings.Join(system, "\n\n")
+
+	return anthropicReq
+}
+
+// anthropicImageSource is the source of an image block: base64-encoded data
+// or a URL
+type anthropicImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// anthropicMessageContent returns the content of a message, a list of
+// blocks when it has parts
+func anthropicMessageContent(msg Message) any {
+	if len(msg.Parts) == 0 {
+		return msg.Content
+	}
+	var blocks []anthropicBlock
+	if msg.Content != "" {
+		blocks = append(blocks, anthropicBlock{Type: "text", Text: msg.Content})
+	}
+	return append(blocks, anthropicParts(msg.Parts)...)
+}
+
+// anthropicParts converts content parts into text and image blocks. Images
+// in data URLs are sent as base64 data, other images by URL.
+func anthropicParts(parts []ContentPart) []anthropicBlock {
+	var blocks []anthropicBlock
+	for _, part := range parts {
+		if part.Type != PartImage {
+			blocks = append(blocks, anthropicBlock{Type: "text", Text: part.Text})
+			continue
+		}
+
+		source := &anthropicImageSource{Type: "url", URL: part.ImageURL}
+		if rest, ok := strings.CutPrefix(part.ImageURL, "data:"); ok {
+			if mediaType, data, ok := strings.Cut(rest, ";base64,"); ok {
+				source = &anthropicImageSource{Type: "base64", MediaType: mediaType, Data: data}
+			}
+		}
+		blocks = append(blocks, anthropicBlock{Type: "image", Source: source})
+	}
+	return blocks
+}
+
+// assistantContent returns the content of an assistant message, adding its
+// thinking blocks ahead of it and a tool_use block for each tool call it made
+func assistantContent(msg Message) any {
+	if len(msg.ToolCalls) == 0 && len(msg.Thinking) == 0 {
+		return anthropicMessageContent(msg)
+	}
+
+	var blocks []anthropicBlock
+	for _, thinking := range msg.Thinking {
+		if thinking.Data != "" {
+			blocks = append(blocks, anthropicBlock{Type: "redacted_thinking", Data: thinking.Data})
+			continue
+		}
+		blocks = append(blocks, anthropicBlock{Type: "thinking", Thinking: thinking.Thinking, Signature: thinking.Signature})
+	}
+	if msg.Content != "" {
+		blocks = append(blocks, anthropicBlock{Type: "text", Text: msg.Content})
+	}
+	blocks = append(blocks, anthropicParts(msg.Parts)...)
+	for _, call := range msg.ToolCalls {
+		input := json.RawMessage(call.Function.Arguments)
+		if !json.Valid(input) {
+			input = json.RawMessage("{}")
+		}
+		blocks = append(blocks, anthropicBlock{Type: "tool_use", ID: call.ID, Name: call.Function.Name, Input: input})
+	}
+	return blocks
+}
+
+// appendToolResult appends a tool message as a tool_result block of a user
+// message. Results of consecutive tool messages share one user message, as
+// Anthropic expects all the results of a turn together. Tool messages
+// without a ToolCallID are sent as plain user messages.
+func appendToolResult(messages []message, msg Message) []message {
+	if msg.ToolCallID == "" {
+		return append(messages, message{Role: RoleUser, Content: anthropicMessageContent(msg)})
+	}
+
+	block := anthropicBlock{Type: "tool_result", ToolUseID: msg.ToolCallID}
+	if msg.Content != "" || len(msg.Parts) > 0 {
+		block.Content = anthropicMessageContent(msg)
+	}
+	if n := len(messages); n > 0 && messages[n-1].Role == RoleUser {
+		if blocks, ok := messages[n-1].Content.([]anthropicBlock); ok && blocks[0].Type == "tool_result" {
+			messages[n-1].Content = append(blocks, block)
+			return messages
+		}
+	}
+	return append(messages, message{Role: RoleUser, Content: []anthropicBlock{block}})
+}
+
+// newAnthropicToolChoice converts CompletionRequest.ToolChoice, where
+// Anthropic calls forcing any tool "any" and forcing a function "tool"
+func newAnthropicToolChoice(choice string) *anthropicToolChoice {
+	switch choice {
+	case "":
+		return nil
+	case ToolChoiceAuto, ToolChoiceNone:
+		return &anthropicToolChoice{Type: choice}
+	case ToolChoiceRequired:
+		return &anthropicToolChoice{Type: "any"}
+	}
+	return &anthropicToolChoice{Type: "tool", Name: choice}
 }
 
 type anthropicResponse struct {
+	ID         string         `json:"id"`
 	Content    []contentBlock `json:"content"`
 	Model      string         `json:"model"`
 	StopReason string         `json:"stop_reason"`
 	Error      *struct {
 		Message string `json:"message"`
 	} `json:"error,omitempty"`
+
+	// Type, Message and Delta are set on stream events: message_start
+	// carries the message, content_block_delta and message_delta a delta.
+	// content_block_start carries the block at Index that deltas add to.
+	Type         string             `json:"type,omitempty"`
+	Message      *anthropicResponse `json:"message,omitempty"`
+	Delta        *anthropicDelta    `json:"delta,omitempty"`
+	Index        int                `json:"index,omitempty"`
+	ContentBlock *contentBlock      `json:"content_block,omitempty"`
+}
+
+type anthropicDelta struct {
+	Type       string `json:"type"`
+	Text       string `json:"text"`
+	Thinking   string `json:"thinking"`
+	Signature  string `json:"signature"`
+	StopReason string `json:"stop_reason"`
+
+	// PartialJSON is a fragment of the input of a tool_use block
+	PartialJSON string `json:"partial_json"`
 }
 
 type contentBlock struct {
 	Text string `json:"text"`
 	Type string `json:"type"`
+
+	// Thinking and Signature are the reasoning of a thinking block and
+	// Data the encrypted reasoning of a redacted_thinking block
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Data      string `json:"data,omitempty"`
+
+	// ID, Name and Input describe the call of a tool_use block
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
 }
 
-// Complete implements non-streaming completion
+// anthropicToolCalls returns the tool_use blocks of content as tool calls
+func anthropicToolCalls(content []contentBlock) []ToolCall {
+	var calls []ToolCall
+	for _, block := range content {
+		if block.Type != "tool_use" {
+			continue
+		}
+		call := ToolCall{ID: block.ID, Type: "function"}
+		call.Function.Name = block.Name
+		call.Function.Arguments = string(block.Input)
+		calls = append(calls, call)
+	}
+	return calls
+}
+
+// anthropicThinkingBlocks returns the thinking and redacted_thinking blocks of
+// content
+func anthropicThinkingBlocks(content []contentBlock) []ThinkingBlock {
+	var blocks []ThinkingBlock
+	for _, block := range content {
+		if block.Type == "thinking" || block.Type == "redacted_thinking" {
+			blocks = append(blocks, ThinkingBlock{Thinking: block.Thinking, Signature: block.Signature, Data: block.Data})
+		}
+	}
+	return blocks
+}
+
+// anthropicContent joins the text blocks of content into the answer and the
+// thinking blocks into the reasoning
+func anthropicContent(content []contentBlock) (text, reasoning string) {
+	var textParts, thinkingParts []string
+	for _, block := range content {
+		switch block.Type {
+		case "thinking":
+			thinkingParts = append(thinkingParts, block.Thinking)
+		case "redacted_thinking", "tool_use":
+			// Encrypted reasoning has nothing to show, and tool calls are
+			// returned separately
+		default:
+			textParts = append(textParts, block.Text)
+		}
+	}
+	return strings.Join(textParts, ""), strings.Join(thinkingParts, "\n\n")
+}
+
+// anthropicFinishReason maps Anthropic stop reasons to the OpenAI finish
+// reasons used across providers
+func anthropicFinishReason(reason string) string {
+	switch reason {
+	case "end_turn", "stop_sequence":
+		return "stop"
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "refusal":
+		return "content_filter"
+	default:
+		return reason
+	}
+}
+
+// Complete implements non-streaming completion with retry support
 func (c *AnthropicClient) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
-	anthropicReq := anthropicRequest{
-		Model: req.Model,
-		Messages: []message{
-			{
-				Role:    "user",
-				Content: req.Prompt,
-			},
-		},
-		MaxTokens:   req.MaxTokens,
-		Temperature: req.Temperature,
+	if req.N > 1 {
+		return nil, ErrMultipleChoicesUnsupported
+	}
+
+	var resp *CompletionResponse
+	err := retry(ctx, c.config.RetryConfig, func() error {
+		var err error
+		resp, err = c.complete(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	return resp, nil
+}
 
-	body, err := json.Marshal(anthropicReq)
+func (c *AnthropicClient) complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	model, err := c.model(req)
 	if err != nil {
 		return nil, err
 	}
+	anthropicReq := newAnthropicRequest(req, model, false)
+	anthropicReq.MaxTokens = c.maxTokens(req, model)
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", anthropicAPIEndpoint, bytes.NewReader(body))
+	extras := newRequestExtras(req)
+	body, err := extras.marshal(anthropicReq)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := c.newHTTPRequest(ctx, "POST", "/messages", bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", c.apiKey)
-	httpReq.Header.Set("anthropic-version", "2023-06-01")
+	extras.setHeaders(httpReq.Header)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := extras.client(c.httpClient).Do(httpReq)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPError(resp)
+	}
+
 	var anthropicResp anthropicResponse
-	if err := json.NewDecoder(resp.Body).Decode(&anthropicResp); err != nil {
+	raw, err := decodeResponse(resp.Body, &anthropicResp, req.IncludeRaw)
+	if err != nil {
 		return nil, err
 	}
 
@@ -103,92 +556,303 @@ func (c *AnthropicClient) Complete(ctx context.Context, req *CompletionRequest)
 		return nil, errors.New("no content in response")
 	}
 
+	content, reasoning := anthropicContent(anthropicResp.Content)
 	return &CompletionResponse{
-		Content:      anthropicResp.Content[0].Text,
+		ID:           anthropicResp.ID,
+		Content:      content,
+		Reasoning:    reasoning,
+		Thinking:     anthropicThinkingBlocks(anthropicResp.Content),
 		Model:        anthropicResp.Model,
-		FinishReason: anthropicResp.StopReason,
+		FinishReason: anthropicFinishReason(anthropicResp.StopReason),
+		ToolCalls:    anthropicToolCalls(anthropicResp.Content),
+		Metadata:     newResponseMetadata(resp.Header),
+		Raw:          raw,
 	}, nil
 }
 
+type anthropicModelList struct {
+	Data []struct {
+		ID          string    `json:"id"`
+		DisplayName string    `json:"display_name"`
+		CreatedAt   time.Time `json:"created_at"`
+	} `json:"data"`
+	HasMore bool   `json:"has_more"`
+	LastID  string `json:"last_id"`
+}
+
+// ListModels implements the ModelLister interface, following the pagination
+// of the models endpoint until all models have been fetched
+func (c *AnthropicClient) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	var infos []ModelInfo
+	afterID := ""
+
+	for {
+		query := url.Values{"limit": {"1000"}}
+		if afterID != "" {
+			query.Set("after_id", afterID)
+		}
+
+		httpReq, err := c.newHTTPRequest(ctx, "GET", "/models?"+query.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		page, err := c.listModelsPage(httpReq)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range page.Data {
+			infos = append(infos, ModelInfo{
+				ID:          m.ID,
+				DisplayName: m.DisplayName,
+				OwnedBy:     "anthropic",
+				Created:     m.CreatedAt,
+			})
+		}
+
+		if !page.HasMore || page.LastID == "" {
+			return infos, nil
+		}
+		afterID = page.LastID
+	}
+}
+
+func (c *AnthropicClient) listModelsPage(httpReq *http.Request) (*anthropicModelList, error) {
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPError(resp)
+	}
+
+	var page anthropicModelList
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &page, nil
+}
+
 // anthropicStream implements CompletionStream for Anthropic
 type anthropicStream struct {
-	reader *bufio.Reader
-	closer io.Closer
+	reader   *bufio.Reader
+	closer   io.Closer
+	metadata *ResponseMetadata
+	raw      bool
+
+	// id and model are announced once by the message_start event
+	id    string
+	model string
+
+	// toolCalls accumulates the tool_use blocks and thinking the thinking
+	// blocks, by index, until the message ends
+	toolCalls toolCallAccumulator
+	thinking  map[int]*ThinkingBlock
 }
 
 // CompleteStream implements streaming completion
 func (c *AnthropicClient) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
-	anthropicReq := anthropicRequest{
-		Model: req.Model,
-		Messages: []message{
-			{
-				Role:    "user",
-				Content: req.Prompt,
-			},
-		},
-		MaxTokens:   req.MaxTokens,
-		Temperature: req.Temperature,
-		Stream:      true,
+	if req.N > 1 {
+		return nil, ErrMultipleChoicesUnsupported
+	}
+
+	model, err := c.model(req)
+	if err != nil {
+		return nil, err
 	}
+	anthropicReq := newAnthropicRequest(req, model, true)
+	anthropicReq.MaxTokens = c.maxTokens(req, model)
 
-	body, err := json.Marshal(anthropicReq)
+	extras := newRequestExtras(req)
+	body, err := extras.marshal(anthropicReq)
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", anthropicAPIEndpoint, bytes.NewReader(body))
+	httpReq, err := c.newHTTPRequest(ctx, "POST", "/messages", bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", c.apiKey)
-	httpReq.Header.Set("anthropic-version", "2023-06-01")
+	extras.setHeaders(httpReq.Header)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := extras.client(c.httpClient).Do(httpReq)
 	if err != nil {
 		return nil, err
 	}
 
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, newHTTPError(resp)
+	}
+
 	return &anthropicStream{
-		reader: bufio.NewReader(resp.Body),
-		closer: resp.Body,
+		reader:   bufio.NewReader(resp.Body),
+		closer:   resp.Body,
+		metadata: newResponseMetadata(resp.Header),
+		raw:      req.IncludeRaw,
 	}, nil
 }
 
 // Recv implements the CompletionStream interface
 func (s *anthropicStream) Recv() (*CompletionResponse, error) {
-	line, err := s.reader.ReadBytes('\n')
-	if err != nil {
-		return nil, err
+	for {
+		line, err := s.reader.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF {
+				return s.pendingToolCalls()
+			}
+			return nil, fmt.Errorf("failed to read stream: %w", err)
+		}
+
+		// Skip event names, comments and blank lines between events
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+
+		data := bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+		if len(data) == 0 {
+			continue
+		}
+		if string(data) == "[DONE]" {
+			return s.pendingToolCalls()
+		}
+
+		var streamResp anthropicResponse
+		if err := json.Unmarshal(data, &streamResp); err != nil {
+			return nil, fmt.Errorf("failed to decode stream response: %w", err)
+		}
+
+		if streamResp.Error != nil {
+			return nil, fmt.Errorf("anthropic API error: %s", streamResp.Error.Message)
+		}
+
+		chunk := &CompletionResponse{
+			ID:           streamResp.ID,
+			Model:        streamResp.Model,
+			FinishReason: anthropicFinishReason(streamResp.StopReason),
+			Metadata:     s.metadata,
+			Raw:          rawEvent(data, s.raw),
+		}
+
+		switch streamResp.Type {
+		case "message_start":
+			if streamResp.Message != nil {
+				s.id, s.model = streamResp.Message.ID, streamResp.Message.Model
+			}
+			continue
+		case "content_block_start":
+			block := streamResp.ContentBlock
+			switch {
+			case block == nil:
+			case block.Type == "tool_use":
+				s.toolCalls.add(streamResp.Index, block.ID, block.Name, "")
+			case block.Type == "thinking" || block.Type == "redacted_thinking":
+				s.addThinking(streamResp.Index, ThinkingBlock{Signature: block.Signature, Data: block.Data})
+			}
+			continue
+		case "content_block_delta", "message_delta":
+			if streamResp.Delta == nil {
+				continue
+			}
+			switch streamResp.Delta.Type {
+			case "input_json_delta":
+				s.toolCalls.add(streamResp.Index, "", "", streamResp.Delta.PartialJSON)
+				continue
+			case "thinking_delta", "signature_delta":
+				s.addThinking(streamResp.Index, ThinkingBlock{Thinking: streamResp.Delta.Thinking, Signature: streamResp.Delta.Signature})
+			}
+			chunk.Content = streamResp.Delta.Text
+			chunk.Reasoning = streamResp.Delta.Thinking
+			chunk.FinishReason = anthropicFinishReason(streamResp.Delta.StopReason)
+			if chunk.FinishReason != "" {
+				chunk.ToolCalls = s.flushToolCalls()
+				chunk.Thinking = s.flushThinking()
+			}
+			if chunk.Content == "" && chunk.Reasoning == "" && chunk.FinishReason == "" {
+				// Signature deltas and usage updates
+				continue
+			}
+		case "message_stop":
+			return s.pendingToolCalls()
+		default:
+			// Events carrying whole content blocks
+			if len(streamResp.Content) == 0 {
+				continue
+			}
+			chunk.Content, chunk.Reasoning = anthropicContent(streamResp.Content)
+		}
+
+		if chunk.ID == "" {
+			chunk.ID = s.id
+		}
+		if chunk.Model == "" {
+			chunk.Model = s.model
+		}
+		return chunk, nil
 	}
+}
 
-	if !bytes.HasPrefix(line, []byte("data: ")) {
-		return nil, fmt.Errorf("invalid SSE format")
+// flushToolCalls returns the tool calls accumulated from tool_use blocks
+func (s *anthropicStream) flushToolCalls() []ToolCall {
+	calls := s.toolCalls.flush()
+	for i := range calls {
+		if calls[i].Function.Arguments == "" {
+			// Tools without parameters stream no input
+			calls[i].Function.Arguments = "{}"
+		}
 	}
+	return calls
+}
 
-	data := bytes.TrimPrefix(line, []byte("data: "))
-	if len(data) == 0 {
-		return nil, nil
+// addThinking adds a delta to the thinking block at index
+func (s *anthropicStream) addThinking(index int, delta ThinkingBlock) {
+	if s.thinking == nil {
+		s.thinking = make(map[int]*ThinkingBlock)
 	}
-
-	var streamResp anthropicResponse
-	if err := json.Unmarshal(data, &streamResp); err != nil {
-		return nil, err
+	block, ok := s.thinking[index]
+	if !ok {
+		block = &ThinkingBlock{}
+		s.thinking[index] = block
 	}
+	block.Thinking += delta.Thinking
+	block.Signature += delta.Signature
+	block.Data += delta.Data
+}
 
-	if streamResp.Error != nil {
-		return nil, fmt.Errorf("anthropic API error: %s", streamResp.Error.Message)
+// flushThinking returns the thinking blocks accumulated so far in order
+func (s *anthropicStream) flushThinking() []ThinkingBlock {
+	indexes := make([]int, 0, len(s.thinking))
+	for index := range s.thinking {
+		indexes = append(indexes, index)
 	}
+	sort.Ints(indexes)
 
-	if len(streamResp.Content) == 0 {
-		return nil, nil
+	var blocks []ThinkingBlock
+	for _, index := range indexes {
+		blocks = append(blocks, *s.thinking[index])
 	}
+	s.thinking = nil
+	return blocks
+}
 
+// pendingToolCalls ends the stream, first returning a chunk with the tool
+// calls and thinking blocks of a message that ended without a stop reason
+func (s *anthropicStream) pendingToolCalls() (*CompletionResponse, error) {
+	if !s.toolCalls.pending() && len(s.thinking) == 0 {
+		return nil, io.EOF
+	}
 	return &CompletionResponse{
-		Content:      streamResp.Content[0].Text,
-		Model:        streamResp.Model,
-		FinishReason: streamResp.StopReason,
+		ID:        s.id,
+		Model:     s.model,
+		ToolCalls: s.flushToolCalls(),
+		Thinking:  s.flushThinking(),
+		Metadata:  s.metadata,
 	}, nil
 }
 
@@ -1,12 +1,18 @@
 package llm
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewAnthropicClient(t *testing.T) {
@@ -34,13 +40,68 @@ func TestNewAnthropicClient(t *testing.T) {
 				t.Errorf("NewAnthropicClient() error = %v, wantErr %v", client == nil, tt.wantErr)
 				return
 			}
-			if !tt.wantErr && client.apiKey != tt.apiKey {
-				t.Errorf("NewAnthropicClient() apiKey = %v, want %v", client.apiKey, tt.apiKey)
+			if !tt.wantErr && client.config.APIKey != tt.apiKey {
+				t.Errorf("NewAnthropicClient() apiKey = %v, want %v", client.config.APIKey, tt.apiKey)
 			}
 		})
 	}
 }
 
+func TestNewAnthropicClientWithConfig(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/proxy/v1/messages" {
+			t.Errorf("Path = %v, want /proxy/v1/messages", r.URL.Path)
+		}
+		if got := r.Header.Get("anthropic-version"); got != "2024-01-01" {
+			t.Errorf("anthropic-version = %v, want 2024-01-01", got)
+		}
+
+		var body anthropicRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Model != "claude-3-haiku-20240307" {
+			t.Errorf("model = %v, want default model", body.Model)
+		}
+
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"content":[{"type":"text","text":"ok"}],"model":"claude-3-haiku-20240307"}`))
+	}))
+	defer server.Close()
+
+	client := NewAnthropicClientWithConfig(AnthropicConfig{
+		APIKey:       "test-key",
+		BaseURL:      server.URL + "/proxy/v1/",
+		APIVersion:   "2024-01-01",
+		DefaultModel: "claude-3-haiku-20240307",
+		RetryConfig: &RetryConfig{
+			MaxRetries:           1,
+			InitialDelay:         time.Millisecond,
+			RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+		},
+	})
+
+	resp, err := client.Complete(context.Background(), &CompletionRequest{Prompt: "Hi"})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Content != "ok" || calls != 2 {
+		t.Errorf("Content = %q after %d calls, want ok after 2", resp.Content, calls)
+	}
+
+	defaults := NewAnthropicClient("key").config
+	if defaults.BaseURL != defaultAnthropicBaseURL || defaults.APIVersion != defaultAnthropicAPIVersion || defaults.RetryConfig == nil {
+		t.Errorf("defaults = %+v, want base URL, API version and retry config", defaults)
+	}
+
+	if _, err := NewAnthropicClient("key").Complete(context.Background(), &CompletionRequest{Prompt: "Hi"}); !errors.Is(err, ErrModelRequired) {
+		t.Errorf("Complete() without model error = %v, want ErrModelRequired", err)
+	}
+}
+
 func TestAnthropicClient_Complete(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -54,7 +115,7 @@ func TestAnthropicClient_Complete(t *testing.T) {
 			response: `{
 				"content": [{"type": "text", "text": "Test response"}],
 				"model": "claude-3-opus-20240229",
-				"stop_reason": "stop"
+				"stop_reason": "end_turn"
 			}`,
 			statusCode: http.StatusOK,
 			wantErr:    false,
@@ -108,10 +169,7 @@ func TestAnthropicClient_Complete(t *testing.T) {
 			}))
 			defer server.Close()
 
-			client := &AnthropicClient{
-				apiKey:     "test-key",
-				httpClient: server.Client(),
-			}
+			client := NewAnthropicClientWithConfig(AnthropicConfig{APIKey: "test-key", BaseURL: server.URL + "/v1"})
 
 			got, err := client.Complete(context.Background(), &CompletionRequest{
 				Model:  "claude-3-opus-20240229",
@@ -188,10 +246,7 @@ func TestAnthropicClient_CompleteStream(t *testing.T) {
 			}))
 			defer server.Close()
 
-			client := &AnthropicClient{
-				apiKey:     "test-key",
-				httpClient: server.Client(),
-			}
+			client := NewAnthropicClientWithConfig(AnthropicConfig{APIKey: "test-key", BaseURL: server.URL + "/v1"})
 
 			stream, err := client.CompleteStream(context.Background(), &CompletionRequest{
 				Model:  "claude-3-opus-20240229",
@@ -233,3 +288,533 @@ func TestAnthropicClient_CompleteStream(t *testing.T) {
 		})
 	}
 }
+
+func TestNewAnthropicRequest_Messages(t *testing.T) {
+	weather := ToolCall{ID: "toolu_1", Type: "function"}
+	weather.Function.Name = "get_weather"
+	weather.Function.Arguments = `{"location": "Paris"}`
+	clock := ToolCall{ID: "toolu_2", Type: "function"}
+	clock.Function.Name = "get_time"
+
+	req := &CompletionRequest{
+		Model: "claude-3-opus-20240229",
+		Messages: []Message{
+			{Role: RoleSystem, Content: "Be brief"},
+			{Role: RoleUser, Content: "What is the weather?"},
+			{Role: RoleAssistant, Content: "Let me check.", ToolCalls: []ToolCall{weather, clock}},
+			{Role: RoleTool, Content: "sunny", ToolCallID: "toolu_1"},
+			{Role: RoleTool, Content: "noon", ToolCallID: "toolu_2"},
+			{Role: RoleAssistant, Content: "It is sunny."},
+			{Role: RoleTool, Content: "rain later"},
+		},
+		Prompt: "Thanks",
+	}
+
+	got := newAnthropicRequest(req, req.Model, true)
+
+	if got.System != "Be brief" {
+		t.Errorf("System = %q, want %q", got.System, "Be brief")
+	}
+	if !got.Stream {
+		t.Error("Stream = false, want true")
+	}
+
+	want := []message{
+		{Role: "user", Content: "What is the weather?"},
+		{Role: "assistant", Content: []anthropicBlock{
+			{Type: "text", Text: "Let me check."},
+			{Type: "tool_use", ID: "toolu_1", Name: "get_weather", Input: json.RawMessage(`{"location": "Paris"}`)},
+			{Type: "tool_use", ID: "toolu_2", Name: "get_time", Input: json.RawMessage(`{}`)},
+		}},
+		{Role: "user", Content: []anthropicBlock{
+			{Type: "tool_result", ToolUseID: "toolu_1", Content: "sunny"},
+			{Type: "tool_result", ToolUseID: "toolu_2", Content: "noon"},
+		}},
+		{Role: "assistant", Content: "It is sunny."},
+		{Role: "user", Content: "rain later"},
+		{Role: "user", Content: "Thanks"},
+	}
+	if !reflect.DeepEqual(got.Messages, want) {
+		t.Errorf("Messages = %+v, want %+v", got.Messages, want)
+	}
+
+	body, err := json.Marshal(got.Messages[1:3])
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	wantBody := `[{"role":"assistant","content":[{"type":"text","text":"Let me check."},` +
+		`{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"location":"Paris"}},` +
+		`{"type":"tool_use","id":"toolu_2","name":"get_time","input":{}}]},` +
+		`{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"sunny"},` +
+		`{"type":"tool_result","tool_use_id":"toolu_2","content":"noon"}]}]`
+	if string(body) != wantBody {
+		t.Errorf("encoded messages = %s\nwant %s", body, wantBody)
+	}
+}
+
+func FuzzAnthropicStream(f *testing.F) {
+	f.Add([]byte("data: {\"content\":[{\"type\":\"text\",\"text\":\"Hello\"}],\"model\":\"claude-3-opus-20240229\"}\n\n"))
+	f.Add([]byte("event: ping\ndata: {\"type\":\"ping\"}\n\n"))
+	f.Add([]byte("data: {\"error\":{\"message\":\"overloaded\"}}\n"))
+	f.Add([]byte("data: \n\n"))
+	f.Add([]byte("data: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"thinking_delta\",\"thinking\":\"Hmm\"}}\n\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkStream(t, &anthropicStream{
+			reader: bufio.NewReader(bytes.NewReader(data)),
+			closer: io.NopCloser(nil),
+		})
+	})
+}
+
+func TestNewAnthropicRequest_SystemPrompt(t *testing.T) {
+	req := &CompletionRequest{
+		SystemPrompt: "You are a pirate",
+		Messages:     []Message{{Role: RoleSystem, Content: "Be brief"}},
+		Prompt:       "Hello",
+	}
+
+	got := newAnthropicRequest(req, req.Model, false)
+
+	if want := "You are a pirate\n\nBe brief"; got.System != want {
+		t.Errorf("System = %q, want %q", got.System, want)
+	}
+	want := []message{{Role: "user", Content: "Hello"}}
+	if !reflect.DeepEqual(got.Messages, want) {
+		t.Errorf("Messages = %+v, want %+v", got.Messages, want)
+	}
+}
+
+func TestAnthropicClient_ListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			t.Errorf("Path = %v, want /v1/models", r.URL.Path)
+		}
+		if got := r.Header.Get("x-api-key"); got != "test-key" {
+			t.Errorf("x-api-key = %v, want test-key", got)
+		}
+
+		switch r.URL.Query().Get("after_id") {
+		case "":
+			w.Write([]byte(`{"data":[{"type":"model","id":"claude-3-5-sonnet-20241022","display_name":"Claude 3.5 Sonnet","created_at":"2024-10-22T00:00:00Z"}],"has_more":true,"last_id":"claude-3-5-sonnet-20241022"}`))
+		case "claude-3-5-sonnet-20241022":
+			w.Write([]byte(`{"data":[{"type":"model","id":"claude-3-haiku-20240307","display_name":"Claude 3 Haiku","created_at":"2024-03-07T00:00:00Z"}],"has_more":false,"last_id":"claude-3-haiku-20240307"}`))
+		default:
+			t.Errorf("unexpected after_id %q", r.URL.Query().Get("after_id"))
+		}
+	}))
+	defer server.Close()
+
+	client := NewAnthropicClientWithConfig(AnthropicConfig{APIKey: "test-key", BaseURL: server.URL + "/v1"})
+
+	got, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+
+	want := []ModelInfo{
+		{ID: "claude-3-5-sonnet-20241022", DisplayName: "Claude 3.5 Sonnet", OwnedBy: "anthropic", Created: time.Date(2024, 10, 22, 0, 0, 0, 0, time.UTC)},
+		{ID: "claude-3-haiku-20240307", DisplayName: "Claude 3 Haiku", OwnedBy: "anthropic", Created: time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListModels() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAnthropicClient_MaxTokens(t *testing.T) {
+	var got int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body anthropicRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		got = body.MaxTokens
+		w.Write([]byte(`{"content":[{"type":"text","text":"ok"}]}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name             string
+		model            string
+		maxTokens        int
+		defaultMaxTokens int
+		want             int
+	}{
+		{name: "request", model: "claude-3-5-sonnet-latest", maxTokens: 100, defaultMaxTokens: 500, want: 100},
+		{name: "client default", model: "claude-3-5-sonnet-latest", defaultMaxTokens: 500, want: 500},
+		{name: "claude 3", model: "claude-3-opus-20240229", want: 4096},
+		{name: "claude 3.5", model: "claude-3-5-haiku-20241022", want: 8192},
+		{name: "claude 4", model: "claude-sonnet-4-20250514", want: 8192},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewAnthropicClientWithConfig(AnthropicConfig{BaseURL: server.URL, DefaultMaxTokens: tt.defaultMaxTokens})
+			_, err := client.Complete(context.Background(), &CompletionRequest{Model: tt.model, Prompt: "Hi", MaxTokens: tt.maxTokens})
+			if err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("max_tokens = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnthropicClient_CompleteStreamHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Request-Id", "req_1")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+	}))
+	defer server.Close()
+
+	client := NewAnthropicClientWithConfig(AnthropicConfig{BaseURL: server.URL})
+	_, err := client.CompleteStream(context.Background(), &CompletionRequest{Model: "claude-3-opus-20240229", Prompt: "Hi"})
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("CompleteStream() error = %v, want *HTTPError", err)
+	}
+	if httpErr.StatusCode != http.StatusUnauthorized || httpErr.RequestID != "req_1" {
+		t.Errorf("HTTPError = %+v, want 401 with request ID", httpErr)
+	}
+	if !strings.Contains(httpErr.Message, "invalid x-api-key") {
+		t.Errorf("Message = %q, want provider message", httpErr.Message)
+	}
+}
+
+func TestAnthropicClient_Thinking(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{
+			"id": "msg_1",
+			"model": "claude-sonnet-4-20250514",
+			"stop_reason": "end_turn",
+			"content": [
+				{"type": "thinking", "thinking": "The user wants a sum.", "signature": "sig"},
+				{"type": "redacted_thinking", "data": "opaque"},
+				{"type": "text", "text": "2 + 2 = 4"}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewAnthropicClientWithConfig(AnthropicConfig{BaseURL: server.URL})
+	resp, err := client.Complete(context.Background(), &CompletionRequest{
+		Model:          "claude-sonnet-4-20250514",
+		Prompt:         "What is 2 + 2?",
+		ThinkingBudget: 2048,
+	})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	wantThinking := map[string]any{"type": "enabled", "budget_tokens": 2048.0}
+	if !reflect.DeepEqual(body["thinking"], wantThinking) {
+		t.Errorf("thinking = %v, want %v", body["thinking"], wantThinking)
+	}
+	if body["max_tokens"] != 2048.0+8192 {
+		t.Errorf("max_tokens = %v, want budget plus default", body["max_tokens"])
+	}
+	if resp.Content != "2 + 2 = 4" || resp.Reasoning != "The user wants a sum." {
+		t.Errorf("Content = %q, Reasoning = %q", resp.Content, resp.Reasoning)
+	}
+	wantBlocks := []ThinkingBlock{{Thinking: "The user wants a sum.", Signature: "sig"}, {Data: "opaque"}}
+	if !reflect.DeepEqual(resp.Thinking, wantBlocks) {
+		t.Errorf("Thinking = %+v, want %+v", resp.Thinking, wantBlocks)
+	}
+
+	call := ToolCall{ID: "toolu_1", Type: "function"}
+	call.Function.Name = "add"
+	call.Function.Arguments = `{"a":2,"b":2}`
+	_, err = client.Complete(context.Background(), &CompletionRequest{
+		Model: "claude-sonnet-4-20250514",
+		Messages: []Message{
+			{Role: RoleUser, Content: "What is 2 + 2?"},
+			{Role: RoleAssistant, ToolCalls: []ToolCall{call}, Thinking: resp.Thinking},
+			{Role: RoleTool, ToolCallID: "toolu_1", Content: "4"},
+		},
+		ThinkingBudget: 2048,
+	})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	wantContent := []any{
+		map[string]any{"type": "thinking", "thinking": "The user wants a sum.", "signature": "sig"},
+		map[string]any{"type": "redacted_thinking", "data": "opaque"},
+		map[string]any{"type": "tool_use", "id": "toolu_1", "name": "add", "input": map[string]any{"a": 2.0, "b": 2.0}},
+	}
+	replayed := body["messages"].([]any)[1].(map[string]any)
+	if !reflect.DeepEqual(replayed["content"], wantContent) {
+		t.Errorf("assistant content = %v, want %v", replayed["content"], wantContent)
+	}
+}
+
+func TestAnthropicStream_Events(t *testing.T) {
+	events := strings.Join([]string{
+		`event: message_start`,
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-20250514","content":[]}}`,
+		`event: content_block_start`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Adding "}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"numbers."}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"It is "}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"4."}}`,
+		`event: ping`,
+		`data: {"type":"ping"}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":12}}`,
+		`data: {"type":"message_stop"}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"after stop"}}`,
+	}, "\n\n")
+
+	stream := &anthropicStream{
+		reader: bufio.NewReader(strings.NewReader(events)),
+		closer: io.NopCloser(nil),
+	}
+
+	var content, reasoning strings.Builder
+	var finishReason string
+	var thinking []ThinkingBlock
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		if chunk.ID != "msg_1" || chunk.Model != "claude-sonnet-4-20250514" {
+			t.Errorf("chunk ID = %q, Model = %q, want message_start values", chunk.ID, chunk.Model)
+		}
+		content.WriteString(chunk.Content)
+		reasoning.WriteString(chunk.Reasoning)
+		thinking = append(thinking, chunk.Thinking...)
+		if chunk.FinishReason != "" {
+			finishReason = chunk.FinishReason
+		}
+	}
+
+	if content.String() != "It is 4." || reasoning.String() != "Adding numbers." || finishReason != "stop" {
+		t.Errorf("Content = %q, Reasoning = %q, FinishReason = %q", content.String(), reasoning.String(), finishReason)
+	}
+	if want := []ThinkingBlock{{Thinking: "Adding numbers.", Signature: "sig"}}; !reflect.DeepEqual(thinking, want) {
+		t.Errorf("Thinking = %+v, want %+v", thinking, want)
+	}
+}
+
+func TestAnthropicClient_User(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"content": [{"type": "text", "text": "Hi"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewAnthropicClientWithConfig(AnthropicConfig{BaseURL: server.URL, DefaultModel: "claude-3-5-haiku-latest"})
+
+	tests := []struct {
+		name string
+		req  CompletionRequest
+		want any
+	}{
+		{
+			name: "user forwarded",
+			req:  CompletionRequest{Prompt: "Hi", User: "user-7f3a", Metadata: map[string]string{"feature": "chat"}},
+			want: map[string]any{"user_id": "user-7f3a"},
+		},
+		{
+			name: "no user",
+			req:  CompletionRequest{Prompt: "Hi", Metadata: map[string]string{"feature": "chat"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.Complete(context.Background(), &tt.req); err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if !reflect.DeepEqual(body["metadata"], tt.want) {
+				t.Errorf("metadata = %v, want %v", body["metadata"], tt.want)
+			}
+		})
+	}
+}
+
+func TestAnthropicClient_Tools(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{
+			"content": [
+				{"type": "text", "text": "Let me check."},
+				{"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": {"location": "Paris"}}
+			],
+			"stop_reason": "tool_use"
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewAnthropicClientWithConfig(AnthropicConfig{BaseURL: server.URL, DefaultModel: "claude-3-5-haiku-latest"})
+	tools := []Tool{
+		{Type: "function", Function: Function{
+			Name:        "get_weather",
+			Description: "Get the weather",
+			Parameters:  map[string]any{"type": "object", "properties": map[string]any{"location": map[string]any{"type": "string"}}},
+		}},
+		{Type: "function", Function: Function{Name: "get_time"}},
+	}
+
+	tests := []struct {
+		name   string
+		tools  []Tool
+		choice string
+		want   any
+	}{
+		{name: "default", tools: tools},
+		{name: "auto", tools: tools, choice: ToolChoiceAuto, want: map[string]any{"type": "auto"}},
+		{name: "none", tools: tools, choice: ToolChoiceNone, want: map[string]any{"type": "none"}},
+		{name: "required", tools: tools, choice: ToolChoiceRequired, want: map[string]any{"type": "any"}},
+		{name: "function", tools: tools, choice: "get_time", want: map[string]any{"type": "tool", "name": "get_time"}},
+		{name: "no tools", choice: ToolChoiceRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &CompletionRequest{Prompt: "Weather in Paris?", Tools: tt.tools, ToolChoice: tt.choice}
+			resp, err := client.Complete(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if !reflect.DeepEqual(body["tool_choice"], tt.want) {
+				t.Errorf("tool_choice = %v, want %v", body["tool_choice"], tt.want)
+			}
+			if tt.tools == nil {
+				if _, ok := body["tools"]; ok {
+					t.Errorf("tools sent without being set: %v", body["tools"])
+				}
+			}
+
+			if resp.Content != "Let me check." || resp.FinishReason != "tool_calls" {
+				t.Errorf("Content = %q, FinishReason = %q, want the text block only and tool_calls", resp.Content, resp.FinishReason)
+			}
+			if len(resp.ToolCalls) != 1 {
+				t.Fatalf("ToolCalls = %+v, want 1 call", resp.ToolCalls)
+			}
+			call := resp.ToolCalls[0]
+			if call.ID != "toolu_1" || call.Type != "function" || call.Function.Name != "get_weather" || call.Function.Arguments != `{"location": "Paris"}` {
+				t.Errorf("ToolCalls[0] = %+v", call)
+			}
+		})
+	}
+
+	want := []any{
+		map[string]any{
+			"name":         "get_weather",
+			"description":  "Get the weather",
+			"input_schema": map[string]any{"type": "object", "properties": map[string]any{"location": map[string]any{"type": "string"}}},
+		},
+		map[string]any{"name": "get_time", "input_schema": map[string]any{"type": "object"}},
+	}
+	req := &CompletionRequest{Prompt: "Weather in Paris?", Tools: tools}
+	if _, err := client.Complete(context.Background(), req); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if !reflect.DeepEqual(body["tools"], want) {
+		t.Errorf("tools = %v, want %v", body["tools"], want)
+	}
+}
+
+func TestAnthropicStream_ToolUse(t *testing.T) {
+	events := []string{
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-20250514","content":[]}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking."}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":""}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"location\": "}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}`,
+		`data: {"type":"content_block_stop","index":1}`,
+		`data: {"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_2","name":"get_time","input":{}}}`,
+		`data: {"type":"content_block_stop","index":2}`,
+	}
+
+	tests := []struct {
+		name string
+		end  []string
+	}{
+		{
+			name: "stop reason",
+			end: []string{
+				`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":12}}`,
+				`data: {"type":"message_stop"}`,
+			},
+		},
+		{
+			name: "no stop reason",
+			end:  []string{`data: {"type":"message_stop"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := &anthropicStream{
+				reader: bufio.NewReader(strings.NewReader(strings.Join(append(events, tt.end...), "\n\n"))),
+				closer: io.NopCloser(nil),
+			}
+
+			var content strings.Builder
+			var calls []string
+			for {
+				chunk, err := stream.Recv()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("Recv() error = %v", err)
+				}
+				if chunk.ID != "msg_1" {
+					t.Errorf("chunk ID = %q, want msg_1", chunk.ID)
+				}
+				content.WriteString(chunk.Content)
+				for _, call := range chunk.ToolCalls {
+					calls = append(calls, call.ID+" "+call.Type+" "+call.Function.Name+" "+call.Function.Arguments)
+				}
+			}
+
+			want := []string{`toolu_1 function get_weather {"location": "Paris"}`, "toolu_2 function get_time {}"}
+			if content.String() != "Checking." || !reflect.DeepEqual(calls, want) {
+				t.Errorf("Content = %q, tool calls = %q, want %q", content.String(), calls, want)
+			}
+		})
+	}
+}
+
+func TestAnthropicClient_ContentParts(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"content": [{"type": "text", "text": "Two cats."}]}`))
+	}))
+	defer server.Close()
+
+	client := NewAnthropicClientWithConfig(AnthropicConfig{BaseURL: server.URL, DefaultModel: "claude-sonnet-4-20250514"})
+	messages := NewMessages().
+		User("What are these?").Image("https://example.com/cat.jpg").ImageData("image/png", []byte("png")).
+		Build()
+	if _, err := client.Complete(context.Background(), &CompletionRequest{Messages: messages}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	want := []any{map[string]any{"role": "user", "content": []any{
+		map[string]any{"type": "text", "text": "What are these?"},
+		map[string]any{"type": "image", "source": map[string]any{"type": "url", "url": "https://example.com/cat.jpg"}},
+		map[string]any{"type": "image", "source": map[string]any{"type": "base64", "media_type": "image/png", "data": "cG5n"}},
+	}}}
+	if !reflect.DeepEqual(body["messages"], want) {
+		t.Errorf("messages = %v, want %v", body["messages"], want)
+	}
+}
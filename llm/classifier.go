@@ -0,0 +1,226 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aiwizzard/gollm/embeddings"
+)
+
+const (
+	defaultClassifierCacheSize = 1024
+
+	classifierPrompt = "Decide whether answering the request below needs a highly capable model " +
+		"(multi-step reasoning, math, code, long or nuanced writing) or whether a small, fast " +
+		"model will do. Reply with exactly one word: COMPLEX or SIMPLE.\n\nRequest:\n"
+)
+
+// ComplexityClassifier decides whether a request needs the premium model of
+// a ModelPolicy
+type ComplexityClassifier interface {
+	IsComplex(ctx context.Context, req *CompletionRequest) (bool, error)
+}
+
+// LLMClassifier is a ComplexityClassifier that asks a model, usually a small
+// and cheap one, to rate each request. Verdicts are cached by request text,
+// so repeated prompts cost a single classification. It is safe for
+// concurrent use.
+type LLMClassifier struct {
+	provider LLMProvider
+	model    string
+	cache    *classifierCache
+}
+
+// NewLLMClassifier creates a classifier that sends its questions to model on
+// provider
+func NewLLMClassifier(provider LLMProvider, model string) *LLMClassifier {
+	return &LLMClassifier{
+		provider: provider,
+		model:    model,
+		cache:    newClassifierCache(defaultClassifierCacheSize),
+	}
+}
+
+// IsComplex implements the ComplexityClassifier interface
+func (c *LLMClassifier) IsComplex(ctx context.Context, req *CompletionRequest) (bool, error) {
+	text := classificationText(req)
+	if verdict, ok := c.cache.get(text); ok {
+		return verdict, nil
+	}
+
+	resp, err := c.provider.Complete(ctx, &CompletionRequest{
+		Model:     c.model,
+		Prompt:    classifierPrompt + text,
+		MaxTokens: 5,
+	})
+	if err != nil {
+		return false, fmt.Errorf("complexity classification failed: %w", err)
+	}
+
+	var verdict bool
+	switch answer := strings.ToUpper(resp.Content); {
+	case strings.Contains(answer, "COMPLEX"):
+		verdict = true
+	case strings.Contains(answer, "SIMPLE"):
+		verdict = false
+	default:
+		return false, fmt.Errorf("complexity classification failed: unexpected answer %q", resp.Content)
+	}
+
+	c.cache.put(text, verdict)
+	return verdict, nil
+}
+
+// EmbeddingFunc returns the embedding of text
+type EmbeddingFunc func(ctx context.Context, text string) ([]float32, error)
+
+// EmbeddingClassifier is a ComplexityClassifier trained on example prompts.
+// A request is complex when its embedding is closer to the centroid of the
+// complex examples than to that of the simple ones by more than Threshold.
+// It is safe for concurrent use once trained.
+type EmbeddingClassifier struct {
+	embed EmbeddingFunc
+
+	// Threshold is the margin of similarity to the complex centroid over the
+	// simple centroid above which a request is complex. Zero picks the
+	// closer centroid; raise it to favor the cheap model.
+	Threshold float64
+
+	simpleCentroid  []float32
+	complexCentroid []float32
+	cache           *classifierCache
+}
+
+// NewEmbeddingClassifier creates a classifier that embeds text with embed.
+// It must be trained with Train before use.
+func NewEmbeddingClassifier(embed EmbeddingFunc) *EmbeddingClassifier {
+	return &EmbeddingClassifier{
+		embed: embed,
+		cache: newClassifierCache(defaultClassifierCacheSize),
+	}
+}
+
+// Train computes the centroids of the simple and complex example prompts
+func (c *EmbeddingClassifier) Train(ctx context.Context, simplePrompts, complexPrompts []string) error {
+	if len(simplePrompts) == 0 || len(complexPrompts) == 0 {
+		return errors.New("training requires simple and complex examples")
+	}
+
+	var err error
+	if c.simpleCentroid, err = c.centroid(ctx, simplePrompts); err != nil {
+		return err
+	}
+	if c.complexCentroid, err = c.centroid(ctx, complexPrompts); err != nil {
+		return err
+	}
+	c.cache.clear()
+	return nil
+}
+
+// IsComplex implements the ComplexityClassifier interface
+func (c *EmbeddingClassifier) IsComplex(ctx context.Context, req *CompletionRequest) (bool, error) {
+	if c.simpleCentroid == nil {
+		return false, errors.New("embedding classifier is not trained")
+	}
+
+	text := classificationText(req)
+	if verdict, ok := c.cache.get(text); ok {
+		return verdict, nil
+	}
+
+	vector, err := c.embed(ctx, text)
+	if err != nil {
+		return false, fmt.Errorf("failed to embed request: %w", err)
+	}
+
+	simpleSimilarity, err := embeddings.CosineSimilarity(vector, c.simpleCentroid)
+	if err != nil {
+		return false, err
+	}
+	complexSimilarity, err := embeddings.CosineSimilarity(vector, c.complexCentroid)
+	if err != nil {
+		return false, err
+	}
+
+	verdict := complexSimilarity-simpleSimilarity > c.Threshold
+	c.cache.put(text, verdict)
+	return verdict, nil
+}
+
+// centroid returns the normalized mean of the normalized embeddings of texts
+func (c *EmbeddingClassifier) centroid(ctx context.Context, texts []string) ([]float32, error) {
+	var sum []float32
+	for _, text := range texts {
+		vector, err := c.embed(ctx, text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed example: %w", err)
+		}
+		vector = embeddings.Normalize(vector)
+
+		if sum == nil {
+			sum = make([]float32, len(vector))
+		}
+		if len(vector) != len(sum) {
+			return nil, embeddings.ErrDimensionMismatch
+		}
+		for i, value := range vector {
+			sum[i] += value
+		}
+	}
+	return embeddings.Normalize(sum), nil
+}
+
+// classificationText is the part of a request a classifier judges: the
+// system prompt and the latest user message
+func classificationText(req *CompletionRequest) string {
+	messages := req.messages()
+	var parts []string
+	for _, msg := range messages {
+		if msg.Role == RoleSystem {
+			parts = append(parts, msg.Content)
+		}
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == RoleUser {
+			parts = append(parts, messages[i].Content)
+			break
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// classifierCache is a bounded cache of verdicts that is cleared once full
+type classifierCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]bool
+}
+
+func newClassifierCache(size int) *classifierCache {
+	return &classifierCache{size: size, entries: make(map[string]bool)}
+}
+
+func (c *classifierCache) get(text string) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	verdict, ok := c.entries[text]
+	return verdict, ok
+}
+
+func (c *classifierCache) put(text string, verdict bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= c.size {
+		clear(c.entries)
+	}
+	c.entries[text] = verdict
+}
+
+func (c *classifierCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
@@ -0,0 +1,176 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLLMClassifier(t *testing.T) {
+	mock := &mockProvider{complete: func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+		switch {
+		case strings.Contains(req.Prompt, "proof"):
+			return &CompletionResponse{Content: "COMPLEX"}, nil
+		case strings.Contains(req.Prompt, "???"):
+			return &CompletionResponse{Content: "I am not sure"}, nil
+		}
+		return &CompletionResponse{Content: "simple."}, nil
+	}}
+	classifier := NewLLMClassifier(mock, "small")
+
+	tests := []struct {
+		prompt  string
+		want    bool
+		wantErr bool
+	}{
+		{prompt: "Write a proof that there are infinitely many primes", want: true},
+		{prompt: "What is the capital of France?", want: false},
+		{prompt: "???", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prompt, func(t *testing.T) {
+			got, err := classifier.IsComplex(context.Background(), &CompletionRequest{Prompt: tt.prompt})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("IsComplex() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("IsComplex() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if mock.requests[0].Model != "small" {
+		t.Errorf("Model = %q, want small", mock.requests[0].Model)
+	}
+
+	// Verdicts are cached, failures are not
+	calls := len(mock.requests)
+	classifier.IsComplex(context.Background(), &CompletionRequest{Prompt: "What is the capital of France?"})
+	classifier.IsComplex(context.Background(), &CompletionRequest{Prompt: "???"})
+	if got := len(mock.requests) - calls; got != 1 {
+		t.Errorf("classification calls = %d, want 1", got)
+	}
+}
+
+// keywordEmbedding embeds text on two axes: how technical and how casual it is
+func keywordEmbedding(ctx context.Context, text string) ([]float32, error) {
+	vector := []float32{0.1, 0.1}
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		switch word {
+		case "prove", "derive", "optimize", "algorithm":
+			vector[0]++
+		case "hi", "thanks", "weather", "hello":
+			vector[1]++
+		}
+	}
+	return vector, nil
+}
+
+func TestEmbeddingClassifier(t *testing.T) {
+	classifier := NewEmbeddingClassifier(keywordEmbedding)
+
+	if _, err := classifier.IsComplex(context.Background(), &CompletionRequest{Prompt: "hi"}); err == nil {
+		t.Error("IsComplex() before Train succeeded")
+	}
+
+	err := classifier.Train(context.Background(),
+		[]string{"hi there", "thanks a lot", "what is the weather"},
+		[]string{"prove this lemma", "derive the gradient", "optimize this algorithm"},
+	)
+	if err != nil {
+		t.Fatalf("Train() error = %v", err)
+	}
+
+	tests := []struct {
+		prompt string
+		want   bool
+	}{
+		{prompt: "hello, how is the weather", want: false},
+		{prompt: "prove the algorithm terminates", want: true},
+	}
+	for _, tt := range tests {
+		got, err := classifier.IsComplex(context.Background(), &CompletionRequest{Prompt: tt.prompt})
+		if err != nil {
+			t.Fatalf("IsComplex() error = %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("IsComplex(%q) = %v, want %v", tt.prompt, got, tt.want)
+		}
+	}
+
+	if err := classifier.Train(context.Background(), nil, []string{"prove"}); err == nil {
+		t.Error("Train() without simple examples succeeded")
+	}
+}
+
+type stubClassifier struct {
+	complex bool
+	err     error
+	calls   int
+}
+
+func (c *stubClassifier) IsComplex(ctx context.Context, req *CompletionRequest) (bool, error) {
+	c.calls++
+	return c.complex, c.err
+}
+
+func TestModelPolicy_Classifier(t *testing.T) {
+	tests := []struct {
+		name       string
+		classifier *stubClassifier
+		req        CompletionRequest
+		want       string
+		wantCalls  int
+	}{
+		{
+			name:       "classified complex",
+			classifier: &stubClassifier{complex: true},
+			req:        CompletionRequest{Prompt: "Hi"},
+			want:       "premium",
+			wantCalls:  1,
+		},
+		{
+			name:       "classified simple",
+			classifier: &stubClassifier{},
+			req:        CompletionRequest{Prompt: "Hi"},
+			want:       "cheap",
+			wantCalls:  1,
+		},
+		{
+			name:       "classifier error",
+			classifier: &stubClassifier{complex: true, err: errors.New("unavailable")},
+			req:        CompletionRequest{Prompt: "Hi"},
+			want:       "cheap",
+			wantCalls:  1,
+		},
+		{
+			name:       "heuristics decide first",
+			classifier: &stubClassifier{},
+			req:        CompletionRequest{Prompt: "Hi", Tools: []Tool{{Type: "function"}}},
+			want:       "premium",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := Chain(&mockProvider{}, ModelPolicy(ModelPolicyConfig{
+				CheapModel:   "cheap",
+				PremiumModel: "premium",
+				Classifier:   tt.classifier,
+			}))
+
+			resp, err := provider.Complete(context.Background(), &tt.req)
+			if err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if resp.Model != tt.want {
+				t.Errorf("Model = %v, want %v", resp.Model, tt.want)
+			}
+			if tt.classifier.calls != tt.wantCalls {
+				t.Errorf("classifier calls = %d, want %d", tt.classifier.calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,403 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCohereBaseURL = "https://api.cohere.com/v2"
+)
+
+// CohereConfig contains configuration options for the Cohere client
+type CohereConfig struct {
+	// APIKey is your Cohere API key
+	APIKey string
+
+	// Credentials supplies the API key for each request in place of APIKey
+	// (optional)
+	Credentials CredentialProvider
+
+	// BaseURL is the base URL for Cohere API (optional, defaults to https://api.cohere.com/v2)
+	BaseURL string
+
+	// Timeout is the timeout for API requests (optional, defaults to 30 seconds)
+	Timeout time.Duration
+
+	// HTTPClient is a custom HTTP client (optional)
+	HTTPClient *http.Client
+
+	// RetryConfig contains retry configuration (optional)
+	RetryConfig *RetryConfig
+}
+
+// CohereClient implements the LLMProvider interface for Cohere's Chat API v2
+type CohereClient struct {
+	config     CohereConfig
+	httpClient *http.Client
+}
+
+// NewCohereClient creates a new Cohere client with the given configuration
+func NewCohereClient(config CohereConfig) *CohereClient {
+	if config.BaseURL == "" {
+		config.BaseURL = defaultCohereBaseURL
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{
+			Timeout: config.Timeout,
+		}
+	}
+
+	if config.RetryConfig == nil {
+		config.RetryConfig = defaultRetryConfig()
+	}
+
+	return &CohereClient{
+		config:     config,
+		httpClient: config.HTTPClient,
+	}
+}
+
+// NewCohereClientWithKey creates a new Cohere client with just an API key
+func NewCohereClientWithKey(apiKey string) *CohereClient {
+	return NewCohereClient(CohereConfig{
+		APIKey: apiKey,
+	})
+}
+
+type cohereRequest struct {
+	Model         string          `json:"model"`
+	Messages      []cohereMessage `json:"messages"`
+	MaxTokens     int             `json:"max_tokens,omitempty"`
+	Temperature   *float32        `json:"temperature,omitempty"`
+	P             float32         `json:"p,omitempty"`
+	StopSequences []string        `json:"stop_sequences,omitempty"`
+	Seed          *int64          `json:"seed,omitempty"`
+	Stream        bool            `json:"stream,omitempty"`
+	Tools         []Tool          `json:"tools,omitempty"`
+
+	FrequencyPenalty float32 `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float32 `json:"presence_penalty,omitempty"`
+
+	extras requestExtras
+}
+
+type cohereMessage struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+}
+
+type cohereContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type cohereResponse struct {
+	ID           string `json:"id"`
+	FinishReason string `json:"finish_reason"`
+	Message      struct {
+		Role      string          `json:"role"`
+		Content   []cohereContent `json:"content"`
+		ToolCalls []ToolCall      `json:"tool_calls"`
+		ToolPlan  string          `json:"tool_plan"`
+	} `json:"message"`
+}
+
+// cohereStreamEvent is a single server-sent event of the v2 chat stream.
+// Text arrives in content-delta events and tool calls in tool-call-start and
+// tool-call-delta events, the call at Index starting with its ID and name and
+// continuing with fragments of its arguments, while message-end carries the
+// finish reason.
+type cohereStreamEvent struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+	Delta struct {
+		FinishReason string `json:"finish_reason"`
+		Message      struct {
+			Content struct {
+				Text string `json:"text"`
+			} `json:"content"`
+			ToolCalls ToolCall `json:"tool_calls"`
+		} `json:"message"`
+	} `json:"delta"`
+}
+
+type cohereError struct {
+	Message string `json:"message"`
+}
+
+// Complete implements non-streaming completion with retry support
+func (c *CohereClient) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	if req.N > 1 {
+		return nil, ErrMultipleChoicesUnsupported
+	}
+	if hasParts(req.Messages) {
+		return nil, ErrContentPartsUnsupported
+	}
+
+	var resp *CompletionResponse
+	err := retry(ctx, c.config.RetryConfig, func() error {
+		var err error
+		resp, err = c.complete(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *CohereClient) complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	resp, err := c.do(ctx, c.newRequest(req, false))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var cohereResp cohereResponse
+	raw, err := decodeResponse(resp.Body, &cohereResp, req.IncludeRaw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(cohereResp.Message.Content) == 0 && len(cohereResp.Message.ToolCalls) == 0 {
+		return nil, errors.New("no content in response")
+	}
+
+	var content strings.Builder
+	for _, block := range cohereResp.Message.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+
+	return &CompletionResponse{
+		ID:           cohereResp.ID,
+		Content:      content.String(),
+		Model:        req.Model,
+		FinishReason: cohereFinishReason(cohereResp.FinishReason),
+		ToolCalls:    cohereResp.Message.ToolCalls,
+		Metadata:     newResponseMetadata(resp.Header),
+		Raw:          raw,
+	}, nil
+}
+
+func (c *CohereClient) newRequest(req *CompletionRequest, stream bool) cohereRequest {
+	cohereReq := cohereRequest{
+		Model:         req.Model,
+		MaxTokens:     req.MaxTokens,
+		Temperature:   req.Temperature,
+		P:             req.TopP,
+		StopSequences: req.Stop,
+		Seed:          req.Seed,
+		Stream:        stream,
+		Tools:         req.Tools,
+
+		FrequencyPenalty: req.FrequencyPenalty,
+		PresencePenalty:  req.PresencePenalty,
+
+		extras: newRequestExtras(req),
+	}
+
+	for _, msg := range req.messages() {
+		cohereReq.Messages = append(cohereReq.Messages, cohereMessage{
+			Role:       msg.Role,
+			Content:    msg.Content,
+			ToolCallID: msg.ToolCallID,
+			ToolCalls:  msg.ToolCalls,
+		})
+	}
+
+	return cohereReq
+}
+
+func (c *CohereClient) do(ctx context.Context, cohereReq cohereRequest) (*http.Response, error) {
+	body, err := cohereReq.extras.marshal(cohereReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/chat", strings.TrimRight(c.config.BaseURL, "/"))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	apiKey, err := resolveAPIKey(ctx, c.config.Credentials, c.config.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	if cohereReq.Stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	cohereReq.extras.setHeaders(httpReq.Header)
+
+	resp, err := cohereReq.extras.client(c.httpClient).Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		httpErr := newHTTPError(resp)
+
+		var cohereErr cohereError
+		if json.Unmarshal([]byte(httpErr.Message), &cohereErr) == nil && cohereErr.Message != "" {
+			httpErr.Message = cohereErr.Message
+		}
+		return nil, httpErr
+	}
+
+	return resp, nil
+}
+
+// cohereFinishReason maps Cohere's upper-case finish reasons onto the values
+// used by the other providers
+func cohereFinishReason(reason string) string {
+	switch reason {
+	case "COMPLETE", "STOP_SEQUENCE":
+		return "stop"
+	case "MAX_TOKENS":
+		return "length"
+	case "TOOL_CALL":
+		return "tool_calls"
+	default:
+		return strings.ToLower(reason)
+	}
+}
+
+// cohereStream implements CompletionStream for Cohere
+type cohereStream struct {
+	reader   *bufio.Reader
+	closer   io.Closer
+	model    string
+	id       string
+	done     bool
+	metadata *ResponseMetadata
+	raw      bool
+
+	// toolCalls merges the tool-call-start and tool-call-delta events of
+	// each call until the message ends
+	toolCalls toolCallAccumulator
+}
+
+// CompleteStream implements streaming completion
+func (c *CohereClient) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
+	if req.N > 1 {
+		return nil, ErrMultipleChoicesUnsupported
+	}
+	if hasParts(req.Messages) {
+		return nil, ErrContentPartsUnsupported
+	}
+
+	resp, err := c.do(ctx, c.newRequest(req, true))
+	if err != nil {
+		return nil, err
+	}
+
+	return &cohereStream{
+		reader:   bufio.NewReader(resp.Body),
+		closer:   resp.Body,
+		model:    req.Model,
+		metadata: newResponseMetadata(resp.Header),
+		raw:      req.IncludeRaw,
+	}, nil
+}
+
+// Recv implements the CompletionStream interface
+func (s *cohereStream) Recv() (*CompletionResponse, error) {
+	for {
+		if s.done {
+			return nil, io.EOF
+		}
+
+		line, err := s.reader.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF {
+				return s.pendingToolCalls()
+			}
+			return nil, fmt.Errorf("failed to read stream: %w", err)
+		}
+
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+
+		data := bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+		if len(data) == 0 {
+			continue
+		}
+
+		var event cohereStreamEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, fmt.Errorf("failed to decode stream response: %w", err)
+		}
+
+		var resp *CompletionResponse
+		switch event.Type {
+		case "message-start":
+			s.id = event.ID
+		case "content-delta":
+			resp = &CompletionResponse{
+				ID:      s.id,
+				Content: event.Delta.Message.Content.Text,
+				Model:   s.model,
+			}
+		case "tool-call-start", "tool-call-delta":
+			call := event.Delta.Message.ToolCalls
+			s.toolCalls.add(event.Index, call.ID, call.Function.Name, call.Function.Arguments)
+		case "message-end":
+			s.done = true
+			resp = &CompletionResponse{
+				ID:           s.id,
+				Model:        s.model,
+				FinishReason: cohereFinishReason(event.Delta.FinishReason),
+				ToolCalls:    s.toolCalls.flush(),
+			}
+		}
+
+		if resp != nil {
+			resp.Metadata = s.metadata
+			resp.Raw = rawEvent(data, s.raw)
+			return resp, nil
+		}
+	}
+}
+
+// pendingToolCalls ends the stream, first returning a chunk with the tool
+// calls of a message that ended without a message-end event
+func (s *cohereStream) pendingToolCalls() (*CompletionResponse, error) {
+	if !s.toolCalls.pending() {
+		return nil, io.EOF
+	}
+	return &CompletionResponse{
+		ID:        s.id,
+		Model:     s.model,
+		ToolCalls: s.toolCalls.flush(),
+		Metadata:  s.metadata,
+	}, nil
+}
+
+// Close implements the CompletionStream interface
+func (s *cohereStream) Close() error {
+	return s.closer.Close()
+}
@@ -0,0 +1,362 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewCohereClient(t *testing.T) {
+	client := NewCohereClient(CohereConfig{
+		APIKey: "test-key",
+	})
+
+	if client.config.BaseURL != defaultCohereBaseURL {
+		t.Errorf("BaseURL = %v, want %v", client.config.BaseURL, defaultCohereBaseURL)
+	}
+	if client.config.Timeout != defaultTimeout {
+		t.Errorf("Timeout = %v, want %v", client.config.Timeout, defaultTimeout)
+	}
+	if client.config.RetryConfig == nil {
+		t.Error("RetryConfig = nil, want default retry configuration")
+	}
+}
+
+func TestCohereClient_Complete(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   string
+		statusCode int
+		wantErr    bool
+		wantResp   *CompletionResponse
+		wantCalls  int
+	}{
+		{
+			name: "successful completion",
+			response: `{
+				"id": "test-id",
+				"finish_reason": "COMPLETE",
+				"message": {
+					"role": "assistant",
+					"content": [{"type": "text", "text": "Test response"}]
+				}
+			}`,
+			statusCode: http.StatusOK,
+			wantErr:    false,
+			wantResp: &CompletionResponse{
+				Content:      "Test response",
+				Model:        "command-r-plus",
+				FinishReason: "stop",
+			},
+		},
+		{
+			name: "tool call",
+			response: `{
+				"id": "test-id",
+				"finish_reason": "TOOL_CALL",
+				"message": {
+					"role": "assistant",
+					"tool_plan": "I will look up the weather",
+					"tool_calls": [
+						{
+							"id": "get_weather_1",
+							"type": "function",
+							"function": {
+								"name": "get_weather",
+								"arguments": "{\"location\":\"London\"}"
+							}
+						}
+					]
+				}
+			}`,
+			statusCode: http.StatusOK,
+			wantErr:    false,
+			wantResp: &CompletionResponse{
+				Model:        "command-r-plus",
+				FinishReason: "tool_calls",
+			},
+			wantCalls: 1,
+		},
+		{
+			name:       "API error",
+			response:   `{"message": "invalid api token"}`,
+			statusCode: http.StatusUnauthorized,
+			wantErr:    true,
+			wantResp:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// Verify request
+				if r.Method != http.MethodPost {
+					t.Errorf("Method = %v, want POST", r.Method)
+				}
+				if !strings.HasSuffix(r.URL.Path, "/chat") {
+					t.Errorf("Path = %v, want /chat", r.URL.Path)
+				}
+				if r.Header.Get("Authorization") != "Bearer test-key" {
+					t.Errorf("Authorization header = %v, want Bearer test-key", r.Header.Get("Authorization"))
+				}
+
+				var reqBody cohereRequest
+				if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+					t.Errorf("Failed to decode request body: %v", err)
+				}
+				if len(reqBody.Messages) != 1 || reqBody.Messages[0].Role != "user" {
+					t.Errorf("Invalid messages in request: %+v", reqBody.Messages)
+				}
+
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			client := NewCohereClient(CohereConfig{
+				APIKey:  "test-key",
+				BaseURL: server.URL,
+			})
+
+			got, err := client.Complete(context.Background(), &CompletionRequest{
+				Model:  "command-r-plus",
+				Prompt: "Test prompt",
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Complete() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				if got.Content != tt.wantResp.Content {
+					t.Errorf("Content = %v, want %v", got.Content, tt.wantResp.Content)
+				}
+				if got.Model != tt.wantResp.Model {
+					t.Errorf("Model = %v, want %v", got.Model, tt.wantResp.Model)
+				}
+				if got.FinishReason != tt.wantResp.FinishReason {
+					t.Errorf("FinishReason = %v, want %v", got.FinishReason, tt.wantResp.FinishReason)
+				}
+				if len(got.ToolCalls) != tt.wantCalls {
+					t.Errorf("Got %d tool calls, want %d", len(got.ToolCalls), tt.wantCalls)
+				}
+			}
+		})
+	}
+}
+
+func TestCohereClient_CompleteStream(t *testing.T) {
+	responses := []string{
+		"event: message-start\ndata: {\"type\":\"message-start\",\"id\":\"test-id\"}\n\n",
+		"event: content-start\ndata: {\"type\":\"content-start\",\"index\":0}\n\n",
+		"event: content-delta\ndata: {\"type\":\"content-delta\",\"index\":0,\"delta\":{\"message\":{\"content\":{\"text\":\"Hello\"}}}}\n\n",
+		"event: content-delta\ndata: {\"type\":\"content-delta\",\"index\":0,\"delta\":{\"message\":{\"content\":{\"text\":\" World\"}}}}\n\n",
+		"event: content-end\ndata: {\"type\":\"content-end\",\"index\":0}\n\n",
+		"event: message-end\ndata: {\"type\":\"message-end\",\"delta\":{\"finish_reason\":\"COMPLETE\"}}\n\n",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("Streaming not supported")
+		}
+
+		for _, resp := range responses {
+			_, err := w.Write([]byte(resp))
+			if err != nil {
+				t.Fatal(err)
+			}
+			flusher.Flush()
+		}
+	}))
+	defer server.Close()
+
+	client := NewCohereClient(CohereConfig{
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+	})
+
+	stream, err := client.CompleteStream(context.Background(), &CompletionRequest{
+		Model:  "command-r-plus",
+		Prompt: "Test prompt",
+	})
+	if err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	var finishReason string
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content.WriteString(resp.Content)
+		if resp.FinishReason != "" {
+			finishReason = resp.FinishReason
+		}
+	}
+
+	if content.String() != "Hello World" {
+		t.Errorf("Content = %v, want 'Hello World'", content.String())
+	}
+	if finishReason != "stop" {
+		t.Errorf("FinishReason = %v, want stop", finishReason)
+	}
+}
+
+func TestCohereStream_ToolCalls(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []string
+		want   map[int][]string
+	}{
+		{
+			name: "fragments by index",
+			events: []string{
+				`{"type":"message-start","id":"1"}`,
+				`{"type":"tool-plan-delta","delta":{"message":{"tool_plan":"I will check."}}}`,
+				`{"type":"tool-call-start","index":0,"delta":{"message":{"tool_calls":{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}}}}`,
+				`{"type":"tool-call-delta","index":0,"delta":{"message":{"tool_calls":{"function":{"arguments":"{\"location\":"}}}}}`,
+				`{"type":"tool-call-delta","index":0,"delta":{"message":{"tool_calls":{"function":{"arguments":" \"Paris\"}"}}}}}`,
+				`{"type":"tool-call-end","index":0}`,
+				`{"type":"tool-call-start","index":1,"delta":{"message":{"tool_calls":{"id":"call_2","type":"function","function":{"name":"get_time","arguments":"{}"}}}}}`,
+				`{"type":"tool-call-end","index":1}`,
+				`{"type":"message-end","delta":{"finish_reason":"TOOL_CALL"}}`,
+			},
+			want: map[int][]string{0: {`call_1 get_weather {"location": "Paris"}`, `call_2 get_time {}`}},
+		},
+		{
+			name: "no message end",
+			events: []string{
+				`{"type":"message-start","id":"1"}`,
+				`{"type":"tool-call-start","index":0,"delta":{"message":{"tool_calls":{"id":"call_1","function":{"name":"a","arguments":"{\"x\""}}}}}`,
+				`{"type":"tool-call-delta","index":0,"delta":{"message":{"tool_calls":{"function":{"arguments":":1}"}}}}}`,
+			},
+			want: map[int][]string{0: {`call_1 a {"x":1}`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data strings.Builder
+			for _, event := range tt.events {
+				data.WriteString("data: " + event + "\n\n")
+			}
+
+			stream := &cohereStream{
+				reader: bufio.NewReader(strings.NewReader(data.String())),
+				closer: io.NopCloser(nil),
+				model:  "command-r",
+			}
+
+			got := make(map[int][]string)
+			for i := 0; ; i++ {
+				chunk, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Recv() error = %v", err)
+				}
+				if chunk.ID != "1" {
+					t.Errorf("chunk %d ID = %q, want 1", i, chunk.ID)
+				}
+				for _, call := range chunk.ToolCalls {
+					got[i] = append(got[i], call.ID+" "+call.Function.Name+" "+call.Function.Arguments)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tool calls by chunk = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCohereClient_CompleteStreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message": "invalid api token"}`))
+	}))
+	defer server.Close()
+
+	client := NewCohereClient(CohereConfig{
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+	})
+
+	_, err := client.CompleteStream(context.Background(), &CompletionRequest{
+		Model:  "command-r-plus",
+		Prompt: "Test prompt",
+	})
+
+	httpErr, ok := err.(*HTTPError)
+	if !ok {
+		t.Fatalf("CompleteStream() error = %v, want *HTTPError", err)
+	}
+	if httpErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("StatusCode = %v, want %v", httpErr.StatusCode, http.StatusUnauthorized)
+	}
+	if httpErr.Message != "invalid api token" {
+		t.Errorf("Message = %v, want 'invalid api token'", httpErr.Message)
+	}
+}
+
+func TestCohereClient_Messages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody cohereRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+
+		want := []cohereMessage{
+			{Role: "system", Content: "Be brief"},
+			{Role: "tool", Content: "sunny", ToolCallID: "call_1"},
+			{Role: "user", Content: "Thanks"},
+		}
+		if !reflect.DeepEqual(reqBody.Messages, want) {
+			t.Errorf("Messages = %+v, want %+v", reqBody.Messages, want)
+		}
+		w.Write([]byte(`{"id":"id","finish_reason":"COMPLETE","message":{"content":[{"type":"text","text":"ok"}]}}`))
+	}))
+	defer server.Close()
+
+	client := NewCohereClient(CohereConfig{APIKey: "test-key", BaseURL: server.URL})
+	_, err := client.Complete(context.Background(), &CompletionRequest{
+		Messages: []Message{
+			{Role: RoleSystem, Content: "Be brief"},
+			{Role: RoleTool, Content: "sunny", ToolCallID: "call_1"},
+		},
+		Prompt: "Thanks",
+	})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+}
+
+func FuzzCohereStream(f *testing.F) {
+	f.Add([]byte("event: message-start\ndata: {\"type\":\"message-start\",\"id\":\"1\"}\n\n" +
+		"data: {\"type\":\"content-delta\",\"delta\":{\"message\":{\"content\":{\"text\":\"Hi\"}}}}\n\n" +
+		"data: {\"type\":\"message-end\",\"delta\":{\"finish_reason\":\"COMPLETE\"}}\n\n"))
+	f.Add([]byte("data: {\"type\":\"tool-call-start\",\"delta\":{\"message\":{\"tool_calls\":{\"id\":\"call_1\"}}}}\n"))
+	f.Add([]byte("data: {\"type\":\"content-delta\",\"delta\":null}\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkStream(t, &cohereStream{
+			reader: bufio.NewReader(bytes.NewReader(data)),
+			closer: io.NopCloser(nil),
+			model:  "command-r",
+		})
+	})
+}
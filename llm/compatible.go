@@ -0,0 +1,103 @@
+package llm
+
+import (
+	"maps"
+	"net/http"
+	"time"
+)
+
+// OpenAIOption customizes the configuration of a client created with
+// NewOpenAICompatibleClient or derived with OpenAIClient.With
+type OpenAIOption func(*OpenAIConfig)
+
+// WithTimeout sets the timeout for API requests
+func WithTimeout(timeout time.Duration) OpenAIOption {
+	return func(config *OpenAIConfig) {
+		config.Timeout = timeout
+	}
+}
+
+// WithHTTPClient sets a custom HTTP client
+func WithHTTPClient(httpClient *http.Client) OpenAIOption {
+	return func(config *OpenAIConfig) {
+		config.HTTPClient = httpClient
+	}
+}
+
+// WithRetryConfig sets the retry configuration
+func WithRetryConfig(retryConfig *RetryConfig) OpenAIOption {
+	return func(config *OpenAIConfig) {
+		config.RetryConfig = retryConfig
+	}
+}
+
+// WithCredentials sets the CredentialProvider supplying the API key
+func WithCredentials(credentials CredentialProvider) OpenAIOption {
+	return func(config *OpenAIConfig) {
+		config.Credentials = credentials
+	}
+}
+
+// WithDefaultModel sets the model used for requests that do not set one
+func WithDefaultModel(model string) OpenAIOption {
+	return func(config *OpenAIConfig) {
+		config.DefaultModel = model
+	}
+}
+
+// WithDefaultTemperature sets the temperature used for requests that do not
+// set one
+func WithDefaultTemperature(temperature float32) OpenAIOption {
+	return func(config *OpenAIConfig) {
+		config.DefaultTemperature = &temperature
+	}
+}
+
+// WithOrganization sets the organization usage is attributed to
+func WithOrganization(organization string) OpenAIOption {
+	return func(config *OpenAIConfig) {
+		config.Organization = organization
+	}
+}
+
+// WithProject sets the project usage is attributed to
+func WithProject(project string) OpenAIOption {
+	return func(config *OpenAIConfig) {
+		config.Project = project
+	}
+}
+
+// WithHeaders adds HTTP headers sent with every request, replacing earlier
+// values of the same headers
+func WithHeaders(headers map[string]string) OpenAIOption {
+	return func(config *OpenAIConfig) {
+		merged := make(map[string]string, len(config.Headers)+len(headers))
+		maps.Copy(merged, config.Headers)
+		maps.Copy(merged, headers)
+		config.Headers = merged
+	}
+}
+
+// NewOpenAICompatibleClient creates a client for servers that implement the
+// OpenAI chat completions API, such as vLLM, LocalAI, LM Studio and the
+// llama.cpp server. baseURL must include the version prefix (usually
+// ending in /v1). apiKey may be empty for servers without authentication,
+// in which case no Authorization header is sent.
+//
+// Responses are decoded leniently to cope with the quirks of these servers:
+// a missing model name falls back to the requested one, finish reasons such
+// as "eos" or "max_tokens" are mapped to OpenAI's "stop" and "length",
+// stream lines written as "data:" without a space are accepted and errors
+// reported as plain strings are surfaced as API errors.
+func NewOpenAICompatibleClient(baseURL, apiKey string, opts ...OpenAIOption) *OpenAIClient {
+	config := OpenAIConfig{
+		APIKey:  apiKey,
+		BaseURL: baseURL,
+	}
+
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return NewOpenAIClient(config)
+}
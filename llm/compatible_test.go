@@ -0,0 +1,167 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewOpenAICompatibleClient(t *testing.T) {
+	httpClient := &http.Client{}
+	client := NewOpenAICompatibleClient("http://localhost:8000/v1", "",
+		WithTimeout(2*time.Minute),
+		WithHTTPClient(httpClient),
+		WithRetryConfig(&RetryConfig{MaxRetries: 1}),
+	)
+
+	if client.config.BaseURL != "http://localhost:8000/v1" {
+		t.Errorf("BaseURL = %v, want http://localhost:8000/v1", client.config.BaseURL)
+	}
+	if client.config.Timeout != 2*time.Minute {
+		t.Errorf("Timeout = %v, want 2m", client.config.Timeout)
+	}
+	if client.httpClient != httpClient {
+		t.Error("HTTPClient option was not applied")
+	}
+	if client.config.RetryConfig.MaxRetries != 1 {
+		t.Errorf("MaxRetries = %v, want 1", client.config.RetryConfig.MaxRetries)
+	}
+}
+
+func TestOpenAICompatibleClient_Complete(t *testing.T) {
+	tests := []struct {
+		name             string
+		response         string
+		statusCode       int
+		wantErr          string
+		wantModel        string
+		wantFinishReason string
+	}{
+		{
+			name: "missing model and id",
+			response: `{
+				"choices": [{"message": {"content": "Test response"}, "finish_reason": "eos"}]
+			}`,
+			statusCode:       http.StatusOK,
+			wantModel:        "local-model",
+			wantFinishReason: "stop",
+		},
+		{
+			name: "max_tokens finish reason",
+			response: `{
+				"model": "served-model",
+				"choices": [{"message": {"content": "Test"}, "finish_reason": "max_tokens"}]
+			}`,
+			statusCode:       http.StatusOK,
+			wantModel:        "served-model",
+			wantFinishReason: "length",
+		},
+		{
+			name:       "string error",
+			response:   `{"error": "model not loaded"}`,
+			statusCode: http.StatusOK,
+			wantErr:    "model not loaded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if auth := r.Header.Get("Authorization"); auth != "" {
+					t.Errorf("Authorization header = %v, want none", auth)
+				}
+
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			client := NewOpenAICompatibleClient(server.URL, "")
+
+			got, err := client.Complete(context.Background(), &CompletionRequest{
+				Model:  "local-model",
+				Prompt: "Test prompt",
+			})
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Complete() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if got.Model != tt.wantModel {
+				t.Errorf("Model = %v, want %v", got.Model, tt.wantModel)
+			}
+			if got.FinishReason != tt.wantFinishReason {
+				t.Errorf("FinishReason = %v, want %v", got.FinishReason, tt.wantFinishReason)
+			}
+		})
+	}
+}
+
+func TestOpenAICompatibleClient_CompleteStream(t *testing.T) {
+	// No space after "data:", no model field and no [DONE] terminator
+	responses := []string{
+		`data:{"choices":[{"delta":{"content":"Hello"}}]}` + "\n\n",
+		`data:{"choices":[{"delta":{"content":" World"},"finish_reason":"eos"}]}` + "\n\n",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("Streaming not supported")
+		}
+
+		for _, resp := range responses {
+			_, err := w.Write([]byte(resp))
+			if err != nil {
+				t.Fatal(err)
+			}
+			flusher.Flush()
+		}
+	}))
+	defer server.Close()
+
+	client := NewOpenAICompatibleClient(server.URL, "")
+
+	stream, err := client.CompleteStream(context.Background(), &CompletionRequest{
+		Model:  "local-model",
+		Prompt: "Test prompt",
+	})
+	if err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	var content, finishReason string
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Model != "local-model" {
+			t.Errorf("Model = %v, want local-model", resp.Model)
+		}
+		content += resp.Content
+		if resp.FinishReason != "" {
+			finishReason = resp.FinishReason
+		}
+	}
+
+	if content != "Hello World" {
+		t.Errorf("Content = %v, want 'Hello World'", content)
+	}
+	if finishReason != "stop" {
+		t.Errorf("FinishReason = %v, want stop", finishReason)
+	}
+}
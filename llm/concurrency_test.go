@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newMultiProviderServer starts a server running multiProviderHandler
+func newMultiProviderServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(multiProviderHandler))
+}
+
+// multiProviderHandler answers with a minimal valid response in the format
+// of whichever provider endpoint is called
+func multiProviderHandler(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/chat/completions"):
+		w.Write([]byte(`{"id":"id","model":"m","choices":[{"message":{"content":"ok"},"finish_reason":"stop"}]}`))
+	case strings.HasSuffix(r.URL.Path, "/chat"):
+		w.Write([]byte(`{"id":"id","finish_reason":"COMPLETE","message":{"content":[{"type":"text","text":"ok"}]}}`))
+	case strings.HasSuffix(r.URL.Path, "/generation"):
+		w.Write([]byte(`{"request_id":"id","output":{"choices":[{"finish_reason":"stop","message":{"content":"ok"}}]}}`))
+	case strings.HasSuffix(r.URL.Path, "/completion"):
+		w.Write([]byte(`{"content":"ok","stop":true,"stop_type":"eos"}`))
+	case strings.HasSuffix(r.URL.Path, "/messages"):
+		w.Write([]byte(`{"id":"id","model":"m","content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn"}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// TestProviders_ConcurrentUse shares one client of every provider between
+// many goroutines. Run with -race to verify the clients are race-free.
+func TestProviders_ConcurrentUse(t *testing.T) {
+	server := newMultiProviderServer()
+	defer server.Close()
+
+	openaiConfig := OpenAIConfig{APIKey: "test-key", BaseURL: server.URL}
+
+	providers := map[string]LLMProvider{
+		"openai":       NewOpenAIClient(openaiConfig),
+		"compatible":   NewOpenAICompatibleClient(server.URL, ""),
+		"groq":         NewGroqClient(openaiConfig),
+		"together":     NewTogetherClient(openaiConfig),
+		"fireworks":    NewFireworksClient(openaiConfig),
+		"deepseek":     NewDeepSeekClient(openaiConfig),
+		"cohere":       NewCohereClient(CohereConfig{APIKey: "test-key", BaseURL: server.URL}),
+		"dashscope":    NewDashScopeClient(DashScopeConfig{APIKey: "test-key", BaseURL: server.URL}),
+		"llamacpp":     NewLlamaCppClient(LlamaCppConfig{BaseURL: server.URL}),
+		"anthropic":    NewAnthropicClientWithConfig(AnthropicConfig{APIKey: "test-key", BaseURL: server.URL + "/v1"}),
+		"singleflight": Chain(NewOpenAIClient(openaiConfig), SingleFlight()),
+	}
+
+	for name, provider := range providers {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					resp, err := provider.Complete(context.Background(), &CompletionRequest{
+						Model:  "m",
+						Prompt: "Test prompt",
+					})
+					if err != nil {
+						t.Errorf("Complete() error = %v", err)
+						return
+					}
+					if resp.Content != "ok" {
+						t.Errorf("Content = %v, want ok", resp.Content)
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const defaultCostMaxTokens = 4096
+
+// Price is what a model costs per million tokens, in any currency as long as
+// it matches CostCeilingConfig.MaxCost
+type Price struct {
+	Input  float64
+	Output float64
+
+	// MaxOutputTokens is the largest completion the model generates, the
+	// worst case CostCeiling assumes for requests without MaxTokens
+	// (optional)
+	MaxOutputTokens int
+}
+
+// CostCeilingConfig contains configuration for the CostCeiling middleware
+type CostCeilingConfig struct {
+	// Prices maps model names to their prices. Versioned names such as
+	// "gpt-4o-2024-08-06" use the price of the longest name they extend.
+	// Requests for models without a price are refused, since their cost
+	// cannot be estimated.
+	Prices map[string]Price
+
+	// DefaultModel is the model of requests that do not set Model (optional,
+	// defaults to the DefaultModel of the wrapped OpenAI-compatible or
+	// Anthropic client). Requests without a model are refused.
+	DefaultModel string
+
+	// DefaultMaxTokens is the output assumed for requests without MaxTokens
+	// when the model's price has no MaxOutputTokens (optional, defaults to
+	// 4096)
+	DefaultMaxTokens int
+
+	// MaxCost is the largest estimated cost allowed for a single request
+	MaxCost float64
+
+	// Fallbacks are cheaper models to downgrade to, in order of preference,
+	// when the estimate for the requested model exceeds MaxCost (optional).
+	// Without a fallback that fits, the request is refused.
+	Fallbacks []string
+}
+
+// CostCeilingError is returned when the estimated cost of a request exceeds
+// the configured ceiling and no fallback model fits under it
+type CostCeilingError struct {
+	Model    string
+	Estimate float64
+	MaxCost  float64
+}
+
+func (e *CostCeilingError) Error() string {
+	return fmt.Sprintf("estimated cost %.6f for model %s exceeds ceiling %.6f", e.Estimate, e.Model, e.MaxCost)
+}
+
+// CostCeiling returns a Middleware that estimates the cost of every request
+// before it is sent and refuses it with a *CostCeilingError, or downgrades it
+// to a fallback model, when the estimate exceeds MaxCost.
+//
+// The estimate counts the input at four characters per token and assumes the
+// full MaxTokens is generated for each of the N choices. Requests without
+// MaxTokens assume the model's MaxOutputTokens, or DefaultMaxTokens, so set
+// MaxTokens (or chain DeadlineMaxTokens first) for a tighter estimate.
+func CostCeiling(config CostCeilingConfig) Middleware {
+	if config.DefaultMaxTokens <= 0 {
+		config.DefaultMaxTokens = defaultCostMaxTokens
+	}
+
+	return func(next LLMProvider) LLMProvider {
+		p := &costCeilingProvider{next: next, config: config}
+		if client, ok := next.(interface{ defaultModel() string }); ok && p.config.DefaultModel == "" {
+			p.config.DefaultModel = client.defaultModel()
+		}
+		return p
+	}
+}
+
+type costCeilingProvider struct {
+	next   LLMProvider
+	config CostCeilingConfig
+}
+
+// Complete implements the LLMProvider interface
+func (p *costCeilingProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	req, err := p.check(req)
+	if err != nil {
+		return nil, err
+	}
+	return p.next.Complete(ctx, req)
+}
+
+// CompleteStream implements the LLMProvider interface
+func (p *costCeilingProvider) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
+	req, err := p.check(req)
+	if err != nil {
+		return nil, err
+	}
+	return p.next.CompleteStream(ctx, req)
+}
+
+// check returns req, or a copy switched to the first fallback model that fits
+// under the ceiling
+func (p *costCeilingProvider) check(req *CompletionRequest) (*CompletionRequest, error) {
+	model := req.Model
+	if model == "" {
+		model = p.config.DefaultModel
+	}
+	if model == "" {
+		return nil, fmt.Errorf("cost ceiling: %w", ErrModelRequired)
+	}
+
+	estimate, err := p.estimate(req, model)
+	if err != nil {
+		return nil, err
+	}
+	if estimate <= p.config.MaxCost {
+		return req, nil
+	}
+
+	for _, model := range p.config.Fallbacks {
+		if cost, err := p.estimate(req, model); err == nil && cost <= p.config.MaxCost {
+			r := *req
+			r.Model = model
+			return &r, nil
+		}
+	}
+
+	return nil, &CostCeilingError{Model: model, Estimate: estimate, MaxCost: p.config.MaxCost}
+}
+
+// estimate returns the worst-case cost of sending req to model
+func (p *costCeilingProvider) estimate(req *CompletionRequest, model string) (float64, error) {
+	price, ok := p.price(model)
+	if !ok {
+		return 0, fmt.Errorf("cost ceiling: no price for model %q", model)
+	}
+
+	var input int
+	for _, msg := range req.messages() {
+		input += estimateMessageTokens(msg)
+		for _, call := range msg.ToolCalls {
+			input += estimateTokens(call.Function.Arguments)
+		}
+	}
+
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = price.MaxOutputTokens
+	}
+	if maxTokens <= 0 {
+		maxTokens = p.config.DefaultMaxTokens
+	}
+
+	output := maxTokens * max(req.N, 1)
+	return (float64(input)*price.Input + float64(output)*price.Output) / 1e6, nil
+}
+
+// price returns the price of model, or of the longest priced name it extends
+func (p *costCeilingProvider) price(model string) (Price, bool) {
+	if price, ok := p.config.Prices[model]; ok {
+		return price, true
+	}
+
+	var best string
+	for name := range p.config.Prices {
+		if strings.HasPrefix(model, name+"-") && len(name) > len(best) {
+			best = name
+		}
+	}
+	price, ok := p.config.Prices[best]
+	return price, ok && best != ""
+}
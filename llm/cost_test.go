@@ -0,0 +1,207 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCostCeiling(t *testing.T) {
+	config := CostCeilingConfig{
+		Prices: map[string]Price{
+			"large": {Input: 10, Output: 30},
+			"small": {Input: 0.5, Output: 1.5},
+		},
+		MaxCost:   0.01,
+		Fallbacks: []string{"unpriced", "small"},
+	}
+
+	tests := []struct {
+		name      string
+		req       CompletionRequest
+		fallbacks []string
+		wantModel string
+		wantErr   bool
+	}{
+		{
+			name:      "under ceiling",
+			req:       CompletionRequest{Model: "large", Prompt: "Hi", MaxTokens: 100},
+			wantModel: "large",
+		},
+		{
+			name:      "downgraded to fallback",
+			req:       CompletionRequest{Model: "large", Prompt: "Hi", MaxTokens: 1000},
+			wantModel: "small",
+		},
+		{
+			name:      "choices multiply output",
+			req:       CompletionRequest{Model: "large", Prompt: "Hi", MaxTokens: 100, N: 4},
+			wantModel: "small",
+		},
+		{
+			name:      "long input downgraded",
+			req:       CompletionRequest{Model: "large", Prompt: strings.Repeat("word ", 1000)},
+			wantModel: "small",
+		},
+		{
+			name:    "no fallback fits",
+			req:     CompletionRequest{Model: "large", Prompt: "Hi", MaxTokens: 10000},
+			wantErr: true,
+		},
+		{
+			name:      "no fallbacks configured",
+			req:       CompletionRequest{Model: "large", Prompt: "Hi", MaxTokens: 1000},
+			fallbacks: []string{},
+			wantErr:   true,
+		},
+		{
+			name:    "unknown model",
+			req:     CompletionRequest{Model: "other", Prompt: "Hi"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config
+			if tt.fallbacks != nil {
+				cfg.Fallbacks = tt.fallbacks
+			}
+			mock := &mockProvider{}
+			provider := Chain(mock, CostCeiling(cfg))
+
+			resp, err := provider.Complete(context.Background(), &tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Complete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if len(mock.requests) != 0 {
+					t.Error("refused request was sent")
+				}
+				return
+			}
+			if resp.Model != tt.wantModel {
+				t.Errorf("Model = %v, want %v", resp.Model, tt.wantModel)
+			}
+			if tt.req.Model != "large" {
+				t.Error("caller's request was modified")
+			}
+		})
+	}
+}
+
+func TestCostCeiling_Error(t *testing.T) {
+	provider := Chain(&mockProvider{}, CostCeiling(CostCeilingConfig{
+		Prices:  map[string]Price{"large": {Input: 10, Output: 30}},
+		MaxCost: 0.01,
+	}))
+
+	_, err := provider.CompleteStream(context.Background(), &CompletionRequest{Model: "large", Prompt: "Hi", MaxTokens: 1000})
+
+	var ceilingErr *CostCeilingError
+	if !errors.As(err, &ceilingErr) {
+		t.Fatalf("error = %v, want *CostCeilingError", err)
+	}
+	if ceilingErr.Model != "large" || ceilingErr.MaxCost != 0.01 {
+		t.Errorf("error = %+v", ceilingErr)
+	}
+	// One input token and 1000 output tokens
+	if want := (10 + 30*1000) / 1e6; ceilingErr.Estimate != want {
+		t.Errorf("Estimate = %v, want %v", ceilingErr.Estimate, want)
+	}
+}
+
+func TestCostCeiling_Estimate(t *testing.T) {
+	prices := map[string]Price{
+		"gpt-4o":      {Input: 2.5, Output: 10, MaxOutputTokens: 16384},
+		"gpt-4o-mini": {Input: 0.15, Output: 0.6},
+	}
+
+	tests := []struct {
+		name         string
+		config       CostCeilingConfig
+		next         LLMProvider
+		req          CompletionRequest
+		wantModel    string
+		wantEstimate float64
+		wantErr      error
+	}{
+		{
+			name:         "snapshot name",
+			req:          CompletionRequest{Model: "gpt-4o-mini-2024-07-18", Prompt: "Hi", MaxTokens: 1000},
+			wantEstimate: (0.15 + 0.6*1000) / 1e6,
+		},
+		{
+			name:         "max output tokens of the model",
+			req:          CompletionRequest{Model: "gpt-4o-2024-08-06", Prompt: "Hi"},
+			wantEstimate: (2.5 + 10*16384) / 1e6,
+		},
+		{
+			name: "text parts",
+			req: CompletionRequest{
+				Model:     "gpt-4o-mini",
+				Messages:  []Message{{Role: RoleUser, Parts: []ContentPart{TextPart("abcdefgh")}}},
+				MaxTokens: 10,
+			},
+			wantEstimate: (0.15*2 + 0.6*10) / 1e6,
+		},
+		{
+			name:         "default max tokens",
+			config:       CostCeilingConfig{DefaultMaxTokens: 100},
+			req:          CompletionRequest{Model: "gpt-4o-mini", Prompt: "Hi"},
+			wantEstimate: (0.15 + 0.6*100) / 1e6,
+		},
+		{
+			name:         "configured default model",
+			config:       CostCeilingConfig{DefaultModel: "gpt-4o"},
+			req:          CompletionRequest{Prompt: "Hi", MaxTokens: 10},
+			wantModel:    "gpt-4o",
+			wantEstimate: (2.5 + 10*10) / 1e6,
+		},
+		{
+			name:         "client's default model",
+			next:         NewOpenAIClient(OpenAIConfig{APIKey: "key", DefaultModel: "gpt-4o-mini"}),
+			req:          CompletionRequest{Prompt: "Hi", MaxTokens: 10},
+			wantModel:    "gpt-4o-mini",
+			wantEstimate: (0.15 + 0.6*10) / 1e6,
+		},
+		{
+			name:    "no model",
+			req:     CompletionRequest{Prompt: "Hi"},
+			wantErr: ErrModelRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.Prices = prices
+			next := tt.next
+			if next == nil {
+				next = &mockProvider{}
+			}
+			provider := Chain(next, CostCeiling(config))
+
+			// A ceiling of zero refuses every request, reporting the estimate
+			_, err := provider.Complete(context.Background(), &tt.req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Complete() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			var ceilingErr *CostCeilingError
+			if !errors.As(err, &ceilingErr) {
+				t.Fatalf("Complete() error = %v, want *CostCeilingError", err)
+			}
+			if math.Abs(ceilingErr.Estimate-tt.wantEstimate) > 1e-12 {
+				t.Errorf("Estimate = %v, want %v", ceilingErr.Estimate, tt.wantEstimate)
+			}
+			if tt.wantModel != "" && ceilingErr.Model != tt.wantModel {
+				t.Errorf("Model = %q, want %q", ceilingErr.Model, tt.wantModel)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CredentialProvider supplies the API key or token sent with each request.
+// Setting one in place of a client's static APIKey lets keys be rotated,
+// fetched from a secret store or exchanged through OAuth without creating
+// new clients. Token is called for every request, including retries, and
+// may be called concurrently; wrap slow providers in CacheCredentials.
+type CredentialProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// CredentialFunc adapts a function to the CredentialProvider interface
+type CredentialFunc func(ctx context.Context) (string, error)
+
+// Token implements the CredentialProvider interface
+func (f CredentialFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// CacheCredentials returns a CredentialProvider that reuses the token of
+// provider for ttl before fetching a new one. Failed fetches are not cached.
+func CacheCredentials(provider CredentialProvider, ttl time.Duration) CredentialProvider {
+	return &cachedCredentials{provider: provider, ttl: ttl}
+}
+
+type cachedCredentials struct {
+	provider CredentialProvider
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// Token implements the CredentialProvider interface
+func (c *cachedCredentials) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.expires.IsZero() && time.Now().Before(c.expires) {
+		return c.token, nil
+	}
+
+	token, err := c.provider.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.token, c.expires = token, time.Now().Add(c.ttl)
+	return token, nil
+}
+
+// resolveAPIKey returns the token of credentials, or apiKey when no
+// CredentialProvider is configured
+func resolveAPIKey(ctx context.Context, credentials CredentialProvider, apiKey string) (string, error) {
+	if credentials == nil {
+		return apiKey, nil
+	}
+
+	token, err := credentials.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get credentials: %w", err)
+	}
+	return token, nil
+}
@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCredentials_Providers(t *testing.T) {
+	var header http.Header
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		header = r.Header
+		multiProviderHandler(w, r)
+	}))
+	defer server.Close()
+
+	errVault := errors.New("vault sealed")
+
+	for _, name := range []string{"openai", "anthropic", "cohere", "dashscope", "llamacpp"} {
+		t.Run(name, func(t *testing.T) {
+			var fetched int
+			rotating := CredentialFunc(func(ctx context.Context) (string, error) {
+				fetched++
+				return fmt.Sprintf("key-%d", fetched), nil
+			})
+
+			provider, err := New(name, Config{
+				APIKey:       "static-key",
+				Credentials:  rotating,
+				BaseURL:      server.URL,
+				RetryConfig:  &RetryConfig{},
+				DefaultModel: "test-model",
+			})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			for _, want := range []string{"key-1", "key-2"} {
+				if _, err := provider.Complete(context.Background(), &CompletionRequest{Model: "test-model", Prompt: "Hi"}); err != nil {
+					t.Fatalf("Complete() error = %v", err)
+				}
+				got := strings.TrimPrefix(header.Get("Authorization"), "Bearer ")
+				if name == "anthropic" {
+					got = header.Get("x-api-key")
+				}
+				if got != want {
+					t.Errorf("API key = %q, want %q", got, want)
+				}
+			}
+
+			failing, err := New(name, Config{
+				Credentials: CredentialFunc(func(ctx context.Context) (string, error) { return "", errVault }),
+				BaseURL:     server.URL,
+				RetryConfig: &RetryConfig{},
+			})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			before := requests.Load()
+			if _, err := failing.Complete(context.Background(), &CompletionRequest{Model: "test-model", Prompt: "Hi"}); !errors.Is(err, errVault) {
+				t.Errorf("Complete() error = %v, want %v", err, errVault)
+			}
+			if requests.Load() != before {
+				t.Error("request sent without credentials")
+			}
+		})
+	}
+}
+
+func TestCacheCredentials(t *testing.T) {
+	var fetched int
+	errFetch := errors.New("fetch failed")
+	failNext := false
+	provider := CredentialFunc(func(ctx context.Context) (string, error) {
+		if failNext {
+			failNext = false
+			return "", errFetch
+		}
+		fetched++
+		return fmt.Sprintf("token-%d", fetched), nil
+	})
+
+	cached := CacheCredentials(provider, 50*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		if token, err := cached.Token(context.Background()); err != nil || token != "token-1" {
+			t.Fatalf("Token() = %q, %v, want token-1", token, err)
+		}
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	failNext = true
+	if _, err := cached.Token(context.Background()); !errors.Is(err, errFetch) {
+		t.Fatalf("Token() error = %v, want %v", err, errFetch)
+	}
+	if token, err := cached.Token(context.Background()); err != nil || token != "token-2" {
+		t.Errorf("Token() after failure = %q, %v, want token-2", token, err)
+	}
+}
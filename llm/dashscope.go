@@ -0,0 +1,474 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDashScopeBaseURL = "https://dashscope.aliyuncs.com/api/v1"
+)
+
+// DashScopeConfig contains configuration options for the DashScope client
+type DashScopeConfig struct {
+	// APIKey is your DashScope API key
+	APIKey string
+
+	// Credentials supplies the API key for each request in place of APIKey
+	// (optional)
+	Credentials CredentialProvider
+
+	// BaseURL is the base URL for DashScope API (optional, defaults to
+	// https://dashscope.aliyuncs.com/api/v1; use
+	// https://dashscope-intl.aliyuncs.com/api/v1 for the international region)
+	BaseURL string
+
+	// Timeout is the timeout for API requests (optional, defaults to 30 seconds)
+	Timeout time.Duration
+
+	// HTTPClient is a custom HTTP client (optional)
+	HTTPClient *http.Client
+
+	// RetryConfig contains retry configuration (optional)
+	RetryConfig *RetryConfig
+}
+
+// DashScopeClient implements the LLMProvider interface for Alibaba Cloud's
+// DashScope text generation API serving the Qwen models
+type DashScopeClient struct {
+	config     DashScopeConfig
+	httpClient *http.Client
+}
+
+// NewDashScopeClient creates a new DashScope client with the given configuration
+func NewDashScopeClient(config DashScopeConfig) *DashScopeClient {
+	if config.BaseURL == "" {
+		config.BaseURL = defaultDashScopeBaseURL
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{
+			Timeout: config.Timeout,
+		}
+	}
+
+	if config.RetryConfig == nil {
+		config.RetryConfig = defaultRetryConfig()
+	}
+
+	return &DashScopeClient{
+		config:     config,
+		httpClient: config.HTTPClient,
+	}
+}
+
+// NewDashScopeClientWithKey creates a new DashScope client with just an API key
+func NewDashScopeClientWithKey(apiKey string) *DashScopeClient {
+	return NewDashScopeClient(DashScopeConfig{
+		APIKey: apiKey,
+	})
+}
+
+type dashScopeRequest struct {
+	Model      string              `json:"model"`
+	Input      dashScopeInput      `json:"input"`
+	Parameters dashScopeParameters `json:"parameters"`
+
+	extras requestExtras
+}
+
+type dashScopeInput struct {
+	Messages []Message `json:"messages"`
+}
+
+type dashScopeParameters struct {
+	ResultFormat      string   `json:"result_format"`
+	MaxTokens         int      `json:"max_tokens,omitempty"`
+	Temperature       *float32 `json:"temperature,omitempty"`
+	TopP              float32  `json:"top_p,omitempty"`
+	PresencePenalty   float32  `json:"presence_penalty,omitempty"`
+	Stop              []string `json:"stop,omitempty"`
+	N                 int      `json:"n,omitempty"`
+	Seed              *int64   `json:"seed,omitempty"`
+	Tools             []Tool   `json:"tools,omitempty"`
+	IncrementalOutput bool     `json:"incremental_output,omitempty"`
+}
+
+// dashScopeResponse covers both result formats: "message" fills Choices,
+// while the legacy "text" format fills Text and FinishReason directly
+type dashScopeResponse struct {
+	RequestID string `json:"request_id"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Output    struct {
+		Text         string `json:"text"`
+		FinishReason string `json:"finish_reason"`
+		Choices      []struct {
+			Index        int    `json:"index"`
+			FinishReason string `json:"finish_reason"`
+			Message      struct {
+				Role      string              `json:"role"`
+				Content   string              `json:"content"`
+				ToolCalls []dashScopeToolCall `json:"tool_calls"`
+			} `json:"message"`
+		} `json:"choices"`
+	} `json:"output"`
+}
+
+// dashScopeToolCall is a tool call of a response. With incremental output
+// the calls arrive in fragments that are merged by their index, see
+// toolCallAccumulator.
+type dashScopeToolCall struct {
+	// Index is missing from the whole calls of non-streaming responses
+	Index *int `json:"index"`
+	ToolCall
+}
+
+// Complete implements non-streaming completion with retry support
+func (c *DashScopeClient) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	if hasParts(req.Messages) {
+		return nil, ErrContentPartsUnsupported
+	}
+
+	var resp *CompletionResponse
+	err := retry(ctx, c.config.RetryConfig, func() error {
+		var err error
+		resp, err = c.complete(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *DashScopeClient) complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	resp, err := c.do(ctx, c.newRequest(req, false), false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var dashResp dashScopeResponse
+	raw, err := decodeResponse(resp.Body, &dashResp, req.IncludeRaw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if dashResp.Code != "" {
+		return nil, fmt.Errorf("DashScope API error: %s: %s", dashResp.Code, dashResp.Message)
+	}
+
+	result := dashResp.toCompletionResponse(req.Model)
+	if result == nil {
+		return nil, errors.New("no content in response")
+	}
+	result.Metadata = newDashScopeMetadata(resp.Header, dashResp.RequestID)
+	result.Raw = raw
+	return result, nil
+}
+
+func (c *DashScopeClient) newRequest(req *CompletionRequest, stream bool) dashScopeRequest {
+	return dashScopeRequest{
+		Model: req.Model,
+		Input: dashScopeInput{
+			Messages: req.messages(),
+		},
+		Parameters: dashScopeParameters{
+			ResultFormat:      "message",
+			MaxTokens:         req.MaxTokens,
+			Temperature:       req.Temperature,
+			TopP:              req.TopP,
+			PresencePenalty:   req.PresencePenalty,
+			Stop:              req.Stop,
+			N:                 req.N,
+			Seed:              req.Seed,
+			Tools:             req.Tools,
+			IncrementalOutput: stream,
+		},
+		extras: newRequestExtras(req),
+	}
+}
+
+func (c *DashScopeClient) do(ctx context.Context, dashReq dashScopeRequest, stream bool) (*http.Response, error) {
+	body, err := dashReq.extras.marshal(dashReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/services/aigc/text-generation/generation", strings.TrimRight(c.config.BaseURL, "/"))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	apiKey, err := resolveAPIKey(ctx, c.config.Credentials, c.config.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+		httpReq.Header.Set("X-DashScope-SSE", "enable")
+	}
+	dashReq.extras.setHeaders(httpReq.Header)
+
+	resp, err := dashReq.extras.client(c.httpClient).Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		httpErr := newHTTPError(resp)
+
+		var dashErr dashScopeResponse
+		if json.Unmarshal([]byte(httpErr.Message), &dashErr) == nil && dashErr.Message != "" {
+			httpErr.Message = dashErr.Code + ": " + dashErr.Message
+		}
+		return nil, httpErr
+	}
+
+	return resp, nil
+}
+
+// toCompletionResponse normalizes either result format into a
+// CompletionResponse, returning nil when the output is empty
+func (r *dashScopeResponse) toCompletionResponse(model string) *CompletionResponse {
+	if len(r.Output.Choices) > 0 {
+		choices := make([]CompletionChoice, len(r.Output.Choices))
+		for i, choice := range r.Output.Choices {
+			choices[i] = CompletionChoice{
+				Index:        choice.Index,
+				Content:      choice.Message.Content,
+				FinishReason: dashScopeFinishReason(choice.FinishReason),
+				ToolCalls:    dashScopeToolCalls(choice.Message.ToolCalls),
+			}
+		}
+		return &CompletionResponse{
+			ID:           r.RequestID,
+			Content:      choices[0].Content,
+			Model:        model,
+			FinishReason: choices[0].FinishReason,
+			ToolCalls:    choices[0].ToolCalls,
+			Choices:      choices,
+		}
+	}
+
+	if r.Output.Text != "" || r.Output.FinishReason != "" {
+		return &CompletionResponse{
+			ID:           r.RequestID,
+			Content:      r.Output.Text,
+			Model:        model,
+			FinishReason: dashScopeFinishReason(r.Output.FinishReason),
+		}
+	}
+
+	return nil
+}
+
+// dashScopeToolCalls strips the indexes from the tool calls of a response
+func dashScopeToolCalls(calls []dashScopeToolCall) []ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	toolCalls := make([]ToolCall, len(calls))
+	for i, call := range calls {
+		toolCalls[i] = call.ToolCall
+	}
+	return toolCalls
+}
+
+// newDashScopeMetadata reads the response headers, falling back to the
+// request ID DashScope reports in the response body
+func newDashScopeMetadata(header http.Header, requestID string) *ResponseMetadata {
+	metadata := newResponseMetadata(header)
+	if metadata.RequestID == "" {
+		metadata.RequestID = requestID
+	}
+	return metadata
+}
+
+// dashScopeFinishReason clears the literal "null" DashScope reports while a
+// stream is still in progress
+func dashScopeFinishReason(reason string) string {
+	if reason == "null" {
+		return ""
+	}
+	return reason
+}
+
+// dashScopeStream implements CompletionStream for DashScope
+type dashScopeStream struct {
+	reader   *bufio.Reader
+	closer   io.Closer
+	model    string
+	metadata *ResponseMetadata
+	raw      bool
+
+	// id is the request ID of the last chunk, and toolCalls accumulates the
+	// tool calls of each choice until it finishes
+	id        string
+	toolCalls map[int]*toolCallAccumulator
+}
+
+// CompleteStream implements streaming completion using DashScope's
+// incremental output mode, so every chunk only carries newly generated text
+func (c *DashScopeClient) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
+	if hasParts(req.Messages) {
+		return nil, ErrContentPartsUnsupported
+	}
+
+	resp, err := c.do(ctx, c.newRequest(req, true), true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dashScopeStream{
+		reader:   bufio.NewReader(resp.Body),
+		closer:   resp.Body,
+		model:    req.Model,
+		metadata: newResponseMetadata(resp.Header),
+		raw:      req.IncludeRaw,
+	}, nil
+}
+
+// Recv implements the CompletionStream interface
+func (s *dashScopeStream) Recv() (*CompletionResponse, error) {
+	for {
+		line, err := s.reader.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF {
+				return s.pendingToolCalls()
+			}
+			return nil, fmt.Errorf("failed to read stream: %w", err)
+		}
+
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+
+		data := bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+		if len(data) == 0 {
+			continue
+		}
+
+		var chunk dashScopeResponse
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return nil, fmt.Errorf("failed to decode stream response: %w", err)
+		}
+
+		if chunk.Code != "" {
+			return nil, fmt.Errorf("DashScope API error: %s: %s", chunk.Code, chunk.Message)
+		}
+
+		resp := chunk.toCompletionResponse(s.model)
+		if resp == nil {
+			continue
+		}
+		if chunk.RequestID != "" {
+			s.id = chunk.RequestID
+		}
+		if !s.accumulate(&chunk, resp) {
+			continue
+		}
+		if s.metadata == nil {
+			s.metadata = &ResponseMetadata{}
+		}
+		if s.metadata.RequestID == "" {
+			s.metadata.RequestID = chunk.RequestID
+		}
+		resp.Metadata = s.metadata
+		resp.Raw = rawEvent(data, s.raw)
+		return resp, nil
+	}
+}
+
+// accumulate merges the tool call fragments of the choices of chunk and sets
+// the complete calls of the choices that finished on resp in their place. It
+// reports whether resp still has anything to return without the fragments.
+func (s *dashScopeStream) accumulate(chunk *dashScopeResponse, resp *CompletionResponse) bool {
+	fragments := false
+	for i, c := range chunk.Output.Choices {
+		acc := s.toolCalls[c.Index]
+		if acc == nil {
+			if len(c.Message.ToolCalls) == 0 {
+				continue
+			}
+			if s.toolCalls == nil {
+				s.toolCalls = make(map[int]*toolCallAccumulator)
+			}
+			acc = &toolCallAccumulator{}
+			s.toolCalls[c.Index] = acc
+		}
+		fragments = fragments || len(c.Message.ToolCalls) > 0
+
+		for _, call := range c.Message.ToolCalls {
+			index := acc.next()
+			if call.Index != nil {
+				index = *call.Index
+			}
+			acc.add(index, call.ID, call.Function.Name, call.Function.Arguments)
+		}
+		resp.Choices[i].ToolCalls = nil
+		if resp.Choices[i].FinishReason != "" {
+			resp.Choices[i].ToolCalls = acc.flush()
+		}
+	}
+	if len(resp.Choices) > 0 {
+		resp.ToolCalls = resp.Choices[0].ToolCalls
+	}
+
+	if !fragments {
+		return true
+	}
+	for _, c := range resp.Choices {
+		if c.Content != "" || c.FinishReason != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// pendingToolCalls ends the stream, first returning a chunk with the tool
+// calls of choices that never reported a finish reason
+func (s *dashScopeStream) pendingToolCalls() (*CompletionResponse, error) {
+	var choices []CompletionChoice
+	for index, acc := range s.toolCalls {
+		if acc.pending() {
+			choices = append(choices, CompletionChoice{Index: index, ToolCalls: acc.flush()})
+		}
+	}
+	if len(choices) == 0 {
+		return nil, io.EOF
+	}
+	sort.Slice(choices, func(i, j int) bool { return choices[i].Index < choices[j].Index })
+
+	return &CompletionResponse{
+		ID:        s.id,
+		Model:     s.model,
+		ToolCalls: choices[0].ToolCalls,
+		Choices:   choices,
+		Metadata:  s.metadata,
+	}, nil
+}
+
+// Close implements the CompletionStream interface
+func (s *dashScopeStream) Close() error {
+	return s.closer.Close()
+}
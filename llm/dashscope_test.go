@@ -0,0 +1,343 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewDashScopeClient(t *testing.T) {
+	client := NewDashScopeClientWithKey("test-key")
+
+	if client.config.BaseURL != defaultDashScopeBaseURL {
+		t.Errorf("BaseURL = %v, want %v", client.config.BaseURL, defaultDashScopeBaseURL)
+	}
+}
+
+func TestDashScopeClient_Complete(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   string
+		statusCode int
+		wantErr    bool
+		wantResp   *CompletionResponse
+	}{
+		{
+			name: "message result format",
+			response: `{
+				"output": {
+					"choices": [
+						{
+							"finish_reason": "stop",
+							"message": {"role": "assistant", "content": "Test response"}
+						}
+					]
+				},
+				"request_id": "test-id"
+			}`,
+			statusCode: http.StatusOK,
+			wantResp: &CompletionResponse{
+				ID:           "test-id",
+				Content:      "Test response",
+				Model:        "qwen-plus",
+				FinishReason: "stop",
+			},
+		},
+		{
+			name: "text result format",
+			response: `{
+				"output": {"text": "Test response", "finish_reason": "length"},
+				"request_id": "test-id"
+			}`,
+			statusCode: http.StatusOK,
+			wantResp: &CompletionResponse{
+				ID:           "test-id",
+				Content:      "Test response",
+				Model:        "qwen-plus",
+				FinishReason: "length",
+			},
+		},
+		{
+			name:       "API error",
+			response:   `{"code": "InvalidApiKey", "message": "Invalid API-key provided.", "request_id": "test-id"}`,
+			statusCode: http.StatusUnauthorized,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasSuffix(r.URL.Path, "/services/aigc/text-generation/generation") {
+					t.Errorf("Path = %v, want /services/aigc/text-generation/generation", r.URL.Path)
+				}
+				if r.Header.Get("Authorization") != "Bearer test-key" {
+					t.Errorf("Authorization header = %v, want Bearer test-key", r.Header.Get("Authorization"))
+				}
+
+				var reqBody dashScopeRequest
+				if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+					t.Errorf("Failed to decode request body: %v", err)
+				}
+				if len(reqBody.Input.Messages) != 1 || reqBody.Input.Messages[0].Content != "Test prompt" {
+					t.Errorf("Invalid messages in request: %+v", reqBody.Input.Messages)
+				}
+				if reqBody.Parameters.ResultFormat != "message" {
+					t.Errorf("ResultFormat = %v, want message", reqBody.Parameters.ResultFormat)
+				}
+
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			client := NewDashScopeClient(DashScopeConfig{
+				APIKey:  "test-key",
+				BaseURL: server.URL,
+			})
+
+			got, err := client.Complete(context.Background(), &CompletionRequest{
+				Model:  "qwen-plus",
+				Prompt: "Test prompt",
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Complete() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if !strings.Contains(err.Error(), "InvalidApiKey") {
+					t.Errorf("Complete() error = %v, want it to mention InvalidApiKey", err)
+				}
+				return
+			}
+			if got.ID != tt.wantResp.ID {
+				t.Errorf("ID = %v, want %v", got.ID, tt.wantResp.ID)
+			}
+			if got.Content != tt.wantResp.Content {
+				t.Errorf("Content = %v, want %v", got.Content, tt.wantResp.Content)
+			}
+			if got.Model != tt.wantResp.Model {
+				t.Errorf("Model = %v, want %v", got.Model, tt.wantResp.Model)
+			}
+			if got.FinishReason != tt.wantResp.FinishReason {
+				t.Errorf("FinishReason = %v, want %v", got.FinishReason, tt.wantResp.FinishReason)
+			}
+		})
+	}
+}
+
+func TestDashScopeClient_CompleteStream(t *testing.T) {
+	responses := []string{
+		"id:1\nevent:result\n:HTTP_STATUS/200\n" +
+			`data:{"output":{"choices":[{"message":{"content":"Hello","role":"assistant"},"finish_reason":"null"}]},"request_id":"test-id"}` + "\n\n",
+		"id:2\nevent:result\n:HTTP_STATUS/200\n" +
+			`data:{"output":{"choices":[{"message":{"content":" World","role":"assistant"},"finish_reason":"stop"}]},"request_id":"test-id"}` + "\n\n",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-DashScope-SSE") != "enable" {
+			t.Errorf("X-DashScope-SSE header = %v, want enable", r.Header.Get("X-DashScope-SSE"))
+		}
+
+		var reqBody dashScopeRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		if !reqBody.Parameters.IncrementalOutput {
+			t.Error("IncrementalOutput = false, want true")
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("Streaming not supported")
+		}
+
+		for _, resp := range responses {
+			_, err := w.Write([]byte(resp))
+			if err != nil {
+				t.Fatal(err)
+			}
+			flusher.Flush()
+		}
+	}))
+	defer server.Close()
+
+	client := NewDashScopeClient(DashScopeConfig{
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+	})
+
+	stream, err := client.CompleteStream(context.Background(), &CompletionRequest{
+		Model:  "qwen-plus",
+		Prompt: "Test prompt",
+	})
+	if err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	var responsesGot []*CompletionResponse
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		responsesGot = append(responsesGot, resp)
+	}
+
+	if len(responsesGot) != 2 {
+		t.Fatalf("Got %d responses, want 2", len(responsesGot))
+	}
+	if responsesGot[0].Content != "Hello" || responsesGot[0].FinishReason != "" {
+		t.Errorf("Response 0 = %+v, want content Hello and no finish reason", responsesGot[0])
+	}
+	if responsesGot[1].Content != " World" || responsesGot[1].FinishReason != "stop" {
+		t.Errorf("Response 1 = %+v, want content ' World' and finish reason stop", responsesGot[1])
+	}
+}
+
+func TestDashScopeStream_ToolCalls(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []string
+		want   map[int][]string
+	}{
+		{
+			name: "fragments by index",
+			events: []string{
+				`{"output":{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]},"finish_reason":"null"}]},"request_id":"1"}`,
+				`{"output":{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[{"index":0,"id":"","type":"function","function":{"arguments":"{\"location\":"}}]},"finish_reason":"null"}]},"request_id":"1"}`,
+				`{"output":{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[{"index":0,"id":"","type":"function","function":{"arguments":" \"Paris\"}"}}]},"finish_reason":"null"}]},"request_id":"1"}`,
+				`{"output":{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"get_time","arguments":"{}"}}]},"finish_reason":"null"}]},"request_id":"1"}`,
+				`{"output":{"choices":[{"message":{"role":"assistant","content":""},"finish_reason":"tool_calls"}]},"request_id":"1"}`,
+			},
+			want: map[int][]string{0: {`call_1 get_weather {"location": "Paris"}`, `call_2 get_time {}`}},
+		},
+		{
+			name: "no finish reason",
+			events: []string{
+				`{"output":{"choices":[{"message":{"content":"Checking.","role":"assistant"},"finish_reason":"null"}]},"request_id":"1"}`,
+				`{"output":{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[{"index":0,"id":"call_1","function":{"name":"a","arguments":"{\"x\""}}]},"finish_reason":"null"}]},"request_id":"1"}`,
+				`{"output":{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[{"index":0,"function":{"arguments":":1}"}}]},"finish_reason":"null"}]},"request_id":"1"}`,
+			},
+			want: map[int][]string{1: {`call_1 a {"x":1}`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data strings.Builder
+			for _, event := range tt.events {
+				data.WriteString("id:1\nevent:result\ndata:" + event + "\n\n")
+			}
+
+			stream := &dashScopeStream{
+				reader: bufio.NewReader(strings.NewReader(data.String())),
+				closer: io.NopCloser(nil),
+				model:  "qwen-plus",
+			}
+
+			got := make(map[int][]string)
+			for i := 0; ; i++ {
+				chunk, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Recv() error = %v", err)
+				}
+				if chunk.ID != "1" {
+					t.Errorf("chunk %d ID = %q, want 1", i, chunk.ID)
+				}
+				for _, call := range chunk.ToolCalls {
+					got[i] = append(got[i], call.ID+" "+call.Function.Name+" "+call.Function.Arguments)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tool calls by chunk = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDashScopeClient_Messages(t *testing.T) {
+	history := []Message{
+		{Role: RoleSystem, Content: "Be brief"},
+		{Role: RoleUser, Content: "Hello"},
+		{Role: RoleAssistant, Content: "Hi"},
+		{Role: RoleUser, Content: "Bye"},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody dashScopeRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		if !reflect.DeepEqual(reqBody.Input.Messages, history) {
+			t.Errorf("Messages = %+v, want %+v", reqBody.Input.Messages, history)
+		}
+		w.Write([]byte(`{"request_id":"id","output":{"choices":[{"finish_reason":"stop","message":{"content":"ok"}}]}}`))
+	}))
+	defer server.Close()
+
+	client := NewDashScopeClient(DashScopeConfig{APIKey: "test-key", BaseURL: server.URL})
+	if _, err := client.Complete(context.Background(), &CompletionRequest{Messages: history}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+}
+
+func FuzzDashScopeStream(f *testing.F) {
+	f.Add([]byte("id:1\nevent:result\ndata:{\"output\":{\"choices\":[{\"message\":{\"content\":\"Hi\"},\"finish_reason\":\"null\"}]},\"request_id\":\"1\"}\n\n"))
+	f.Add([]byte("data:{\"output\":{\"text\":\"Hi\",\"finish_reason\":\"stop\"}}\n"))
+	f.Add([]byte("data:{\"code\":\"InvalidParameter\",\"message\":\"bad\"}\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkStream(t, &dashScopeStream{
+			reader: bufio.NewReader(bytes.NewReader(data)),
+			closer: io.NopCloser(nil),
+			model:  "qwen-turbo",
+		})
+	})
+}
+
+func TestDashScopeClient_MultipleChoices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody dashScopeRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		if reqBody.Parameters.N != 2 {
+			t.Errorf("N = %v, want 2", reqBody.Parameters.N)
+		}
+		w.Write([]byte(`{"request_id":"id","output":{"choices":[
+			{"index":0,"finish_reason":"stop","message":{"content":"Heads"}},
+			{"index":1,"finish_reason":"stop","message":{"content":"Tails"}}
+		]}}`))
+	}))
+	defer server.Close()
+
+	client := NewDashScopeClient(DashScopeConfig{APIKey: "test-key", BaseURL: server.URL})
+	resp, err := client.Complete(context.Background(), &CompletionRequest{Prompt: "Flip a coin", N: 2})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	want := []CompletionChoice{
+		{Index: 0, Content: "Heads", FinishReason: "stop"},
+		{Index: 1, Content: "Tails", FinishReason: "stop"},
+	}
+	if !reflect.DeepEqual(resp.Choices, want) {
+		t.Errorf("Choices = %+v, want %+v", resp.Choices, want)
+	}
+}
@@ -0,0 +1,201 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultTokensPerSecond = 20
+	defaultDeadlineMargin  = 2 * time.Second
+	defaultMinTokens       = 16
+
+	// throughputSmoothing is the weight of the latest observation in the
+	// moving average of a model's throughput
+	throughputSmoothing = 0.3
+)
+
+// DeadlineConfig contains configuration for the DeadlineMaxTokens middleware
+type DeadlineConfig struct {
+	// TokensPerSecond is the generation speed assumed for a model until its
+	// throughput has been observed (optional, defaults to 20)
+	TokensPerSecond float64
+
+	// Margin is reserved out of the remaining time for network latency and
+	// time to first token (optional, defaults to 2 seconds)
+	Margin time.Duration
+
+	// MinTokens is the smallest cap ever applied (optional, defaults to 16)
+	MinTokens int
+
+	// MaxTokens is the MaxTokens assumed for requests that do not set one,
+	// which are then capped like the others (optional). Without it such
+	// requests are left unchanged, keeping the provider's default, since a
+	// cap derived from a long deadline can exceed the model's output limit.
+	MaxTokens int
+}
+
+// DeadlineMaxTokens returns a Middleware that caps MaxTokens so that the
+// response is expected to finish before the context deadline, rather than
+// being cut off by cancellation. The cap is derived from the time remaining
+// and the throughput observed for the model on earlier calls. MaxTokens is
+// only ever lowered: requests without a deadline, without MaxTokens (unless
+// the config sets one) or whose MaxTokens is already below the cap are left
+// unchanged.
+func DeadlineMaxTokens(config DeadlineConfig) Middleware {
+	if config.TokensPerSecond <= 0 {
+		config.TokensPerSecond = defaultTokensPerSecond
+	}
+	if config.Margin == 0 {
+		config.Margin = defaultDeadlineMargin
+	}
+	if config.MinTokens <= 0 {
+		config.MinTokens = defaultMinTokens
+	}
+
+	return func(next LLMProvider) LLMProvider {
+		return &deadlineProvider{
+			next:   next,
+			config: config,
+			rates:  make(map[string]float64),
+		}
+	}
+}
+
+type deadlineProvider struct {
+	next   LLMProvider
+	config DeadlineConfig
+
+	mu    sync.Mutex
+	rates map[string]float64
+}
+
+// Complete implements the LLMProvider interface
+func (p *deadlineProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	start := time.Now()
+	resp, err := p.next.Complete(ctx, p.capRequest(ctx, req))
+	if err != nil {
+		return nil, err
+	}
+
+	p.observe(req.Model, estimateTokens(resp.Content), time.Since(start))
+	return resp, nil
+}
+
+// CompleteStream implements the LLMProvider interface
+func (p *deadlineProvider) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
+	start := time.Now()
+	stream, err := p.next.CompleteStream(ctx, p.capRequest(ctx, req))
+	if err != nil {
+		return nil, err
+	}
+
+	return &deadlineStream{
+		CompletionStream: stream,
+		provider:         p,
+		model:            req.Model,
+		start:            start,
+	}, nil
+}
+
+// capRequest returns req with MaxTokens lowered to what fits in the time
+// left before the context deadline
+func (p *deadlineProvider) capRequest(ctx context.Context, req *CompletionRequest) *CompletionRequest {
+	deadline, ok := ctx.Deadline()
+	maxTokens := req.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = p.config.MaxTokens
+	}
+	if !ok || maxTokens == 0 {
+		return req
+	}
+
+	remaining := time.Until(deadline) - p.config.Margin
+	limit := int(remaining.Seconds() * p.rate(req.Model))
+	if limit < p.config.MinTokens {
+		limit = p.config.MinTokens
+	}
+
+	if maxTokens == req.MaxTokens && maxTokens <= limit {
+		return req
+	}
+
+	r := *req
+	r.MaxTokens = min(maxTokens, limit)
+	return &r
+}
+
+// rate returns the observed throughput of model in tokens per second
+func (p *deadlineProvider) rate(model string) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if rate, ok := p.rates[model]; ok {
+		return rate
+	}
+	return p.config.TokensPerSecond
+}
+
+// observe folds a completed response into the throughput average of model
+func (p *deadlineProvider) observe(model string, tokens int, elapsed time.Duration) {
+	if tokens < p.config.MinTokens || elapsed <= 0 {
+		// Short responses are dominated by latency and would skew the rate
+		return
+	}
+
+	rate := float64(tokens) / elapsed.Seconds()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if previous, ok := p.rates[model]; ok {
+		rate = throughputSmoothing*rate + (1-throughputSmoothing)*previous
+	}
+	p.rates[model] = rate
+}
+
+// deadlineStream collects the streamed content to observe the throughput
+// once the stream completes
+type deadlineStream struct {
+	CompletionStream
+	provider *deadlineProvider
+	model    string
+	start    time.Time
+	content  strings.Builder
+}
+
+// Recv implements the CompletionStream interface
+func (s *deadlineStream) Recv() (*CompletionResponse, error) {
+	resp, err := s.CompletionStream.Recv()
+	if err == io.EOF {
+		s.provider.observe(s.model, estimateTokens(s.content.String()), time.Since(s.start))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.content.WriteString(resp.Content)
+	return resp, nil
+}
+
+// estimateTokens approximates the token count of text at four characters
+// per token, which is close enough for English prose
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// estimateMessageTokens approximates the token count of the text of msg, in
+// its content and its text parts
+func estimateMessageTokens(msg Message) int {
+	tokens := estimateTokens(msg.Content)
+	for _, part := range msg.Parts {
+		if part.Type == PartText {
+			tokens += estimateTokens(part.Text)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,144 @@
+package llm
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeadlineMaxTokens(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeout   time.Duration
+		maxTokens int
+		ceiling   int
+		wantMin   int
+		wantMax   int
+	}{
+		{
+			name:      "no deadline",
+			maxTokens: 1000,
+			wantMin:   1000,
+			wantMax:   1000,
+		},
+		{
+			name:    "unset MaxTokens kept",
+			timeout: 10 * time.Minute,
+			wantMin: 0,
+			wantMax: 0,
+		},
+		{
+			name:    "unset MaxTokens capped from configured ceiling",
+			timeout: 10 * time.Second,
+			ceiling: 4096,
+			wantMin: 85,
+			wantMax: 90,
+		},
+		{
+			name:    "configured ceiling below cap",
+			timeout: 10 * time.Minute,
+			ceiling: 4096,
+			wantMin: 4096,
+			wantMax: 4096,
+		},
+		{
+			name:      "lower MaxTokens kept",
+			timeout:   10 * time.Second,
+			maxTokens: 50,
+			wantMin:   50,
+			wantMax:   50,
+		},
+		{
+			name:      "higher MaxTokens lowered",
+			timeout:   10 * time.Second,
+			maxTokens: 1000,
+			wantMin:   85,
+			wantMax:   90,
+		},
+		{
+			name:      "deadline too close",
+			timeout:   500 * time.Millisecond,
+			maxTokens: 1000,
+			wantMin:   16,
+			wantMax:   16,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockProvider{}
+			provider := Chain(mock, DeadlineMaxTokens(DeadlineConfig{
+				TokensPerSecond: 10,
+				Margin:          time.Second,
+				MaxTokens:       tt.ceiling,
+			}))
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			req := &CompletionRequest{Model: "m", Prompt: "Hello", MaxTokens: tt.maxTokens}
+			if _, err := provider.Complete(ctx, req); err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+
+			got := mock.requests[0].MaxTokens
+			if got < tt.wantMin || got > tt.wantMax {
+				t.Errorf("MaxTokens = %d, want between %d and %d", got, tt.wantMin, tt.wantMax)
+			}
+			if req.MaxTokens != tt.maxTokens {
+				t.Errorf("caller's MaxTokens changed to %d", req.MaxTokens)
+			}
+		})
+	}
+}
+
+func TestDeadlineMaxTokens_Observe(t *testing.T) {
+	provider := DeadlineMaxTokens(DeadlineConfig{TokensPerSecond: 10})(&mockProvider{}).(*deadlineProvider)
+
+	provider.observe("m", 100, 2*time.Second)
+	if got := provider.rate("m"); got != 50 {
+		t.Errorf("rate after first observation = %v, want 50", got)
+	}
+
+	provider.observe("m", 100, time.Second)
+	if got := provider.rate("m"); math.Abs(got-65) > 1e-9 {
+		t.Errorf("rate after second observation = %v, want 65", got)
+	}
+
+	provider.observe("m", 4, time.Second)
+	if got := provider.rate("m"); math.Abs(got-65) > 1e-9 {
+		t.Errorf("rate after short response = %v, want 65", got)
+	}
+
+	if got := provider.rate("other"); got != 10 {
+		t.Errorf("rate of unobserved model = %v, want 10", got)
+	}
+}
+
+func TestDeadlineMaxTokens_ObserveStream(t *testing.T) {
+	upstream := &streamProvider{chunks: []*CompletionResponse{
+		{Content: strings.Repeat("word ", 40)},
+		{Content: strings.Repeat("word ", 40)},
+	}}
+	provider := DeadlineMaxTokens(DeadlineConfig{TokensPerSecond: 10})(upstream).(*deadlineProvider)
+
+	stream, err := provider.CompleteStream(context.Background(), &CompletionRequest{Model: "m"})
+	if err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+	for {
+		if _, err := stream.Recv(); err != nil {
+			break
+		}
+	}
+
+	if got := provider.rate("m"); got <= 10 {
+		t.Errorf("rate = %v, want the observed throughput of the stream", got)
+	}
+}
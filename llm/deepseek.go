@@ -0,0 +1,37 @@
+package llm
+
+const (
+	defaultDeepSeekBaseURL = "https://api.deepseek.com"
+)
+
+// DeepSeekClient implements the LLMProvider interface for DeepSeek's
+// OpenAI-compatible API. The reasoning trace of deepseek-reasoner is
+// returned in CompletionResponse.Reasoning, separately from the answer.
+type DeepSeekClient struct {
+	*OpenAIClient
+}
+
+// NewDeepSeekClient creates a new DeepSeek client with the given
+// configuration. BaseURL defaults to https://api.deepseek.com.
+func NewDeepSeekClient(config OpenAIConfig) *DeepSeekClient {
+	if config.BaseURL == "" {
+		config.BaseURL = defaultDeepSeekBaseURL
+	}
+
+	return &DeepSeekClient{
+		OpenAIClient: NewOpenAIClient(config),
+	}
+}
+
+// NewDeepSeekClientWithKey creates a new DeepSeek client with just an API key
+func NewDeepSeekClientWithKey(apiKey string) *DeepSeekClient {
+	return NewDeepSeekClient(OpenAIConfig{
+		APIKey: apiKey,
+	})
+}
+
+// With returns a copy of the client with opts applied to its configuration,
+// sharing its HTTP client; see OpenAIClient.With
+func (c *DeepSeekClient) With(opts ...OpenAIOption) *DeepSeekClient {
+	return &DeepSeekClient{OpenAIClient: c.OpenAIClient.With(opts...)}
+}
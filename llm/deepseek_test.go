@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewDeepSeekClient(t *testing.T) {
+	client := NewDeepSeekClientWithKey("test-key")
+
+	if client.config.BaseURL != defaultDeepSeekBaseURL {
+		t.Errorf("BaseURL = %v, want %v", client.config.BaseURL, defaultDeepSeekBaseURL)
+	}
+}
+
+func TestDeepSeekClient_Complete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"id": "test-id",
+			"choices": [
+				{
+					"message": {
+						"role": "assistant",
+						"reasoning_content": "9.11 has fewer tenths than 9.8.",
+						"content": "9.8 is greater."
+					},
+					"finish_reason": "stop"
+				}
+			],
+			"model": "deepseek-reasoner"
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewDeepSeekClient(OpenAIConfig{
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+	})
+
+	got, err := client.Complete(context.Background(), &CompletionRequest{
+		Model:  "deepseek-reasoner",
+		Prompt: "Which is greater, 9.11 or 9.8?",
+	})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	if got.Content != "9.8 is greater." {
+		t.Errorf("Content = %v, want '9.8 is greater.'", got.Content)
+	}
+	if got.Reasoning != "9.11 has fewer tenths than 9.8." {
+		t.Errorf("Reasoning = %v, want '9.11 has fewer tenths than 9.8.'", got.Reasoning)
+	}
+}
+
+func TestDeepSeekClient_CompleteStream(t *testing.T) {
+	responses := []string{
+		`data: {"id":"test-id","choices":[{"delta":{"reasoning_content":"Compare tenths."}}],"model":"deepseek-reasoner"}` + "\n\n",
+		`data: {"id":"test-id","choices":[{"delta":{"reasoning_content":" 1 < 8."}}],"model":"deepseek-reasoner"}` + "\n\n",
+		`data: {"id":"test-id","choices":[{"delta":{"content":"9.8"}}],"model":"deepseek-reasoner"}` + "\n\n",
+		`data: {"id":"test-id","choices":[{"delta":{"content":""},"finish_reason":"stop"}],"model":"deepseek-reasoner"}` + "\n\n",
+		"data: [DONE]\n\n",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("Streaming not supported")
+		}
+
+		for _, resp := range responses {
+			_, err := w.Write([]byte(resp))
+			if err != nil {
+				t.Fatal(err)
+			}
+			flusher.Flush()
+		}
+	}))
+	defer server.Close()
+
+	client := NewDeepSeekClient(OpenAIConfig{
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+	})
+
+	stream, err := client.CompleteStream(context.Background(), &CompletionRequest{
+		Model:  "deepseek-reasoner",
+		Prompt: "Which is greater, 9.11 or 9.8?",
+	})
+	if err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	var content, reasoning string
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content += resp.Content
+		reasoning += resp.Reasoning
+	}
+
+	if content != "9.8" {
+		t.Errorf("Content = %v, want 9.8", content)
+	}
+	if reasoning != "Compare tenths. 1 < 8." {
+		t.Errorf("Reasoning = %v, want 'Compare tenths. 1 < 8.'", reasoning)
+	}
+}
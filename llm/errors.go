@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrMultipleChoicesUnsupported is returned when a request asks for N > 1
+// choices from a provider that can only generate one
+var ErrMultipleChoicesUnsupported = errors.New("multiple choices (N > 1) are not supported by this provider")
+
+// ErrContentPartsUnsupported is returned when a request has messages with
+// Parts, such as images, for a provider that only accepts text
+var ErrContentPartsUnsupported = errors.New("content parts (Message.Parts) are not supported by this provider")
+
+// ErrToolsUnsupported is returned when a request has Tools for a provider
+// that cannot call them
+var ErrToolsUnsupported = errors.New("tools (CompletionRequest.Tools) are not supported by this provider")
+
+// ErrModelRequired is returned when a request has no Model and the client
+// has no default model to fall back to
+var ErrModelRequired = errors.New("model is required: set CompletionRequest.Model or the client's DefaultModel")
+
+// HTTPError represents an HTTP error response
+type HTTPError struct {
+	StatusCode int
+	Message    string
+
+	// RetryAfter is how long the provider asked us to wait before retrying,
+	// taken from the retry-after or rate-limit reset headers (zero if absent)
+	RetryAfter time.Duration
+
+	// RequestID is the provider-assigned request identifier, if reported
+	RequestID string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// newHTTPError builds an HTTPError from a non-200 response. The caller is
+// responsible for closing the response body.
+func newHTTPError(resp *http.Response) *HTTPError {
+	body, _ := io.ReadAll(resp.Body)
+	return &HTTPError{
+		StatusCode: resp.StatusCode,
+		Message:    string(body),
+		RetryAfter: parseRetryAfter(resp.Header),
+		RequestID:  newResponseMetadata(resp.Header).RequestID,
+	}
+}
+
+// parseRetryAfter extracts the server-requested backoff from the response
+// headers. The standard retry-after header wins; otherwise the OpenAI-style
+// x-ratelimit-reset-* headers are consulted for whichever limit is exhausted.
+func parseRetryAfter(header http.Header) time.Duration {
+	if value := strings.TrimSpace(header.Get("Retry-After")); value != "" {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+			return time.Duration(seconds * float64(time.Second))
+		}
+		if date, err := http.ParseTime(value); err == nil {
+			if wait := time.Until(date); wait > 0 {
+				return wait
+			}
+		}
+	}
+
+	var wait time.Duration
+	for _, limit := range []string{"requests", "tokens"} {
+		if header.Get("X-Ratelimit-Remaining-"+limit) != "0" {
+			continue
+		}
+		reset, err := time.ParseDuration(header.Get("X-Ratelimit-Reset-" + limit))
+		if err == nil && reset > wait {
+			wait = reset
+		}
+	}
+	return wait
+}
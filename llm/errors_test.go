@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{
+			name:   "no headers",
+			header: http.Header{},
+			want:   0,
+		},
+		{
+			name: "retry-after seconds",
+			header: http.Header{
+				"Retry-After": []string{"2"},
+			},
+			want: 2 * time.Second,
+		},
+		{
+			name: "retry-after wins over reset headers",
+			header: http.Header{
+				"Retry-After":                    []string{"1"},
+				"X-Ratelimit-Remaining-Requests": []string{"0"},
+				"X-Ratelimit-Reset-Requests":     []string{"30s"},
+			},
+			want: time.Second,
+		},
+		{
+			name: "exhausted request limit",
+			header: http.Header{
+				"X-Ratelimit-Remaining-Requests": []string{"0"},
+				"X-Ratelimit-Reset-Requests":     []string{"2m59.56s"},
+				"X-Ratelimit-Remaining-Tokens":   []string{"1200"},
+				"X-Ratelimit-Reset-Tokens":       []string{"7.66s"},
+			},
+			want: 2*time.Minute + 59560*time.Millisecond,
+		},
+		{
+			name: "both limits exhausted uses the longer reset",
+			header: http.Header{
+				"X-Ratelimit-Remaining-Requests": []string{"0"},
+				"X-Ratelimit-Reset-Requests":     []string{"1s"},
+				"X-Ratelimit-Remaining-Tokens":   []string{"0"},
+				"X-Ratelimit-Reset-Tokens":       []string{"7.5s"},
+			},
+			want: 7500 * time.Millisecond,
+		},
+		{
+			name: "limits not exhausted",
+			header: http.Header{
+				"X-Ratelimit-Remaining-Requests": []string{"10"},
+				"X-Ratelimit-Reset-Requests":     []string{"1s"},
+			},
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.header); got != tt.want {
+				t.Errorf("parseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func FuzzParseRetryAfter(f *testing.F) {
+	f.Add("3", "", "")
+	f.Add("Wed, 21 Oct 2015 07:28:00 GMT", "", "")
+	f.Add("", "0", "6m0s")
+	f.Add("-1", "0", "1e400h")
+
+	f.Fuzz(func(t *testing.T, retryAfter, remaining, reset string) {
+		header := http.Header{}
+		header.Set("Retry-After", retryAfter)
+		header.Set("X-Ratelimit-Remaining-Requests", remaining)
+		header.Set("X-Ratelimit-Reset-Requests", reset)
+
+		if got := parseRetryAfter(header); got < 0 {
+			t.Errorf("parseRetryAfter() = %v, want a non-negative duration", got)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// requestExtras carries the Extra body fields, ExtraHeaders and
+// RequestTimeout of a CompletionRequest through to the HTTP request a
+// provider sends
+type requestExtras struct {
+	body    map[string]any
+	headers map[string]string
+	timeout time.Duration
+}
+
+func newRequestExtras(req *CompletionRequest) requestExtras {
+	return requestExtras{body: req.Extra, headers: req.ExtraHeaders, timeout: req.RequestTimeout}
+}
+
+// marshal encodes payload as JSON with the extra body fields merged in. Extra
+// fields replace the fields gollm sets, except that JSON objects present in
+// both are merged recursively, so nested settings can be added one at a time.
+func (e requestExtras) marshal(payload any) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil || len(e.body) == 0 {
+		return body, err
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("extra fields require a JSON object body: %w", err)
+	}
+
+	extra, err := json.Marshal(e.body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal extra fields: %w", err)
+	}
+	var extraFields map[string]any
+	if err := json.Unmarshal(extra, &extraFields); err != nil {
+		return nil, err
+	}
+
+	mergeFields(fields, extraFields)
+	return json.Marshal(fields)
+}
+
+// setHeaders adds the extra headers to header, replacing any already set
+func (e requestExtras) setHeaders(header http.Header) {
+	for name, value := range e.headers {
+		header.Set(name, value)
+	}
+}
+
+// client returns httpClient, or a copy with the request timeout when one is
+// set. The copy shares the transport, and with it the connection pool.
+func (e requestExtras) client(httpClient *http.Client) *http.Client {
+	if e.timeout == 0 {
+		return httpClient
+	}
+
+	c := *httpClient
+	c.Timeout = e.timeout
+	return &c
+}
+
+func mergeFields(dst, src map[string]any) {
+	for key, value := range src {
+		srcObject, srcOK := value.(map[string]any)
+		dstObject, dstOK := dst[key].(map[string]any)
+		if srcOK && dstOK {
+			mergeFields(dstObject, srcObject)
+			continue
+		}
+		dst[key] = value
+	}
+}
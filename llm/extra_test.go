@@ -0,0 +1,158 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRequestExtras_Marshal(t *testing.T) {
+	payload := map[string]any{
+		"model":      "m",
+		"parameters": map[string]any{"top_p": 0.5},
+	}
+
+	tests := []struct {
+		name  string
+		extra map[string]any
+		want  string
+	}{
+		{
+			name: "no extra",
+			want: `{"model":"m","parameters":{"top_p":0.5}}`,
+		},
+		{
+			name:  "new field",
+			extra: map[string]any{"reasoning_effort": "low"},
+			want:  `{"model":"m","parameters":{"top_p":0.5},"reasoning_effort":"low"}`,
+		},
+		{
+			name:  "replaces field",
+			extra: map[string]any{"model": "other"},
+			want:  `{"model":"other","parameters":{"top_p":0.5}}`,
+		},
+		{
+			name:  "merges objects",
+			extra: map[string]any{"parameters": map[string]any{"enable_search": true}},
+			want:  `{"model":"m","parameters":{"enable_search":true,"top_p":0.5}}`,
+		},
+		{
+			name:  "replaces object with null",
+			extra: map[string]any{"parameters": nil},
+			want:  `{"model":"m","parameters":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := requestExtras{body: tt.extra}.marshal(payload)
+			if err != nil {
+				t.Fatalf("marshal() error = %v", err)
+			}
+
+			var got, want any
+			json.Unmarshal(body, &got)
+			json.Unmarshal([]byte(tt.want), &want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("marshal() = %s, want %s", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtra_Providers(t *testing.T) {
+	var (
+		body   map[string]any
+		header http.Header
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		header = r.Header
+		multiProviderHandler(w, r)
+	}))
+	defer server.Close()
+
+	providers := map[string]LLMProvider{
+		"openai":    NewOpenAIClient(OpenAIConfig{BaseURL: server.URL}),
+		"anthropic": NewAnthropicClientWithConfig(AnthropicConfig{BaseURL: server.URL + "/v1"}),
+		"cohere":    NewCohereClient(CohereConfig{BaseURL: server.URL}),
+		"dashscope": NewDashScopeClient(DashScopeConfig{BaseURL: server.URL}),
+		"llamacpp":  NewLlamaCppClient(LlamaCppConfig{BaseURL: server.URL}),
+	}
+
+	req := &CompletionRequest{
+		Model:        "test-model",
+		Prompt:       "Hi",
+		Extra:        map[string]any{"safe_prompt": true},
+		ExtraHeaders: map[string]string{"X-Feature": "beta"},
+	}
+
+	for name, provider := range providers {
+		t.Run(name, func(t *testing.T) {
+			if _, err := provider.Complete(context.Background(), req); err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if body["safe_prompt"] != true {
+				t.Errorf("safe_prompt = %v, want true", body["safe_prompt"])
+			}
+			if body["model"] == nil && name != "llamacpp" {
+				t.Error("model missing from body")
+			}
+			if got := header.Get("X-Feature"); got != "beta" {
+				t.Errorf("X-Feature = %q, want beta", got)
+			}
+
+			stream, err := provider.CompleteStream(context.Background(), req)
+			if err != nil {
+				t.Fatalf("CompleteStream() error = %v", err)
+			}
+			stream.Close()
+			if body["safe_prompt"] != true {
+				t.Errorf("stream safe_prompt = %v, want true", body["safe_prompt"])
+			}
+		})
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		multiProviderHandler(w, r)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		clientTimeout  time.Duration
+		requestTimeout time.Duration
+		wantErr        bool
+	}{
+		{name: "client timeout", clientTimeout: 20 * time.Millisecond, wantErr: true},
+		{name: "longer request timeout", clientTimeout: 20 * time.Millisecond, requestTimeout: 5 * time.Second},
+		{name: "shorter request timeout", clientTimeout: 5 * time.Second, requestTimeout: 20 * time.Millisecond, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewOpenAIClient(OpenAIConfig{
+				BaseURL:     server.URL,
+				Timeout:     tt.clientTimeout,
+				RetryConfig: &RetryConfig{},
+			})
+			req := &CompletionRequest{Model: "gpt-4", Prompt: "Hi", RequestTimeout: tt.requestTimeout}
+
+			_, err := client.Complete(context.Background(), req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Complete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if client.httpClient.Timeout != tt.clientTimeout {
+				t.Errorf("client Timeout changed to %v", client.httpClient.Timeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+const (
+	defaultFireworksBaseURL = "https://api.fireworks.ai/inference/v1"
+)
+
+// FireworksClient implements the LLMProvider interface for Fireworks AI's
+// OpenAI-compatible inference API.
+//
+// Fireworks' structured output extensions are selected through
+// CompletionRequest.Options:
+//   - "grammar": a GBNF grammar the output must follow
+//   - "json_schema": a JSON schema the output must match (enables JSON mode)
+//   - "response_format": set to "json_object" for JSON mode without a schema
+type FireworksClient struct {
+	*OpenAIClient
+}
+
+// NewFireworksClient creates a new Fireworks AI client with the given
+// configuration. BaseURL defaults to https://api.fireworks.ai/inference/v1.
+func NewFireworksClient(config OpenAIConfig) *FireworksClient {
+	if config.BaseURL == "" {
+		config.BaseURL = defaultFireworksBaseURL
+	}
+
+	client := NewOpenAIClient(config)
+	client.prepareRequest = fireworksPrepareRequest
+
+	return &FireworksClient{
+		OpenAIClient: client,
+	}
+}
+
+// NewFireworksClientWithKey creates a new Fireworks AI client with just an API key
+func NewFireworksClientWithKey(apiKey string) *FireworksClient {
+	return NewFireworksClient(OpenAIConfig{
+		APIKey: apiKey,
+	})
+}
+
+type fireworksResponseFormat struct {
+	Type    string          `json:"type"`
+	Schema  json.RawMessage `json:"schema,omitempty"`
+	Grammar string          `json:"grammar,omitempty"`
+}
+
+func fireworksPrepareRequest(req *CompletionRequest, openaiReq *openaiRequest) error {
+	grammar := req.Options["grammar"]
+	schema := req.Options["json_schema"]
+	jsonMode := req.Options["response_format"] == "json_object"
+
+	if grammar != "" && (schema != "" || jsonMode) {
+		return errors.New("fireworks: grammar and JSON mode cannot be combined")
+	}
+
+	switch {
+	case grammar != "":
+		openaiReq.ResponseFormat = fireworksResponseFormat{
+			Type:    "grammar",
+			Grammar: grammar,
+		}
+	case schema != "":
+		if !json.Valid([]byte(schema)) {
+			return errors.New("fireworks: json_schema option is not valid JSON")
+		}
+		openaiReq.ResponseFormat = fireworksResponseFormat{
+			Type:   "json_object",
+			Schema: json.RawMessage(schema),
+		}
+	case jsonMode:
+		openaiReq.ResponseFormat = fireworksResponseFormat{
+			Type: "json_object",
+		}
+	}
+
+	return nil
+}
+
+// With returns a copy of the client with opts applied to its configuration,
+// sharing its HTTP client; see OpenAIClient.With
+func (c *FireworksClient) With(opts ...OpenAIOption) *FireworksClient {
+	return &FireworksClient{OpenAIClient: c.OpenAIClient.With(opts...)}
+}
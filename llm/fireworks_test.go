@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewFireworksClient(t *testing.T) {
+	client := NewFireworksClientWithKey("test-key")
+
+	if client.config.BaseURL != defaultFireworksBaseURL {
+		t.Errorf("BaseURL = %v, want %v", client.config.BaseURL, defaultFireworksBaseURL)
+	}
+}
+
+func TestFireworksClient_Complete(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    map[string]string
+		wantErr    bool
+		wantFormat string
+	}{
+		{
+			name:       "no extensions",
+			options:    nil,
+			wantFormat: "",
+		},
+		{
+			name:       "grammar",
+			options:    map[string]string{"grammar": `root ::= "yes" | "no"`},
+			wantFormat: `{"type":"grammar","grammar":"root ::= \"yes\" | \"no\""}`,
+		},
+		{
+			name:       "json schema",
+			options:    map[string]string{"json_schema": `{"type":"object","properties":{"answer":{"type":"string"}}}`},
+			wantFormat: `{"type":"json_object","schema":{"type":"object","properties":{"answer":{"type":"string"}}}}`,
+		},
+		{
+			name:       "json mode",
+			options:    map[string]string{"response_format": "json_object"},
+			wantFormat: `{"type":"json_object"}`,
+		},
+		{
+			name:    "invalid schema",
+			options: map[string]string{"json_schema": `{"type":`},
+			wantErr: true,
+		},
+		{
+			name:    "grammar with json mode",
+			options: map[string]string{"grammar": `root ::= "yes"`, "response_format": "json_object"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var reqBody struct {
+					ResponseFormat json.RawMessage `json:"response_format"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+					t.Errorf("Failed to decode request body: %v", err)
+				}
+				if string(reqBody.ResponseFormat) != tt.wantFormat {
+					t.Errorf("response_format = %s, want %s", reqBody.ResponseFormat, tt.wantFormat)
+				}
+
+				w.Write([]byte(`{
+					"id": "test-id",
+					"choices": [{"message": {"content": "yes"}, "finish_reason": "stop"}],
+					"model": "accounts/fireworks/models/llama-v3p1-8b-instruct"
+				}`))
+			}))
+			defer server.Close()
+
+			client := NewFireworksClient(OpenAIConfig{
+				APIKey:  "test-key",
+				BaseURL: server.URL,
+			})
+
+			got, err := client.Complete(context.Background(), &CompletionRequest{
+				Model:   "accounts/fireworks/models/llama-v3p1-8b-instruct",
+				Prompt:  "Answer yes or no",
+				Options: tt.options,
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Complete() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.Content != "yes" {
+				t.Errorf("Content = %v, want yes", got.Content)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package llm
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	defaultGroqBaseURL = "https://api.groq.com/openai/v1"
+)
+
+// GroqClient implements the LLMProvider interface for Groq's OpenAI-compatible API
+type GroqClient struct {
+	*OpenAIClient
+}
+
+// NewGroqClient creates a new Groq client with the given configuration.
+// BaseURL defaults to https://api.groq.com/openai/v1 and, unless a RetryConfig
+// is provided, retries wait for as long as Groq's rate-limit headers ask to.
+func NewGroqClient(config OpenAIConfig) *GroqClient {
+	if config.BaseURL == "" {
+		config.BaseURL = defaultGroqBaseURL
+	}
+
+	if config.RetryConfig == nil {
+		config.RetryConfig = &RetryConfig{
+			MaxRetries:   5,
+			InitialDelay: time.Second,
+			MaxDelay:     30 * time.Second,
+			RetryableStatusCodes: []int{
+				http.StatusTooManyRequests,
+				http.StatusInternalServerError,
+				http.StatusBadGateway,
+				http.StatusServiceUnavailable,
+			},
+			RespectRetryAfter: true,
+		}
+	}
+
+	return &GroqClient{
+		OpenAIClient: NewOpenAIClient(config),
+	}
+}
+
+// NewGroqClientWithKey creates a new Groq client with just an API key
+func NewGroqClientWithKey(apiKey string) *GroqClient {
+	return NewGroqClient(OpenAIConfig{
+		APIKey: apiKey,
+	})
+}
+
+// With returns a copy of the client with opts applied to its configuration,
+// sharing its HTTP client; see OpenAIClient.With
+func (c *GroqClient) With(opts ...OpenAIOption) *GroqClient {
+	return &GroqClient{OpenAIClient: c.OpenAIClient.With(opts...)}
+}
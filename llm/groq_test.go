@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewGroqClient(t *testing.T) {
+	client := NewGroqClientWithKey("test-key")
+
+	if client.config.BaseURL != defaultGroqBaseURL {
+		t.Errorf("BaseURL = %v, want %v", client.config.BaseURL, defaultGroqBaseURL)
+	}
+	if !client.config.RetryConfig.RespectRetryAfter {
+		t.Error("RespectRetryAfter = false, want true")
+	}
+}
+
+func TestGroqClient_RateLimitRetry(t *testing.T) {
+	attempts := 0
+	var retriedAfter time.Duration
+	var firstAttempt time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/chat/completions") {
+			t.Errorf("Path = %v, want /chat/completions", r.URL.Path)
+		}
+
+		attempts++
+		if attempts == 1 {
+			firstAttempt = time.Now()
+			w.Header().Set("x-ratelimit-remaining-requests", "0")
+			w.Header().Set("x-ratelimit-reset-requests", "50ms")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		retriedAfter = time.Since(firstAttempt)
+
+		json.NewEncoder(w).Encode(openaiResponse{
+			Model: "llama-3.1-8b-instant",
+			Choices: []choice{
+				{
+					Message: openaiMessage{
+						Content: "Success after retry",
+					},
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewGroqClient(OpenAIConfig{
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+	})
+
+	resp, err := client.Complete(context.Background(), &CompletionRequest{
+		Model:  "llama-3.1-8b-instant",
+		Prompt: "Test prompt",
+	})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("Got %d attempts, want 2", attempts)
+	}
+	if retriedAfter < 50*time.Millisecond || retriedAfter >= time.Second {
+		t.Errorf("Retried after %v, want the 50ms requested by the rate-limit headers", retriedAfter)
+	}
+	if resp.Content != "Success after retry" {
+		t.Errorf("Content = %v, want 'Success after retry'", resp.Content)
+	}
+}
@@ -0,0 +1,231 @@
+package llm
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// LanguageConfig contains configuration for the MatchLanguage middleware
+type LanguageConfig struct {
+	// Detect returns the ISO 639-1 code of the language of text, or "" when
+	// unsure (optional, defaults to DetectLanguage)
+	Detect func(text string) string
+
+	// Names are the names of the languages Detect returns, by code, used in
+	// the instruction to the model (optional). Languages without a name
+	// there or among the languages DetectLanguage recognizes are named by
+	// their code.
+	Names map[string]string
+
+	// Regenerate is how many times Complete asks again when the reply is
+	// detected in another language than the user's (optional). The last
+	// reply is returned even if it still does not match. Streams are never
+	// regenerated.
+	Regenerate int
+}
+
+// MatchLanguage returns a Middleware that detects the language of the last
+// user message of every request and instructs the model, through the system
+// prompt, to reply in that language. Requests whose language cannot be
+// detected are sent unchanged.
+func MatchLanguage(config LanguageConfig) Middleware {
+	if config.Detect == nil {
+		config.Detect = DetectLanguage
+	}
+
+	return func(next LLMProvider) LLMProvider {
+		return &languageProvider{next: next, config: config}
+	}
+}
+
+type languageProvider struct {
+	next   LLMProvider
+	config LanguageConfig
+}
+
+// Complete implements the LLMProvider interface
+func (p *languageProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	req, language := p.instruct(req)
+
+	resp, err := p.next.Complete(ctx, req)
+	for attempt := 0; err == nil && language != "" && attempt < p.config.Regenerate; attempt++ {
+		if detected := p.config.Detect(resp.Content); detected == "" || detected == language {
+			break
+		}
+		resp, err = p.next.Complete(ctx, req)
+	}
+	return resp, err
+}
+
+// CompleteStream implements the LLMProvider interface
+func (p *languageProvider) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
+	req, _ = p.instruct(req)
+	return p.next.CompleteStream(ctx, req)
+}
+
+// instruct returns req with the reply language added to its system prompt,
+// and that language, or req itself and "" when it cannot be detected
+func (p *languageProvider) instruct(req *CompletionRequest) (*CompletionRequest, string) {
+	var text string
+	for _, msg := range req.messages() {
+		if msg.Role == RoleUser {
+			text = msg.Content
+		}
+	}
+
+	language := p.config.Detect(text)
+	if language == "" {
+		return req, ""
+	}
+
+	instruction := "Always reply in " + p.name(language) + ", the language of the user."
+	r := *req
+	if r.SystemPrompt == "" {
+		r.SystemPrompt = instruction
+	} else {
+		r.SystemPrompt += "\n\n" + instruction
+	}
+	return &r, language
+}
+
+// name returns the name of language for the instruction to the model
+func (p *languageProvider) name(language string) string {
+	if name, ok := p.config.Names[language]; ok {
+		return name
+	}
+	if name, ok := languageNames[language]; ok {
+		return name
+	}
+	return "the language with ISO 639-1 code " + strconv.Quote(language)
+}
+
+// languageNames are the English names of the languages DetectLanguage
+// recognizes, by ISO 639-1 code
+var languageNames = map[string]string{
+	"ar": "Arabic",
+	"de": "German",
+	"el": "Greek",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"he": "Hebrew",
+	"hi": "Hindi",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"th": "Thai",
+	"uk": "Ukrainian",
+	"zh": "Chinese",
+}
+
+// stopwords are frequent words that tell Latin-script languages apart
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "you", "what", "how", "this", "that", "with", "for", "of", "to", "in", "it", "my", "can", "please", "hello", "hi", "thanks", "i"},
+	"es": {"el", "la", "los", "las", "y", "es", "que", "de", "en", "por", "para", "con", "una", "un", "qué", "cómo", "hola", "gracias", "mi", "está", "puedes", "yo"},
+	"fr": {"le", "la", "les", "et", "est", "que", "de", "des", "en", "pour", "avec", "une", "un", "je", "vous", "bonjour", "merci", "comment", "quoi", "mon", "pouvez", "c'est"},
+	"de": {"der", "die", "das", "und", "ist", "sind", "ich", "du", "sie", "mit", "für", "von", "zu", "ein", "eine", "nicht", "hallo", "danke", "wie", "was", "bitte", "mein"},
+	"it": {"il", "lo", "gli", "e", "è", "che", "di", "per", "con", "una", "un", "sono", "ciao", "grazie", "come", "cosa", "mio", "puoi", "della", "io"},
+	"pt": {"o", "os", "as", "e", "é", "que", "de", "em", "para", "com", "uma", "um", "não", "olá", "obrigado", "obrigada", "como", "você", "meu", "eu"},
+	"nl": {"de", "het", "een", "en", "is", "zijn", "ik", "je", "jij", "met", "voor", "van", "niet", "hallo", "dank", "bedankt", "hoe", "wat", "mijn", "alsjeblieft"},
+}
+
+// DetectLanguage returns the ISO 639-1 code of the language text is written
+// in, or "" when it cannot tell. Non-Latin scripts are recognized by their
+// characters and Latin-script languages (English, Spanish, French, German,
+// Italian, Portuguese and Dutch) by their most frequent words, so short or
+// mixed texts may not be recognized.
+func DetectLanguage(text string) string {
+	if language := detectScript(text); language != "" {
+		return language
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+
+	scores := make(map[string]int)
+	for _, word := range words {
+		for language, list := range stopwords {
+			for _, stopword := range list {
+				if word == stopword {
+					scores[language]++
+				}
+			}
+		}
+	}
+
+	best, bestScore, tie := "", 0, false
+	for language, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, tie = language, score, false
+		case score == bestScore:
+			tie = true
+		}
+	}
+	if tie {
+		return ""
+	}
+	return best
+}
+
+// detectScript recognizes languages by the script of the majority of the
+// letters of text, returning "" for Latin script or no letters
+func detectScript(text string) string {
+	counts := make(map[string]int)
+	var letters int
+	var kana, ukrainian bool
+
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+
+		switch {
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			kana = true
+			counts["ja"]++
+		case unicode.Is(unicode.Han, r):
+			counts["zh"]++
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			if strings.ContainsRune("іїєґІЇЄҐ", r) {
+				ukrainian = true
+			}
+			counts["ru"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Hebrew, r):
+			counts["he"]++
+		case unicode.Is(unicode.Greek, r):
+			counts["el"]++
+		case unicode.Is(unicode.Devanagari, r):
+			counts["hi"]++
+		case unicode.Is(unicode.Thai, r):
+			counts["th"]++
+		}
+	}
+
+	// Japanese mixes kanji with kana
+	if kana {
+		counts["ja"] += counts["zh"]
+		delete(counts, "zh")
+	}
+
+	for language, count := range counts {
+		if 2*count > letters {
+			if language == "ru" && ukrainian {
+				return "uk"
+			}
+			return language
+		}
+	}
+	return ""
+}
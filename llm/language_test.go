@@ -0,0 +1,138 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "What is the weather like in London today?", want: "en"},
+		{text: "¿Qué tiempo hace hoy en Madrid? Gracias", want: "es"},
+		{text: "Bonjour, pouvez-vous m'aider avec mon code ?", want: "fr"},
+		{text: "Wie ist das Wetter heute in Berlin?", want: "de"},
+		{text: "Ciao, come stai? Io sono qui per imparare", want: "it"},
+		{text: "Olá, você pode me ajudar com uma pergunta?", want: "pt"},
+		{text: "Hallo, hoe gaat het met je? Ik ben hier voor een vraag", want: "nl"},
+		{text: "今日の天気はどうですか", want: "ja"},
+		{text: "今天天气怎么样", want: "zh"},
+		{text: "오늘 날씨 어때요?", want: "ko"},
+		{text: "Какая сегодня погода?", want: "ru"},
+		{text: "Яка сьогодні погода в Києві?", want: "uk"},
+		{text: "ما هو الطقس اليوم؟", want: "ar"},
+		{text: "Τι καιρό κάνει σήμερα;", want: "el"},
+		{text: "12345 !!!", want: ""},
+		{text: "Kubernetes", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := DetectLanguage(tt.text); got != tt.want {
+				t.Errorf("DetectLanguage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchLanguage(t *testing.T) {
+	polish := func(string) string { return "pl" }
+
+	tests := []struct {
+		name       string
+		config     LanguageConfig
+		req        CompletionRequest
+		wantSystem string
+	}{
+		{
+			name:       "instruction added",
+			req:        CompletionRequest{Prompt: "Wie ist das Wetter heute?"},
+			wantSystem: "Always reply in German, the language of the user.",
+		},
+		{
+			name:       "appended to system prompt",
+			req:        CompletionRequest{SystemPrompt: "Be brief.", Prompt: "¿Qué hora es? Gracias"},
+			wantSystem: "Be brief.\n\nAlways reply in Spanish, the language of the user.",
+		},
+		{
+			name: "last user message decides",
+			req: CompletionRequest{Messages: []Message{
+				{Role: RoleUser, Content: "What is the time?"},
+				{Role: RoleAssistant, Content: "Noon."},
+				{Role: RoleUser, Content: "Merci, et le temps pour demain ?"},
+			}},
+			wantSystem: "Always reply in French, the language of the user.",
+		},
+		{
+			name:       "undetected language left unchanged",
+			req:        CompletionRequest{SystemPrompt: "Be brief.", Prompt: "42"},
+			wantSystem: "Be brief.",
+		},
+		{
+			name:       "custom detection named by code",
+			config:     LanguageConfig{Detect: polish},
+			req:        CompletionRequest{Prompt: "Jaka jest pogoda?"},
+			wantSystem: `Always reply in the language with ISO 639-1 code "pl", the language of the user.`,
+		},
+		{
+			name:       "custom detection with names",
+			config:     LanguageConfig{Detect: polish, Names: map[string]string{"pl": "Polish"}},
+			req:        CompletionRequest{Prompt: "Jaka jest pogoda?"},
+			wantSystem: "Always reply in Polish, the language of the user.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockProvider{}
+			provider := Chain(mock, MatchLanguage(tt.config))
+
+			if _, err := provider.Complete(context.Background(), &tt.req); err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if got := mock.requests[0].SystemPrompt; got != tt.wantSystem {
+				t.Errorf("SystemPrompt = %q, want %q", got, tt.wantSystem)
+			}
+		})
+	}
+}
+
+func TestMatchLanguage_Regenerate(t *testing.T) {
+	replies := []string{"The weather is sunny.", "The weather is sunny.", "Il fait beau, merci."}
+	mock := &mockProvider{}
+	mock.complete = func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+		// The mock records the request before replying, so this is the
+		// reply to the n-th call
+		reply := replies[min(len(mock.requests), len(replies))-1]
+		return &CompletionResponse{Content: reply}, nil
+	}
+
+	tests := []struct {
+		regenerate int
+		wantCalls  int
+		wantFrench bool
+	}{
+		{regenerate: 0, wantCalls: 1},
+		{regenerate: 1, wantCalls: 2},
+		{regenerate: 5, wantCalls: 3, wantFrench: true},
+	}
+
+	for _, tt := range tests {
+		mock.requests = nil
+		provider := Chain(mock, MatchLanguage(LanguageConfig{Regenerate: tt.regenerate}))
+
+		resp, err := provider.Complete(context.Background(), &CompletionRequest{Prompt: "Bonjour, quel temps fait-il ? Merci"})
+		if err != nil {
+			t.Fatalf("Complete() error = %v", err)
+		}
+		if len(mock.requests) != tt.wantCalls {
+			t.Errorf("Regenerate %d: calls = %d, want %d", tt.regenerate, len(mock.requests), tt.wantCalls)
+		}
+		if got := strings.Contains(resp.Content, "merci"); got != tt.wantFrench {
+			t.Errorf("Regenerate %d: Content = %q", tt.regenerate, resp.Content)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package llm
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// Range is an inclusive range of allowed values. The zero Range allows any
+// value.
+type Range struct {
+	Min float32
+	Max float32
+}
+
+// clamp returns v limited to the range
+func (r Range) clamp(v float32) float32 {
+	if r == (Range{}) {
+		return v
+	}
+	return min(max(v, r.Min), r.Max)
+}
+
+// ParameterLimits are the values a provider accepts for request
+// parameters. Zero fields impose no limit.
+type ParameterLimits struct {
+	// MaxStop is the largest number of stop sequences
+	MaxStop int
+
+	// MaxN is the largest number of choices
+	MaxN int
+
+	// Temperature, TopP and Penalty bound the sampling parameters; Penalty
+	// applies to both FrequencyPenalty and PresencePenalty
+	Temperature Range
+	TopP        Range
+	Penalty     Range
+}
+
+// providerLimits are the documented limits of the built-in providers, by
+// registry name
+var providerLimits = map[string]ParameterLimits{
+	"openai":    {MaxStop: 4, MaxN: 128, Temperature: Range{0, 2}, TopP: Range{0, 1}, Penalty: Range{-2, 2}},
+	"anthropic": {MaxN: 1, Temperature: Range{0, 1}, TopP: Range{0, 1}},
+	"cohere":    {MaxStop: 5, MaxN: 1, Temperature: Range{0, 1}, TopP: Range{0.01, 0.99}, Penalty: Range{0, 1}},
+	"groq":      {MaxStop: 4, MaxN: 1, Temperature: Range{0, 2}, TopP: Range{0, 1}, Penalty: Range{-2, 2}},
+	"together":  {MaxStop: 4, Temperature: Range{0, 2}, TopP: Range{0, 1}, Penalty: Range{-2, 2}},
+	"fireworks": {MaxStop: 4, Temperature: Range{0, 2}, TopP: Range{0, 1}, Penalty: Range{-2, 2}},
+	"deepseek":  {MaxStop: 16, MaxN: 1, Temperature: Range{0, 2}, TopP: Range{0, 1}, Penalty: Range{-2, 2}},
+	"dashscope": {MaxN: 1, Temperature: Range{0, 1.99}, TopP: Range{0, 1}, Penalty: Range{-2, 2}},
+}
+
+// LimitsFor returns the parameter limits of the provider registered under
+// name, or false for providers without known limits such as local servers
+func LimitsFor(name string) (ParameterLimits, bool) {
+	limits, ok := providerLimits[strings.ToLower(name)]
+	return limits, ok
+}
+
+// ClampConfig contains configuration for the ClampParameters middleware
+type ClampConfig struct {
+	// Limits are the limits to enforce, usually from LimitsFor
+	Limits ParameterLimits
+
+	// Logger receives a warning for every adjusted parameter (optional,
+	// defaults to slog.Default())
+	Logger *slog.Logger
+}
+
+// ClampParameters returns a Middleware that brings request parameters
+// within the provider's limits before the request is sent, instead of
+// letting the provider reject it: extra stop sequences are dropped and
+// numbers are clamped to their range. Every adjustment is logged as a
+// warning so it does not go unnoticed.
+func ClampParameters(config ClampConfig) Middleware {
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	return func(next LLMProvider) LLMProvider {
+		return &clampProvider{next: next, config: config}
+	}
+}
+
+type clampProvider struct {
+	next   LLMProvider
+	config ClampConfig
+}
+
+// Complete implements the LLMProvider interface
+func (p *clampProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	return p.next.Complete(ctx, p.clamp(ctx, req))
+}
+
+// CompleteStream implements the LLMProvider interface
+func (p *clampProvider) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
+	return p.next.CompleteStream(ctx, p.clamp(ctx, req))
+}
+
+// clamp returns req, or a copy with the parameters outside the limits
+// adjusted
+func (p *clampProvider) clamp(ctx context.Context, req *CompletionRequest) *CompletionRequest {
+	limits := p.config.Limits
+	r := *req
+	changed := false
+
+	warn := func(param string, value, limit any) {
+		changed = true
+		p.config.Logger.WarnContext(ctx, "request parameter exceeds provider limit",
+			"param", param, "value", value, "limit", limit, "model", req.Model)
+	}
+
+	if limits.MaxStop > 0 && len(r.Stop) > limits.MaxStop {
+		warn("stop", len(r.Stop), limits.MaxStop)
+		r.Stop = r.Stop[:limits.MaxStop:limits.MaxStop]
+	}
+	if limits.MaxN > 0 && r.N > limits.MaxN {
+		warn("n", r.N, limits.MaxN)
+		r.N = limits.MaxN
+	}
+
+	if r.Temperature != nil {
+		if clamped := limits.Temperature.clamp(*r.Temperature); clamped != *r.Temperature {
+			warn("temperature", *r.Temperature, clamped)
+			r.Temperature = &clamped
+		}
+	}
+
+	// Zero means unset for the other sampling parameters, so it is left alone
+	for _, param := range []struct {
+		name  string
+		value *float32
+		limit Range
+	}{
+		{"top_p", &r.TopP, limits.TopP},
+		{"frequency_penalty", &r.FrequencyPenalty, limits.Penalty},
+		{"presence_penalty", &r.PresencePenalty, limits.Penalty},
+	} {
+		if *param.value == 0 {
+			continue
+		}
+		if clamped := param.limit.clamp(*param.value); clamped != *param.value {
+			warn(param.name, *param.value, clamped)
+			*param.value = clamped
+		}
+	}
+
+	if !changed {
+		return req
+	}
+	return &r
+}
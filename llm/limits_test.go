@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClampParameters(t *testing.T) {
+	openai, _ := LimitsFor("OpenAI")
+
+	tests := []struct {
+		name     string
+		limits   ParameterLimits
+		req      CompletionRequest
+		want     CompletionRequest
+		wantLogs []string
+	}{
+		{
+			name:   "within limits",
+			limits: openai,
+			req:    CompletionRequest{Stop: []string{"a", "b"}, Temperature: temperature(1.5), TopP: 0.9, N: 2},
+			want:   CompletionRequest{Stop: []string{"a", "b"}, Temperature: temperature(1.5), TopP: 0.9, N: 2},
+		},
+		{
+			name:     "stop sequences dropped",
+			limits:   openai,
+			req:      CompletionRequest{Stop: []string{"1", "2", "3", "4", "5", "6"}},
+			want:     CompletionRequest{Stop: []string{"1", "2", "3", "4"}},
+			wantLogs: []string{"param=stop value=6 limit=4"},
+		},
+		{
+			name:     "numbers clamped",
+			limits:   openai,
+			req:      CompletionRequest{Temperature: temperature(3), TopP: 1.2, FrequencyPenalty: -5, PresencePenalty: 2.5, N: 200},
+			want:     CompletionRequest{Temperature: temperature(2), TopP: 1, FrequencyPenalty: -2, PresencePenalty: 2, N: 128},
+			wantLogs: []string{"param=temperature", "param=top_p", "param=frequency_penalty", "param=presence_penalty", "param=n"},
+		},
+		{
+			name:   "unset parameters left alone",
+			limits: ParameterLimits{TopP: Range{0.01, 0.99}},
+			req:    CompletionRequest{},
+			want:   CompletionRequest{},
+		},
+		{
+			name:     "zero temperature kept",
+			limits:   ParameterLimits{Temperature: Range{0.1, 1}},
+			req:      CompletionRequest{Temperature: temperature(0)},
+			want:     CompletionRequest{Temperature: temperature(0.1)},
+			wantLogs: []string{"param=temperature value=0 limit=0.1"},
+		},
+		{
+			name:   "no limits",
+			limits: ParameterLimits{},
+			req:    CompletionRequest{Temperature: temperature(5), Stop: []string{"1", "2", "3", "4", "5"}},
+			want:   CompletionRequest{Temperature: temperature(5), Stop: []string{"1", "2", "3", "4", "5"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			mock := &mockProvider{}
+			provider := Chain(mock, ClampParameters(ClampConfig{
+				Limits: tt.limits,
+				Logger: slog.New(slog.NewTextHandler(&logs, nil)),
+			}))
+
+			req := tt.req
+			if _, err := provider.Complete(context.Background(), &req); err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if got := mock.requests[0]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("request = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(req, tt.req) {
+				t.Errorf("caller's request modified to %+v", req)
+			}
+
+			lines := strings.Count(logs.String(), "\n")
+			if lines != len(tt.wantLogs) {
+				t.Errorf("logged %d warnings, want %d:\n%s", lines, len(tt.wantLogs), logs.String())
+			}
+			for _, want := range tt.wantLogs {
+				if !strings.Contains(logs.String(), want) {
+					t.Errorf("logs do not contain %q:\n%s", want, logs.String())
+				}
+			}
+		})
+	}
+}
+
+// temperature returns a pointer to t, for CompletionRequest.Temperature
+func temperature(t float32) *float32 {
+	return &t
+}
+
+func TestLimitsFor(t *testing.T) {
+	for _, name := range Providers() {
+		limits, ok := LimitsFor(name)
+		switch name {
+		case "llamacpp", "ollama", "compatible":
+			if ok {
+				t.Errorf("LimitsFor(%q) = %+v, want none for local servers", name, limits)
+			}
+		default:
+			if !ok {
+				t.Errorf("LimitsFor(%q) returned no limits", name)
+			}
+		}
+	}
+}
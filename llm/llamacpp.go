@@ -0,0 +1,396 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLlamaCppBaseURL = "http://localhost:8080"
+)
+
+// LlamaCppConfig contains configuration options for the llama.cpp server client
+type LlamaCppConfig struct {
+	// BaseURL is the address of the llama.cpp server (optional, defaults to http://localhost:8080)
+	BaseURL string
+
+	// APIKey is the key the server was started with via --api-key (optional)
+	APIKey string
+
+	// Credentials supplies the API key for each request in place of APIKey
+	// (optional)
+	Credentials CredentialProvider
+
+	// Timeout is the timeout for API requests (optional, defaults to 30 seconds)
+	Timeout time.Duration
+
+	// HTTPClient is a custom HTTP client (optional)
+	HTTPClient *http.Client
+
+	// RetryConfig contains retry configuration (optional)
+	RetryConfig *RetryConfig
+}
+
+// LlamaCppClient implements the LLMProvider interface for the native
+// /completion endpoint of the llama.cpp server.
+//
+// Server-specific sampling features are selected through
+// CompletionRequest.Options:
+//   - "grammar": a GBNF grammar constraining the output
+//   - "n_probs": number of token probabilities to compute per generated token
+//   - "id_slot": the server slot to run the completion in
+//   - "cache_prompt": "true" or "false" to control prompt caching in the slot
+//
+// A bare Prompt is completed as raw text. Requests with Messages or a
+// SystemPrompt are rendered with the model's chat template through the
+// server's /apply-template endpoint first.
+type LlamaCppClient struct {
+	config     LlamaCppConfig
+	httpClient *http.Client
+}
+
+// NewLlamaCppClient creates a new llama.cpp server client with the given configuration
+func NewLlamaCppClient(config LlamaCppConfig) *LlamaCppClient {
+	if config.BaseURL == "" {
+		config.BaseURL = defaultLlamaCppBaseURL
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{
+			Timeout: config.Timeout,
+		}
+	}
+
+	if config.RetryConfig == nil {
+		config.RetryConfig = defaultRetryConfig()
+	}
+
+	return &LlamaCppClient{
+		config:     config,
+		httpClient: config.HTTPClient,
+	}
+}
+
+type llamaCppRequest struct {
+	Prompt      string   `json:"prompt"`
+	NPredict    int      `json:"n_predict,omitempty"`
+	Temperature *float32 `json:"temperature,omitempty"`
+	TopP        float32  `json:"top_p,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+	Stream      bool     `json:"stream,omitempty"`
+	Seed        *int64   `json:"seed,omitempty"`
+	Grammar     string   `json:"grammar,omitempty"`
+	NProbs      int      `json:"n_probs,omitempty"`
+	IDSlot      *int     `json:"id_slot,omitempty"`
+	CachePrompt *bool    `json:"cache_prompt,omitempty"`
+
+	FrequencyPenalty float32            `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float32            `json:"presence_penalty,omitempty"`
+	LogitBias        map[string]float32 `json:"logit_bias,omitempty"`
+}
+
+type llamaCppResponse struct {
+	Content  string `json:"content"`
+	Model    string `json:"model"`
+	Stop     bool   `json:"stop"`
+	StopType string `json:"stop_type"`
+}
+
+// Complete implements non-streaming completion with retry support
+func (c *LlamaCppClient) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	llamaReq, err := c.newRequest(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *CompletionResponse
+	err = retry(ctx, c.config.RetryConfig, func() error {
+		var err error
+		resp, err = c.complete(ctx, req, llamaReq)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *LlamaCppClient) complete(ctx context.Context, req *CompletionRequest, llamaReq llamaCppRequest) (*CompletionResponse, error) {
+	resp, err := c.do(ctx, "POST", "/completion", llamaReq, newRequestExtras(req))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var llamaResp llamaCppResponse
+	raw, err := decodeResponse(resp.Body, &llamaResp, req.IncludeRaw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &CompletionResponse{
+		Content:      llamaResp.Content,
+		Model:        responseModel(llamaResp.Model, req.Model),
+		FinishReason: llamaCppFinishReason(llamaResp.StopType),
+		Metadata:     newResponseMetadata(resp.Header),
+		Raw:          raw,
+	}, nil
+}
+
+// newRequest builds the /completion payload. A bare Prompt is sent as raw
+// text, while Messages and SystemPrompt are first rendered with the model's
+// chat template.
+func (c *LlamaCppClient) newRequest(ctx context.Context, req *CompletionRequest, stream bool) (llamaCppRequest, error) {
+	if req.N > 1 {
+		return llamaCppRequest{}, ErrMultipleChoicesUnsupported
+	}
+	if hasParts(req.Messages) {
+		return llamaCppRequest{}, ErrContentPartsUnsupported
+	}
+	if len(req.Tools) > 0 {
+		return llamaCppRequest{}, ErrToolsUnsupported
+	}
+
+	prompt := req.Prompt
+	if len(req.Messages) > 0 || req.SystemPrompt != "" {
+		var err error
+		prompt, err = c.applyTemplate(ctx, req.messages())
+		if err != nil {
+			return llamaCppRequest{}, err
+		}
+	}
+
+	llamaReq := llamaCppRequest{
+		Prompt:      prompt,
+		NPredict:    req.MaxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		Stop:        req.Stop,
+		Stream:      stream,
+		Seed:        req.Seed,
+		Grammar:     req.Options["grammar"],
+
+		FrequencyPenalty: req.FrequencyPenalty,
+		PresencePenalty:  req.PresencePenalty,
+		LogitBias:        req.LogitBias,
+	}
+
+	if value, ok := req.Options["n_probs"]; ok {
+		nProbs, err := strconv.Atoi(value)
+		if err != nil {
+			return llamaCppRequest{}, fmt.Errorf("llama.cpp: invalid n_probs option: %w", err)
+		}
+		llamaReq.NProbs = nProbs
+	}
+
+	if value, ok := req.Options["id_slot"]; ok {
+		slot, err := strconv.Atoi(value)
+		if err != nil {
+			return llamaCppRequest{}, fmt.Errorf("llama.cpp: invalid id_slot option: %w", err)
+		}
+		llamaReq.IDSlot = &slot
+	}
+
+	if value, ok := req.Options["cache_prompt"]; ok {
+		cachePrompt, err := strconv.ParseBool(value)
+		if err != nil {
+			return llamaCppRequest{}, fmt.Errorf("llama.cpp: invalid cache_prompt option: %w", err)
+		}
+		llamaReq.CachePrompt = &cachePrompt
+	}
+
+	return llamaReq, nil
+}
+
+// applyTemplate formats messages into a prompt using the chat template of
+// the loaded model via the server's /apply-template endpoint
+func (c *LlamaCppClient) applyTemplate(ctx context.Context, messages []Message) (string, error) {
+	resp, err := c.do(ctx, "POST", "/apply-template", map[string]any{"messages": messages}, requestExtras{})
+	if err != nil {
+		return "", fmt.Errorf("llama.cpp: failed to apply chat template: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Prompt string `json:"prompt"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	return result.Prompt, nil
+}
+
+func (c *LlamaCppClient) do(ctx context.Context, method, path string, payload any, extras requestExtras) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		data, err := extras.marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	endpoint := strings.TrimRight(c.config.BaseURL, "/") + path
+	httpReq, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	apiKey, err := resolveAPIKey(ctx, c.config.Credentials, c.config.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	extras.setHeaders(httpReq.Header)
+
+	resp, err := extras.client(c.httpClient).Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, newHTTPError(resp)
+	}
+
+	return resp, nil
+}
+
+// llamaCppFinishReason maps the server's stop_type onto the values used by
+// the other providers
+func llamaCppFinishReason(stopType string) string {
+	switch stopType {
+	case "eos", "word":
+		return "stop"
+	case "limit":
+		return "length"
+	default:
+		return stopType
+	}
+}
+
+// SaveSlot saves the prompt cache of a slot to filename in the server's
+// --slot-save-path directory
+func (c *LlamaCppClient) SaveSlot(ctx context.Context, slot int, filename string) error {
+	return c.slotAction(ctx, slot, "save", filename)
+}
+
+// RestoreSlot restores the prompt cache of a slot from filename in the
+// server's --slot-save-path directory
+func (c *LlamaCppClient) RestoreSlot(ctx context.Context, slot int, filename string) error {
+	return c.slotAction(ctx, slot, "restore", filename)
+}
+
+// EraseSlot clears the prompt cache of a slot
+func (c *LlamaCppClient) EraseSlot(ctx context.Context, slot int) error {
+	return c.slotAction(ctx, slot, "erase", "")
+}
+
+func (c *LlamaCppClient) slotAction(ctx context.Context, slot int, action, filename string) error {
+	var payload any
+	if filename != "" {
+		payload = map[string]string{"filename": filename}
+	}
+
+	path := fmt.Sprintf("/slots/%d?action=%s", slot, action)
+	resp, err := c.do(ctx, "POST", path, payload, requestExtras{})
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// llamaCppStream implements CompletionStream for the llama.cpp server
+type llamaCppStream struct {
+	reader   *bufio.Reader
+	closer   io.Closer
+	model    string
+	done     bool
+	metadata *ResponseMetadata
+	raw      bool
+}
+
+// CompleteStream implements streaming completion
+func (c *LlamaCppClient) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
+	llamaReq, err := c.newRequest(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(ctx, "POST", "/completion", llamaReq, newRequestExtras(req))
+	if err != nil {
+		return nil, err
+	}
+
+	return &llamaCppStream{
+		reader:   bufio.NewReader(resp.Body),
+		closer:   resp.Body,
+		model:    req.Model,
+		metadata: newResponseMetadata(resp.Header),
+		raw:      req.IncludeRaw,
+	}, nil
+}
+
+// Recv implements the CompletionStream interface
+func (s *llamaCppStream) Recv() (*CompletionResponse, error) {
+	for {
+		if s.done {
+			return nil, io.EOF
+		}
+
+		line, err := s.reader.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("failed to read stream: %w", err)
+		}
+
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+
+		data := bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+		if len(data) == 0 {
+			continue
+		}
+
+		var chunk llamaCppResponse
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return nil, fmt.Errorf("failed to decode stream response: %w", err)
+		}
+
+		resp := &CompletionResponse{
+			Content:  chunk.Content,
+			Model:    responseModel(chunk.Model, s.model),
+			Metadata: s.metadata,
+			Raw:      rawEvent(data, s.raw),
+		}
+		if chunk.Stop {
+			s.done = true
+			resp.FinishReason = llamaCppFinishReason(chunk.StopType)
+		}
+		return resp, nil
+	}
+}
+
+// Close implements the CompletionStream interface
+func (s *llamaCppStream) Close() error {
+	return s.closer.Close()
+}
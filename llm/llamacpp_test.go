@@ -0,0 +1,295 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestNewLlamaCppClient(t *testing.T) {
+	client := NewLlamaCppClient(LlamaCppConfig{})
+
+	if client.config.BaseURL != defaultLlamaCppBaseURL {
+		t.Errorf("BaseURL = %v, want %v", client.config.BaseURL, defaultLlamaCppBaseURL)
+	}
+	if client.config.Timeout != defaultTimeout {
+		t.Errorf("Timeout = %v, want %v", client.config.Timeout, defaultTimeout)
+	}
+}
+
+func TestLlamaCppClient_Complete(t *testing.T) {
+	tests := []struct {
+		name         string
+		options      map[string]string
+		wantErr      bool
+		wantGrammar  string
+		wantNProbs   int
+		wantSlot     *int
+		wantFinish   string
+		responseStop string
+	}{
+		{
+			name:         "plain completion",
+			responseStop: "eos",
+			wantFinish:   "stop",
+		},
+		{
+			name: "grammar, n_probs and slot",
+			options: map[string]string{
+				"grammar": `root ::= "yes" | "no"`,
+				"n_probs": "5",
+				"id_slot": "1",
+			},
+			wantGrammar:  `root ::= "yes" | "no"`,
+			wantNProbs:   5,
+			wantSlot:     intPtr(1),
+			responseStop: "limit",
+			wantFinish:   "length",
+		},
+		{
+			name:    "invalid n_probs",
+			options: map[string]string{"n_probs": "many"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid id_slot",
+			options: map[string]string{"id_slot": "first"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/completion" {
+					t.Errorf("Path = %v, want /completion", r.URL.Path)
+				}
+
+				var reqBody llamaCppRequest
+				if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+					t.Errorf("Failed to decode request body: %v", err)
+				}
+				if reqBody.Prompt != "Test prompt" {
+					t.Errorf("Prompt = %v, want 'Test prompt'", reqBody.Prompt)
+				}
+				if reqBody.NPredict != 16 {
+					t.Errorf("NPredict = %v, want 16", reqBody.NPredict)
+				}
+				if reqBody.Grammar != tt.wantGrammar {
+					t.Errorf("Grammar = %v, want %v", reqBody.Grammar, tt.wantGrammar)
+				}
+				if reqBody.NProbs != tt.wantNProbs {
+					t.Errorf("NProbs = %v, want %v", reqBody.NProbs, tt.wantNProbs)
+				}
+				if (reqBody.IDSlot == nil) != (tt.wantSlot == nil) ||
+					(reqBody.IDSlot != nil && *reqBody.IDSlot != *tt.wantSlot) {
+					t.Errorf("IDSlot = %v, want %v", reqBody.IDSlot, tt.wantSlot)
+				}
+
+				json.NewEncoder(w).Encode(map[string]any{
+					"content":   "yes",
+					"model":     "llama-3-8b.gguf",
+					"stop":      true,
+					"stop_type": tt.responseStop,
+				})
+			}))
+			defer server.Close()
+
+			client := NewLlamaCppClient(LlamaCppConfig{
+				BaseURL: server.URL,
+			})
+
+			got, err := client.Complete(context.Background(), &CompletionRequest{
+				Prompt:    "Test prompt",
+				MaxTokens: 16,
+				Options:   tt.options,
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Complete() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Content != "yes" {
+				t.Errorf("Content = %v, want yes", got.Content)
+			}
+			if got.Model != "llama-3-8b.gguf" {
+				t.Errorf("Model = %v, want llama-3-8b.gguf", got.Model)
+			}
+			if got.FinishReason != tt.wantFinish {
+				t.Errorf("FinishReason = %v, want %v", got.FinishReason, tt.wantFinish)
+			}
+		})
+	}
+}
+
+func TestLlamaCppClient_CompleteStream(t *testing.T) {
+	responses := []string{
+		`data: {"content":"Hello","stop":false}` + "\n\n",
+		`data: {"content":" World","stop":false}` + "\n\n",
+		`data: {"content":"","stop":true,"stop_type":"eos","model":"llama-3-8b.gguf"}` + "\n\n",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("Streaming not supported")
+		}
+
+		for _, resp := range responses {
+			_, err := w.Write([]byte(resp))
+			if err != nil {
+				t.Fatal(err)
+			}
+			flusher.Flush()
+		}
+	}))
+	defer server.Close()
+
+	client := NewLlamaCppClient(LlamaCppConfig{
+		BaseURL: server.URL,
+	})
+
+	stream, err := client.CompleteStream(context.Background(), &CompletionRequest{
+		Prompt: "Test prompt",
+	})
+	if err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	var content, finishReason string
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content += resp.Content
+		if resp.FinishReason != "" {
+			finishReason = resp.FinishReason
+		}
+	}
+
+	if content != "Hello World" {
+		t.Errorf("Content = %v, want 'Hello World'", content)
+	}
+	if finishReason != "stop" {
+		t.Errorf("FinishReason = %v, want stop", finishReason)
+	}
+}
+
+func TestLlamaCppClient_Slots(t *testing.T) {
+	var gotActions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/slots/2" {
+			t.Errorf("Path = %v, want /slots/2", r.URL.Path)
+		}
+
+		action := r.URL.Query().Get("action")
+		var body struct {
+			Filename string `json:"filename"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		gotActions = append(gotActions, action+":"+body.Filename)
+
+		w.Write([]byte(`{"id_slot": 2}`))
+	}))
+	defer server.Close()
+
+	client := NewLlamaCppClient(LlamaCppConfig{
+		BaseURL: server.URL,
+	})
+
+	ctx := context.Background()
+	if err := client.SaveSlot(ctx, 2, "session.bin"); err != nil {
+		t.Fatalf("SaveSlot() error = %v", err)
+	}
+	if err := client.RestoreSlot(ctx, 2, "session.bin"); err != nil {
+		t.Fatalf("RestoreSlot() error = %v", err)
+	}
+	if err := client.EraseSlot(ctx, 2); err != nil {
+		t.Fatalf("EraseSlot() error = %v", err)
+	}
+
+	want := []string{"save:session.bin", "restore:session.bin", "erase:"}
+	if len(gotActions) != len(want) {
+		t.Fatalf("Got actions %v, want %v", gotActions, want)
+	}
+	for i := range want {
+		if gotActions[i] != want[i] {
+			t.Errorf("Action %d = %v, want %v", i, gotActions[i], want[i])
+		}
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestLlamaCppClient_Messages(t *testing.T) {
+	history := []Message{
+		{Role: RoleSystem, Content: "Be brief"},
+		{Role: RoleUser, Content: "Hello"},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/apply-template":
+			var reqBody struct {
+				Messages []Message `json:"messages"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+				t.Errorf("Failed to decode request body: %v", err)
+			}
+			if !reflect.DeepEqual(reqBody.Messages, history) {
+				t.Errorf("Messages = %+v, want %+v", reqBody.Messages, history)
+			}
+			w.Write([]byte(`{"prompt":"<|system|>Be brief<|user|>Hello<|assistant|>"}`))
+		case "/completion":
+			var reqBody llamaCppRequest
+			if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+				t.Errorf("Failed to decode request body: %v", err)
+			}
+			if reqBody.Prompt != "<|system|>Be brief<|user|>Hello<|assistant|>" {
+				t.Errorf("Prompt = %q, want the templated prompt", reqBody.Prompt)
+			}
+			w.Write([]byte(`{"content":"Hi","stop":true,"stop_type":"eos"}`))
+		default:
+			t.Errorf("unexpected path %v", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewLlamaCppClient(LlamaCppConfig{BaseURL: server.URL})
+	resp, err := client.Complete(context.Background(), &CompletionRequest{Messages: history})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Content != "Hi" {
+		t.Errorf("Content = %v, want Hi", resp.Content)
+	}
+}
+
+func FuzzLlamaCppStream(f *testing.F) {
+	f.Add([]byte("data: {\"content\":\"Hi\",\"stop\":false}\n\ndata: {\"content\":\"\",\"stop\":true,\"stop_type\":\"eos\"}\n\n"))
+	f.Add([]byte("data: {\"content\":\"x\",\"stop\":true,\"stop_type\":\"limit\"}\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkStream(t, &llamaCppStream{
+			reader: bufio.NewReader(bytes.NewReader(data)),
+			closer: io.NopCloser(nil),
+			model:  "llama",
+		})
+	})
+}
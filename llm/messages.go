@@ -0,0 +1,78 @@
+package llm
+
+// MessageBuilder builds a conversation without spelling out Message literals:
+//
+//	messages := llm.NewMessages().
+//		System("Be brief").
+//		User("What is the weather in London?").
+//		User("And what is in this picture?").Image("https://example.com/photo.jpg").
+//		Build()
+type MessageBuilder struct {
+	messages []Message
+}
+
+// NewMessages starts an empty conversation
+func NewMessages() *MessageBuilder {
+	return &MessageBuilder{}
+}
+
+// System adds a system message
+func (b *MessageBuilder) System(content string) *MessageBuilder {
+	return b.Add(Message{Role: RoleSystem, Content: content})
+}
+
+// User adds a user message
+func (b *MessageBuilder) User(content string) *MessageBuilder {
+	return b.Add(Message{Role: RoleUser, Content: content})
+}
+
+// Assistant adds an assistant message
+func (b *MessageBuilder) Assistant(content string) *MessageBuilder {
+	return b.Add(Message{Role: RoleAssistant, Content: content})
+}
+
+// AssistantToolCalls adds an assistant message making tool calls, as
+// returned in CompletionResponse.ToolCalls
+func (b *MessageBuilder) AssistantToolCalls(content string, calls ...ToolCall) *MessageBuilder {
+	return b.Add(Message{Role: RoleAssistant, Content: content, ToolCalls: calls})
+}
+
+// ToolResult adds the result of the tool call with the given ID
+func (b *MessageBuilder) ToolResult(toolCallID, content string) *MessageBuilder {
+	return b.Add(Message{Role: RoleTool, Content: content, ToolCallID: toolCallID})
+}
+
+// Image attaches the image at url, an http(s) or data URL, to the last
+// message if it is a user message, or to a new user message otherwise
+func (b *MessageBuilder) Image(url string) *MessageBuilder {
+	return b.attach(ImagePart(url))
+}
+
+// ImageData attaches an image given by its media type, such as "image/png",
+// and its bytes, see Image
+func (b *MessageBuilder) ImageData(mediaType string, data []byte) *MessageBuilder {
+	return b.attach(ImageDataPart(mediaType, data))
+}
+
+// attach adds part to the Parts of the last message if it is a user
+// message, and to a new user message otherwise
+func (b *MessageBuilder) attach(part ContentPart) *MessageBuilder {
+	if len(b.messages) == 0 || b.messages[len(b.messages)-1].Role != RoleUser {
+		b.messages = append(b.messages, Message{Role: RoleUser})
+	}
+	last := &b.messages[len(b.messages)-1]
+	last.Parts = append(last.Parts[:len(last.Parts):len(last.Parts)], part)
+	return b
+}
+
+// Add adds messages as they are
+func (b *MessageBuilder) Add(messages ...Message) *MessageBuilder {
+	b.messages = append(b.messages, messages...)
+	return b
+}
+
+// Build returns the messages added so far. The builder can keep adding
+// messages without affecting the returned slice.
+func (b *MessageBuilder) Build() []Message {
+	return append([]Message(nil), b.messages...)
+}
@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMessageBuilder(t *testing.T) {
+	var call ToolCall
+	call.ID = "call_1"
+	call.Type = "function"
+	call.Function.Name = "get_weather"
+	call.Function.Arguments = `{"location":"London"}`
+
+	builder := NewMessages().
+		System("Be brief").
+		User("Weather in London?").
+		AssistantToolCalls("", call).
+		ToolResult("call_1", "sunny").
+		Assistant("It is sunny.").
+		Add(Message{Role: RoleUser, Content: "Thanks", Name: "ann"})
+
+	want := []Message{
+		{Role: RoleSystem, Content: "Be brief"},
+		{Role: RoleUser, Content: "Weather in London?"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{call}},
+		{Role: RoleTool, Content: "sunny", ToolCallID: "call_1"},
+		{Role: RoleAssistant, Content: "It is sunny."},
+		{Role: RoleUser, Content: "Thanks", Name: "ann"},
+	}
+	got := builder.Build()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Build() = %+v, want %+v", got, want)
+	}
+
+	builder.User("More")
+	if len(got) != len(want) {
+		t.Errorf("built slice changed to %d messages after adding more", len(got))
+	}
+	if NewMessages().Build() != nil {
+		t.Error("Build() of empty builder is not nil")
+	}
+}
+
+func TestMessageBuilder_Images(t *testing.T) {
+	builder := NewMessages().Image("https://example.com/a.png")
+	first := builder.Build()
+	builder.User("Compare these").Image("https://example.com/b.png").ImageData("image/png", []byte("png"))
+
+	want := []Message{
+		{Role: RoleUser, Parts: []ContentPart{ImagePart("https://example.com/a.png")}},
+		{Role: RoleUser, Content: "Compare these", Parts: []ContentPart{
+			{Type: PartImage, ImageURL: "https://example.com/b.png"},
+			{Type: PartImage, ImageURL: "data:image/png;base64,cG5n"},
+		}},
+	}
+	if got := builder.Build(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Build() = %+v, want %+v", got, want)
+	}
+	if !reflect.DeepEqual(first, want[:1]) {
+		t.Errorf("built slice changed to %+v after attaching more", first)
+	}
+
+	got := NewMessages().System("Describe images").Image("https://example.com/a.png").
+		Assistant("A cat").Image("https://example.com/b.png").Build()
+	want = []Message{
+		{Role: RoleSystem, Content: "Describe images"},
+		{Role: RoleUser, Parts: []ContentPart{ImagePart("https://example.com/a.png")}},
+		{Role: RoleAssistant, Content: "A cat"},
+		{Role: RoleUser, Parts: []ContentPart{ImagePart("https://example.com/b.png")}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("images after other roles = %+v, want new user messages %+v", got, want)
+	}
+}
@@ -0,0 +1,88 @@
+package llm
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResponseMetadata holds transport-level details of a provider response,
+// taken from its HTTP headers
+type ResponseMetadata struct {
+	// RequestID is the provider-assigned request identifier, useful when
+	// reporting issues to the provider or correlating logs
+	RequestID string `json:"request_id,omitempty"`
+
+	// Requests and Tokens describe the request and token rate limits, or are
+	// nil when the provider does not report them
+	Requests *RateLimit `json:"requests,omitempty"`
+	Tokens   *RateLimit `json:"tokens,omitempty"`
+
+	// RetryAfter is how long the provider asks clients to wait before the
+	// next request, or zero if it did not ask
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
+
+	// SystemPromptVersion is the version of the managed system prompt the
+	// request was sent with. It is set by the InjectSystemPrompt middleware
+	// rather than the provider.
+	SystemPromptVersion string `json:"system_prompt_version,omitempty"`
+}
+
+// RateLimit is the state of a single rate limit
+type RateLimit struct {
+	// Limit is the maximum allowed in the current window
+	Limit int `json:"limit"`
+
+	// Remaining is what is left in the current window
+	Remaining int `json:"remaining"`
+
+	// Reset is how long until the window resets
+	Reset time.Duration `json:"reset"`
+}
+
+// newResponseMetadata extracts the request ID and rate limits from response
+// headers. It understands the OpenAI-style x-ratelimit-* headers, also used
+// by Groq and Together, and Anthropic's anthropic-ratelimit-* headers.
+func newResponseMetadata(header http.Header) *ResponseMetadata {
+	metadata := &ResponseMetadata{
+		RetryAfter: parseRetryAfter(header),
+	}
+
+	for _, name := range []string{"X-Request-Id", "Request-Id"} {
+		if id := header.Get(name); id != "" {
+			metadata.RequestID = id
+			break
+		}
+	}
+
+	metadata.Requests = parseRateLimit(header, "requests")
+	metadata.Tokens = parseRateLimit(header, "tokens")
+	return metadata
+}
+
+// parseRateLimit reads the limit of the given kind ("requests" or "tokens"),
+// returning nil when neither header style reports it
+func parseRateLimit(header http.Header, kind string) *RateLimit {
+	if limit, ok := headerInt(header, "X-Ratelimit-Limit-"+kind); ok {
+		remaining, _ := headerInt(header, "X-Ratelimit-Remaining-"+kind)
+		reset, _ := time.ParseDuration(header.Get("X-Ratelimit-Reset-" + kind))
+		return &RateLimit{Limit: limit, Remaining: remaining, Reset: reset}
+	}
+
+	if limit, ok := headerInt(header, "Anthropic-Ratelimit-"+kind+"-Limit"); ok {
+		remaining, _ := headerInt(header, "Anthropic-Ratelimit-"+kind+"-Remaining")
+		rateLimit := &RateLimit{Limit: limit, Remaining: remaining}
+		if reset, err := time.Parse(time.RFC3339, header.Get("Anthropic-Ratelimit-"+kind+"-Reset")); err == nil {
+			rateLimit.Reset = max(time.Until(reset), 0)
+		}
+		return rateLimit
+	}
+
+	return nil
+}
+
+func headerInt(header http.Header, name string) (int, bool) {
+	value, err := strconv.Atoi(strings.TrimSpace(header.Get(name)))
+	return value, err == nil
+}
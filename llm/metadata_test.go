@@ -0,0 +1,228 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewResponseMetadata(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       map[string]string
+		wantID       string
+		wantRequests *RateLimit
+		wantTokens   *RateLimit
+		wantRetry    time.Duration
+	}{
+		{
+			name:   "no headers",
+			header: map[string]string{},
+		},
+		{
+			name: "openai style",
+			header: map[string]string{
+				"X-Request-Id":                   "req_123",
+				"X-Ratelimit-Limit-Requests":     "500",
+				"X-Ratelimit-Remaining-Requests": "499",
+				"X-Ratelimit-Reset-Requests":     "120ms",
+				"X-Ratelimit-Limit-Tokens":       "30000",
+				"X-Ratelimit-Remaining-Tokens":   "29000",
+				"X-Ratelimit-Reset-Tokens":       "2s",
+			},
+			wantID:       "req_123",
+			wantRequests: &RateLimit{Limit: 500, Remaining: 499, Reset: 120 * time.Millisecond},
+			wantTokens:   &RateLimit{Limit: 30000, Remaining: 29000, Reset: 2 * time.Second},
+		},
+		{
+			name: "anthropic style",
+			header: map[string]string{
+				"Request-Id":                             "req_abc",
+				"Anthropic-Ratelimit-Requests-Limit":     "50",
+				"Anthropic-Ratelimit-Requests-Remaining": "10",
+				"Anthropic-Ratelimit-Requests-Reset":     "2000-01-01T00:00:00Z",
+				"Retry-After":                            "3",
+			},
+			wantID:       "req_abc",
+			wantRequests: &RateLimit{Limit: 50, Remaining: 10},
+			wantRetry:    3 * time.Second,
+		},
+		{
+			name: "invalid limit",
+			header: map[string]string{
+				"X-Ratelimit-Limit-Requests":     "many",
+				"X-Ratelimit-Remaining-Requests": "1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for name, value := range tt.header {
+				header.Set(name, value)
+			}
+
+			got := newResponseMetadata(header)
+			if got.RequestID != tt.wantID {
+				t.Errorf("RequestID = %q, want %q", got.RequestID, tt.wantID)
+			}
+			if got.RetryAfter != tt.wantRetry {
+				t.Errorf("RetryAfter = %v, want %v", got.RetryAfter, tt.wantRetry)
+			}
+			checkRateLimit(t, "Requests", got.Requests, tt.wantRequests)
+			checkRateLimit(t, "Tokens", got.Tokens, tt.wantTokens)
+		})
+	}
+}
+
+func checkRateLimit(t *testing.T, name string, got, want *RateLimit) {
+	t.Helper()
+	if (got == nil) != (want == nil) {
+		t.Errorf("%s = %+v, want %+v", name, got, want)
+		return
+	}
+	if got != nil && *got != *want {
+		t.Errorf("%s = %+v, want %+v", name, *got, *want)
+	}
+}
+
+func TestResponseMetadata_Providers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req_"+r.URL.Path)
+		w.Header().Set("X-Ratelimit-Limit-Requests", "100")
+		w.Header().Set("X-Ratelimit-Remaining-Requests", "99")
+		multiProviderHandler(w, r)
+	}))
+	defer server.Close()
+
+	providers := map[string]LLMProvider{
+		"openai":    NewOpenAIClient(OpenAIConfig{APIKey: "test-key", BaseURL: server.URL}),
+		"anthropic": NewAnthropicClientWithConfig(AnthropicConfig{APIKey: "test-key", BaseURL: server.URL + "/v1"}),
+		"cohere":    NewCohereClient(CohereConfig{APIKey: "test-key", BaseURL: server.URL}),
+		"dashscope": NewDashScopeClient(DashScopeConfig{APIKey: "test-key", BaseURL: server.URL}),
+		"llamacpp":  NewLlamaCppClient(LlamaCppConfig{BaseURL: server.URL}),
+	}
+
+	for name, provider := range providers {
+		t.Run(name, func(t *testing.T) {
+			req := &CompletionRequest{Model: "test-model", Prompt: "Hello"}
+
+			resp, err := provider.Complete(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			checkMetadata(t, resp.Metadata)
+
+			stream, err := provider.CompleteStream(context.Background(), req)
+			if err != nil {
+				t.Fatalf("CompleteStream() error = %v", err)
+			}
+			defer stream.Close()
+
+			for {
+				chunk, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Recv() error = %v", err)
+				}
+				checkMetadata(t, chunk.Metadata)
+			}
+		})
+	}
+}
+
+func checkMetadata(t *testing.T, metadata *ResponseMetadata) {
+	t.Helper()
+	if metadata == nil {
+		t.Fatal("Metadata = nil")
+	}
+	if metadata.RequestID == "" {
+		t.Error("RequestID is empty")
+	}
+	if metadata.Requests == nil || metadata.Requests.Remaining != 99 {
+		t.Errorf("Requests = %+v, want Remaining 99", metadata.Requests)
+	}
+}
+
+func TestHTTPError_RequestID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req_failed")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error": {"message": "bad request"}}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient(OpenAIConfig{APIKey: "test-key", BaseURL: server.URL})
+	_, err := client.Complete(context.Background(), &CompletionRequest{Model: "gpt-4", Prompt: "Hello"})
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("error = %v, want *HTTPError", err)
+	}
+	if httpErr.RequestID != "req_failed" {
+		t.Errorf("RequestID = %q, want %q", httpErr.RequestID, "req_failed")
+	}
+}
+
+func TestIncludeRaw_Providers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(multiProviderHandler))
+	defer server.Close()
+
+	providers := map[string]LLMProvider{
+		"openai":    NewOpenAIClient(OpenAIConfig{BaseURL: server.URL}),
+		"anthropic": NewAnthropicClientWithConfig(AnthropicConfig{BaseURL: server.URL + "/v1"}),
+		"cohere":    NewCohereClient(CohereConfig{BaseURL: server.URL}),
+		"dashscope": NewDashScopeClient(DashScopeConfig{BaseURL: server.URL}),
+		"llamacpp":  NewLlamaCppClient(LlamaCppConfig{BaseURL: server.URL}),
+	}
+
+	for name, provider := range providers {
+		t.Run(name, func(t *testing.T) {
+			req := &CompletionRequest{Model: "test-model", Prompt: "Hello"}
+			resp, err := provider.Complete(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if resp.Raw != nil {
+				t.Errorf("Raw = %s without IncludeRaw", resp.Raw)
+			}
+
+			req.IncludeRaw = true
+			resp, err = provider.Complete(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if !json.Valid(resp.Raw) {
+				t.Errorf("Raw = %q, want the JSON body", resp.Raw)
+			}
+
+			stream, err := provider.CompleteStream(context.Background(), req)
+			if err != nil {
+				t.Fatalf("CompleteStream() error = %v", err)
+			}
+			defer stream.Close()
+
+			for {
+				chunk, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Recv() error = %v", err)
+				}
+				if !json.Valid(chunk.Raw) {
+					t.Errorf("chunk Raw = %q, want the JSON event", chunk.Raw)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package llm
+
+// Middleware wraps an LLMProvider to add behaviour around its calls. The
+// returned provider must be safe for concurrent use, like the one it wraps.
+type Middleware func(LLMProvider) LLMProvider
+
+// Chain wraps provider with the given middlewares. The first middleware is
+// the outermost one, so it sees each request first and each response last.
+func Chain(provider LLMProvider, middlewares ...Middleware) LLMProvider {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		provider = middlewares[i](provider)
+	}
+	return provider
+}
@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// mockProvider is an in-memory LLMProvider for testing middlewares
+type mockProvider struct {
+	mu       sync.Mutex
+	requests []CompletionRequest
+	complete func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error)
+}
+
+func (m *mockProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	m.mu.Lock()
+	m.requests = append(m.requests, *req)
+	m.mu.Unlock()
+
+	if m.complete != nil {
+		return m.complete(ctx, req)
+	}
+	return &CompletionResponse{Content: req.Prompt, Model: req.Model}, nil
+}
+
+func (m *mockProvider) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
+	m.mu.Lock()
+	m.requests = append(m.requests, *req)
+	m.mu.Unlock()
+	return nil, nil
+}
+
+// sliceStream is a CompletionStream over a fixed list of chunks
+type sliceStream struct {
+	chunks []*CompletionResponse
+	closed bool
+}
+
+func (s *sliceStream) Recv() (*CompletionResponse, error) {
+	if len(s.chunks) == 0 {
+		return nil, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func (s *sliceStream) Close() error {
+	s.closed = true
+	return nil
+}
+
+// streamProvider is a mockProvider whose streams replay chunks
+type streamProvider struct {
+	mockProvider
+	chunks []*CompletionResponse
+}
+
+func (p *streamProvider) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
+	p.mu.Lock()
+	p.requests = append(p.requests, *req)
+	p.mu.Unlock()
+	return &sliceStream{chunks: p.chunks}, nil
+}
+
+func (m *mockProvider) calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.requests)
+}
+
+// prefixPrompt is a test middleware that prepends a marker to the prompt
+func prefixPrompt(marker string) Middleware {
+	return func(next LLMProvider) LLMProvider {
+		return &prefixProvider{next: next, marker: marker}
+	}
+}
+
+type prefixProvider struct {
+	next   LLMProvider
+	marker string
+}
+
+func (p *prefixProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	r := *req
+	r.Prompt = p.marker + r.Prompt
+	return p.next.Complete(ctx, &r)
+}
+
+func (p *prefixProvider) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
+	return p.next.CompleteStream(ctx, req)
+}
+
+func TestChain(t *testing.T) {
+	provider := &mockProvider{}
+
+	chained := Chain(provider, prefixPrompt("a"), prefixPrompt("b"))
+	resp, err := chained.Complete(context.Background(), &CompletionRequest{Prompt: "-prompt"})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	// The outermost middleware runs first, so its marker ends up innermost
+	if !strings.HasPrefix(resp.Content, "ba-") {
+		t.Errorf("Content = %v, want prefix ba-", resp.Content)
+	}
+
+	if Chain(provider) != LLMProvider(provider) {
+		t.Error("Chain() without middlewares should return the provider itself")
+	}
+}
@@ -9,13 +9,16 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
 	"time"
 )
 
 const (
-	defaultTimeout = 30 * time.Second
-	defaultBaseURL = "https://api.openai.com/v1"
+	defaultTimeout             = 30 * time.Second
+	defaultBaseURL             = "https://api.openai.com/v1"
+	defaultChatCompletionsPath = "/chat/completions"
 )
 
 // OpenAIConfig contains configuration options for the OpenAI client
@@ -23,6 +26,10 @@ type OpenAIConfig struct {
 	// APIKey is your OpenAI API key
 	APIKey string
 
+	// Credentials supplies the API key for each request in place of APIKey
+	// (optional)
+	Credentials CredentialProvider
+
 	// BaseURL is the base URL for OpenAI API (optional, defaults to https://api.openai.com/v1)
 	BaseURL string
 
@@ -34,27 +41,45 @@ type OpenAIConfig struct {
 
 	// RetryConfig contains retry configuration (optional)
 	RetryConfig *RetryConfig
-}
 
-// RetryConfig contains configuration for retry behavior
-type RetryConfig struct {
-	// MaxRetries is the maximum number of retries (default: 3)
-	MaxRetries int
+	// DefaultModel is used for requests that do not set Model (optional)
+	DefaultModel string
+
+	// DefaultTemperature is used for requests that do not set Temperature
+	// (optional)
+	DefaultTemperature *float32
+
+	// Organization is sent as the OpenAI-Organization header so usage is
+	// attributed to that organization on multi-organization accounts
+	// (optional)
+	Organization string
 
-	// InitialDelay is the initial delay between retries (default: 1s)
-	InitialDelay time.Duration
+	// Project is sent as the OpenAI-Project header so usage is attributed to
+	// that project (optional)
+	Project string
 
-	// MaxDelay is the maximum delay between retries (default: 5s)
-	MaxDelay time.Duration
+	// Headers are additional HTTP headers sent with every request (optional)
+	Headers map[string]string
 
-	// RetryableStatusCodes are the HTTP status codes that should trigger a retry
-	RetryableStatusCodes []int
+	// ChatCompletionsPath is the path of the chat completions endpoint below
+	// BaseURL, for gateways that route it differently (optional, defaults to
+	// /chat/completions)
+	ChatCompletionsPath string
+
+	// QueryParams are added to the URL of every request, such as the
+	// api-version some gateways require (optional)
+	QueryParams map[string]string
 }
 
-// OpenAIClient implements the LLMProvider interface for OpenAI
+// OpenAIClient implements the LLMProvider interface for OpenAI. A client is
+// immutable once created and safe for concurrent use by multiple goroutines.
 type OpenAIClient struct {
 	config     OpenAIConfig
 	httpClient *http.Client
+
+	// prepareRequest lets OpenAI-compatible providers adjust the request body
+	// with their own extensions before it is sent
+	prepareRequest func(req *CompletionRequest, openaiReq *openaiRequest) error
 }
 
 // NewOpenAIClient creates a new OpenAI client with the given configuration
@@ -63,6 +88,10 @@ func NewOpenAIClient(config OpenAIConfig) *OpenAIClient {
 		config.BaseURL = defaultBaseURL
 	}
 
+	if config.ChatCompletionsPath == "" {
+		config.ChatCompletionsPath = defaultChatCompletionsPath
+	}
+
 	if config.Timeout == 0 {
 		config.Timeout = defaultTimeout
 	}
@@ -74,17 +103,7 @@ func NewOpenAIClient(config OpenAIConfig) *OpenAIClient {
 	}
 
 	if config.RetryConfig == nil {
-		config.RetryConfig = &RetryConfig{
-			MaxRetries:   3,
-			InitialDelay: time.Second,
-			MaxDelay:     5 * time.Second,
-			RetryableStatusCodes: []int{
-				http.StatusTooManyRequests,
-				http.StatusInternalServerError,
-				http.StatusBadGateway,
-				http.StatusServiceUnavailable,
-			},
-		}
+		config.RetryConfig = defaultRetryConfig()
 	}
 
 	return &OpenAIClient{
@@ -93,6 +112,50 @@ func NewOpenAIClient(config OpenAIConfig) *OpenAIClient {
 	}
 }
 
+// With returns a copy of the client with opts applied to its configuration,
+// for example to derive per-tenant clients with their own model, temperature
+// or headers. The copy shares the HTTP client, and with it the connection
+// pool, unless an option replaces it; a changed Timeout is applied to a copy
+// of the HTTP client that shares its transport. Options clearing a setting
+// restore its default as NewOpenAIClient does, except for the base URL and
+// path, which keep the client's.
+func (c *OpenAIClient) With(opts ...OpenAIOption) *OpenAIClient {
+	config := c.config
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if config.BaseURL == "" {
+		config.BaseURL = c.config.BaseURL
+	}
+	if config.ChatCompletionsPath == "" {
+		config.ChatCompletionsPath = c.config.ChatCompletionsPath
+	}
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.RetryConfig == nil {
+		config.RetryConfig = defaultRetryConfig()
+	}
+
+	httpClient := c.httpClient
+	if config.HTTPClient == nil {
+		httpClient = &http.Client{Timeout: config.Timeout}
+	} else if config.HTTPClient != c.config.HTTPClient {
+		httpClient = config.HTTPClient
+	} else if config.Timeout != c.config.Timeout {
+		clientCopy := *httpClient
+		clientCopy.Timeout = config.Timeout
+		httpClient = &clientCopy
+	}
+
+	clone := *c
+	clone.config = config
+	clone.config.HTTPClient = httpClient
+	clone.httpClient = httpClient
+	return &clone
+}
+
 // NewOpenAIClientWithKey creates a new OpenAI client with just an API key
 func NewOpenAIClientWithKey(apiKey string) *OpenAIClient {
 	return NewOpenAIClient(OpenAIConfig{
@@ -104,11 +167,26 @@ type openaiRequest struct {
 	Model       string          `json:"model"`
 	Messages    []openaiMessage `json:"messages"`
 	MaxTokens   int             `json:"max_tokens,omitempty"`
-	Temperature float32         `json:"temperature,omitempty"`
+	Temperature *float32        `json:"temperature,omitempty"`
+	TopP        float32         `json:"top_p,omitempty"`
 	Stop        []string        `json:"stop,omitempty"`
+	N           int             `json:"n,omitempty"`
+	Seed        *int64          `json:"seed,omitempty"`
 	Stream      bool            `json:"stream,omitempty"`
 	Tools       []Tool          `json:"tools,omitempty"`
-	ToolChoice  string          `json:"tool_choice,omitempty"`
+	ToolChoice  any             `json:"tool_choice,omitempty"`
+
+	FrequencyPenalty float32            `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float32            `json:"presence_penalty,omitempty"`
+	LogitBias        map[string]float32 `json:"logit_bias,omitempty"`
+
+	User     string            `json:"user,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// ResponseFormat carries provider-specific structured output settings
+	ResponseFormat any `json:"response_format,omitempty"`
+
+	extras requestExtras
 }
 
 type openaiMessage struct {
@@ -117,210 +195,496 @@ type openaiMessage struct {
 	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string     `json:"tool_call_id,omitempty"`
 	Name       string     `json:"name,omitempty"`
+
+	// ReasoningContent is returned by reasoning models on OpenAI-compatible
+	// APIs such as DeepSeek; it is never sent back
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+
+	// Parts, when set, are sent as the content instead of Content
+	Parts []openaiContentPart `json:"-"`
+}
+
+// MarshalJSON sends the content as a list of parts when the message has any
+func (m openaiMessage) MarshalJSON() ([]byte, error) {
+	type plain openaiMessage
+	if len(m.Parts) == 0 {
+		return json.Marshal(plain(m))
+	}
+	return json.Marshal(struct {
+		plain
+		Content []openaiContentPart `json:"content"`
+	}{plain(m), m.Parts})
+}
+
+// openaiContentPart is a text or image_url part of the content of a message
+type openaiContentPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openaiImageURL `json:"image_url,omitempty"`
+}
+
+type openaiImageURL struct {
+	URL string `json:"url"`
+}
+
+// newOpenAIContentParts converts the content of msg into parts, starting
+// with Content, or returns nil if msg has no Parts
+func newOpenAIContentParts(msg Message) []openaiContentPart {
+	if len(msg.Parts) == 0 {
+		return nil
+	}
+
+	var parts []openaiContentPart
+	if msg.Content != "" {
+		parts = append(parts, openaiContentPart{Type: "text", Text: msg.Content})
+	}
+	for _, part := range msg.Parts {
+		if part.Type == PartImage {
+			parts = append(parts, openaiContentPart{Type: "image_url", ImageURL: &openaiImageURL{URL: part.ImageURL}})
+			continue
+		}
+		parts = append(parts, openaiContentPart{Type: "text", Text: part.Text})
+	}
+	return parts
+}
+
+// openaiDelta is the delta of a stream chunk choice. Tool calls arrive in
+// fragments that are merged by their index, see toolCallAccumulator.
+type openaiDelta struct {
+	Content          string                `json:"content"`
+	ReasoningContent string                `json:"reasoning_content,omitempty"`
+	ToolCalls        []openaiToolCallDelta `json:"tool_calls,omitempty"`
+}
+
+type openaiToolCallDelta struct {
+	// Index is missing from servers that send each call whole
+	Index    *int   `json:"index"`
+	ID       string `json:"id"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
 }
 
 type openaiResponse struct {
-	ID      string   `json:"id"`
-	Choices []choice `json:"choices"`
-	Model   string   `json:"model"`
-	Error   *struct {
-		Message string `json:"message"`
-	} `json:"error,omitempty"`
+	ID                string       `json:"id"`
+	Choices           []choice     `json:"choices"`
+	Model             string       `json:"model"`
+	SystemFingerprint string       `json:"system_fingerprint"`
+	Error             *openaiError `json:"error,omitempty"`
+}
+
+// openaiError is the error object of an OpenAI response. Some compatible
+// servers report the error as a plain string instead, which is accepted too.
+type openaiError struct {
+	Message string `json:"message"`
+}
+
+func (e *openaiError) UnmarshalJSON(data []byte) error {
+	var message string
+	if err := json.Unmarshal(data, &message); err == nil {
+		e.Message = message
+		return nil
+	}
+
+	type plain openaiError
+	return json.Unmarshal(data, (*plain)(e))
 }
 
 type choice struct {
+	Index        int           `json:"index"`
 	Message      openaiMessage `json:"message"`
 	FinishReason string        `json:"finish_reason"`
-	Delta        openaiMessage `json:"delta"`
+	Delta        openaiDelta   `json:"delta"`
 }
 
 // Complete implements non-streaming completion with retry support
 func (c *OpenAIClient) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
 	var resp *CompletionResponse
-	var lastErr error
-
-	for attempt := 0; attempt <= c.config.RetryConfig.MaxRetries; attempt++ {
-		if attempt > 0 {
-			delay := c.getRetryDelay(attempt)
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(delay):
-			}
-		}
+	err := retry(ctx, c.config.RetryConfig, func() error {
+		var err error
+		resp, err = c.complete(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
 
-		resp, lastErr = c.complete(ctx, req)
-		if lastErr == nil {
-			return resp, nil
-		}
+func (c *OpenAIClient) complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	openaiReq, err := c.newRequest(req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(ctx, openaiReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var openaiResp openaiResponse
+	raw, err := decodeResponse(resp.Body, &openaiResp, req.IncludeRaw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if openaiResp.Error != nil {
+		return nil, fmt.Errorf("OpenAI API error: %s", openaiResp.Error.Message)
+	}
+
+	if len(openaiResp.Choices) == 0 {
+		return nil, errors.New("no completion choices returned")
+	}
+
+	result := newOpenAIResponse(openaiResp, openaiReq.Model, false)
+	result.Metadata = newResponseMetadata(resp.Header)
+	result.Raw = raw
+	return result, nil
+}
 
-		if !c.shouldRetry(lastErr) {
-			return nil, lastErr
+// newOpenAIResponse converts a response or stream chunk with at least one
+// choice, reading the message or the delta of each choice respectively. The
+// tool calls of deltas are left to the stream to accumulate.
+func newOpenAIResponse(openaiResp openaiResponse, requestedModel string, delta bool) *CompletionResponse {
+	choices := make([]CompletionChoice, len(openaiResp.Choices))
+	for i, c := range openaiResp.Choices {
+		msg := c.Message
+		if delta {
+			msg = openaiMessage{Content: c.Delta.Content, ReasoningContent: c.Delta.ReasoningContent}
 		}
+		choices[i] = CompletionChoice{
+			Index:        c.Index,
+			Content:      msg.Content,
+			FinishReason: normalizeFinishReason(c.FinishReason),
+			ToolCalls:    msg.ToolCalls,
+			Reasoning:    msg.ReasoningContent,
+		}
+	}
+
+	return &CompletionResponse{
+		ID:                openaiResp.ID,
+		Content:           choices[0].Content,
+		Model:             responseModel(openaiResp.Model, requestedModel),
+		FinishReason:      choices[0].FinishReason,
+		ToolCalls:         choices[0].ToolCalls,
+		Reasoning:         choices[0].Reasoning,
+		Choices:           choices,
+		SystemFingerprint: openaiResp.SystemFingerprint,
 	}
+}
 
-	return nil, fmt.Errorf("max retries exceeded: %w", lastErr)
+// defaultModel returns the model of requests that do not set one
+func (c *OpenAIClient) defaultModel() string {
+	return c.config.DefaultModel
 }
 
-func (c *OpenAIClient) complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+func (c *OpenAIClient) newRequest(req *CompletionRequest, stream bool) (openaiRequest, error) {
+	model := req.Model
+	if model == "" {
+		model = c.config.DefaultModel
+	}
+	if model == "" {
+		return openaiRequest{}, ErrModelRequired
+	}
+
+	temperature := req.Temperature
+	if temperature == nil {
+		temperature = c.config.DefaultTemperature
+	}
+
 	openaiReq := openaiRequest{
-		Model: req.Model,
-		Messages: []openaiMessage{
-			{
-				Role:    "user",
-				Content: req.Prompt,
-			},
-		},
+		Model:       model,
 		MaxTokens:   req.MaxTokens,
-		Temperature: req.Temperature,
+		Temperature: temperature,
+		TopP:        req.TopP,
 		Stop:        req.Stop,
+		N:           req.N,
+		Seed:        req.Seed,
+		Stream:      stream,
 		Tools:       req.Tools,
+
+		FrequencyPenalty: req.FrequencyPenalty,
+		PresencePenalty:  req.PresencePenalty,
+		LogitBias:        req.LogitBias,
+
+		User:     req.User,
+		Metadata: req.Metadata,
+
+		extras: newRequestExtras(req),
+	}
+
+	for _, msg := range req.messages() {
+		openaiReq.Messages = append(openaiReq.Messages, openaiMessage{
+			Role:       msg.Role,
+			Content:    msg.Content,
+			Parts:      newOpenAIContentParts(msg),
+			Name:       msg.Name,
+			ToolCallID: msg.ToolCallID,
+			ToolCalls:  msg.ToolCalls,
+		})
 	}
 
 	if len(req.Tools) > 0 {
-		openaiReq.ToolChoice = "auto"
+		openaiReq.ToolChoice = openaiToolChoice(req.ToolChoice)
+	}
+
+	if c.prepareRequest != nil {
+		if err := c.prepareRequest(req, &openaiReq); err != nil {
+			return openaiRequest{}, err
+		}
 	}
 
-	body, err := json.Marshal(openaiReq)
+	return openaiReq, nil
+}
+
+func (c *OpenAIClient) do(ctx context.Context, openaiReq openaiRequest) (*http.Response, error) {
+	body, err := openaiReq.extras.marshal(openaiReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	endpoint := fmt.Sprintf("%s/chat/completions", strings.TrimRight(c.config.BaseURL, "/"))
+	endpoint := c.endpoint(c.config.ChatCompletionsPath)
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	apiKey, err := resolveAPIKey(ctx, c.config.Credentials, c.config.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	if openaiReq.Stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	c.setHeaders(httpReq.Header)
+	openaiReq.extras.setHeaders(httpReq.Header)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := openaiReq.extras.client(c.httpClient).Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, &HTTPError{
-			StatusCode: resp.StatusCode,
-			Message:    string(body),
-		}
+		defer resp.Body.Close()
+		return nil, newHTTPError(resp)
 	}
 
-	var openaiResp openaiResponse
-	if err := json.NewDecoder(resp.Body).Decode(&openaiResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
+	return resp, nil
+}
 
-	if openaiResp.Error != nil {
-		return nil, fmt.Errorf("OpenAI API error: %s", openaiResp.Error.Message)
+// openaiToolChoice converts CompletionRequest.ToolChoice into OpenAI's
+// format, in which a forced function is an object
+func openaiToolChoice(choice string) any {
+	switch choice {
+	case "":
+		return ToolChoiceAuto
+	case ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired:
+		return choice
 	}
-
-	if len(openaiResp.Choices) == 0 {
-		return nil, errors.New("no completion choices returned")
+	return map[string]any{
+		"type":     "function",
+		"function": map[string]string{"name": choice},
 	}
-
-	return &CompletionResponse{
-		Content:      openaiResp.Choices[0].Message.Content,
-		Model:        openaiResp.Model,
-		FinishReason: openaiResp.Choices[0].FinishReason,
-		ToolCalls:    openaiResp.Choices[0].Message.ToolCalls,
-	}, nil
 }
 
-func (c *OpenAIClient) shouldRetry(err error) bool {
-	var httpErr *HTTPError
-	if !errors.As(err, &httpErr) {
-		return false
+// endpoint returns the URL of path below BaseURL with the configured
+// QueryParams
+func (c *OpenAIClient) endpoint(path string) string {
+	endpoint := strings.TrimRight(c.config.BaseURL, "/") + "/" + strings.TrimLeft(path, "/")
+	if len(c.config.QueryParams) == 0 {
+		return endpoint
 	}
 
-	for _, code := range c.config.RetryConfig.RetryableStatusCodes {
-		if httpErr.StatusCode == code {
-			return true
-		}
+	query := make(url.Values, len(c.config.QueryParams))
+	for name, value := range c.config.QueryParams {
+		query.Set(name, value)
 	}
-	return false
+	return endpoint + "?" + query.Encode()
 }
 
-func (c *OpenAIClient) getRetryDelay(attempt int) time.Duration {
-	delay := c.config.RetryConfig.InitialDelay * time.Duration(1<<uint(attempt-1))
-	if delay > c.config.RetryConfig.MaxDelay {
-		delay = c.config.RetryConfig.MaxDelay
+// setHeaders adds the configured organization, project and Headers to
+// header
+func (c *OpenAIClient) setHeaders(header http.Header) {
+	if c.config.Organization != "" {
+		header.Set("OpenAI-Organization", c.config.Organization)
+	}
+	if c.config.Project != "" {
+		header.Set("OpenAI-Project", c.config.Project)
+	}
+	for name, value := range c.config.Headers {
+		header.Set(name, value)
 	}
-	return delay
 }
 
-// HTTPError represents an HTTP error response
-type HTTPError struct {
-	StatusCode int
-	Message    string
+// openaiModel is an entry of the /models listing. Besides OpenAI's own
+// fields it covers the context sizes and capabilities that OpenAI-compatible
+// servers add.
+type openaiModel struct {
+	ID            string   `json:"id"`
+	Created       int64    `json:"created"`
+	OwnedBy       string   `json:"owned_by"`
+	DisplayName   string   `json:"display_name"`
+	Type          string   `json:"type"`
+	ContextWindow int      `json:"context_window"`
+	ContextLength int      `json:"context_length"`
+	MaxModelLen   int      `json:"max_model_len"`
+	Capabilities  []string `json:"capabilities"`
 }
 
-func (e *HTTPError) Error() string {
-	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
-}
+// ListModels implements the ModelLister interface using the /models
+// endpoint. Context windows and capabilities are filled in when the server
+// reports them, as Groq, Together and vLLM do; OpenAI itself does not.
+func (c *OpenAIClient) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	endpoint := c.endpoint("/models")
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
 
-// openAIStream implements CompletionStream for OpenAI
-type openAIStream struct {
-	reader *bufio.Reader
-	closer io.Closer
-}
+	apiKey, err := resolveAPIKey(ctx, c.config.Credentials, c.config.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	c.setHeaders(httpReq.Header)
 
-// CompleteStream implements streaming completion
-func (c *OpenAIClient) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
-	openaiReq := openaiRequest{
-		Model: req.Model,
-		Messages: []openaiMessage{
-			{
-				Role:    "user",
-				Content: req.Prompt,
-			},
-		},
-		MaxTokens:   req.MaxTokens,
-		Temperature: req.Temperature,
-		Stop:        req.Stop,
-		Stream:      true,
-		Tools:       req.Tools,
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
+	defer resp.Body.Close()
 
-	if len(req.Tools) > 0 {
-		openaiReq.ToolChoice = "auto"
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPError(resp)
 	}
 
-	body, err := json.Marshal(openaiReq)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	endpoint := fmt.Sprintf("%s/chat/completions", strings.TrimRight(c.config.BaseURL, "/"))
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	// Most servers wrap the list in a data field, but Together returns a
+	// bare array
+	var models []openaiModel
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &models)
+	} else {
+		var list struct {
+			Data []openaiModel `json:"data"`
+		}
+		err = json.Unmarshal(body, &list)
+		models = list.Data
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+c.config.APIKey)
-	httpReq.Header.Set("Accept", "text/event-stream")
+	infos := make([]ModelInfo, 0, len(models))
+	for _, m := range models {
+		info := ModelInfo{
+			ID:            m.ID,
+			DisplayName:   m.DisplayName,
+			OwnedBy:       m.OwnedBy,
+			ContextWindow: max(m.ContextWindow, m.ContextLength, m.MaxModelLen),
+			Capabilities:  m.Capabilities,
+		}
+		if m.Created > 0 {
+			info.Created = time.Unix(m.Created, 0).UTC()
+		}
+		if len(info.Capabilities) == 0 && m.Type != "" {
+			info.Capabilities = []string{m.Type}
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
 
-	resp, err := c.httpClient.Do(httpReq)
+// normalizeFinishReason maps finish reasons reported by OpenAI-compatible
+// hosts onto OpenAI's own values. Open-weight model servers such as Together
+// report "eos" when the model emits its end-of-sequence token, and some local
+// servers use Anthropic-style "stop_sequence" and "max_tokens".
+func normalizeFinishReason(reason string) string {
+	switch reason {
+	case "eos", "eos_token", "stop_sequence":
+		return "stop"
+	case "max_tokens":
+		return "length"
+	default:
+		return reason
+	}
+}
+
+// decodeResponse decodes the JSON body r into v. When raw is true it also
+// returns the body itself, for CompletionResponse.Raw.
+func decodeResponse(r io.Reader, v any, raw bool) (json.RawMessage, error) {
+	if !raw {
+		return nil, json.NewDecoder(r).Decode(v)
+	}
+
+	body, err := io.ReadAll(r)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
+	return body, json.Unmarshal(body, v)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		resp.Body.Close()
-		body, _ := io.ReadAll(resp.Body)
-		return nil, &HTTPError{
-			StatusCode: resp.StatusCode,
-			Message:    string(body),
-		}
+// rawEvent returns data as the raw JSON of a stream chunk when raw is true
+func rawEvent(data []byte, raw bool) json.RawMessage {
+	if !raw {
+		return nil
+	}
+	return append(json.RawMessage(nil), data...)
+}
+
+// responseModel returns the model reported by the server, falling back to
+// the requested one for servers that leave it out
+func responseModel(reported, requested string) string {
+	if reported == "" {
+		return requested
+	}
+	return reported
+}
+
+// openAIStream implements CompletionStream for OpenAI
+type openAIStream struct {
+	reader   *bufio.Reader
+	closer   io.Closer
+	model    string
+	metadata *ResponseMetadata
+	raw      bool
+
+	// id is the ID of the last chunk, and toolCalls accumulates the tool
+	// calls of each choice until it finishes
+	id        string
+	toolCalls map[int]*toolCallAccumulator
+}
+
+// CompleteStream implements streaming completion
+func (c *OpenAIClient) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
+	openaiReq, err := c.newRequest(req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(ctx, openaiReq)
+	if err != nil {
+		return nil, err
 	}
 
 	return &openAIStream{
-		reader: bufio.NewReader(resp.Body),
-		closer: resp.Body,
+		reader:   bufio.NewReader(resp.Body),
+		closer:   resp.Body,
+		model:    openaiReq.Model,
+		metadata: newResponseMetadata(resp.Header),
+		raw:      req.IncludeRaw,
 	}, nil
 }
 
@@ -330,7 +694,7 @@ func (s *openAIStream) Recv() (*CompletionResponse, error) {
 		line, err := s.reader.ReadBytes('\n')
 		if err != nil {
 			if err == io.EOF {
-				return nil, io.EOF
+				return s.pendingToolCalls()
 			}
 			return nil, fmt.Errorf("failed to read stream: %w", err)
 		}
@@ -340,13 +704,13 @@ func (s *openAIStream) Recv() (*CompletionResponse, error) {
 			continue
 		}
 
-		if !bytes.HasPrefix(line, []byte("data: ")) {
+		if !bytes.HasPrefix(line, []byte("data:")) {
 			continue
 		}
 
-		data := bytes.TrimPrefix(line, []byte("data: "))
-		if strings.TrimSpace(string(data)) == "[DONE]" {
-			return nil, io.EOF
+		data := bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+		if string(data) == "[DONE]" {
+			return s.pendingToolCalls()
 		}
 
 		var streamResp openaiResponse
@@ -362,15 +726,70 @@ func (s *openAIStream) Recv() (*CompletionResponse, error) {
 			continue
 		}
 
-		return &CompletionResponse{
-			Content:      streamResp.Choices[0].Delta.Content,
-			Model:        streamResp.Model,
-			FinishReason: streamResp.Choices[0].FinishReason,
-			ToolCalls:    streamResp.Choices[0].Delta.ToolCalls,
-		}, nil
+		result := newOpenAIResponse(streamResp, s.model, true)
+		result.Metadata = s.metadata
+		result.Raw = rawEvent(data, s.raw)
+		s.accumulate(streamResp.Choices, result)
+		if streamResp.ID != "" {
+			s.id = streamResp.ID
+		}
+		return result, nil
 	}
 }
 
+// accumulate merges the tool call fragments of choices and sets the complete
+// calls of the choices that finished on result
+func (s *openAIStream) accumulate(choices []choice, result *CompletionResponse) {
+	for i, c := range choices {
+		acc := s.toolCalls[c.Index]
+		if acc == nil {
+			if len(c.Delta.ToolCalls) == 0 {
+				continue
+			}
+			if s.toolCalls == nil {
+				s.toolCalls = make(map[int]*toolCallAccumulator)
+			}
+			acc = &toolCallAccumulator{}
+			s.toolCalls[c.Index] = acc
+		}
+
+		for _, delta := range c.Delta.ToolCalls {
+			index := acc.next()
+			if delta.Index != nil {
+				index = *delta.Index
+			}
+			acc.add(index, delta.ID, delta.Function.Name, delta.Function.Arguments)
+		}
+		if c.FinishReason != "" {
+			result.Choices[i].ToolCalls = acc.flush()
+		}
+	}
+	result.ToolCalls = result.Choices[0].ToolCalls
+}
+
+// pendingToolCalls ends the stream, first returning a chunk with the tool
+// calls of choices that never reported a finish reason
+func (s *openAIStream) pendingToolCalls() (*CompletionResponse, error) {
+	var choices []CompletionChoice
+	for index, acc := range s.toolCalls {
+		if acc.pending() {
+			choices = append(choices, CompletionChoice{Index: index, ToolCalls: acc.flush()})
+		}
+	}
+	if len(choices) == 0 {
+		return nil, io.EOF
+	}
+	sort.Slice(choices, func(i, j int) bool { return choices[i].Index < choices[j].Index })
+
+	return &CompletionResponse{
+		ID:        s.id,
+		Model:     s.model,
+		ToolCalls: choices[0].ToolCalls,
+		Choices:   choices,
+		Metadata:  s.metadata,
+	}, nil
+}
+
 // Close implements the CompletionStream interface
 func (s *openAIStream) Close() error {
 	return s.closer.Close()
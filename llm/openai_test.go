@@ -1,11 +1,15 @@
 package llm
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -103,6 +107,7 @@ func TestOpenAIClient_Complete(t *testing.T) {
 			statusCode: http.StatusOK,
 			wantErr:    false,
 			wantResp: &CompletionResponse{
+				ID:           "test-id",
 				Content:      "Test response",
 				Model:        "gpt-4",
 				FinishReason: "stop",
@@ -157,6 +162,9 @@ func TestOpenAIClient_Complete(t *testing.T) {
 			if !tt.wantErr && got.Content != tt.wantResp.Content {
 				t.Errorf("Content = %v, want %v", got.Content, tt.wantResp.Content)
 			}
+			if !tt.wantErr && got.ID != tt.wantResp.ID {
+				t.Errorf("ID = %v, want %v", got.ID, tt.wantResp.ID)
+			}
 		})
 	}
 }
@@ -309,3 +317,946 @@ func TestOpenAIClient_RetryBehavior(t *testing.T) {
 		t.Errorf("Content = %v, want 'Success after retry'", resp.Content)
 	}
 }
+
+func TestOpenAIClient_Messages(t *testing.T) {
+	history := []Message{
+		{Role: RoleSystem, Content: "Be brief"},
+		{Role: RoleUser, Content: "What is the weather?"},
+		{Role: RoleAssistant, Content: "Let me check."},
+		{Role: RoleTool, Content: "sunny", ToolCallID: "call_1"},
+	}
+
+	tests := []struct {
+		name     string
+		req      *CompletionRequest
+		wantMsgs []openaiMessage
+	}{
+		{
+			name: "prompt only",
+			req:  &CompletionRequest{Prompt: "Hello"},
+			wantMsgs: []openaiMessage{
+				{Role: "user", Content: "Hello"},
+			},
+		},
+		{
+			name: "messages only",
+			req:  &CompletionRequest{Messages: history},
+			wantMsgs: []openaiMessage{
+				{Role: "system", Content: "Be brief"},
+				{Role: "user", Content: "What is the weather?"},
+				{Role: "assistant", Content: "Let me check."},
+				{Role: "tool", Content: "sunny", ToolCallID: "call_1"},
+			},
+		},
+		{
+			name: "prompt appended to messages",
+			req: &CompletionRequest{
+				Messages: []Message{{Role: RoleSystem, Content: "Be brief", Name: "setup"}},
+				Prompt:   "Hello",
+			},
+			wantMsgs: []openaiMessage{
+				{Role: "system", Content: "Be brief", Name: "setup"},
+				{Role: "user", Content: "Hello"},
+			},
+		},
+		{
+			name: "system prompt",
+			req:  &CompletionRequest{SystemPrompt: "Be brief", Prompt: "Hello"},
+			wantMsgs: []openaiMessage{
+				{Role: "system", Content: "Be brief"},
+				{Role: "user", Content: "Hello"},
+			},
+		},
+		{
+			name: "system prompt before messages",
+			req: &CompletionRequest{
+				SystemPrompt: "Be brief",
+				Messages:     []Message{{Role: RoleUser, Content: "Hello"}},
+			},
+			wantMsgs: []openaiMessage{
+				{Role: "system", Content: "Be brief"},
+				{Role: "user", Content: "Hello"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var reqBody openaiRequest
+				if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+					t.Errorf("Failed to decode request body: %v", err)
+				}
+				if !reflect.DeepEqual(reqBody.Messages, tt.wantMsgs) {
+					t.Errorf("Messages = %+v, want %+v", reqBody.Messages, tt.wantMsgs)
+				}
+				w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+			}))
+			defer server.Close()
+
+			client := NewOpenAIClient(OpenAIConfig{APIKey: "test-key", BaseURL: server.URL, DefaultModel: "gpt-4"})
+			if _, err := client.Complete(context.Background(), tt.req); err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestOpenAIClient_ContentParts(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"choices":[{"message":{"content":"A cat."}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient(OpenAIConfig{APIKey: "test-key", BaseURL: server.URL, DefaultModel: "gpt-4o"})
+	messages := NewMessages().System("Be brief").User("What is this?").Image("https://example.com/cat.jpg").Build()
+	if _, err := client.Complete(context.Background(), &CompletionRequest{Messages: messages}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	want := []any{
+		map[string]any{"role": "system", "content": "Be brief"},
+		map[string]any{"role": "user", "content": []any{
+			map[string]any{"type": "text", "text": "What is this?"},
+			map[string]any{"type": "image_url", "image_url": map[string]any{"url": "https://example.com/cat.jpg"}},
+		}},
+	}
+	if !reflect.DeepEqual(body["messages"], want) {
+		t.Errorf("messages = %v, want %v", body["messages"], want)
+	}
+}
+
+// checkStream drains a stream fed with fuzzed input and fails if Recv breaks
+// the CompletionStream contract
+func checkStream(t *testing.T, stream CompletionStream) {
+	t.Helper()
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return
+		}
+		if resp == nil {
+			t.Fatal("Recv() returned neither a response nor an error")
+		}
+	}
+}
+
+func FuzzOpenAIStream(f *testing.F) {
+	f.Add([]byte("data: {\"id\":\"1\",\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\ndata: [DONE]\n\n"))
+	f.Add([]byte("data:{\"choices\":[{\"delta\":{\"tool_calls\":[{\"id\":\"call_1\"}]},\"finish_reason\":\"eos\"}]}\n"))
+	f.Add([]byte("data: {\"error\":\"overloaded\"}\n"))
+	f.Add([]byte(": keep-alive\n\ndata: {\"choices\":[]}\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkStream(t, &openAIStream{
+			reader: bufio.NewReader(bytes.NewReader(data)),
+			closer: io.NopCloser(nil),
+			model:  "gpt-4",
+		})
+	})
+}
+
+func FuzzOpenAIResponse(f *testing.F) {
+	f.Add([]byte(`{"id":"1","model":"gpt-4","choices":[{"message":{"content":"Hi"},"finish_reason":"stop"}]}`))
+	f.Add([]byte(`{"error":{"message":"Invalid API key"}}`))
+	f.Add([]byte(`{"error":"rate limited"}`))
+	f.Add([]byte(`{"error":null}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var resp openaiResponse
+		if json.Unmarshal(data, &resp) != nil {
+			return
+		}
+		if resp.Error != nil {
+			_ = resp.Error.Message
+		}
+	})
+}
+
+func TestOpenAIClient_ListModels(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   string
+		statusCode int
+		want       []ModelInfo
+		wantErr    bool
+	}{
+		{
+			name:       "OpenAI listing",
+			response:   `{"object":"list","data":[{"id":"gpt-4o","object":"model","created":1715367049,"owned_by":"system"}]}`,
+			statusCode: http.StatusOK,
+			want: []ModelInfo{
+				{ID: "gpt-4o", OwnedBy: "system", Created: time.Unix(1715367049, 0).UTC()},
+			},
+		},
+		{
+			name:       "context windows from compatible servers",
+			response:   `{"data":[{"id":"llama-3.1-8b-instant","owned_by":"Meta","context_window":131072},{"id":"qwen","max_model_len":32768,"capabilities":["chat","tools"]}]}`,
+			statusCode: http.StatusOK,
+			want: []ModelInfo{
+				{ID: "llama-3.1-8b-instant", OwnedBy: "Meta", ContextWindow: 131072},
+				{ID: "qwen", ContextWindow: 32768, Capabilities: []string{"chat", "tools"}},
+			},
+		},
+		{
+			name:       "bare array",
+			response:   `[{"id":"mistralai/Mixtral-8x7B-Instruct-v0.1","display_name":"Mixtral","type":"chat","context_length":32768}]`,
+			statusCode: http.StatusOK,
+			want: []ModelInfo{
+				{ID: "mistralai/Mixtral-8x7B-Instruct-v0.1", DisplayName: "Mixtral", ContextWindow: 32768, Capabilities: []string{"chat"}},
+			},
+		},
+		{
+			name:       "API error",
+			response:   `{"error":{"message":"Invalid API key"}}`,
+			statusCode: http.StatusUnauthorized,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet || r.URL.Path != "/models" {
+					t.Errorf("request = %v %v, want GET /models", r.Method, r.URL.Path)
+				}
+				if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+					t.Errorf("Authorization = %v, want Bearer test-key", got)
+				}
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			client := NewOpenAIClient(OpenAIConfig{APIKey: "test-key", BaseURL: server.URL})
+			var lister ModelLister = client
+
+			got, err := lister.ListModels(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ListModels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListModels() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIClient_MultipleChoices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody openaiRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		if reqBody.N != 2 {
+			t.Errorf("N = %v, want 2", reqBody.N)
+		}
+
+		if reqBody.Stream {
+			w.Write([]byte("data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Heads\"}},{\"index\":1,\"delta\":{\"content\":\"Tails\"}}]}\n\n" +
+				"data: {\"choices\":[{\"index\":1,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n" +
+				"data: [DONE]\n\n"))
+			return
+		}
+		w.Write([]byte(`{"id":"test-id","choices":[
+			{"index":0,"message":{"content":"Heads"},"finish_reason":"stop"},
+			{"index":1,"message":{"content":"Tails"},"finish_reason":"length"}
+		]}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient(OpenAIConfig{APIKey: "test-key", BaseURL: server.URL})
+	req := &CompletionRequest{Model: "gpt-4", Prompt: "Flip a coin", N: 2}
+
+	resp, err := client.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	want := []CompletionChoice{
+		{Index: 0, Content: "Heads", FinishReason: "stop"},
+		{Index: 1, Content: "Tails", FinishReason: "length"},
+	}
+	if !reflect.DeepEqual(resp.Choices, want) {
+		t.Errorf("Choices = %+v, want %+v", resp.Choices, want)
+	}
+	if resp.Content != "Heads" || resp.FinishReason != "stop" {
+		t.Errorf("Content = %v, FinishReason = %v, want the first choice", resp.Content, resp.FinishReason)
+	}
+
+	stream, err := client.CompleteStream(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	contents := map[int]string{}
+	finished := map[int]string{}
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		for _, c := range chunk.Choices {
+			contents[c.Index] += c.Content
+			if c.FinishReason != "" {
+				finished[c.Index] = c.FinishReason
+			}
+		}
+	}
+	if contents[0] != "Heads" || contents[1] != "Tails" || finished[1] != "stop" {
+		t.Errorf("streamed choices = %v, finish reasons = %v", contents, finished)
+	}
+}
+
+func TestMultipleChoicesUnsupported(t *testing.T) {
+	req := &CompletionRequest{Model: "m", Prompt: "Hi", N: 3}
+
+	providers := map[string]LLMProvider{
+		"anthropic": NewAnthropicClient("test-key"),
+		"cohere":    NewCohereClient(CohereConfig{APIKey: "test-key", BaseURL: "http://127.0.0.1:0"}),
+		"llamacpp":  NewLlamaCppClient(LlamaCppConfig{BaseURL: "http://127.0.0.1:0"}),
+	}
+
+	for name, provider := range providers {
+		t.Run(name, func(t *testing.T) {
+			if _, err := provider.Complete(context.Background(), req); !errors.Is(err, ErrMultipleChoicesUnsupported) {
+				t.Errorf("Complete() error = %v, want ErrMultipleChoicesUnsupported", err)
+			}
+			if _, err := provider.CompleteStream(context.Background(), req); !errors.Is(err, ErrMultipleChoicesUnsupported) {
+				t.Errorf("CompleteStream() error = %v, want ErrMultipleChoicesUnsupported", err)
+			}
+		})
+	}
+}
+
+func TestContentPartsUnsupported(t *testing.T) {
+	req := &CompletionRequest{Model: "m", Messages: NewMessages().User("What is this?").Image("https://example.com/cat.jpg").Build()}
+
+	providers := map[string]LLMProvider{
+		"cohere":    NewCohereClient(CohereConfig{APIKey: "test-key", BaseURL: "http://127.0.0.1:0"}),
+		"dashscope": NewDashScopeClient(DashScopeConfig{APIKey: "test-key", BaseURL: "http://127.0.0.1:0"}),
+		"llamacpp":  NewLlamaCppClient(LlamaCppConfig{BaseURL: "http://127.0.0.1:0"}),
+	}
+
+	for name, provider := range providers {
+		t.Run(name, func(t *testing.T) {
+			if _, err := provider.Complete(context.Background(), req); !errors.Is(err, ErrContentPartsUnsupported) {
+				t.Errorf("Complete() error = %v, want ErrContentPartsUnsupported", err)
+			}
+			if _, err := provider.CompleteStream(context.Background(), req); !errors.Is(err, ErrContentPartsUnsupported) {
+				t.Errorf("CompleteStream() error = %v, want ErrContentPartsUnsupported", err)
+			}
+		})
+	}
+}
+
+func TestToolsUnsupported(t *testing.T) {
+	req := &CompletionRequest{Model: "m", Prompt: "Weather?", Tools: []Tool{{Type: "function"}}}
+	provider := NewLlamaCppClient(LlamaCppConfig{BaseURL: "http://127.0.0.1:0"})
+
+	if _, err := provider.Complete(context.Background(), req); !errors.Is(err, ErrToolsUnsupported) {
+		t.Errorf("Complete() error = %v, want ErrToolsUnsupported", err)
+	}
+	if _, err := provider.CompleteStream(context.Background(), req); !errors.Is(err, ErrToolsUnsupported) {
+		t.Errorf("CompleteStream() error = %v, want ErrToolsUnsupported", err)
+	}
+}
+
+func TestOpenAIClient_Seed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody openaiRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		if reqBody.Seed == nil || *reqBody.Seed != 42 {
+			t.Errorf("Seed = %v, want 42", reqBody.Seed)
+		}
+		w.Write([]byte(`{"system_fingerprint":"fp_44709d6fcb","choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	seed := int64(42)
+	client := NewOpenAIClient(OpenAIConfig{APIKey: "test-key", BaseURL: server.URL})
+	resp, err := client.Complete(context.Background(), &CompletionRequest{Model: "gpt-4", Prompt: "Hi", Seed: &seed})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.SystemFingerprint != "fp_44709d6fcb" {
+		t.Errorf("SystemFingerprint = %v, want fp_44709d6fcb", resp.SystemFingerprint)
+	}
+}
+
+func TestSeed_Providers(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		multiProviderHandler(w, r)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		provider LLMProvider
+		want     string
+	}{
+		{"cohere", NewCohereClient(CohereConfig{BaseURL: server.URL}), `"seed":7`},
+		{"dashscope", NewDashScopeClient(DashScopeConfig{BaseURL: server.URL}), `"seed":7`},
+		{"llamacpp", NewLlamaCppClient(LlamaCppConfig{BaseURL: server.URL}), `"seed":7`},
+	}
+
+	seed := int64(7)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.provider.Complete(context.Background(), &CompletionRequest{Prompt: "Hi", Seed: &seed}); err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if !strings.Contains(string(body), tt.want) {
+				t.Errorf("request body = %s, want it to contain %s", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestSamplingParameters_Providers(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		multiProviderHandler(w, r)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		provider LLMProvider
+		params   func(body map[string]any) map[string]any
+		want     map[string]any
+	}{
+		{
+			name:     "openai",
+			provider: NewOpenAIClient(OpenAIConfig{BaseURL: server.URL, DefaultModel: "gpt-4"}),
+			params:   func(body map[string]any) map[string]any { return body },
+			want: map[string]any{
+				"top_p": 0.5, "frequency_penalty": 0.25, "presence_penalty": -0.5,
+				"logit_bias": map[string]any{"50256": -100.0},
+			},
+		},
+		{
+			name:     "anthropic",
+			provider: NewAnthropicClientWithConfig(AnthropicConfig{BaseURL: server.URL + "/v1", DefaultModel: "claude-3-opus"}),
+			params:   func(body map[string]any) map[string]any { return body },
+			want:     map[string]any{"top_p": 0.5},
+		},
+		{
+			name:     "cohere",
+			provider: NewCohereClient(CohereConfig{BaseURL: server.URL}),
+			params:   func(body map[string]any) map[string]any { return body },
+			want:     map[string]any{"p": 0.5, "frequency_penalty": 0.25, "presence_penalty": -0.5},
+		},
+		{
+			name:     "dashscope",
+			provider: NewDashScopeClient(DashScopeConfig{BaseURL: server.URL}),
+			params:   func(body map[string]any) map[string]any { return body["parameters"].(map[string]any) },
+			want:     map[string]any{"top_p": 0.5, "presence_penalty": -0.5},
+		},
+		{
+			name:     "llamacpp",
+			provider: NewLlamaCppClient(LlamaCppConfig{BaseURL: server.URL}),
+			params:   func(body map[string]any) map[string]any { return body },
+			want: map[string]any{
+				"top_p": 0.5, "frequency_penalty": 0.25, "presence_penalty": -0.5,
+				"logit_bias": map[string]any{"50256": -100.0},
+			},
+		},
+	}
+
+	keys := []string{"top_p", "p", "frequency_penalty", "presence_penalty", "logit_bias"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.provider.Complete(context.Background(), &CompletionRequest{
+				Prompt:           "Hi",
+				TopP:             0.5,
+				FrequencyPenalty: 0.25,
+				PresencePenalty:  -0.5,
+				LogitBias:        map[string]float32{"50256": -100},
+			})
+			if err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+
+			params := tt.params(body)
+			got := map[string]any{}
+			for _, key := range keys {
+				if value, ok := params[key]; ok {
+					got[key] = value
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sampling parameters = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIClient_DefaultModel(t *testing.T) {
+	var gotModel string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody openaiRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		gotModel = reqBody.Model
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		defaultModel string
+		model        string
+		want         string
+		wantErr      error
+	}{
+		{name: "default used", defaultModel: "gpt-4o-mini", want: "gpt-4o-mini"},
+		{name: "request overrides default", defaultModel: "gpt-4o-mini", model: "gpt-4", want: "gpt-4"},
+		{name: "request without default", model: "gpt-4", want: "gpt-4"},
+		{name: "neither set", wantErr: ErrModelRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotModel = ""
+			client := NewOpenAIClient(OpenAIConfig{BaseURL: server.URL, DefaultModel: tt.defaultModel})
+			req := &CompletionRequest{Model: tt.model, Prompt: "Hi"}
+
+			resp, err := client.Complete(context.Background(), req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Complete() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if _, err := client.CompleteStream(context.Background(), req); !errors.Is(err, tt.wantErr) {
+					t.Errorf("CompleteStream() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if gotModel != tt.want {
+				t.Errorf("sent model = %q, want %q", gotModel, tt.want)
+			}
+			if resp.Model != tt.want {
+				t.Errorf("Model = %q, want %q", resp.Model, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIClient_WithClearedSettings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	base := NewOpenAIClient(OpenAIConfig{APIKey: "test-key", BaseURL: server.URL, DefaultModel: "gpt-4"})
+	client := base.With(WithHTTPClient(nil), WithRetryConfig(nil), WithTimeout(0))
+
+	if client.httpClient == nil || client.httpClient.Timeout != defaultTimeout || client.config.RetryConfig == nil {
+		t.Fatalf("With() = %+v, want the defaults restored", client.config)
+	}
+	if _, err := client.Complete(context.Background(), &CompletionRequest{Prompt: "Hi"}); err != nil {
+		t.Errorf("Complete() error = %v", err)
+	}
+}
+
+func TestOpenAIClient_With(t *testing.T) {
+	var (
+		reqBody openaiRequest
+		header  http.Header
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqBody = openaiRequest{}
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		header = r.Header
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	base := NewOpenAIClient(OpenAIConfig{
+		APIKey:       "test-key",
+		BaseURL:      server.URL,
+		DefaultModel: "gpt-4",
+		Headers:      map[string]string{"X-Team": "core"},
+	})
+	tenant := base.With(
+		WithDefaultModel("gpt-4o-mini"),
+		WithDefaultTemperature(0.2),
+		WithHeaders(map[string]string{"X-Tenant": "acme"}),
+	)
+
+	if tenant.httpClient != base.httpClient {
+		t.Error("With() did not share the HTTP client")
+	}
+
+	if _, err := tenant.Complete(context.Background(), &CompletionRequest{Prompt: "Hi"}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if reqBody.Model != "gpt-4o-mini" || reqBody.Temperature == nil || *reqBody.Temperature != 0.2 {
+		t.Errorf("model = %q, temperature = %v, want gpt-4o-mini and 0.2", reqBody.Model, reqBody.Temperature)
+	}
+	if header.Get("X-Team") != "core" || header.Get("X-Tenant") != "acme" {
+		t.Errorf("headers = %v, want X-Team and X-Tenant", header)
+	}
+	if header.Get("Authorization") != "Bearer test-key" {
+		t.Errorf("Authorization = %q, want Bearer test-key", header.Get("Authorization"))
+	}
+
+	if _, err := tenant.Complete(context.Background(), &CompletionRequest{Model: "gpt-4", Prompt: "Hi", Temperature: temperature(0.9)}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if reqBody.Model != "gpt-4" || reqBody.Temperature == nil || *reqBody.Temperature != 0.9 {
+		t.Errorf("request values not preferred: model = %q, temperature = %v", reqBody.Model, reqBody.Temperature)
+	}
+
+	// An explicit temperature of 0 is sent rather than the default
+	if _, err := tenant.Complete(context.Background(), &CompletionRequest{Prompt: "Hi", Temperature: temperature(0)}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if reqBody.Temperature == nil || *reqBody.Temperature != 0 {
+		t.Errorf("temperature = %v, want an explicit 0", reqBody.Temperature)
+	}
+
+	// The original client keeps its own defaults
+	if _, err := base.Complete(context.Background(), &CompletionRequest{Prompt: "Hi"}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if reqBody.Model != "gpt-4" || reqBody.Temperature != nil || header.Get("X-Tenant") != "" {
+		t.Errorf("base client changed: model = %q, temperature = %v, headers = %v", reqBody.Model, reqBody.Temperature, header)
+	}
+
+	slow := base.With(WithTimeout(time.Minute))
+	if slow.httpClient == base.httpClient || slow.httpClient.Transport != base.httpClient.Transport {
+		t.Error("WithTimeout should copy the HTTP client and share its transport")
+	}
+	if slow.httpClient.Timeout != time.Minute || base.httpClient.Timeout != defaultTimeout {
+		t.Errorf("timeouts = %v, %v", slow.httpClient.Timeout, base.httpClient.Timeout)
+	}
+
+	groq := NewGroqClient(OpenAIConfig{APIKey: "test-key"}).With(WithDefaultModel("llama-3.1-8b-instant"))
+	if groq.config.DefaultModel != "llama-3.1-8b-instant" || !groq.config.RetryConfig.RespectRetryAfter {
+		t.Errorf("Groq With() config = %+v", groq.config)
+	}
+}
+
+func TestOpenAIClient_OrganizationAndProject(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		if r.URL.Path == "/models" {
+			w.Write([]byte(`{"data":[]}`))
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient(OpenAIConfig{
+		APIKey:       "test-key",
+		BaseURL:      server.URL,
+		DefaultModel: "gpt-4",
+		Organization: "org-main",
+		Project:      "proj-a",
+	})
+
+	tests := []struct {
+		name        string
+		client      *OpenAIClient
+		call        func(c *OpenAIClient) error
+		wantOrg     string
+		wantProject string
+	}{
+		{
+			name:   "complete",
+			client: client,
+			call: func(c *OpenAIClient) error {
+				_, err := c.Complete(context.Background(), &CompletionRequest{Prompt: "Hi"})
+				return err
+			},
+			wantOrg:     "org-main",
+			wantProject: "proj-a",
+		},
+		{
+			name:   "list models",
+			client: client,
+			call: func(c *OpenAIClient) error {
+				_, err := c.ListModels(context.Background())
+				return err
+			},
+			wantOrg:     "org-main",
+			wantProject: "proj-a",
+		},
+		{
+			name:   "derived client",
+			client: client.With(WithOrganization("org-other"), WithProject("")),
+			call: func(c *OpenAIClient) error {
+				_, err := c.Complete(context.Background(), &CompletionRequest{Prompt: "Hi"})
+				return err
+			},
+			wantOrg: "org-other",
+		},
+		{
+			name:   "not configured",
+			client: NewOpenAIClient(OpenAIConfig{BaseURL: server.URL, DefaultModel: "gpt-4"}),
+			call: func(c *OpenAIClient) error {
+				_, err := c.Complete(context.Background(), &CompletionRequest{Prompt: "Hi"})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(tt.client); err != nil {
+				t.Fatalf("call error = %v", err)
+			}
+			if got := header.Get("OpenAI-Organization"); got != tt.wantOrg {
+				t.Errorf("OpenAI-Organization = %q, want %q", got, tt.wantOrg)
+			}
+			if got := header.Get("OpenAI-Project"); got != tt.wantProject {
+				t.Errorf("OpenAI-Project = %q, want %q", got, tt.wantProject)
+			}
+		})
+	}
+}
+
+func TestOpenAIClient_UserAndMetadata(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient(OpenAIConfig{BaseURL: server.URL, DefaultModel: "gpt-4o"})
+
+	if _, err := client.Complete(context.Background(), &CompletionRequest{
+		Prompt:   "Hi",
+		User:     "user-7f3a",
+		Metadata: map[string]string{"feature": "chat"},
+	}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if body["user"] != "user-7f3a" {
+		t.Errorf("user = %v, want user-7f3a", body["user"])
+	}
+	if want := map[string]any{"feature": "chat"}; !reflect.DeepEqual(body["metadata"], want) {
+		t.Errorf("metadata = %v, want %v", body["metadata"], want)
+	}
+
+	if _, err := client.Complete(context.Background(), &CompletionRequest{Prompt: "Hi"}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if _, ok := body["user"]; ok {
+		t.Errorf("user sent without being set: %v", body["user"])
+	}
+	if _, ok := body["metadata"]; ok {
+		t.Errorf("metadata sent without being set: %v", body["metadata"])
+	}
+}
+
+func TestOpenAIClient_Endpoint(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.RequestURI())
+		if strings.HasSuffix(r.URL.Path, "/models") {
+			w.Write([]byte(`{"data":[]}`))
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		config     OpenAIConfig
+		wantChat   string
+		wantModels string
+	}{
+		{
+			name:       "default",
+			config:     OpenAIConfig{BaseURL: server.URL + "/v1/"},
+			wantChat:   "/v1/chat/completions",
+			wantModels: "/v1/models",
+		},
+		{
+			name: "gateway route",
+			config: OpenAIConfig{
+				BaseURL:             server.URL + "/openai/deployments/gpt-4o",
+				ChatCompletionsPath: "chat/completions",
+				QueryParams:         map[string]string{"api-version": "2024-10-21"},
+			},
+			wantChat:   "/openai/deployments/gpt-4o/chat/completions?api-version=2024-10-21",
+			wantModels: "/openai/deployments/gpt-4o/models?api-version=2024-10-21",
+		},
+		{
+			name: "versioned path",
+			config: OpenAIConfig{
+				BaseURL:             server.URL,
+				ChatCompletionsPath: "/v2/chat",
+				QueryParams:         map[string]string{"tenant": "a b", "region": "eu"},
+			},
+			wantChat:   "/v2/chat?region=eu&tenant=a+b",
+			wantModels: "/models?region=eu&tenant=a+b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requested = nil
+			tt.config.DefaultModel = "gpt-4o"
+			client := NewOpenAIClient(tt.config)
+
+			if _, err := client.Complete(context.Background(), &CompletionRequest{Prompt: "Hi"}); err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if _, err := client.ListModels(context.Background()); err != nil {
+				t.Fatalf("ListModels() error = %v", err)
+			}
+			if len(requested) != 2 || requested[0] != tt.wantChat || requested[1] != tt.wantModels {
+				t.Errorf("requested %q, want %q and %q", requested, tt.wantChat, tt.wantModels)
+			}
+		})
+	}
+}
+
+func TestOpenAIClient_ToolChoice(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient(OpenAIConfig{BaseURL: server.URL, DefaultModel: "gpt-4o"})
+	tools := []Tool{{Type: "function", Function: Function{Name: "get_weather"}}}
+
+	tests := []struct {
+		name   string
+		tools  []Tool
+		choice string
+		want   any
+	}{
+		{name: "default", tools: tools, want: "auto"},
+		{name: "auto", tools: tools, choice: ToolChoiceAuto, want: "auto"},
+		{name: "none", tools: tools, choice: ToolChoiceNone, want: "none"},
+		{name: "required", tools: tools, choice: ToolChoiceRequired, want: "required"},
+		{
+			name:   "function",
+			tools:  tools,
+			choice: "get_weather",
+			want:   map[string]any{"type": "function", "function": map[string]any{"name": "get_weather"}},
+		},
+		{name: "no tools", choice: ToolChoiceRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &CompletionRequest{Prompt: "Hi", Tools: tt.tools, ToolChoice: tt.choice}
+			if _, err := client.Complete(context.Background(), req); err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if !reflect.DeepEqual(body["tool_choice"], tt.want) {
+				t.Errorf("tool_choice = %v, want %v", body["tool_choice"], tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIStream_ToolCalls(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []string
+		want   map[int][]string
+	}{
+		{
+			name: "fragments by index",
+			events: []string{
+				`{"id":"1","choices":[{"delta":{"content":"Checking."}}]}`,
+				`{"id":"1","choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+				`{"id":"1","choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"location\":"}}]}}]}`,
+				`{"id":"1","choices":[{"delta":{"tool_calls":[{"index":1,"id":"call_2","function":{"name":"get_time","arguments":"{}"}}]}}]}`,
+				`{"id":"1","choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":" \"Paris\"}"}}]}}]}`,
+				`{"id":"1","choices":[{"delta":{},"finish_reason":"tool_calls"}]}`,
+			},
+			want: map[int][]string{5: {`call_1 get_weather {"location": "Paris"}`, `call_2 get_time {}`}},
+		},
+		{
+			name: "whole calls without index",
+			events: []string{
+				`{"id":"1","choices":[{"delta":{"tool_calls":[{"id":"call_1","function":{"name":"a","arguments":"{}"}},{"id":"call_2","function":{"name":"b","arguments":"{}"}}]},"finish_reason":"tool_calls"}]}`,
+			},
+			want: map[int][]string{0: {"call_1 a {}", "call_2 b {}"}},
+		},
+		{
+			name: "no finish reason",
+			events: []string{
+				`{"id":"1","choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"a","arguments":"{\"x\""}}]}}]}`,
+				`{"id":"1","choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":":1}"}}]}}]}`,
+			},
+			want: map[int][]string{2: {`call_1 a {"x":1}`}},
+		},
+		{
+			name: "choices",
+			events: []string{
+				`{"id":"1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"a","arguments":"{"}}]}},{"index":1,"delta":{"tool_calls":[{"index":0,"id":"call_2","function":{"name":"b","arguments":"{}"}}]},"finish_reason":"tool_calls"}]}`,
+				`{"id":"1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"}"}}]},"finish_reason":"tool_calls"}]}`,
+			},
+			want: map[int][]string{0: {"call_2 b {}"}, 1: {"call_1 a {}"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data strings.Builder
+			for _, event := range tt.events {
+				data.WriteString("data: " + event + "\n\n")
+			}
+			data.WriteString("data: [DONE]\n\n")
+
+			stream := &openAIStream{
+				reader: bufio.NewReader(strings.NewReader(data.String())),
+				closer: io.NopCloser(nil),
+				model:  "gpt-4",
+			}
+
+			got := make(map[int][]string)
+			for i := 0; ; i++ {
+				chunk, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Recv() error = %v", err)
+				}
+				if chunk.ID != "1" {
+					t.Errorf("chunk %d ID = %q, want 1", i, chunk.ID)
+				}
+				for _, choice := range chunk.Choices {
+					for _, call := range choice.ToolCalls {
+						got[i] = append(got[i], call.ID+" "+call.Function.Name+" "+call.Function.Arguments)
+					}
+				}
+				if len(chunk.Choices) > 0 && !reflect.DeepEqual(chunk.ToolCalls, chunk.Choices[0].ToolCalls) {
+					t.Errorf("chunk %d ToolCalls = %+v, want those of the first choice", i, chunk.ToolCalls)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tool calls by chunk = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
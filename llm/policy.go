@@ -0,0 +1,118 @@
+package llm
+
+import "context"
+
+const (
+	defaultMaxCheapTokens = 1000
+
+	// ModelTierOption is the CompletionRequest.Options key that overrides the
+	// ModelPolicy decision for a request, with the value "cheap" or "premium"
+	ModelTierOption = "model_tier"
+)
+
+// ModelPolicyConfig contains configuration for the ModelPolicy middleware
+type ModelPolicyConfig struct {
+	// CheapModel serves requests the policy considers simple
+	CheapModel string
+
+	// PremiumModel serves all other requests
+	PremiumModel string
+
+	// MaxCheapTokens is the largest estimated input, in tokens, still sent to
+	// the cheap model (optional, defaults to 1000)
+	MaxCheapTokens int
+
+	// CheapWithTools lets requests with tools use the cheap model. By default
+	// they always go to the premium model.
+	CheapWithTools bool
+
+	// CheapWithImages lets requests with image parts use the cheap model. By
+	// default they always go to the premium model.
+	CheapWithImages bool
+
+	// Premium reports further requests that need the premium model, for
+	// example based on their content (optional)
+	Premium func(req *CompletionRequest) bool
+
+	// Classifier is consulted for requests the heuristics above would send
+	// to the cheap model (optional). If it fails, the request stays on the
+	// cheap model.
+	Classifier ComplexityClassifier
+}
+
+// ModelPolicy returns a Middleware that picks the model for requests that do
+// not set one: short, simple prompts go to the cheap model and long or
+// complex ones to the premium model. Requests that set Model are left
+// unchanged, and the ModelTierOption option forces either tier.
+func ModelPolicy(config ModelPolicyConfig) Middleware {
+	if config.MaxCheapTokens <= 0 {
+		config.MaxCheapTokens = defaultMaxCheapTokens
+	}
+
+	return func(next LLMProvider) LLMProvider {
+		return &modelPolicyProvider{next: next, config: config}
+	}
+}
+
+type modelPolicyProvider struct {
+	next   LLMProvider
+	config ModelPolicyConfig
+}
+
+// Complete implements the LLMProvider interface
+func (p *modelPolicyProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	return p.next.Complete(ctx, p.route(ctx, req))
+}
+
+// CompleteStream implements the LLMProvider interface
+func (p *modelPolicyProvider) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
+	return p.next.CompleteStream(ctx, p.route(ctx, req))
+}
+
+// route returns req with the model chosen by the policy
+func (p *modelPolicyProvider) route(ctx context.Context, req *CompletionRequest) *CompletionRequest {
+	if req.Model != "" {
+		return req
+	}
+
+	r := *req
+	if p.premium(ctx, req) {
+		r.Model = p.config.PremiumModel
+	} else {
+		r.Model = p.config.CheapModel
+	}
+	return &r
+}
+
+func (p *modelPolicyProvider) premium(ctx context.Context, req *CompletionRequest) bool {
+	switch req.Options[ModelTierOption] {
+	case "cheap":
+		return false
+	case "premium":
+		return true
+	}
+
+	if len(req.Tools) > 0 && !p.config.CheapWithTools {
+		return true
+	}
+	if !p.config.CheapWithImages && hasImages(req.Messages) {
+		return true
+	}
+	if p.config.Premium != nil && p.config.Premium(req) {
+		return true
+	}
+
+	var tokens int
+	for _, msg := range req.messages() {
+		tokens += estimateMessageTokens(msg)
+	}
+	if tokens > p.config.MaxCheapTokens {
+		return true
+	}
+
+	if p.config.Classifier != nil {
+		isComplex, err := p.config.Classifier.IsComplex(ctx, req)
+		return err == nil && isComplex
+	}
+	return false
+}
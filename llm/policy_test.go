@@ -0,0 +1,122 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestModelPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ModelPolicyConfig
+		req    CompletionRequest
+		want   string
+	}{
+		{
+			name: "short prompt",
+			req:  CompletionRequest{Prompt: "What is 2+2?"},
+			want: "cheap",
+		},
+		{
+			name: "long prompt",
+			req:  CompletionRequest{Prompt: strings.Repeat("word ", 1000)},
+			want: "premium",
+		},
+		{
+			name: "long history",
+			req: CompletionRequest{
+				SystemPrompt: strings.Repeat("rule ", 400),
+				Messages:     []Message{{Role: RoleUser, Content: strings.Repeat("text ", 600)}},
+			},
+			want: "premium",
+		},
+		{
+			name:   "custom threshold",
+			config: ModelPolicyConfig{MaxCheapTokens: 2},
+			req:    CompletionRequest{Prompt: "What is 2+2?"},
+			want:   "premium",
+		},
+		{
+			name: "tools",
+			req:  CompletionRequest{Prompt: "Weather?", Tools: []Tool{{Type: "function"}}},
+			want: "premium",
+		},
+		{
+			name:   "cheap with tools",
+			config: ModelPolicyConfig{CheapWithTools: true},
+			req:    CompletionRequest{Prompt: "Weather?", Tools: []Tool{{Type: "function"}}},
+			want:   "cheap",
+		},
+		{
+			name: "long text parts",
+			req: CompletionRequest{Messages: []Message{
+				{Role: RoleUser, Parts: []ContentPart{TextPart(strings.Repeat("word ", 1000))}},
+			}},
+			want: "premium",
+		},
+		{
+			name: "images",
+			req: CompletionRequest{Messages: []Message{
+				{Role: RoleUser, Content: "What is this?", Parts: []ContentPart{ImagePart("https://example.com/a.png")}},
+			}},
+			want: "premium",
+		},
+		{
+			name:   "cheap with images",
+			config: ModelPolicyConfig{CheapWithImages: true},
+			req: CompletionRequest{Messages: []Message{
+				{Role: RoleUser, Content: "What is this?", Parts: []ContentPart{ImagePart("https://example.com/a.png")}},
+			}},
+			want: "cheap",
+		},
+		{
+			name: "custom rule",
+			config: ModelPolicyConfig{Premium: func(req *CompletionRequest) bool {
+				return strings.Contains(req.Prompt, "prove")
+			}},
+			req:  CompletionRequest{Prompt: "prove it"},
+			want: "premium",
+		},
+		{
+			name: "forced premium",
+			req:  CompletionRequest{Prompt: "Hi", Options: map[string]string{ModelTierOption: "premium"}},
+			want: "premium",
+		},
+		{
+			name: "forced cheap",
+			req: CompletionRequest{
+				Prompt:  strings.Repeat("word ", 1000),
+				Options: map[string]string{ModelTierOption: "cheap"},
+			},
+			want: "cheap",
+		},
+		{
+			name: "pinned model",
+			req:  CompletionRequest{Model: "pinned", Prompt: strings.Repeat("word ", 1000)},
+			want: "pinned",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.CheapModel = "cheap"
+			tt.config.PremiumModel = "premium"
+			mock := &mockProvider{}
+			provider := Chain(mock, ModelPolicy(tt.config))
+
+			resp, err := provider.Complete(context.Background(), &tt.req)
+			if err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if resp.Model != tt.want {
+				t.Errorf("Model = %v, want %v", resp.Model, tt.want)
+			}
+
+			provider.CompleteStream(context.Background(), &tt.req)
+			if got := mock.requests[1].Model; got != tt.want {
+				t.Errorf("stream Model = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
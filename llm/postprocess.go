@@ -0,0 +1,252 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PostProcessor transforms the content of a response
+type PostProcessor func(content string) string
+
+// PostProcess returns a Middleware that runs the content of every response,
+// and of each of its Choices, through processors in order. Streamed content
+// is processed as a whole: it is held back and released on the chunk that
+// finishes its choice, or at the end of the stream, so the reply arrives at
+// once. Use PostProcessChunks to keep streams incremental.
+func PostProcess(processors ...PostProcessor) Middleware {
+	return func(next LLMProvider) LLMProvider {
+		return &postProcessProvider{next: next, processors: processors}
+	}
+}
+
+// PostProcessChunks is PostProcess with every stream chunk run through
+// processors as it arrives. Processors only see one chunk at a time, so those
+// that match across chunk boundaries, such as MaskWords, may miss text that
+// is split between chunks, and those that trim, such as TrimSpace, alter the
+// text between chunks. Complete responses are processed as a whole.
+func PostProcessChunks(processors ...PostProcessor) Middleware {
+	return func(next LLMProvider) LLMProvider {
+		return &postProcessProvider{next: next, processors: processors, chunks: true}
+	}
+}
+
+type postProcessProvider struct {
+	next       LLMProvider
+	processors []PostProcessor
+	chunks     bool
+}
+
+// Complete implements the LLMProvider interface
+func (p *postProcessProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	resp, err := p.next.Complete(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	return p.process(resp), nil
+}
+
+// CompleteStream implements the LLMProvider interface
+func (p *postProcessProvider) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
+	stream, err := p.next.CompleteStream(ctx, req)
+	if err != nil {
+		return stream, err
+	}
+	return &postProcessStream{CompletionStream: stream, provider: p}, nil
+}
+
+// process returns a copy of resp with its content and the content of its
+// choices processed
+func (p *postProcessProvider) process(resp *CompletionResponse) *CompletionResponse {
+	processed := *resp
+	processed.Content = p.apply(resp.Content)
+	if resp.Choices != nil {
+		processed.Choices = make([]CompletionChoice, len(resp.Choices))
+		for i, choice := range resp.Choices {
+			choice.Content = p.apply(choice.Content)
+			processed.Choices[i] = choice
+		}
+	}
+	return &processed
+}
+
+func (p *postProcessProvider) apply(content string) string {
+	for _, process := range p.processors {
+		content = process(content)
+	}
+	return content
+}
+
+// postProcessStream processes the chunks of a stream, holding back the
+// content of each choice until it finishes unless the provider processes
+// chunks one by one
+type postProcessStream struct {
+	CompletionStream
+	provider *postProcessProvider
+
+	// content holds back the content of the response and choices the
+	// content of each choice by index
+	content strings.Builder
+	choices map[int]*strings.Builder
+	last    *CompletionResponse
+	done    bool
+}
+
+// Recv implements the CompletionStream interface
+func (s *postProcessStream) Recv() (*CompletionResponse, error) {
+	for {
+		if s.done {
+			return nil, io.EOF
+		}
+		resp, err := s.CompletionStream.Recv()
+		if err == io.EOF && !s.provider.chunks {
+			s.done = true
+			if chunk := s.rest(); chunk != nil {
+				return chunk, nil
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if s.provider.chunks {
+			return s.provider.process(resp), nil
+		}
+
+		s.last = resp
+		chunk := *resp
+		chunk.Content = s.hold(&s.content, resp.Content, resp.FinishReason != "")
+		chunk.Choices = nil
+		for _, choice := range resp.Choices {
+			choice.Content = s.hold(s.buffer(choice.Index), choice.Content, choice.FinishReason != "")
+			if choice.Content != "" || choice.FinishReason != "" || len(choice.ToolCalls) > 0 || choice.Reasoning != "" {
+				chunk.Choices = append(chunk.Choices, choice)
+			}
+		}
+		if chunk.Content != "" || hasPayload(&chunk) {
+			return &chunk, nil
+		}
+	}
+}
+
+// hold adds content to buffer and, once finished, returns the processed
+// content of the buffer
+func (s *postProcessStream) hold(buffer *strings.Builder, content string, finished bool) string {
+	buffer.WriteString(content)
+	if !finished || buffer.Len() == 0 {
+		return ""
+	}
+	processed := s.provider.apply(buffer.String())
+	buffer.Reset()
+	return processed
+}
+
+func (s *postProcessStream) buffer(index int) *strings.Builder {
+	if s.choices == nil {
+		s.choices = make(map[int]*strings.Builder)
+	}
+	buffer, ok := s.choices[index]
+	if !ok {
+		buffer = &strings.Builder{}
+		s.choices[index] = buffer
+	}
+	return buffer
+}
+
+// rest returns a chunk with the content still held back when the stream
+// ends, or nil if there is none
+func (s *postProcessStream) rest() *CompletionResponse {
+	if s.last == nil {
+		return nil
+	}
+	chunk := &CompletionResponse{
+		ID:                s.last.ID,
+		Model:             s.last.Model,
+		SystemFingerprint: s.last.SystemFingerprint,
+		Metadata:          s.last.Metadata,
+		Content:           s.hold(&s.content, "", true),
+	}
+
+	indexes := make([]int, 0, len(s.choices))
+	for index := range s.choices {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		if content := s.hold(s.choices[index], "", true); content != "" {
+			chunk.Choices = append(chunk.Choices, CompletionChoice{Index: index, Content: content})
+		}
+	}
+
+	if chunk.Content == "" && len(chunk.Choices) == 0 {
+		return nil
+	}
+	return chunk
+}
+
+// TrimSpace returns a PostProcessor that removes leading and trailing whitespace
+func TrimSpace() PostProcessor {
+	return strings.TrimSpace
+}
+
+var (
+	horizontalSpace = regexp.MustCompile(`[ \t]+`)
+	blankLines      = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+\n`)
+)
+
+// CollapseWhitespace returns a PostProcessor that replaces runs of spaces and
+// tabs with a single space and runs of blank lines with a single blank line
+func CollapseWhitespace() PostProcessor {
+	return func(content string) string {
+		content = blankLines.ReplaceAllString(content, "\n\n")
+		return horizontalSpace.ReplaceAllString(content, " ")
+	}
+}
+
+// StripCodeFences returns a PostProcessor that unwraps content consisting of
+// a single fenced code block, as models often return for JSON or code even
+// when asked not to. Content with text outside the fence is left unchanged.
+func StripCodeFences() PostProcessor {
+	return func(content string) string {
+		trimmed := strings.TrimSpace(content)
+		if !strings.HasPrefix(trimmed, "```") || !strings.HasSuffix(trimmed, "```") || len(trimmed) < 6 {
+			return content
+		}
+
+		inner := trimmed[3 : len(trimmed)-3]
+		newline := strings.IndexByte(inner, '\n')
+		if newline < 0 {
+			return content
+		}
+
+		// The opening fence line may name a language; the body must not
+		// contain further fences
+		body := inner[newline+1:]
+		if strings.Contains(body, "```") {
+			return content
+		}
+		return strings.TrimSuffix(body, "\n")
+	}
+}
+
+// MaskWords returns a PostProcessor that replaces every whole-word,
+// case-insensitive occurrence of words with asterisks of the same length,
+// for example to mask profanity
+func MaskWords(words ...string) PostProcessor {
+	if len(words) == 0 {
+		return func(content string) string { return content }
+	}
+
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = regexp.QuoteMeta(word)
+	}
+	pattern := regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+
+	return func(content string) string {
+		return pattern.ReplaceAllStringFunc(content, func(match string) string {
+			return strings.Repeat("*", len([]rune(match)))
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestPostProcessors(t *testing.T) {
+	tests := []struct {
+		name      string
+		processor PostProcessor
+		input     string
+		want      string
+	}{
+		{
+			name:      "trim space",
+			processor: TrimSpace(),
+			input:     "  \n Hello \n",
+			want:      "Hello",
+		},
+		{
+			name:      "collapse whitespace",
+			processor: CollapseWhitespace(),
+			input:     "Hello  \t world\n\n\n\nBye",
+			want:      "Hello world\n\nBye",
+		},
+		{
+			name:      "collapse keeps single blank line",
+			processor: CollapseWhitespace(),
+			input:     "a\n\nb\nc",
+			want:      "a\n\nb\nc",
+		},
+		{
+			name:      "strip fence with language",
+			processor: StripCodeFences(),
+			input:     "```json\n{\"a\": 1}\n```\n",
+			want:      `{"a": 1}`,
+		},
+		{
+			name:      "strip bare fence",
+			processor: StripCodeFences(),
+			input:     "```\nfmt.Println()\n```",
+			want:      "fmt.Println()",
+		},
+		{
+			name:      "text around fence kept",
+			processor: StripCodeFences(),
+			input:     "Here you go:\n```json\n{}\n```",
+			want:      "Here you go:\n```json\n{}\n```",
+		},
+		{
+			name:      "multiple fences kept",
+			processor: StripCodeFences(),
+			input:     "```\na\n```\n\n```\nb\n```",
+			want:      "```\na\n```\n\n```\nb\n```",
+		},
+		{
+			name:      "mask words",
+			processor: MaskWords("darn", "heck"),
+			input:     "Darn it, what the heck? Darnation!",
+			want:      "**** it, what the ****? Darnation!",
+		},
+		{
+			name:      "mask nothing",
+			processor: MaskWords(),
+			input:     "darn",
+			want:      "darn",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.processor(tt.input); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostProcess(t *testing.T) {
+	mock := &mockProvider{
+		complete: func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+			return &CompletionResponse{Content: "  ```\nHello   world\n```  "}, nil
+		},
+	}
+	provider := Chain(mock, PostProcess(StripCodeFences(), CollapseWhitespace()))
+
+	resp, err := provider.Complete(context.Background(), &CompletionRequest{Prompt: "Hi"})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Content != "Hello world" {
+		t.Errorf("Content = %q, want %q", resp.Content, "Hello world")
+	}
+}
+
+func TestPostProcess_Choices(t *testing.T) {
+	mock := &mockProvider{
+		complete: func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+			return &CompletionResponse{
+				Content: " a ",
+				Choices: []CompletionChoice{{Index: 0, Content: " a "}, {Index: 1, Content: " b "}},
+			}, nil
+		},
+	}
+
+	for _, middleware := range []Middleware{PostProcess(TrimSpace()), PostProcessChunks(TrimSpace())} {
+		resp, err := Chain(mock, middleware).Complete(context.Background(), &CompletionRequest{Prompt: "Hi", N: 2})
+		if err != nil {
+			t.Fatalf("Complete() error = %v", err)
+		}
+		if resp.Content != "a" || resp.Choices[0].Content != "a" || resp.Choices[1].Content != "b" {
+			t.Errorf("response = %+v, want every choice processed", resp)
+		}
+	}
+}
+
+func TestPostProcess_Stream(t *testing.T) {
+	upstream := &streamProvider{chunks: []*CompletionResponse{
+		{Content: "  Hello", Choices: []CompletionChoice{{Index: 0, Content: "  Hello"}, {Index: 1, Content: " Bye"}}},
+		{Content: " world  ", Choices: []CompletionChoice{{Index: 0, Content: " world  "}}},
+		{FinishReason: "stop", Choices: []CompletionChoice{{Index: 0, FinishReason: "stop"}}},
+		{Choices: []CompletionChoice{{Index: 1, Content: " now "}}},
+	}}
+	provider := Chain(upstream, PostProcess(TrimSpace()))
+
+	stream, err := provider.CompleteStream(context.Background(), &CompletionRequest{Prompt: "Hi", N: 2})
+	if err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	var chunks []*CompletionResponse
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	want := []*CompletionResponse{
+		{Content: "Hello world", FinishReason: "stop", Choices: []CompletionChoice{{Index: 0, Content: "Hello world", FinishReason: "stop"}}},
+		{Choices: []CompletionChoice{{Index: 1, Content: "Bye now"}}},
+	}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Errorf("chunks = %+v, want %+v", chunks, want)
+	}
+}
+
+func TestPostProcessChunks(t *testing.T) {
+	upstream := &streamProvider{chunks: []*CompletionResponse{
+		{Content: "Oh  darn"},
+		{Content: " it"},
+	}}
+	provider := Chain(upstream, PostProcessChunks(MaskWords("darn"), CollapseWhitespace()))
+
+	stream, err := provider.CompleteStream(context.Background(), &CompletionRequest{Prompt: "Hi"})
+	if err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	var content string
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		content += chunk.Content
+	}
+	if content != "Oh **** it" {
+		t.Errorf("content = %q, want %q", content, "Oh **** it")
+	}
+	if upstream.chunks[0].Content != "Oh  darn" {
+		t.Errorf("upstream chunk modified to %q", upstream.chunks[0].Content)
+	}
+}
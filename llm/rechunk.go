@@ -0,0 +1,295 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Segmentation is the unit Rechunk aligns stream chunks to
+type Segmentation int
+
+const (
+	// Words emits one chunk per word, including the whitespace that follows
+	// it. Chinese and Japanese text is split per character.
+	Words Segmentation = iota
+
+	// Sentences emits one chunk per sentence, including the whitespace that
+	// follows it. Line breaks also end sentences.
+	Sentences
+)
+
+// RechunkConfig contains configuration for the Rechunk middleware
+type RechunkConfig struct {
+	// Segmentation selects words or sentences (optional, defaults to Words)
+	Segmentation Segmentation
+
+	// Language is the ISO 639-1 code of the language of the reply, which
+	// selects the abbreviations that do not end a sentence, such as "Dr."
+	// or "z.B." (optional, defaults to the abbreviations of all supported
+	// languages: English, German, French, Spanish, Italian, Portuguese and
+	// Dutch)
+	Language string
+}
+
+// Rechunk returns a Middleware that re-chunks streams so that every chunk
+// holds exactly one word or sentence, as text-to-speech and subtitles need,
+// instead of arbitrary token deltas. Text is held back until its unit is
+// complete; the end of the stream or a chunk with a FinishReason releases
+// the rest. Fields other than the content, such as FinishReason and
+// ToolCalls, are kept on the chunk for the last unit of the delta that
+// carried them, or on a chunk without content. Choices are passed through
+// as they are, so the middleware is meant for single-choice streams.
+// Complete responses are passed through unchanged.
+func Rechunk(config RechunkConfig) Middleware {
+	return func(next LLMProvider) LLMProvider {
+		return &rechunkProvider{next: next, config: config}
+	}
+}
+
+// RechunkStream re-chunks stream as the Rechunk middleware does, for
+// streams obtained without a middleware chain
+func RechunkStream(stream CompletionStream, config RechunkConfig) CompletionStream {
+	return &rechunkStream{CompletionStream: stream, config: config}
+}
+
+type rechunkProvider struct {
+	next   LLMProvider
+	config RechunkConfig
+}
+
+// Complete implements the LLMProvider interface
+func (p *rechunkProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	return p.next.Complete(ctx, req)
+}
+
+// CompleteStream implements the LLMProvider interface
+func (p *rechunkProvider) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
+	stream, err := p.next.CompleteStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return RechunkStream(stream, p.config), nil
+}
+
+type rechunkStream struct {
+	CompletionStream
+	config RechunkConfig
+
+	// buffer holds the text of the unit in progress
+	buffer  string
+	last    *CompletionResponse
+	pending []*CompletionResponse
+	err     error
+}
+
+// Recv implements the CompletionStream interface
+func (s *rechunkStream) Recv() (*CompletionResponse, error) {
+	for len(s.pending) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+
+		resp, err := s.CompletionStream.Recv()
+		if err != nil {
+			s.err = err
+			if err == io.EOF && s.buffer != "" {
+				s.pending = append(s.pending, s.unit(s.buffer))
+				s.buffer = ""
+			}
+			continue
+		}
+		s.last = resp
+
+		var units []string
+		units, s.buffer = segment(s.buffer+resp.Content, s.config, resp.FinishReason != "")
+		for _, unit := range units[:max(len(units)-1, 0)] {
+			s.pending = append(s.pending, s.unit(unit))
+		}
+
+		// The last unit carries the other fields of the delta
+		chunk := *resp
+		chunk.Content = ""
+		if len(units) > 0 {
+			chunk.Content = units[len(units)-1]
+		}
+		if chunk.Content != "" || hasPayload(&chunk) {
+			s.pending = append(s.pending, &chunk)
+		}
+	}
+
+	chunk := s.pending[0]
+	s.pending = s.pending[1:]
+	return chunk, nil
+}
+
+// unit returns a chunk with content and the identifying fields of the
+// latest delta
+func (s *rechunkStream) unit(content string) *CompletionResponse {
+	return &CompletionResponse{
+		ID:                s.last.ID,
+		Model:             s.last.Model,
+		SystemFingerprint: s.last.SystemFingerprint,
+		Metadata:          s.last.Metadata,
+		Content:           content,
+	}
+}
+
+// hasPayload reports whether a chunk carries more than its content and
+// identifying fields
+func hasPayload(resp *CompletionResponse) bool {
+	return resp.FinishReason != "" || len(resp.ToolCalls) > 0 || resp.Reasoning != "" || len(resp.Thinking) > 0 ||
+		len(resp.Choices) > 0 || len(resp.Raw) > 0
+}
+
+// segment splits text into complete units and the rest, which may still be
+// continued; when final, the rest is returned as the last unit
+func segment(text string, config RechunkConfig, final bool) (units []string, rest string) {
+	var cuts []int
+	if config.Segmentation == Sentences {
+		cuts = sentenceCuts(text, config.Language)
+	} else {
+		cuts = wordCuts(text)
+	}
+
+	start := 0
+	for _, cut := range cuts {
+		if cut > start {
+			units = append(units, text[start:cut])
+			start = cut
+		}
+	}
+	if final && start < len(text) {
+		units = append(units, text[start:])
+		start = len(text)
+	}
+	return units, text[start:]
+}
+
+// wordCuts returns the offsets at which words of text end: after the first
+// whitespace following a word, and before every Chinese or Japanese
+// character, which are not separated by spaces
+func wordCuts(text string) []int {
+	var cuts []int
+	inWord := false
+	for i, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			if inWord {
+				cuts = append(cuts, i+utf8.RuneLen(r))
+			}
+			inWord = false
+		case isCJK(r):
+			if inWord {
+				cuts = append(cuts, i)
+			}
+			inWord = true
+		default:
+			inWord = true
+		}
+	}
+	return cuts
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+const (
+	// sentenceTerminals end a sentence when followed by whitespace
+	sentenceTerminals = ".!?…؟।"
+
+	// fullWidthTerminals end a sentence without whitespace
+	fullWidthTerminals = "。！？"
+
+	// sentenceClosers may follow a terminal within the sentence
+	sentenceClosers = "\"')]»”’」』）"
+)
+
+// sentenceCuts returns the offsets at which sentences of text end. A
+// sentence ends after a line break, or after terminal punctuation and any
+// closing quotes or brackets once the whitespace that follows is known,
+// unless the period belongs to an abbreviation or an initial.
+func sentenceCuts(text, language string) []int {
+	var cuts []int
+	for i, r := range text {
+		end := i + utf8.RuneLen(r)
+		switch {
+		case r == '\n':
+			cuts = append(cuts, end)
+
+		case strings.ContainsRune(fullWidthTerminals, r):
+			end = skipClosers(text, end)
+			if end < len(text) {
+				cuts = append(cuts, end)
+			}
+
+		case strings.ContainsRune(sentenceTerminals, r):
+			end = skipClosers(text, end)
+			next, size := utf8.DecodeRuneInString(text[end:])
+			if end == len(text) || !unicode.IsSpace(next) || next == '\n' {
+				continue
+			}
+			if r == '.' && isAbbreviation(text[:i], language) {
+				continue
+			}
+			cuts = append(cuts, end+size)
+		}
+	}
+	return cuts
+}
+
+func skipClosers(text string, i int) int {
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !strings.ContainsRune(sentenceClosers, r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// isAbbreviation reports whether the word at the end of text, which is
+// followed by a period, is an abbreviation or a single-letter initial
+func isAbbreviation(text, language string) bool {
+	word := text[strings.LastIndexFunc(text, unicode.IsSpace)+1:]
+	word = strings.ToLower(strings.TrimLeft(word, "(\"'«“‘"))
+
+	if utf8.RuneCountInString(word) == 1 {
+		r, _ := utf8.DecodeRuneInString(word)
+		return unicode.IsLetter(r)
+	}
+
+	if language != "" {
+		return abbreviations[language][word]
+	}
+	for _, words := range abbreviations {
+		if words[word] {
+			return true
+		}
+	}
+	return false
+}
+
+// abbreviations are common abbreviations ending in a period, without it, by
+// ISO 639-1 code
+var abbreviations = map[string]map[string]bool{
+	"en": setOf("mr", "mrs", "ms", "dr", "prof", "sr", "jr", "st", "vs", "etc", "e.g", "i.e", "inc", "ltd", "co", "no", "fig", "approx", "jan", "feb", "mar", "apr", "aug", "sept", "oct", "nov", "dec"),
+	"de": setOf("z.b", "bzw", "usw", "dr", "prof", "nr", "ca", "vgl", "evtl", "ggf", "u.a", "d.h", "hr", "fr", "str", "inkl", "bzgl"),
+	"fr": setOf("mme", "mlle", "dr", "pr", "etc", "cf", "p.ex", "env", "av", "bd", "st", "ste"),
+	"es": setOf("sr", "sra", "srta", "dr", "dra", "etc", "p.ej", "ud", "uds", "pág", "núm", "av", "aprox"),
+	"it": setOf("sig", "sig.ra", "dott", "prof", "ecc", "es", "pag", "ca", "avv", "ing"),
+	"pt": setOf("sr", "sra", "dr", "dra", "etc", "ex", "pág", "av", "aprox", "nº"),
+	"nl": setOf("dhr", "mevr", "dr", "prof", "bijv", "enz", "o.a", "d.w.z", "blz", "ca", "nr"),
+}
+
+func setOf(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
@@ -0,0 +1,149 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// deltas returns stream chunks with the given contents
+func deltas(contents ...string) []*CompletionResponse {
+	chunks := make([]*CompletionResponse, len(contents))
+	for i, content := range contents {
+		chunks[i] = &CompletionResponse{ID: "resp-1", Model: "gpt-4", Content: content}
+	}
+	return chunks
+}
+
+func TestRechunk(t *testing.T) {
+	tests := []struct {
+		name   string
+		config RechunkConfig
+		chunks []*CompletionResponse
+		want   []string
+	}{
+		{
+			name:   "words",
+			chunks: deltas("Hel", "lo wo", "rld, how", " are", " you?"),
+			want:   []string{"Hello ", "world, ", "how ", "are ", "you?"},
+		},
+		{
+			name:   "words with extra whitespace",
+			chunks: deltas("One  two\n", "three"),
+			want:   []string{"One ", " two\n", "three"},
+		},
+		{
+			name:   "chinese characters",
+			chunks: deltas("你好", "，世界"),
+			want:   []string{"你", "好，", "世", "界"},
+		},
+		{
+			name:   "sentences",
+			config: RechunkConfig{Segmentation: Sentences},
+			chunks: deltas("It is sunny", ". Take a hat! Do you", " need more?", " Bye."),
+			want:   []string{"It is sunny. ", "Take a hat! ", "Do you need more? ", "Bye."},
+		},
+		{
+			name:   "abbreviations and initials",
+			config: RechunkConfig{Segmentation: Sentences, Language: "en"},
+			chunks: deltas("Dr. Smith met J. R. Doe, e.g. at noon. It went well."),
+			want:   []string{"Dr. Smith met J. R. Doe, e.g. at noon. ", "It went well."},
+		},
+		{
+			name:   "german abbreviation",
+			config: RechunkConfig{Segmentation: Sentences, Language: "de"},
+			chunks: deltas("Obst, z.B. Äpfel. Gemüse, ", "bzw. Salat."),
+			want:   []string{"Obst, z.B. Äpfel. ", "Gemüse, bzw. Salat."},
+		},
+		{
+			name:   "quotes, decimals and line breaks",
+			config: RechunkConfig{Segmentation: Sentences},
+			chunks: deltas(`He said "Pi is 3.14." Then`, " left.\nNext line"),
+			want:   []string{`He said "Pi is 3.14." `, "Then left.\n", "Next line"},
+		},
+		{
+			name:   "full-width punctuation",
+			config: RechunkConfig{Segmentation: Sentences},
+			chunks: deltas("今天天气很好。明天", "会下雨吗？」好"),
+			want:   []string{"今天天气很好。", "明天会下雨吗？」", "好"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := Chain(&streamProvider{chunks: tt.chunks}, Rechunk(tt.config))
+			stream, err := provider.CompleteStream(context.Background(), &CompletionRequest{Prompt: "Hi"})
+			if err != nil {
+				t.Fatalf("CompleteStream() error = %v", err)
+			}
+			defer stream.Close()
+
+			var got []string
+			for {
+				chunk, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Recv() error = %v", err)
+				}
+				if chunk.ID != "resp-1" || chunk.Model != "gpt-4" {
+					t.Errorf("chunk ID = %q, Model = %q, want the stream's", chunk.ID, chunk.Model)
+				}
+				got = append(got, chunk.Content)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("chunks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRechunk_Payload(t *testing.T) {
+	call := ToolCall{ID: "call_1"}
+	chunks := []*CompletionResponse{
+		{Content: "Let me check the"},
+		{Content: " weather for you", ToolCalls: []ToolCall{call}},
+		{Content: " now", FinishReason: "tool_calls"},
+		{FinishReason: "stop"},
+	}
+
+	stream := RechunkStream(&sliceStream{chunks: chunks}, RechunkConfig{})
+	var got []CompletionResponse
+	for {
+		chunk, err := stream.Recv()
+		if err != nil {
+			if err != io.EOF {
+				t.Fatalf("Recv() error = %v", err)
+			}
+			break
+		}
+		got = append(got, *chunk)
+	}
+
+	want := []CompletionResponse{
+		{Content: "Let "},
+		{Content: "me "},
+		{Content: "check "},
+		{Content: "the "},
+		{Content: "weather "},
+		{Content: "for ", ToolCalls: []ToolCall{call}},
+		{Content: "you "},
+		{Content: "now", FinishReason: "tool_calls"},
+		{FinishReason: "stop"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("chunks = %+v\nwant %+v", got, want)
+	}
+
+	// Concatenating the chunks yields the original text
+	var text strings.Builder
+	for _, chunk := range got {
+		text.WriteString(chunk.Content)
+	}
+	if text.String() != "Let me check the weather for you now" {
+		t.Errorf("text = %q", text.String())
+	}
+}
@@ -0,0 +1,170 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultOllamaBaseURL = "http://localhost:11434/v1"
+)
+
+// Config is the provider-independent configuration passed to New. Fields a
+// provider does not support are ignored unless noted otherwise.
+type Config struct {
+	// APIKey is the provider API key
+	APIKey string
+
+	// Credentials supplies the API key for each request in place of APIKey
+	// (optional)
+	Credentials CredentialProvider
+
+	// BaseURL overrides the provider's default API endpoint (optional)
+	BaseURL string
+
+	// Timeout is the timeout for API requests (optional, defaults to 30 seconds)
+	Timeout time.Duration
+
+	// HTTPClient is a custom HTTP client (optional)
+	HTTPClient *http.Client
+
+	// RetryConfig contains retry configuration (optional)
+	RetryConfig *RetryConfig
+
+	// DefaultModel is used for requests that do not set Model (optional,
+	// supported by Anthropic and the OpenAI-compatible providers)
+	DefaultModel string
+}
+
+// Factory creates a provider from a Config
+type Factory func(cfg Config) (LLMProvider, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{
+		"openai":     func(cfg Config) (LLMProvider, error) { return NewOpenAIClient(cfg.openAIConfig()), nil },
+		"anthropic":  newAnthropicFromConfig,
+		"cohere":     newCohereFromConfig,
+		"groq":       func(cfg Config) (LLMProvider, error) { return NewGroqClient(cfg.openAIConfig()), nil },
+		"together":   func(cfg Config) (LLMProvider, error) { return NewTogetherClient(cfg.openAIConfig()), nil },
+		"fireworks":  func(cfg Config) (LLMProvider, error) { return NewFireworksClient(cfg.openAIConfig()), nil },
+		"deepseek":   func(cfg Config) (LLMProvider, error) { return NewDeepSeekClient(cfg.openAIConfig()), nil },
+		"dashscope":  newDashScopeFromConfig,
+		"llamacpp":   newLlamaCppFromConfig,
+		"ollama":     newOllamaFromConfig,
+		"compatible": newCompatibleFromConfig,
+	}
+)
+
+// Register makes a provider available to New under name, replacing any
+// provider previously registered with that name. Names are case-insensitive.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(name)] = factory
+}
+
+// New creates the provider registered under name, so the backend can be
+// chosen from configuration at runtime. The built-in providers are "openai",
+// "anthropic", "cohere", "groq", "together", "fireworks", "deepseek",
+// "dashscope", "llamacpp", "ollama" (through its OpenAI-compatible API) and
+// "compatible" (any OpenAI-compatible server, BaseURL required).
+func New(name string, cfg Config) (LLMProvider, error) {
+	registryMu.RLock()
+	factory, ok := registry[strings.ToLower(name)]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q (available: %s)", name, strings.Join(Providers(), ", "))
+	}
+	return factory(cfg)
+}
+
+// Providers returns the sorted names of all registered providers
+func Providers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c Config) openAIConfig() OpenAIConfig {
+	return OpenAIConfig{
+		APIKey:       c.APIKey,
+		Credentials:  c.Credentials,
+		BaseURL:      c.BaseURL,
+		Timeout:      c.Timeout,
+		HTTPClient:   c.HTTPClient,
+		RetryConfig:  c.RetryConfig,
+		DefaultModel: c.DefaultModel,
+	}
+}
+
+func newAnthropicFromConfig(cfg Config) (LLMProvider, error) {
+	return NewAnthropicClientWithConfig(AnthropicConfig{
+		APIKey:       cfg.APIKey,
+		Credentials:  cfg.Credentials,
+		BaseURL:      cfg.BaseURL,
+		Timeout:      cfg.Timeout,
+		HTTPClient:   cfg.HTTPClient,
+		RetryConfig:  cfg.RetryConfig,
+		DefaultModel: cfg.DefaultModel,
+	}), nil
+}
+
+func newCohereFromConfig(cfg Config) (LLMProvider, error) {
+	return NewCohereClient(CohereConfig{
+		APIKey:      cfg.APIKey,
+		Credentials: cfg.Credentials,
+		BaseURL:     cfg.BaseURL,
+		Timeout:     cfg.Timeout,
+		HTTPClient:  cfg.HTTPClient,
+		RetryConfig: cfg.RetryConfig,
+	}), nil
+}
+
+func newDashScopeFromConfig(cfg Config) (LLMProvider, error) {
+	return NewDashScopeClient(DashScopeConfig{
+		APIKey:      cfg.APIKey,
+		Credentials: cfg.Credentials,
+		BaseURL:     cfg.BaseURL,
+		Timeout:     cfg.Timeout,
+		HTTPClient:  cfg.HTTPClient,
+		RetryConfig: cfg.RetryConfig,
+	}), nil
+}
+
+func newLlamaCppFromConfig(cfg Config) (LLMProvider, error) {
+	return NewLlamaCppClient(LlamaCppConfig{
+		APIKey:      cfg.APIKey,
+		Credentials: cfg.Credentials,
+		BaseURL:     cfg.BaseURL,
+		Timeout:     cfg.Timeout,
+		HTTPClient:  cfg.HTTPClient,
+		RetryConfig: cfg.RetryConfig,
+	}), nil
+}
+
+func newOllamaFromConfig(cfg Config) (LLMProvider, error) {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultOllamaBaseURL
+	}
+	return NewOpenAIClient(cfg.openAIConfig()), nil
+}
+
+func newCompatibleFromConfig(cfg Config) (LLMProvider, error) {
+	if cfg.BaseURL == "" {
+		return nil, errors.New("compatible: BaseURL is required")
+	}
+	return NewOpenAIClient(cfg.openAIConfig()), nil
+}
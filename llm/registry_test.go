@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		cfg      Config
+		wantType LLMProvider
+		wantErr  string
+	}{
+		{name: "openai", provider: "openai", wantType: &OpenAIClient{}},
+		{name: "case-insensitive", provider: "OpenAI", wantType: &OpenAIClient{}},
+		{name: "anthropic", provider: "anthropic", wantType: &AnthropicClient{}},
+		{name: "anthropic base URL", provider: "anthropic", cfg: Config{BaseURL: "http://localhost"}, wantType: &AnthropicClient{}},
+		{name: "cohere", provider: "cohere", wantType: &CohereClient{}},
+		{name: "groq", provider: "groq", wantType: &GroqClient{}},
+		{name: "together", provider: "together", wantType: &TogetherClient{}},
+		{name: "fireworks", provider: "fireworks", wantType: &FireworksClient{}},
+		{name: "deepseek", provider: "deepseek", wantType: &DeepSeekClient{}},
+		{name: "dashscope", provider: "dashscope", wantType: &DashScopeClient{}},
+		{name: "llamacpp", provider: "llamacpp", wantType: &LlamaCppClient{}},
+		{name: "ollama", provider: "ollama", wantType: &OpenAIClient{}},
+		{name: "compatible", provider: "compatible", cfg: Config{BaseURL: "http://localhost:8000/v1"}, wantType: &OpenAIClient{}},
+		{name: "compatible without base URL", provider: "compatible", wantErr: "BaseURL is required"},
+		{name: "unknown", provider: "nope", wantErr: `unknown provider "nope"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := New(tt.provider, tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("New() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if reflect.TypeOf(provider) != reflect.TypeOf(tt.wantType) {
+				t.Errorf("New() = %T, want %T", provider, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestNew_Ollama(t *testing.T) {
+	provider, err := New("ollama", Config{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := provider.(*OpenAIClient).config.BaseURL; got != defaultOllamaBaseURL {
+		t.Errorf("BaseURL = %v, want %v", got, defaultOllamaBaseURL)
+	}
+}
+
+func TestNew_Config(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Authorization = %v, want Bearer test-key", got)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	provider, err := New("openai", Config{APIKey: "test-key", BaseURL: server.URL, DefaultModel: "gpt-4"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	resp, err := provider.Complete(context.Background(), &CompletionRequest{Prompt: "Hi"})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Content != "ok" {
+		t.Errorf("Content = %v, want ok", resp.Content)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	mock := &mockProvider{}
+	Register("Mock", func(cfg Config) (LLMProvider, error) {
+		return mock, nil
+	})
+	defer func() {
+		registryMu.Lock()
+		delete(registry, "mock")
+		registryMu.Unlock()
+	}()
+
+	provider, err := New("mock", Config{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if provider != mock {
+		t.Errorf("New() = %v, want the registered provider", provider)
+	}
+
+	found := false
+	for _, name := range Providers() {
+		found = found || name == "mock"
+	}
+	if !found {
+		t.Errorf("Providers() = %v, want it to include mock", Providers())
+	}
+}
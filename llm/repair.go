@@ -0,0 +1,217 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aiwizzard/gollm/jsonschema"
+)
+
+const defaultRepairAttempts = 3
+
+// ErrSchemaValidation matches, through errors.Is, the *SchemaValidationError
+// returned when structured output still fails validation after all repair
+// attempts
+var ErrSchemaValidation = errors.New("structured output failed validation")
+
+// SchemaValidationError is returned by the RepairOutput middleware when no
+// attempt produced valid output
+type SchemaValidationError struct {
+	// Best is the response with the fewest validation problems
+	Best *CompletionResponse
+
+	// Problems are the validation problems of Best
+	Problems []string
+
+	// Attempts is the number of completions requested
+	Attempts int
+}
+
+func (e *SchemaValidationError) Error() string {
+	return fmt.Sprintf("%v after %d attempts: %s", ErrSchemaValidation, e.Attempts, strings.Join(e.Problems, "; "))
+}
+
+// Is reports whether target is ErrSchemaValidation
+func (e *SchemaValidationError) Is(target error) bool {
+	return target == ErrSchemaValidation
+}
+
+// OutputValidator checks the content of a structured response and returns
+// the problems found, phrased so the model can fix them, or none if the
+// content is valid
+type OutputValidator func(content string) []string
+
+// ValidJSON returns an OutputValidator that accepts content that decodes into
+// the value newTarget returns, such as a pointer to a struct, rejecting
+// fields the target does not have. A nil newTarget accepts any JSON. Missing
+// fields and invalid values decode without error, so use ValidSchema to
+// check them.
+func ValidJSON(newTarget func() any) OutputValidator {
+	return func(content string) []string {
+		var target any
+		if newTarget != nil {
+			target = newTarget()
+		} else {
+			target = new(any)
+		}
+
+		decoder := json.NewDecoder(strings.NewReader(StripCodeFences()(content)))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(target); err != nil {
+			return []string{err.Error()}
+		}
+		if decoder.More() {
+			return []string{"unexpected content after the JSON value"}
+		}
+		return nil
+	}
+}
+
+// ValidSchema returns an OutputValidator that accepts JSON content valid
+// against schema, given as raw JSON or any value encoding to a JSON schema;
+// see package jsonschema for the keywords checked. Problems are prefixed with
+// the JSONPath of the offending value, e.g. "$.age: must be an integer".
+func ValidSchema(schema any) OutputValidator {
+	return func(content string) []string {
+		decoder := json.NewDecoder(strings.NewReader(StripCodeFences()(content)))
+		decoder.UseNumber()
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			return []string{"invalid JSON: " + err.Error()}
+		}
+		if decoder.More() {
+			return []string{"unexpected content after the JSON value"}
+		}
+
+		found, err := jsonschema.Validate(schema, value)
+		if err != nil {
+			return []string{err.Error()}
+		}
+		var problems []string
+		for _, problem := range found {
+			path := "$"
+			if problem.Path != "" {
+				path += "." + problem.Path
+			}
+			problems = append(problems, path+": "+problem.Message)
+		}
+		return problems
+	}
+}
+
+// RepairConfig contains configuration for the RepairOutput middleware
+type RepairConfig struct {
+	// Validate checks each response (optional). Responses to requests with
+	// a JSON schema in the json_schema option, the structured output schema
+	// of providers such as Fireworks, are also checked against it, see
+	// ValidSchema.
+	Validate OutputValidator
+
+	// MaxAttempts is the total number of completions to request, including
+	// the first one (optional, defaults to 3)
+	MaxAttempts int
+}
+
+// RepairOutput returns a Middleware that validates every Complete response
+// and, while it is invalid, sends the output and its problems back to the
+// model to fix, up to MaxAttempts completions in total. If no attempt is
+// valid, it fails with a *SchemaValidationError holding the best attempt.
+// Requests with nothing to validate them against, and streams, are passed
+// through unchanged.
+func RepairOutput(config RepairConfig) Middleware {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultRepairAttempts
+	}
+
+	return func(next LLMProvider) LLMProvider {
+		return &repairProvider{next: next, config: config}
+	}
+}
+
+type repairProvider struct {
+	next   LLMProvider
+	config RepairConfig
+}
+
+// Complete implements the LLMProvider interface
+func (p *repairProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	validators, err := p.validators(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(validators) == 0 {
+		return p.next.Complete(ctx, req)
+	}
+
+	var best *CompletionResponse
+	var bestProblems []string
+
+	r := *req
+	for attempt := 1; attempt <= p.config.MaxAttempts; attempt++ {
+		resp, err := p.next.Complete(ctx, &r)
+		if err != nil {
+			return nil, err
+		}
+
+		var problems []string
+		for _, validate := range validators {
+			problems = append(problems, validate(resp.Content)...)
+		}
+		if len(problems) == 0 {
+			return resp, nil
+		}
+		if best == nil || len(problems) < len(bestProblems) {
+			best, bestProblems = resp, problems
+		}
+
+		// Continue the conversation with the invalid output and its
+		// problems; the system prompt stays where it is
+		conversation := r
+		conversation.SystemPrompt = ""
+		r.Messages = append(conversation.messages(),
+			Message{Role: RoleAssistant, Content: resp.Content},
+			Message{Role: RoleUser, Content: repairPrompt(problems)},
+		)
+		r.Prompt = ""
+	}
+
+	return nil, &SchemaValidationError{Best: best, Problems: bestProblems, Attempts: p.config.MaxAttempts}
+}
+
+// validators returns the validators of the responses to req: the request's
+// schema, if any, and the configured validator
+func (p *repairProvider) validators(req *CompletionRequest) ([]OutputValidator, error) {
+	var validators []OutputValidator
+	if schema := req.Options["json_schema"]; schema != "" {
+		// Reject an invalid schema up front rather than on every attempt
+		if _, err := jsonschema.Validate(json.RawMessage(schema), nil); err != nil {
+			return nil, fmt.Errorf("repair: json_schema option: %w", err)
+		}
+		validators = append(validators, ValidSchema(json.RawMessage(schema)))
+	}
+	if p.config.Validate != nil {
+		validators = append(validators, p.config.Validate)
+	}
+	return validators, nil
+}
+
+// CompleteStream implements the LLMProvider interface
+func (p *repairProvider) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
+	return p.next.CompleteStream(ctx, req)
+}
+
+// repairPrompt asks the model to fix problems in its previous output
+func repairPrompt(problems []string) string {
+	var b strings.Builder
+	b.WriteString("Your response failed validation:\n")
+	for _, problem := range problems {
+		b.WriteString("- ")
+		b.WriteString(problem)
+		b.WriteString("\n")
+	}
+	b.WriteString("Fix these problems and reply with the corrected output only.")
+	return b.String()
+}
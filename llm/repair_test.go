@@ -0,0 +1,243 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidJSON(t *testing.T) {
+	type answer struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	newAnswer := func() any { return new(answer) }
+
+	tests := []struct {
+		name      string
+		newTarget func() any
+		content   string
+		wantValid bool
+	}{
+		{name: "valid", newTarget: newAnswer, content: `{"name": "Ada", "age": 36}`, wantValid: true},
+		{name: "code fence", newTarget: newAnswer, content: "```json\n{\"name\": \"Ada\"}\n```", wantValid: true},
+		{name: "wrong type", newTarget: newAnswer, content: `{"name": "Ada", "age": "36"}`},
+		{name: "unknown field", newTarget: newAnswer, content: `{"name": "Ada", "email": "ada@example.com"}`},
+		{name: "trailing content", newTarget: newAnswer, content: `{"name": "Ada"} {"name": "Bob"}`},
+		{name: "not JSON", newTarget: newAnswer, content: "Sure! Here is the JSON you asked for."},
+		{name: "any JSON", content: `[1, 2, 3]`, wantValid: true},
+		{name: "any JSON invalid", content: `[1, 2,`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := ValidJSON(tt.newTarget)(tt.content)
+			if got := len(problems) == 0; got != tt.wantValid {
+				t.Errorf("ValidJSON() problems = %q, want valid %v", problems, tt.wantValid)
+			}
+		})
+	}
+}
+
+func TestValidSchema(t *testing.T) {
+	validate := ValidSchema(json.RawMessage(`{
+		"type": "object",
+		"required": ["name", "role"],
+		"properties": {
+			"name": {"type": "string"},
+			"role": {"enum": ["admin", "user"]},
+			"age": {"type": "integer"}
+		}
+	}`))
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{name: "valid", content: "```json\n{\"name\": \"Ada\", \"role\": \"admin\", \"age\": 36}\n```"},
+		{name: "missing required", content: `{"name": "Ada"}`, want: []string{"$.role: missing required property"}},
+		{name: "wrong enum", content: `{"name": "Ada", "role": "root", "age": 36.5}`, want: []string{
+			"$.age: must be an integer",
+			`$.role: must be one of "admin", "user"`,
+		}},
+		{name: "not JSON", content: "Sure!", want: []string{"invalid JSON: invalid character 'S' looking for beginning of value"}},
+		{name: "trailing content", content: `{"name": "Ada", "role": "user"} {}`, want: []string{"unexpected content after the JSON value"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validate(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidSchema() problems = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepairOutput_RequestSchema(t *testing.T) {
+	replies := []string{`{"name": "Ada"}`, `{"name": "Ada", "role": "admin"}`}
+	mock := &mockProvider{}
+	mock.complete = func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+		return &CompletionResponse{Content: replies[len(mock.requests)-1]}, nil
+	}
+	provider := Chain(mock, RepairOutput(RepairConfig{}))
+
+	schema := `{"type": "object", "required": ["name", "role"]}`
+	req := &CompletionRequest{Prompt: "Who are you?", Options: map[string]string{"json_schema": schema}}
+	resp, err := provider.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Content != replies[1] || len(mock.requests) != 2 {
+		t.Errorf("Content = %q after %d calls, want the repaired reply", resp.Content, len(mock.requests))
+	}
+	if repair := mock.requests[1].Messages; !strings.Contains(repair[len(repair)-1].Content, "$.role: missing required property") {
+		t.Errorf("repair prompt = %q, want the schema problems", repair[len(repair)-1].Content)
+	}
+
+	// Without a schema or a validator, requests are passed through
+	mock.requests = nil
+	if _, err := provider.Complete(context.Background(), &CompletionRequest{Prompt: "Hi"}); err != nil || len(mock.requests) != 1 {
+		t.Errorf("Complete() without schema = %v after %d calls", err, len(mock.requests))
+	}
+
+	req.Options = map[string]string{"json_schema": `{"type":`}
+	if _, err := provider.Complete(context.Background(), req); err == nil {
+		t.Error("Complete() with an invalid schema succeeded")
+	}
+}
+
+func TestRepairOutput(t *testing.T) {
+	validate := func(content string) []string {
+		var problems []string
+		for _, field := range []string{"name", "age"} {
+			if !strings.Contains(content, field) {
+				problems = append(problems, field+" is missing")
+			}
+		}
+		return problems
+	}
+
+	tests := []struct {
+		name         string
+		replies      []string
+		maxAttempts  int
+		wantContent  string
+		wantCalls    int
+		wantErr      bool
+		wantBest     string
+		wantProblems []string
+	}{
+		{
+			name:        "valid first time",
+			replies:     []string{"name age"},
+			wantContent: "name age",
+			wantCalls:   1,
+		},
+		{
+			name:        "repaired",
+			replies:     []string{"nothing", "name", "name age"},
+			wantContent: "name age",
+			wantCalls:   3,
+		},
+		{
+			name:         "attempts exhausted",
+			replies:      []string{"nothing", "name", "nothing"},
+			wantCalls:    3,
+			wantErr:      true,
+			wantBest:     "name",
+			wantProblems: []string{"age is missing"},
+		},
+		{
+			name:         "single attempt",
+			replies:      []string{"age", "name age"},
+			maxAttempts:  1,
+			wantCalls:    1,
+			wantErr:      true,
+			wantBest:     "age",
+			wantProblems: []string{"name is missing"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockProvider{}
+			mock.complete = func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+				return &CompletionResponse{Content: tt.replies[len(mock.requests)-1]}, nil
+			}
+			provider := Chain(mock, RepairOutput(RepairConfig{Validate: validate, MaxAttempts: tt.maxAttempts}))
+
+			req := &CompletionRequest{SystemPrompt: "Reply with a person.", Prompt: "Who invented the compiler?"}
+			resp, err := provider.Complete(context.Background(), req)
+			if len(mock.requests) != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", len(mock.requests), tt.wantCalls)
+			}
+
+			if tt.wantErr {
+				var validationErr *SchemaValidationError
+				if !errors.As(err, &validationErr) || !errors.Is(err, ErrSchemaValidation) {
+					t.Fatalf("Complete() error = %v, want SchemaValidationError", err)
+				}
+				if validationErr.Best.Content != tt.wantBest {
+					t.Errorf("Best = %q, want %q", validationErr.Best.Content, tt.wantBest)
+				}
+				if strings.Join(validationErr.Problems, ";") != strings.Join(tt.wantProblems, ";") {
+					t.Errorf("Problems = %q, want %q", validationErr.Problems, tt.wantProblems)
+				}
+				if validationErr.Attempts != tt.wantCalls {
+					t.Errorf("Attempts = %d, want %d", validationErr.Attempts, tt.wantCalls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if resp.Content != tt.wantContent {
+				t.Errorf("Content = %q, want %q", resp.Content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestRepairOutput_Conversation(t *testing.T) {
+	replies := []string{"{", `{"name": 1}`}
+	mock := &mockProvider{}
+	mock.complete = func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+		return &CompletionResponse{Content: replies[len(mock.requests)-1]}, nil
+	}
+	provider := Chain(mock, RepairOutput(RepairConfig{
+		Validate:    func(content string) []string { return []string{"bad " + content} },
+		MaxAttempts: 2,
+	}))
+
+	req := &CompletionRequest{SystemPrompt: "Reply in JSON.", Prompt: "Who are you?"}
+	if _, err := provider.Complete(context.Background(), req); !errors.Is(err, ErrSchemaValidation) {
+		t.Fatalf("Complete() error = %v, want ErrSchemaValidation", err)
+	}
+	if req.Prompt != "Who are you?" || len(req.Messages) != 0 {
+		t.Errorf("caller's request modified to %+v", req)
+	}
+
+	repair := mock.requests[1]
+	if repair.SystemPrompt != "Reply in JSON." || repair.Prompt != "" {
+		t.Errorf("repair request = %+v", repair)
+	}
+	wantRoles := []string{RoleUser, RoleAssistant, RoleUser}
+	if len(repair.Messages) != len(wantRoles) {
+		t.Fatalf("repair messages = %+v", repair.Messages)
+	}
+	for i, role := range wantRoles {
+		if repair.Messages[i].Role != role {
+			t.Errorf("message %d role = %q, want %q", i, repair.Messages[i].Role, role)
+		}
+	}
+	if repair.Messages[0].Content != "Who are you?" || repair.Messages[1].Content != "{" {
+		t.Errorf("repair messages = %+v", repair.Messages)
+	}
+	if !strings.Contains(repair.Messages[2].Content, "- bad {") {
+		t.Errorf("repair prompt = %q, want the validation problems", repair.Messages[2].Content)
+	}
+}
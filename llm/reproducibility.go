@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+)
+
+// Turn records one turn of a reproducible ChatSession: the exact request
+// sent and what identifies the backend that answered it
+type Turn struct {
+	// Request is the full request of the turn, including its seed and the
+	// history it was sent with
+	Request CompletionRequest
+
+	// Model is the model snapshot that answered, as reported by the
+	// provider; it is more specific than Request.Model when that is an alias
+	Model string
+
+	// SystemFingerprint identifies the backend configuration that answered
+	SystemFingerprint string
+
+	// Content is the reply of the turn
+	Content string
+
+	// Warnings explain why re-running the turn may not reproduce Content,
+	// for example because the provider reported no fingerprint to detect
+	// backend changes with. Providers without seed support, such as
+	// Anthropic, never report a fingerprint and are always flagged.
+	Warnings []string
+}
+
+// Deterministic reports whether nothing is known to prevent the turn from
+// being reproduced. Even then, providers only promise best-effort
+// determinism for a given seed and fingerprint.
+func (t Turn) Deterministic() bool {
+	return len(t.Warnings) == 0
+}
+
+// Reproducible makes the session send seed with every turn, unless its
+// defaults already set a Seed, and record each turn for Turns and Replay
+func (s *ChatSession) Reproducible(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.defaults.Seed == nil {
+		s.defaults.Seed = &seed
+	}
+	s.recording = true
+}
+
+// Turns returns the turns recorded since Reproducible was called
+func (s *ChatSession) Turns() []Turn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Turn(nil), s.turns...)
+}
+
+// record adds the turn for req answered by resp. The caller must hold s.mu.
+func (s *ChatSession) record(req *CompletionRequest, resp *CompletionResponse) {
+	if !s.recording {
+		return
+	}
+
+	var previous string
+	if len(s.turns) > 0 {
+		previous = s.turns[len(s.turns)-1].SystemFingerprint
+	}
+	s.turns = append(s.turns, newTurn(req, resp, previous))
+}
+
+// Replay sends the requests of turns to provider again, pinned to the model
+// snapshots that answered them, and returns the new turns. Each new turn
+// warns about a changed fingerprint or reply, so a replay surfaces where the
+// conversation did not reproduce.
+func Replay(ctx context.Context, provider LLMProvider, turns []Turn) ([]Turn, error) {
+	replayed := make([]Turn, 0, len(turns))
+	for i, turn := range turns {
+		req := turn.Request
+		if turn.Model != "" {
+			req.Model = turn.Model
+		}
+
+		resp, err := provider.Complete(ctx, &req)
+		if err != nil {
+			return replayed, fmt.Errorf("replay turn %d: %w", i+1, err)
+		}
+
+		next := newTurn(&req, resp, turn.SystemFingerprint)
+		if resp.Content != turn.Content {
+			next.Warnings = append(next.Warnings, "reply differs from the recorded turn")
+		}
+		replayed = append(replayed, next)
+	}
+	return replayed, nil
+}
+
+// newTurn records req and resp, warning when the fingerprint is missing or
+// differs from previous
+func newTurn(req *CompletionRequest, resp *CompletionResponse, previous string) Turn {
+	turn := Turn{
+		Request:           *req,
+		Model:             resp.Model,
+		SystemFingerprint: resp.SystemFingerprint,
+		Content:           resp.Content,
+	}
+	turn.Request.Messages = append([]Message(nil), req.Messages...)
+
+	switch {
+	case req.Seed == nil:
+		turn.Warnings = append(turn.Warnings, "request has no seed")
+	case resp.SystemFingerprint == "":
+		turn.Warnings = append(turn.Warnings, "provider reported no system fingerprint; seed support and backend changes cannot be verified")
+	case previous != "" && previous != resp.SystemFingerprint:
+		turn.Warnings = append(turn.Warnings, fmt.Sprintf("system fingerprint changed from %s to %s", previous, resp.SystemFingerprint))
+	}
+	return turn
+}
@@ -0,0 +1,138 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestChatSession_Reproducible(t *testing.T) {
+	fingerprints := []string{"fp_1", "fp_1", "fp_2", ""}
+	mock := &mockProvider{complete: func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+		turn := len(req.Messages) / 2
+		return &CompletionResponse{
+			Content:           "reply",
+			Model:             "gpt-4o-2024-08-06",
+			SystemFingerprint: fingerprints[turn],
+		}, nil
+	}}
+	session := NewChatSession(mock, CompletionRequest{Model: "gpt-4o", Temperature: temperature(0.7)})
+
+	// Turns before Reproducible are not recorded
+	if _, err := session.Send(context.Background(), "zero"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	session.Reproducible(42)
+	for _, text := range []string{"one", "two", "three"} {
+		if _, err := session.Send(context.Background(), text); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	if seed := mock.requests[1].Seed; seed == nil || *seed != 42 {
+		t.Errorf("Seed = %v, want 42", seed)
+	}
+
+	turns := session.Turns()
+	if len(turns) != 3 {
+		t.Fatalf("len(Turns()) = %d, want 3", len(turns))
+	}
+
+	first := turns[0]
+	if first.Model != "gpt-4o-2024-08-06" || first.SystemFingerprint != "fp_1" || !first.Deterministic() {
+		t.Errorf("first turn = %+v, want deterministic snapshot", first)
+	}
+	if first.Request.Temperature == nil || *first.Request.Temperature != 0.7 || len(first.Request.Messages) != 3 {
+		t.Errorf("first turn request = %+v, want full parameters and history", first.Request)
+	}
+	if turns[1].Deterministic() || !strings.Contains(turns[1].Warnings[0], "fp_1 to fp_2") {
+		t.Errorf("second turn warnings = %q, want fingerprint change", turns[1].Warnings)
+	}
+	if turns[2].Deterministic() || !strings.Contains(turns[2].Warnings[0], "no system fingerprint") {
+		t.Errorf("third turn warnings = %q, want missing fingerprint", turns[2].Warnings)
+	}
+
+	session.Reset()
+	if len(session.Turns()) != 0 {
+		t.Error("Turns() not empty after Reset")
+	}
+}
+
+func TestChatSession_ReproducibleKeepsSeed(t *testing.T) {
+	seed := int64(7)
+	mock := &mockProvider{}
+	session := NewChatSession(mock, CompletionRequest{Seed: &seed})
+	session.Reproducible(42)
+
+	session.Send(context.Background(), "Hi")
+	if got := *mock.requests[0].Seed; got != 7 {
+		t.Errorf("Seed = %d, want 7", got)
+	}
+}
+
+func TestChatSession_ReproducibleStream(t *testing.T) {
+	upstream := &streamProvider{chunks: []*CompletionResponse{
+		{Content: "Hel", Model: "gpt-4o-2024-08-06", SystemFingerprint: "fp_1"},
+		{Content: "lo"},
+	}}
+	session := NewChatSession(upstream, CompletionRequest{Model: "gpt-4o"})
+	session.Reproducible(1)
+
+	stream, err := session.SendStream(context.Background(), "Hi")
+	if err != nil {
+		t.Fatalf("SendStream() error = %v", err)
+	}
+	for {
+		if _, err := stream.Recv(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+	}
+	stream.Close()
+
+	turns := session.Turns()
+	if len(turns) != 1 {
+		t.Fatalf("len(Turns()) = %d, want 1", len(turns))
+	}
+	if turns[0].Content != "Hello" || turns[0].Model != "gpt-4o-2024-08-06" || turns[0].SystemFingerprint != "fp_1" {
+		t.Errorf("turn = %+v, want streamed reply and snapshot", turns[0])
+	}
+}
+
+func TestReplay(t *testing.T) {
+	seed := int64(42)
+	turns := []Turn{
+		{
+			Request:           CompletionRequest{Model: "gpt-4o", Prompt: "same", Seed: &seed},
+			Model:             "gpt-4o-2024-08-06",
+			SystemFingerprint: "fp_1",
+			Content:           "same",
+		},
+		{
+			Request:           CompletionRequest{Model: "gpt-4o", Prompt: "new", Seed: &seed},
+			Model:             "gpt-4o-2024-08-06",
+			SystemFingerprint: "fp_1",
+			Content:           "old",
+		},
+	}
+
+	mock := &mockProvider{complete: func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+		return &CompletionResponse{Content: req.Prompt, Model: req.Model, SystemFingerprint: "fp_1"}, nil
+	}}
+	replayed, err := Replay(context.Background(), mock, turns)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+
+	if mock.requests[0].Model != "gpt-4o-2024-08-06" {
+		t.Errorf("Model = %q, want pinned snapshot", mock.requests[0].Model)
+	}
+	if !replayed[0].Deterministic() {
+		t.Errorf("first replay warnings = %q, want none", replayed[0].Warnings)
+	}
+	if replayed[1].Deterministic() {
+		t.Error("second replay is deterministic, want reply mismatch")
+	}
+}
@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// RetryConfig contains configuration for retry behavior
+type RetryConfig struct {
+	// MaxRetries is the maximum number of retries (default: 3)
+	MaxRetries int
+
+	// InitialDelay is the initial delay between retries (default: 1s)
+	InitialDelay time.Duration
+
+	// MaxDelay is the maximum delay between retries (default: 5s)
+	MaxDelay time.Duration
+
+	// RetryableStatusCodes are the HTTP status codes that should trigger a retry
+	RetryableStatusCodes []int
+
+	// RespectRetryAfter uses the delay requested by the provider through the
+	// retry-after and rate-limit reset headers instead of exponential backoff
+	RespectRetryAfter bool
+}
+
+// defaultRetryConfig returns the retry configuration used when a client is
+// created without one
+func defaultRetryConfig() *RetryConfig {
+	return &RetryConfig{
+		MaxRetries:   3,
+		InitialDelay: time.Second,
+		MaxDelay:     5 * time.Second,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+		},
+	}
+}
+
+// retry calls fn until it succeeds, fails with a non-retryable error or the
+// configured number of retries is exhausted
+func retry(ctx context.Context, config *RetryConfig, fn func() error) error {
+	var lastErr error
+
+	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(config.delay(attempt, lastErr)):
+			}
+		}
+
+		lastErr = fn()
+		if lastErr == nil {
+			return nil
+		}
+
+		if !config.shouldRetry(lastErr) {
+			return lastErr
+		}
+	}
+
+	return fmt.Errorf("max retries exceeded: %w", lastErr)
+}
+
+func (r *RetryConfig) shouldRetry(err error) bool {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+
+	for _, code := range r.RetryableStatusCodes {
+		if httpErr.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RetryConfig) delay(attempt int, err error) time.Duration {
+	var httpErr *HTTPError
+	if r.RespectRetryAfter && errors.As(err, &httpErr) && httpErr.RetryAfter > 0 {
+		return httpErr.RetryAfter
+	}
+
+	delay := r.InitialDelay * time.Duration(1<<uint(attempt-1))
+	if delay > r.MaxDelay {
+		delay = r.MaxDelay
+	}
+	return delay
+}
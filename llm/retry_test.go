@@ -0,0 +1,104 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryConfig_Delay(t *testing.T) {
+	rateLimited := &HTTPError{
+		StatusCode: http.StatusTooManyRequests,
+		RetryAfter: 3 * time.Second,
+	}
+
+	tests := []struct {
+		name    string
+		config  RetryConfig
+		attempt int
+		err     error
+		want    time.Duration
+	}{
+		{
+			name:    "first retry uses initial delay",
+			config:  RetryConfig{InitialDelay: time.Second, MaxDelay: 5 * time.Second},
+			attempt: 1,
+			want:    time.Second,
+		},
+		{
+			name:    "exponential backoff",
+			config:  RetryConfig{InitialDelay: time.Second, MaxDelay: 5 * time.Second},
+			attempt: 3,
+			want:    4 * time.Second,
+		},
+		{
+			name:    "capped at max delay",
+			config:  RetryConfig{InitialDelay: time.Second, MaxDelay: 5 * time.Second},
+			attempt: 4,
+			want:    5 * time.Second,
+		},
+		{
+			name:    "retry-after ignored by default",
+			config:  RetryConfig{InitialDelay: time.Second, MaxDelay: 5 * time.Second},
+			attempt: 1,
+			err:     rateLimited,
+			want:    time.Second,
+		},
+		{
+			name:    "retry-after respected",
+			config:  RetryConfig{InitialDelay: time.Second, MaxDelay: 5 * time.Second, RespectRetryAfter: true},
+			attempt: 1,
+			err:     rateLimited,
+			want:    3 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.delay(tt.attempt, tt.err); got != tt.want {
+				t.Errorf("delay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	config := &RetryConfig{
+		MaxRetries:           2,
+		InitialDelay:         time.Millisecond,
+		MaxDelay:             time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+	}
+
+	t.Run("non-retryable error", func(t *testing.T) {
+		attempts := 0
+		wantErr := errors.New("boom")
+		err := retry(context.Background(), config, func() error {
+			attempts++
+			return wantErr
+		})
+		if !errors.Is(err, wantErr) {
+			t.Errorf("retry() error = %v, want %v", err, wantErr)
+		}
+		if attempts != 1 {
+			t.Errorf("Got %d attempts, want 1", attempts)
+		}
+	})
+
+	t.Run("max retries exceeded", func(t *testing.T) {
+		attempts := 0
+		err := retry(context.Background(), config, func() error {
+			attempts++
+			return &HTTPError{StatusCode: http.StatusServiceUnavailable}
+		})
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) {
+			t.Errorf("retry() error = %v, want wrapped *HTTPError", err)
+		}
+		if attempts != 3 {
+			t.Errorf("Got %d attempts, want 3", attempts)
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ChatSession holds a multi-turn conversation with a provider. Every turn
+// sends the full history and appends the assistant's reply to it, so callers
+// only supply the new user text and any tool results.
+//
+// A ChatSession is safe for concurrent use; turns are serialized so that the
+// history stays in order.
+type ChatSession struct {
+	provider LLMProvider
+	defaults CompletionRequest
+
+	mu       sync.Mutex
+	messages []Message
+
+	// recording and turns back Reproducible
+	recording bool
+	turns     []Turn
+}
+
+// NewChatSession creates a session on provider. The model, system prompt,
+// tools and sampling settings of defaults are used for every turn, and its
+// Messages seed the history. Its Prompt is ignored.
+func NewChatSession(provider LLMProvider, defaults CompletionRequest) *ChatSession {
+	messages := append([]Message(nil), defaults.Messages...)
+	defaults.Prompt = ""
+	defaults.Messages = nil
+
+	return &ChatSession{
+		provider: provider,
+		defaults: defaults,
+		messages: messages,
+	}
+}
+
+// Send adds text as a user message, completes the conversation and records
+// the reply. An empty text sends the history as is, for example to let the
+// model continue after AddToolResult. On error the history is left unchanged.
+func (s *ChatSession) Send(ctx context.Context, text string) (*CompletionResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req := s.request(text)
+	resp, err := s.provider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.record(req, resp)
+	s.messages = append(req.Messages, Message{
+		Role:      RoleAssistant,
+		Content:   resp.Content,
+		ToolCalls: resp.ToolCalls,
+		Thinking:  resp.Thinking,
+	})
+	return resp, nil
+}
+
+// SendStream is the streaming variant of Send. The reply is recorded once the
+// stream has been read to the end; a stream that fails or is closed early
+// leaves the history unchanged. The session is locked until the stream ends
+// or is closed, so the stream must always be closed.
+func (s *ChatSession) SendStream(ctx context.Context, text string) (CompletionStream, error) {
+	s.mu.Lock()
+
+	req := s.request(text)
+	stream, err := s.provider.CompleteStream(ctx, req)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	return &sessionStream{
+		CompletionStream: stream,
+		session:          s,
+		req:              req,
+	}, nil
+}
+
+// AddToolResult appends the result of the tool call with the given ID to the
+// history. Call Send with an empty text to hand the results to the model.
+func (s *ChatSession) AddToolResult(toolCallID, content string) {
+	s.AddMessages(Message{
+		Role:       RoleTool,
+		Content:    content,
+		ToolCallID: toolCallID,
+	})
+}
+
+// AddMessages appends messages to the history without sending them
+func (s *ChatSession) AddMessages(messages ...Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, messages...)
+}
+
+// Messages returns a copy of the conversation history
+func (s *ChatSession) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Reset clears the conversation history and the recorded turns
+func (s *ChatSession) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+	s.turns = nil
+}
+
+// request builds the request for the next turn. The caller must hold s.mu.
+func (s *ChatSession) request(text string) *CompletionRequest {
+	req := s.defaults
+	req.Messages = append([]Message(nil), s.messages...)
+	if text != "" {
+		req.Messages = append(req.Messages, Message{Role: RoleUser, Content: text})
+	}
+	return &req
+}
+
+// sessionStream accumulates a streamed reply and records it in the session
+// once the stream completes
+type sessionStream struct {
+	CompletionStream
+	session     *ChatSession
+	req         *CompletionRequest
+	content     strings.Builder
+	toolCalls   []ToolCall
+	thinking    []ThinkingBlock
+	model       string
+	fingerprint string
+	released    bool
+}
+
+// Recv implements the CompletionStream interface
+func (s *sessionStream) Recv() (*CompletionResponse, error) {
+	resp, err := s.CompletionStream.Recv()
+	if err == io.EOF && !s.released {
+		s.session.record(s.req, &CompletionResponse{
+			Content:           s.content.String(),
+			Model:             s.model,
+			SystemFingerprint: s.fingerprint,
+		})
+		s.session.messages = append(s.req.Messages, Message{
+			Role:      RoleAssistant,
+			Content:   s.content.String(),
+			ToolCalls: s.toolCalls,
+			Thinking:  s.thinking,
+		})
+		s.release()
+	}
+	if err != nil {
+		s.release()
+		return nil, err
+	}
+
+	s.content.WriteString(resp.Content)
+	s.toolCalls = append(s.toolCalls, resp.ToolCalls...)
+	s.thinking = append(s.thinking, resp.Thinking...)
+	if resp.Model != "" {
+		s.model = resp.Model
+	}
+	if resp.SystemFingerprint != "" {
+		s.fingerprint = resp.SystemFingerprint
+	}
+	return resp, nil
+}
+
+// Close implements the CompletionStream interface
+func (s *sessionStream) Close() error {
+	s.release()
+	return s.CompletionStream.Close()
+}
+
+func (s *sessionStream) release() {
+	if !s.released {
+		s.released = true
+		s.session.mu.Unlock()
+	}
+}
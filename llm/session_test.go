@@ -0,0 +1,181 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestChatSession_Send(t *testing.T) {
+	mock := &mockProvider{
+		complete: func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+			last := req.Messages[len(req.Messages)-1]
+			return &CompletionResponse{Content: "re: " + last.Content}, nil
+		},
+	}
+	session := NewChatSession(mock, CompletionRequest{
+		Model:    "gpt-4",
+		Prompt:   "ignored",
+		Messages: []Message{{Role: RoleSystem, Content: "Be brief"}},
+	})
+
+	if _, err := session.Send(context.Background(), "Hello"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	resp, err := session.Send(context.Background(), "Again")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if resp.Content != "re: Again" {
+		t.Errorf("Content = %v, want 're: Again'", resp.Content)
+	}
+
+	want := []Message{
+		{Role: RoleSystem, Content: "Be brief"},
+		{Role: RoleUser, Content: "Hello"},
+		{Role: RoleAssistant, Content: "re: Hello"},
+		{Role: RoleUser, Content: "Again"},
+		{Role: RoleAssistant, Content: "re: Again"},
+	}
+	if got := session.Messages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Messages() = %+v, want %+v", got, want)
+	}
+
+	second := mock.requests[1]
+	if second.Model != "gpt-4" || second.Prompt != "" {
+		t.Errorf("request Model = %q, Prompt = %q, want gpt-4 and no prompt", second.Model, second.Prompt)
+	}
+	if !reflect.DeepEqual(second.Messages, want[:4]) {
+		t.Errorf("request Messages = %+v, want %+v", second.Messages, want[:4])
+	}
+}
+
+func TestChatSession_SendError(t *testing.T) {
+	mock := &mockProvider{
+		complete: func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+			return nil, errors.New("upstream down")
+		},
+	}
+	session := NewChatSession(mock, CompletionRequest{Model: "gpt-4"})
+
+	if _, err := session.Send(context.Background(), "Hello"); err == nil {
+		t.Fatal("Send() error = nil, want error")
+	}
+	if got := session.Messages(); len(got) != 0 {
+		t.Errorf("Messages() = %+v, want empty history", got)
+	}
+}
+
+func TestChatSession_ToolResults(t *testing.T) {
+	var call ToolCall
+	call.ID = "call_1"
+	call.Type = "function"
+	call.Function.Name = "get_weather"
+	call.Function.Arguments = `{"location":"London"}`
+
+	mock := &mockProvider{
+		complete: func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+			if req.Messages[len(req.Messages)-1].Role == RoleTool {
+				return &CompletionResponse{Content: "It is sunny"}, nil
+			}
+			return &CompletionResponse{ToolCalls: []ToolCall{call}}, nil
+		},
+	}
+	session := NewChatSession(mock, CompletionRequest{Model: "gpt-4"})
+
+	resp, err := session.Send(context.Background(), "Weather in London?")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	session.AddToolResult(resp.ToolCalls[0].ID, "sunny")
+
+	resp, err = session.Send(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if resp.Content != "It is sunny" {
+		t.Errorf("Content = %v, want 'It is sunny'", resp.Content)
+	}
+
+	want := []Message{
+		{Role: RoleUser, Content: "Weather in London?"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{call}},
+		{Role: RoleTool, Content: "sunny", ToolCallID: "call_1"},
+		{Role: RoleAssistant, Content: "It is sunny"},
+	}
+	if got := session.Messages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Messages() = %+v, want %+v", got, want)
+	}
+}
+
+func TestChatSession_SendStream(t *testing.T) {
+	// Providers accumulate streamed tool calls and send each one whole
+	var london, paris ToolCall
+	london.ID = "call_1"
+	london.Function.Name = "get_weather"
+	london.Function.Arguments = `{"location":"London"}`
+	paris.ID = "call_2"
+	paris.Function.Name = "get_weather"
+	paris.Function.Arguments = `{"location":"Paris"}`
+
+	tests := []struct {
+		name        string
+		readAll     bool
+		wantHistory int
+	}{
+		{
+			name:        "reply recorded at end of stream",
+			readAll:     true,
+			wantHistory: 2,
+		},
+		{
+			name:        "closed early",
+			readAll:     false,
+			wantHistory: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := &streamProvider{chunks: []*CompletionResponse{
+				{Content: "Let me "},
+				{Content: "check."},
+				{ToolCalls: []ToolCall{london}},
+				{ToolCalls: []ToolCall{paris}, FinishReason: "tool_calls"},
+			}}
+			session := NewChatSession(upstream, CompletionRequest{Model: "gpt-4"})
+
+			stream, err := session.SendStream(context.Background(), "Weather in London?")
+			if err != nil {
+				t.Fatalf("SendStream() error = %v", err)
+			}
+			for tt.readAll {
+				if _, err := stream.Recv(); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("Recv() error = %v", err)
+				}
+			}
+			stream.Close()
+
+			history := session.Messages()
+			if len(history) != tt.wantHistory {
+				t.Fatalf("len(Messages()) = %d, want %d", len(history), tt.wantHistory)
+			}
+			if tt.readAll {
+				reply := history[1]
+				if reply.Content != "Let me check." {
+					t.Errorf("Content = %q, want 'Let me check.'", reply.Content)
+				}
+				if want := []ToolCall{london, paris}; !reflect.DeepEqual(reply.ToolCalls, want) {
+					t.Errorf("ToolCalls = %+v, want %+v", reply.ToolCalls, want)
+				}
+			}
+
+			// The session must be unlocked once the stream is closed
+			session.AddToolResult("call_1", "sunny")
+		})
+	}
+}
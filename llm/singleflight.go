@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// errFlightPanicked is returned to the callers waiting on an upstream call
+// that panicked; the caller that made it receives the panic
+var errFlightPanicked = errors.New("single flight: upstream call panicked")
+
+// SingleFlight returns a Middleware that coalesces identical concurrent
+// Complete calls into a single upstream request and hands every caller its
+// own copy of the result. It is meant for deterministic requests (for example
+// Temperature 0 on a cold cache), since all callers receive the same answer.
+// The upstream call runs with the context of the caller that started it, so
+// if that caller is cancelled the waiting callers receive the same error, and
+// if the call panics they receive an error while it panics.
+// Streaming requests are passed through unchanged.
+func SingleFlight() Middleware {
+	return func(next LLMProvider) LLMProvider {
+		return &singleFlightProvider{
+			next:  next,
+			calls: make(map[string]*flightCall),
+		}
+	}
+}
+
+type singleFlightProvider struct {
+	next LLMProvider
+
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done chan struct{}
+	resp *CompletionResponse
+	err  error
+}
+
+// Complete implements the LLMProvider interface
+func (p *singleFlightProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	key, err := json.Marshal(req)
+	if err != nil {
+		return p.next.Complete(ctx, req)
+	}
+
+	p.mu.Lock()
+	call, ok := p.calls[string(key)]
+	if !ok {
+		call = &flightCall{done: make(chan struct{}), err: errFlightPanicked}
+		p.calls[string(key)] = call
+		p.mu.Unlock()
+
+		func() {
+			defer func() {
+				p.mu.Lock()
+				delete(p.calls, string(key))
+				p.mu.Unlock()
+				close(call.done)
+			}()
+			call.resp, call.err = p.next.Complete(ctx, req)
+		}()
+	} else {
+		p.mu.Unlock()
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-call.done:
+	}
+
+	if call.err != nil {
+		return nil, call.err
+	}
+	return cloneResponse(call.resp), nil
+}
+
+// cloneResponse copies resp deeply enough that callers sharing a result can
+// modify their copy
+func cloneResponse(resp *CompletionResponse) *CompletionResponse {
+	clone := *resp
+	clone.ToolCalls = append([]ToolCall(nil), resp.ToolCalls...)
+	clone.Thinking = append([]ThinkingBlock(nil), resp.Thinking...)
+	clone.Raw = append(json.RawMessage(nil), resp.Raw...)
+	if resp.Choices != nil {
+		clone.Choices = make([]CompletionChoice, len(resp.Choices))
+		for i, choice := range resp.Choices {
+			choice.ToolCalls = append([]ToolCall(nil), choice.ToolCalls...)
+			clone.Choices[i] = choice
+		}
+	}
+	if resp.Metadata != nil {
+		metadata := *resp.Metadata
+		if metadata.Requests != nil {
+			requests := *metadata.Requests
+			metadata.Requests = &requests
+		}
+		if metadata.Tokens != nil {
+			tokens := *metadata.Tokens
+			metadata.Tokens = &tokens
+		}
+		clone.Metadata = &metadata
+	}
+	return &clone
+}
+
+// CompleteStream implements the LLMProvider interface
+func (p *singleFlightProvider) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
+	return p.next.CompleteStream(ctx, req)
+}
@@ -0,0 +1,187 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSingleFlight_CoalescesIdenticalRequests(t *testing.T) {
+	release := make(chan struct{})
+	provider := &mockProvider{
+		complete: func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+			<-release
+			return &CompletionResponse{Content: "shared"}, nil
+		},
+	}
+	client := Chain(provider, SingleFlight())
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make([]*CompletionResponse, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := client.Complete(context.Background(), &CompletionRequest{
+				Model:  "gpt-4",
+				Prompt: "Test prompt",
+			})
+			if err != nil {
+				t.Errorf("Complete() error = %v", err)
+				return
+			}
+			results[i] = resp
+		}(i)
+	}
+
+	// Give every caller time to join the in-flight request
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if provider.calls() != 1 {
+		t.Errorf("Got %d upstream calls, want 1", provider.calls())
+	}
+	for i, resp := range results {
+		if resp == nil || resp.Content != "shared" {
+			t.Fatalf("Result %d = %+v, want shared content", i, resp)
+		}
+		if i > 0 && resp == results[0] {
+			t.Error("Callers should receive their own copy of the response")
+		}
+	}
+}
+
+func TestSingleFlight_DistinctRequests(t *testing.T) {
+	provider := &mockProvider{}
+	client := Chain(provider, SingleFlight())
+
+	for _, prompt := range []string{"first", "second", "first"} {
+		resp, err := client.Complete(context.Background(), &CompletionRequest{Prompt: prompt})
+		if err != nil {
+			t.Fatalf("Complete() error = %v", err)
+		}
+		if resp.Content != prompt {
+			t.Errorf("Content = %v, want %v", resp.Content, prompt)
+		}
+	}
+
+	// Sequential calls are never coalesced
+	if provider.calls() != 3 {
+		t.Errorf("Got %d upstream calls, want 3", provider.calls())
+	}
+}
+
+func TestSingleFlight_SharesErrors(t *testing.T) {
+	wantErr := errors.New("upstream failure")
+	provider := &mockProvider{
+		complete: func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+			return nil, wantErr
+		},
+	}
+	client := Chain(provider, SingleFlight())
+
+	_, err := client.Complete(context.Background(), &CompletionRequest{Prompt: "Test prompt"})
+	if !errors.Is(err, wantErr) {
+		t.Errorf("Complete() error = %v, want %v", err, wantErr)
+	}
+}
+
+func TestSingleFlight_WaiterCancellation(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	provider := &mockProvider{
+		complete: func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+			<-release
+			return &CompletionResponse{Content: "late"}, nil
+		},
+	}
+	client := Chain(provider, SingleFlight())
+
+	go client.Complete(context.Background(), &CompletionRequest{Prompt: "Test prompt"})
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := client.Complete(ctx, &CompletionRequest{Prompt: "Test prompt"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Complete() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestSingleFlight_CopiesResponses(t *testing.T) {
+	call := ToolCall{ID: "call_1"}
+	call.Function.Arguments = `{"a":1}`
+	provider := &mockProvider{
+		complete: func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+			return &CompletionResponse{
+				ToolCalls: []ToolCall{call},
+				Choices:   []CompletionChoice{{Content: "shared", ToolCalls: []ToolCall{call}}},
+				Raw:       []byte(`{}`),
+				Metadata:  &ResponseMetadata{RequestID: "req_1", Tokens: &RateLimit{Remaining: 10}},
+			}, nil
+		},
+	}
+	client := &singleFlightProvider{next: provider, calls: make(map[string]*flightCall)}
+
+	resp, err := client.Complete(context.Background(), &CompletionRequest{Prompt: "Test prompt"})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	shared := cloneResponse(resp)
+	resp.ToolCalls[0].Function.Arguments = "changed"
+	resp.Choices[0].ToolCalls[0].ID = "changed"
+	resp.Choices[0].Content = "changed"
+	resp.Raw[0] = '['
+	resp.Metadata.Tokens.Remaining = 0
+
+	if !reflect.DeepEqual(shared.ToolCalls, []ToolCall{call}) || shared.Choices[0].ToolCalls[0].ID != "call_1" ||
+		shared.Choices[0].Content != "shared" || string(shared.Raw) != "{}" || shared.Metadata.Tokens.Remaining != 10 {
+		t.Errorf("copy = %+v, changed along with the original", shared)
+	}
+}
+
+func TestSingleFlight_Panic(t *testing.T) {
+	release := make(chan struct{})
+	var panicked sync.Once
+	provider := &mockProvider{
+		complete: func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+			panicked.Do(func() {
+				<-release
+				panic("upstream bug")
+			})
+			return &CompletionResponse{Content: "ok"}, nil
+		},
+	}
+	client := &singleFlightProvider{next: provider, calls: make(map[string]*flightCall)}
+	req := &CompletionRequest{Prompt: "Test prompt"}
+
+	done := make(chan any)
+	go func() {
+		defer func() { done <- recover() }()
+		client.Complete(context.Background(), req)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	waiter := make(chan error)
+	go func() {
+		_, err := client.Complete(context.Background(), req)
+		waiter <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	if recovered := <-done; recovered != "upstream bug" {
+		t.Errorf("leader recovered %v, want the panic", recovered)
+	}
+	if err := <-waiter; !errors.Is(err, errFlightPanicked) {
+		t.Errorf("waiter error = %v, want %v", err, errFlightPanicked)
+	}
+	if resp, err := client.Complete(context.Background(), req); err != nil || resp.Content != "ok" {
+		t.Errorf("Complete() after the panic = %+v, %v, want a new upstream call", resp, err)
+	}
+}
@@ -0,0 +1,121 @@
+package llm
+
+import (
+	"context"
+	"strings"
+)
+
+// SystemPromptConfig contains configuration for the InjectSystemPrompt
+// middleware
+type SystemPromptConfig struct {
+	// Prompt is the centrally managed system prompt, such as company policy
+	// or formatting rules
+	Prompt string
+
+	// Version identifies the prompt, for example "policy@v3" (optional). It
+	// is recorded as ResponseMetadata.SystemPromptVersion on the responses to
+	// requests sent with the prompt.
+	Version string
+
+	// Always injects the prompt ahead of the request's own system prompt.
+	// By default requests that bring their own system prompt are left
+	// unchanged.
+	Always bool
+}
+
+// InjectSystemPrompt returns a Middleware that adds the configured system
+// prompt to every request without one. A request that already contains the
+// prompt, for example because it was built from a history that went through
+// the middleware before, does not get it twice.
+func InjectSystemPrompt(config SystemPromptConfig) Middleware {
+	return func(next LLMProvider) LLMProvider {
+		return &systemPromptProvider{next: next, config: config}
+	}
+}
+
+type systemPromptProvider struct {
+	next   LLMProvider
+	config SystemPromptConfig
+}
+
+// Complete implements the LLMProvider interface
+func (p *systemPromptProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	req, applied := p.inject(req)
+	resp, err := p.next.Complete(ctx, req)
+	if err != nil || !applied {
+		return resp, err
+	}
+	return p.record(resp), nil
+}
+
+// CompleteStream implements the LLMProvider interface
+func (p *systemPromptProvider) CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error) {
+	req, applied := p.inject(req)
+	stream, err := p.next.CompleteStream(ctx, req)
+	if err != nil || !applied {
+		return stream, err
+	}
+	return &systemPromptStream{CompletionStream: stream, provider: p}, nil
+}
+
+// inject returns req with the prompt added, or req itself when it already
+// contains the prompt or, unless Always is set, another system prompt. It
+// reports whether the request is sent with the prompt.
+func (p *systemPromptProvider) inject(req *CompletionRequest) (*CompletionRequest, bool) {
+	prompt := strings.TrimSpace(p.config.Prompt)
+	if prompt == "" {
+		return req, false
+	}
+
+	present := false
+	for _, msg := range req.messages() {
+		if msg.Role != RoleSystem {
+			continue
+		}
+		if strings.TrimSpace(msg.Content) == prompt || strings.HasPrefix(msg.Content, prompt+"\n\n") {
+			return req, true
+		}
+		present = true
+	}
+	if present && !p.config.Always {
+		return req, false
+	}
+
+	r := *req
+	if r.SystemPrompt == "" {
+		r.SystemPrompt = prompt
+	} else {
+		r.SystemPrompt = prompt + "\n\n" + r.SystemPrompt
+	}
+	return &r, true
+}
+
+// record returns a copy of resp with the prompt version in its metadata
+func (p *systemPromptProvider) record(resp *CompletionResponse) *CompletionResponse {
+	if p.config.Version == "" {
+		return resp
+	}
+
+	recorded := *resp
+	var metadata ResponseMetadata
+	if resp.Metadata != nil {
+		metadata = *resp.Metadata
+	}
+	metadata.SystemPromptVersion = p.config.Version
+	recorded.Metadata = &metadata
+	return &recorded
+}
+
+type systemPromptStream struct {
+	CompletionStream
+	provider *systemPromptProvider
+}
+
+// Recv implements the CompletionStream interface
+func (s *systemPromptStream) Recv() (*CompletionResponse, error) {
+	resp, err := s.CompletionStream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	return s.provider.record(resp), nil
+}
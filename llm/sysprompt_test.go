@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestInjectSystemPrompt(t *testing.T) {
+	const policy = "Follow the company policy."
+
+	tests := []struct {
+		name        string
+		always      bool
+		req         CompletionRequest
+		wantSystem  string
+		wantVersion string
+	}{
+		{
+			name:        "injected",
+			req:         CompletionRequest{Prompt: "Hi"},
+			wantSystem:  policy,
+			wantVersion: "policy@v2",
+		},
+		{
+			name:       "own system prompt kept",
+			req:        CompletionRequest{Prompt: "Hi", SystemPrompt: "Be a pirate."},
+			wantSystem: "Be a pirate.",
+		},
+		{
+			name:       "own system message kept",
+			req:        CompletionRequest{Messages: []Message{{Role: RoleSystem, Content: "Be a pirate."}, {Role: RoleUser, Content: "Hi"}}},
+			wantSystem: "",
+		},
+		{
+			name:        "always injected ahead",
+			always:      true,
+			req:         CompletionRequest{Prompt: "Hi", SystemPrompt: "Be a pirate."},
+			wantSystem:  policy + "\n\nBe a pirate.",
+			wantVersion: "policy@v2",
+		},
+		{
+			name:        "already present in history",
+			always:      true,
+			req:         CompletionRequest{Messages: []Message{{Role: RoleSystem, Content: policy}, {Role: RoleUser, Content: "Hi"}}},
+			wantSystem:  "",
+			wantVersion: "policy@v2",
+		},
+		{
+			name:        "already injected ahead",
+			always:      true,
+			req:         CompletionRequest{Prompt: "Hi", SystemPrompt: policy + "\n\nBe a pirate."},
+			wantSystem:  policy + "\n\nBe a pirate.",
+			wantVersion: "policy@v2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockProvider{complete: func(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+				return &CompletionResponse{Content: "ok", Metadata: &ResponseMetadata{RequestID: "req_1"}}, nil
+			}}
+			provider := Chain(mock, InjectSystemPrompt(SystemPromptConfig{Prompt: policy, Version: "policy@v2", Always: tt.always}))
+
+			resp, err := provider.Complete(context.Background(), &tt.req)
+			if err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if got := mock.requests[0].SystemPrompt; got != tt.wantSystem {
+				t.Errorf("SystemPrompt = %q, want %q", got, tt.wantSystem)
+			}
+			if !reflect.DeepEqual(mock.requests[0].Messages, tt.req.Messages) {
+				t.Errorf("Messages = %+v, want unchanged", mock.requests[0].Messages)
+			}
+			if got := resp.Metadata.SystemPromptVersion; got != tt.wantVersion {
+				t.Errorf("SystemPromptVersion = %q, want %q", got, tt.wantVersion)
+			}
+			if resp.Metadata.RequestID != "req_1" {
+				t.Errorf("RequestID = %q, want provider metadata kept", resp.Metadata.RequestID)
+			}
+		})
+	}
+}
+
+func TestInjectSystemPrompt_Stream(t *testing.T) {
+	upstream := &streamProvider{chunks: []*CompletionResponse{{Content: "a"}, {Content: "b"}}}
+	provider := Chain(upstream, InjectSystemPrompt(SystemPromptConfig{Prompt: "Be brief.", Version: "v1"}))
+
+	stream, err := provider.CompleteStream(context.Background(), &CompletionRequest{Prompt: "Hi"})
+	if err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	if got := upstream.requests[0].SystemPrompt; got != "Be brief." {
+		t.Errorf("SystemPrompt = %q, want injected prompt", got)
+	}
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		if chunk.Metadata == nil || chunk.Metadata.SystemPromptVersion != "v1" {
+			t.Errorf("chunk Metadata = %+v, want version v1", chunk.Metadata)
+		}
+	}
+}
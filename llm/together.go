@@ -0,0 +1,37 @@
+package llm
+
+const (
+	defaultTogetherBaseURL = "https://api.together.xyz/v1"
+)
+
+// TogetherClient implements the LLMProvider interface for Together AI's
+// OpenAI-compatible inference API, which serves open-weight models such as
+// Llama, Qwen and DeepSeek
+type TogetherClient struct {
+	*OpenAIClient
+}
+
+// NewTogetherClient creates a new Together AI client with the given
+// configuration. BaseURL defaults to https://api.together.xyz/v1.
+func NewTogetherClient(config OpenAIConfig) *TogetherClient {
+	if config.BaseURL == "" {
+		config.BaseURL = defaultTogetherBaseURL
+	}
+
+	return &TogetherClient{
+		OpenAIClient: NewOpenAIClient(config),
+	}
+}
+
+// NewTogetherClientWithKey creates a new Together AI client with just an API key
+func NewTogetherClientWithKey(apiKey string) *TogetherClient {
+	return NewTogetherClient(OpenAIConfig{
+		APIKey: apiKey,
+	})
+}
+
+// With returns a copy of the client with opts applied to its configuration,
+// sharing its HTTP client; see OpenAIClient.With
+func (c *TogetherClient) With(opts ...OpenAIOption) *TogetherClient {
+	return &TogetherClient{OpenAIClient: c.OpenAIClient.With(opts...)}
+}
@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewTogetherClient(t *testing.T) {
+	client := NewTogetherClientWithKey("test-key")
+
+	if client.config.BaseURL != defaultTogetherBaseURL {
+		t.Errorf("BaseURL = %v, want %v", client.config.BaseURL, defaultTogetherBaseURL)
+	}
+}
+
+func TestTogetherClient_CompleteStream(t *testing.T) {
+	// Together streams OpenAI-style chunks with extra token fields, reports
+	// "eos" when the model stops on its own and ends with a usage-only chunk
+	responses := []string{
+		`data: {"id":"test-id","choices":[{"index":0,"text":"Hello","delta":{"token_id":9906,"role":"assistant","content":"Hello"}}],"model":"meta-llama/Llama-3-8b-chat-hf"}` + "\n\n",
+		`data: {"id":"test-id","choices":[{"index":0,"text":" World","delta":{"token_id":4435,"role":"assistant","content":" World"}}],"model":"meta-llama/Llama-3-8b-chat-hf"}` + "\n\n",
+		`data: {"id":"test-id","choices":[{"index":0,"text":"","finish_reason":"eos","delta":{"token_id":128009,"role":"assistant","content":""}}],"model":"meta-llama/Llama-3-8b-chat-hf"}` + "\n\n",
+		`data: {"id":"test-id","choices":[],"usage":{"prompt_tokens":10,"completion_tokens":3,"total_tokens":13}}` + "\n\n",
+		"data: [DONE]\n\n",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody openaiRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		if len(reqBody.Stop) != 1 || reqBody.Stop[0] != "<|eot_id|>" {
+			t.Errorf("Stop = %v, want [<|eot_id|>]", reqBody.Stop)
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("Streaming not supported")
+		}
+
+		for _, resp := range responses {
+			_, err := w.Write([]byte(resp))
+			if err != nil {
+				t.Fatal(err)
+			}
+			flusher.Flush()
+		}
+	}))
+	defer server.Close()
+
+	client := NewTogetherClient(OpenAIConfig{
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+	})
+
+	stream, err := client.CompleteStream(context.Background(), &CompletionRequest{
+		Model:  "meta-llama/Llama-3-8b-chat-hf",
+		Prompt: "Test prompt",
+		Stop:   []string{"<|eot_id|>"},
+	})
+	if err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	var content, finishReason string
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content += resp.Content
+		if resp.FinishReason != "" {
+			finishReason = resp.FinishReason
+		}
+	}
+
+	if content != "Hello World" {
+		t.Errorf("Content = %v, want 'Hello World'", content)
+	}
+	if finishReason != "stop" {
+		t.Errorf("FinishReason = %v, want stop", finishReason)
+	}
+}
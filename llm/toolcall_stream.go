@@ -0,0 +1,65 @@
+package llm
+
+import "sort"
+
+// toolCallAccumulator merges the fragments of tool calls streamed by a
+// provider into complete calls. Fragments are keyed by the index of the call
+// in the response: the first one usually carries the ID and the function
+// name, and the following ones pieces of the JSON arguments.
+type toolCallAccumulator struct {
+	calls map[int]*ToolCall
+}
+
+// add merges a fragment into the call at index
+func (a *toolCallAccumulator) add(index int, id, name, arguments string) {
+	if a.calls == nil {
+		a.calls = make(map[int]*ToolCall)
+	}
+	call, ok := a.calls[index]
+	if !ok {
+		call = &ToolCall{Type: "function"}
+		a.calls[index] = call
+	}
+	if id != "" {
+		call.ID = id
+	}
+	if name != "" {
+		call.Function.Name = name
+	}
+	call.Function.Arguments += arguments
+}
+
+// next returns the index following the calls seen so far, for fragments
+// that come without one
+func (a *toolCallAccumulator) next() int {
+	next := 0
+	for index := range a.calls {
+		next = max(next, index+1)
+	}
+	return next
+}
+
+// pending reports whether calls were accumulated since the last flush
+func (a *toolCallAccumulator) pending() bool {
+	return len(a.calls) > 0
+}
+
+// flush returns the accumulated calls in index order and resets the
+// accumulator
+func (a *toolCallAccumulator) flush() []ToolCall {
+	if len(a.calls) == 0 {
+		return nil
+	}
+	indexes := make([]int, 0, len(a.calls))
+	for index := range a.calls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	calls := make([]ToolCall, len(indexes))
+	for i, index := range indexes {
+		calls[i] = *a.calls[index]
+	}
+	clear(a.calls)
+	return calls
+}
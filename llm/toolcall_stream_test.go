@@ -0,0 +1,35 @@
+package llm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToolCallAccumulator(t *testing.T) {
+	var acc toolCallAccumulator
+	if acc.pending() || acc.flush() != nil {
+		t.Fatal("empty accumulator has pending calls")
+	}
+
+	acc.add(1, "call_2", "get_time", "")
+	acc.add(0, "call_1", "get_weather", `{"loc`)
+	acc.add(1, "", "", `{}`)
+	acc.add(0, "", "", `ation": "Paris"}`)
+	if next := acc.next(); next != 2 {
+		t.Errorf("next() = %d, want 2", next)
+	}
+
+	weather := ToolCall{ID: "call_1", Type: "function"}
+	weather.Function.Name = "get_weather"
+	weather.Function.Arguments = `{"location": "Paris"}`
+	clock := ToolCall{ID: "call_2", Type: "function"}
+	clock.Function.Name = "get_time"
+	clock.Function.Arguments = `{}`
+
+	if got := acc.flush(); !reflect.DeepEqual(got, []ToolCall{weather, clock}) {
+		t.Errorf("flush() = %+v", got)
+	}
+	if acc.pending() || acc.next() != 0 {
+		t.Error("flush() did not reset the accumulator")
+	}
+}
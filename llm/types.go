@@ -2,20 +2,228 @@ package llm
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"io"
+	"time"
 )
 
+// Message roles understood by all providers
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// Message is a single turn of a conversation
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+
+	// Parts is content sent after Content, such as images (optional). It is
+	// supported by OpenAI-compatible providers and Anthropic; other providers
+	// fail with ErrContentPartsUnsupported.
+	Parts []ContentPart `json:"parts,omitempty"`
+
+	// Name optionally identifies the author of the message
+	Name string `json:"name,omitempty"`
+
+	// ToolCallID links a tool message to the tool call it answers
+	ToolCallID string `json:"tool_call_id,omitempty"`
+
+	// ToolCalls are the tool calls made in an assistant message
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// Thinking are the thinking blocks of an assistant message, replayed
+	// ahead of its tool calls as Anthropic's extended thinking requires.
+	// Copy them from CompletionResponse.Thinking when recording a reply.
+	Thinking []ThinkingBlock `json:"thinking,omitempty"`
+}
+
+// Types of ContentPart
+const (
+	PartText  = "text"
+	PartImage = "image"
+)
+
+// ContentPart is a part of the content of a message, see Message.Parts
+type ContentPart struct {
+	// Type is PartText or PartImage
+	Type string `json:"type"`
+
+	// Text is the text of a PartText part
+	Text string `json:"text,omitempty"`
+
+	// ImageURL locates the image of a PartImage part: an http(s) URL or a
+	// data URL holding the base64-encoded image, as ImageDataPart builds
+	ImageURL string `json:"image_url,omitempty"`
+}
+
+// TextPart returns a part holding text
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: PartText, Text: text}
+}
+
+// ImagePart returns a part holding the image at url, an http(s) or data URL
+func ImagePart(url string) ContentPart {
+	return ContentPart{Type: PartImage, ImageURL: url}
+}
+
+// ImageDataPart returns a part holding an image given by its media type,
+// such as "image/png", and its bytes
+func ImageDataPart(mediaType string, data []byte) ContentPart {
+	return ImagePart("data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data))
+}
+
+// hasParts reports whether any of messages has content parts
+func hasParts(messages []Message) bool {
+	for _, msg := range messages {
+		if len(msg.Parts) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasImages reports whether any of messages has an image part
+func hasImages(messages []Message) bool {
+	for _, msg := range messages {
+		for _, part := range msg.Parts {
+			if part.Type == PartImage {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ThinkingBlock is a block of reasoning from Anthropic's extended thinking.
+// Its signature lets the block be sent back in a later request.
+type ThinkingBlock struct {
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+
+	// Data is the encrypted reasoning of a redacted block, which has no
+	// Thinking or Signature
+	Data string `json:"data,omitempty"`
+}
+
 // CompletionRequest represents a request to the LLM
 type CompletionRequest struct {
-	Prompt      string            `json:"prompt"`
-	Model       string            `json:"model"`
-	MaxTokens   int               `json:"max_tokens,omitempty"`
-	Temperature float32           `json:"temperature,omitempty"`
-	Stop        []string          `json:"stop,omitempty"`
-	Options     map[string]string `json:"options,omitempty"`
-	Tools       []Tool            `json:"tools,omitempty"`
+	// Prompt is a shortcut for a single user message. When Messages is also
+	// set, Prompt is sent as a final user message after them.
+	Prompt string `json:"prompt"`
+
+	// Messages is the conversation history to complete
+	Messages []Message `json:"messages,omitempty"`
+
+	// SystemPrompt is a system instruction sent ahead of Messages
+	SystemPrompt string `json:"system_prompt,omitempty"`
+
+	Model     string            `json:"model"`
+	MaxTokens int               `json:"max_tokens,omitempty"`
+	Stop      []string          `json:"stop,omitempty"`
+	Options   map[string]string `json:"options,omitempty"`
+	Tools     []Tool            `json:"tools,omitempty"`
+
+	// ToolChoice controls whether the model calls one of Tools: ToolChoiceAuto
+	// lets it decide, ToolChoiceNone prevents tool calls, ToolChoiceRequired
+	// forces at least one, and the name of a function forces a call to that
+	// function (optional, defaults to ToolChoiceAuto). It is supported by
+	// OpenAI-compatible providers and Anthropic. Providers that cannot call
+	// tools at all, such as llama.cpp, fail requests with Tools with
+	// ErrToolsUnsupported.
+	ToolChoice string `json:"tool_choice,omitempty"`
+
+	// N is the number of alternative completions to generate (optional,
+	// defaults to 1). Providers that cannot generate several choices fail
+	// with ErrMultipleChoicesUnsupported.
+	N int `json:"n,omitempty"`
+
+	// Seed requests deterministic sampling: repeated requests with the same
+	// seed and parameters should return the same result (optional). It is
+	// ignored by providers without seed support, such as Anthropic.
+	Seed *int64 `json:"seed,omitempty"`
+
+	// Temperature controls the randomness of sampling (optional, defaults to
+	// the provider's or client's default). A pointer, so that 0 requests
+	// greedy sampling rather than the default.
+	Temperature *float32 `json:"temperature,omitempty"`
+
+	// TopP enables nucleus sampling over the tokens making up the top TopP
+	// probability mass (optional)
+	TopP float32 `json:"top_p,omitempty"`
+
+	// FrequencyPenalty and PresencePenalty discourage repeating tokens in
+	// proportion to, respectively regardless of, how often they already
+	// appeared (optional). Providers without penalties ignore them.
+	FrequencyPenalty float32 `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float32 `json:"presence_penalty,omitempty"`
+
+	// LogitBias adjusts the likelihood of tokens, keyed by token ID, by a bias
+	// between -100 and 100 (optional). Providers without logit bias support
+	// ignore it.
+	LogitBias map[string]float32 `json:"logit_bias,omitempty"`
+
+	// Extra holds provider-specific fields merged into the JSON request body,
+	// for provider features without a field of their own such as
+	// reasoning_effort (optional). Extra fields replace the ones gollm sets;
+	// JSON objects present in both are merged.
+	Extra map[string]any `json:"extra,omitempty"`
+
+	// ExtraHeaders are additional HTTP headers sent with the request
+	// (optional). They replace headers gollm sets with the same name.
+	ExtraHeaders map[string]string `json:"extra_headers,omitempty"`
+
+	// RequestTimeout replaces the client's Timeout for this request
+	// (optional). It can be longer than the client's, as long-running
+	// streams often need, and covers reading the whole response.
+	RequestTimeout time.Duration `json:"request_timeout,omitempty"`
+
+	// IncludeRaw attaches the provider's raw JSON to the response, or to each
+	// stream chunk, as Raw (optional)
+	IncludeRaw bool `json:"include_raw,omitempty"`
+
+	// ThinkingBudget enables extended thinking on Anthropic models with up
+	// to this many tokens of reasoning, returned as Reasoning (optional,
+	// minimum 1024). Extended thinking does not allow a Temperature. Other
+	// providers ignore it.
+	ThinkingBudget int `json:"thinking_budget,omitempty"`
+
+	// User is a stable, non-identifying ID of the end user the request is
+	// made for, which lets providers attribute abuse (optional). It is sent
+	// as user to OpenAI-compatible providers and as metadata.user_id to
+	// Anthropic.
+	User string `json:"user,omitempty"`
+
+	// Metadata are key-value pairs stored with the request by OpenAI
+	// (optional). Other providers ignore them.
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
+// messages returns the conversation to send to the provider, combining
+// SystemPrompt, Messages and Prompt
+func (r *CompletionRequest) messages() []Message {
+	var messages []Message
+	if r.SystemPrompt != "" {
+		messages = append(messages, Message{Role: RoleSystem, Content: r.SystemPrompt})
+	}
+
+	messages = append(messages, r.Messages...)
+	if r.Prompt != "" || len(r.Messages) == 0 {
+		messages = append(messages, Message{Role: RoleUser, Content: r.Prompt})
+	}
+	return messages
+}
+
+// Values of CompletionRequest.ToolChoice other than a function name
+const (
+	ToolChoiceAuto     = "auto"
+	ToolChoiceNone     = "none"
+	ToolChoiceRequired = "required"
+)
+
 // Tool represents a function that can be called by the model
 type Tool struct {
 	Type     string   `json:"type"`
@@ -41,13 +249,86 @@ type ToolCall struct {
 
 // CompletionResponse represents a response from the LLM
 type CompletionResponse struct {
+	// ID is the provider-assigned identifier of the response, useful for
+	// correlating logs and recording feedback
+	ID           string     `json:"id,omitempty"`
 	Content      string     `json:"content"`
 	Model        string     `json:"model"`
 	FinishReason string     `json:"finish_reason,omitempty"`
 	ToolCalls    []ToolCall `json:"tool_calls,omitempty"`
+
+	// Reasoning holds the model's reasoning trace for providers that return
+	// it separately from the answer (e.g. deepseek-reasoner)
+	Reasoning string `json:"reasoning,omitempty"`
+
+	// Thinking holds the signed thinking blocks behind Reasoning, to be
+	// replayed with the assistant message in Message.Thinking. Only
+	// Anthropic returns them; in a stream they come with the last chunk.
+	Thinking []ThinkingBlock `json:"thinking,omitempty"`
+
+	// SystemFingerprint identifies the backend configuration that generated
+	// the response. Together with Seed it tells whether results can be
+	// expected to be reproducible; it changes when the provider updates the
+	// model deployment. Only OpenAI-compatible providers report it.
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+
+	// Metadata holds the request ID and rate limits reported in the HTTP
+	// response headers. Every chunk of a stream shares the same metadata.
+	Metadata *ResponseMetadata `json:"metadata,omitempty"`
+
+	// Raw is the provider's JSON response body, or the stream event behind a
+	// chunk, when the request set IncludeRaw. It gives access to provider
+	// fields that CompletionResponse does not model.
+	Raw json.RawMessage `json:"raw,omitempty"`
+
+	// Choices holds every choice returned by providers that support N, in
+	// order; the fields above mirror the first one. In a stream, each chunk
+	// carries the deltas of the choices it updates.
+	Choices []CompletionChoice `json:"choices,omitempty"`
+}
+
+// CompletionChoice is one of several alternative completions
+type CompletionChoice struct {
+	Index        int        `json:"index"`
+	Content      string     `json:"content"`
+	FinishReason string     `json:"finish_reason,omitempty"`
+	ToolCalls    []ToolCall `json:"tool_calls,omitempty"`
+	Reasoning    string     `json:"reasoning,omitempty"`
+}
+
+// ModelInfo describes a model offered by a provider
+type ModelInfo struct {
+	// ID is the model name to use in CompletionRequest.Model
+	ID string `json:"id"`
+
+	// DisplayName is a human-readable name, if the provider has one
+	DisplayName string `json:"display_name,omitempty"`
+
+	// OwnedBy is the organization that owns the model, if reported
+	OwnedBy string `json:"owned_by,omitempty"`
+
+	// Created is when the model was released, if reported
+	Created time.Time `json:"created"`
+
+	// ContextWindow is the maximum number of tokens in the context, or 0 if
+	// the provider does not report it
+	ContextWindow int `json:"context_window,omitempty"`
+
+	// Capabilities lists the features the provider reports for the model,
+	// such as "chat" or "embedding"; it is empty when unknown
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// ModelLister is implemented by providers that can list their available
+// models, for example to populate a model picker
+type ModelLister interface {
+	// ListModels returns the models available to the caller
+	ListModels(ctx context.Context) ([]ModelInfo, error)
 }
 
-// LLMProvider interface defines methods that must be implemented by all LLM providers
+// LLMProvider interface defines methods that must be implemented by all LLM providers.
+// Implementations must be safe for concurrent use by multiple goroutines, so
+// a single client can be shared across an application.
 type LLMProvider interface {
 	// Complete makes a non-streaming request to the LLM
 	Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error)
@@ -56,7 +337,8 @@ type LLMProvider interface {
 	CompleteStream(ctx context.Context, req *CompletionRequest) (CompletionStream, error)
 }
 
-// CompletionStream interface for handling streaming responses
+// CompletionStream interface for handling streaming responses. A stream is
+// not safe for concurrent use and should be consumed by a single goroutine.
 type CompletionStream interface {
 	// Recv receives the next chunk of the stream
 	Recv() (*CompletionResponse, error)
@@ -0,0 +1,186 @@
+package llmtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aiwizzard/gollm/jsonschema"
+	"github.com/aiwizzard/gollm/llm"
+)
+
+// The assertions below check model output in ordinary Go tests, against the
+// fake Server or a live provider. They report failures with t.Errorf, so a
+// test keeps going, and return whether the assertion held. JSON content may
+// be wrapped in a Markdown code fence.
+
+// AssertContains checks that content contains every one of substrings
+func AssertContains(t testing.TB, content string, substrings ...string) bool {
+	t.Helper()
+
+	ok := true
+	for _, s := range substrings {
+		if !strings.Contains(content, s) {
+			t.Errorf("content does not contain %q:\n%s", s, content)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// AssertMatches checks that content matches the regular expression pattern
+func AssertMatches(t testing.TB, content, pattern string) bool {
+	t.Helper()
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		t.Errorf("invalid pattern %q: %v", pattern, err)
+		return false
+	}
+	if !re.MatchString(content) {
+		t.Errorf("content does not match %q:\n%s", pattern, content)
+		return false
+	}
+	return true
+}
+
+// AssertJSONPath checks that content is JSON whose value at path equals
+// want, compared after a JSON round trip so that want may be any Go value
+// with the same encoding. Paths are dot-separated keys and array indexes,
+// optionally starting with "$", e.g. "$.items[0].name" or "items.0.name".
+func AssertJSONPath(t testing.TB, content, path string, want any) bool {
+	t.Helper()
+
+	var doc any
+	if err := json.Unmarshal([]byte(llm.StripCodeFences()(content)), &doc); err != nil {
+		t.Errorf("content is not JSON: %v:\n%s", err, content)
+		return false
+	}
+
+	got, err := lookupPath(doc, path)
+	if err != nil {
+		t.Errorf("JSON path %q: %v", path, err)
+		return false
+	}
+
+	wantValue, err := normalizeJSON(want)
+	if err != nil {
+		t.Errorf("JSON path %q: cannot encode want: %v", path, err)
+		return false
+	}
+	if !reflect.DeepEqual(got, wantValue) {
+		t.Errorf("JSON path %q = %s, want %s", path, encodeJSON(got), encodeJSON(wantValue))
+		return false
+	}
+	return true
+}
+
+// AssertMatchesSchema checks that content is JSON valid against schema, a
+// JSON Schema given as any value encoding to one, such as a tool's
+// Parameters. See package jsonschema for the keywords checked.
+func AssertMatchesSchema(t testing.TB, content string, schema any) bool {
+	t.Helper()
+
+	var doc any
+	if err := json.Unmarshal([]byte(llm.StripCodeFences()(content)), &doc); err != nil {
+		t.Errorf("content is not JSON: %v:\n%s", err, content)
+		return false
+	}
+
+	problems, err := jsonschema.Validate(schema, doc)
+	if err != nil {
+		t.Errorf("invalid schema: %v", err)
+		return false
+	}
+	for _, problem := range problems {
+		path := "$"
+		if problem.Path != "" {
+			path += "." + problem.Path
+		}
+		t.Errorf("content does not match schema: %s: %s", path, problem.Message)
+	}
+	return len(problems) == 0
+}
+
+// AssertJudgeScoreAtLeast asks judge to score content against criteria on
+// a scale from 0 to 10 and checks that the score is at least minScore. The
+// judge must have a default model, and a deterministic one makes for less
+// flaky tests.
+func AssertJudgeScoreAtLeast(t testing.TB, judge llm.LLMProvider, content, criteria string, minScore float64) bool {
+	t.Helper()
+
+	resp, err := judge.Complete(context.Background(), &llm.CompletionRequest{
+		SystemPrompt: "You grade responses. Score how well the response meets the criteria on a scale from 0 (not at all) to 10 (perfectly). Reply with the score only.",
+		Prompt:       fmt.Sprintf("Criteria:\n%s\n\nResponse:\n%s", criteria, content),
+	})
+	if err != nil {
+		t.Errorf("judge failed: %v", err)
+		return false
+	}
+
+	match := judgeScore.FindString(resp.Content)
+	if match == "" {
+		t.Errorf("judge reply has no score: %q", resp.Content)
+		return false
+	}
+	score, _ := strconv.ParseFloat(match, 64)
+	if score < minScore {
+		t.Errorf("judge score %g below %g for criteria %q:\n%s", score, minScore, criteria, content)
+		return false
+	}
+	return true
+}
+
+// judgeScore matches the first number in a judge reply
+var judgeScore = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// lookupPath returns the value at path in a decoded JSON document
+func lookupPath(doc any, path string) (any, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	if path == "" {
+		return doc, nil
+	}
+
+	value := doc
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			field, ok := v[key]
+			if !ok {
+				return nil, fmt.Errorf("no field %q", key)
+			}
+			value = field
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("no element %q in array of %d", key, len(v))
+			}
+			value = v[index]
+		default:
+			return nil, fmt.Errorf("cannot look up %q in %s", key, encodeJSON(value))
+		}
+	}
+	return value, nil
+}
+
+// normalizeJSON returns v as decoded from its JSON encoding
+func normalizeJSON(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var normalized any
+	err = json.Unmarshal(data, &normalized)
+	return normalized, err
+}
+
+func encodeJSON(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
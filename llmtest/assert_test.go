@@ -0,0 +1,158 @@
+package llmtest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aiwizzard/gollm/llm"
+)
+
+// recorder captures the failures reported by an assertion
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Logf(format string, args ...any) {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// check runs assert against a recorder and verifies its outcome
+func check(t *testing.T, wantOK bool, wantError string, assert func(t testing.TB) bool) {
+	t.Helper()
+
+	r := &recorder{}
+	ok := assert(r)
+	if ok != wantOK || ok != (len(r.errors) == 0) {
+		t.Errorf("assertion returned %v with errors %q, want %v", ok, r.errors, wantOK)
+	}
+	if wantError != "" && !strings.Contains(strings.Join(r.errors, "\n"), wantError) {
+		t.Errorf("errors = %q, want one containing %q", r.errors, wantError)
+	}
+}
+
+func TestAssertContains(t *testing.T) {
+	content := "The capital of France is Paris."
+
+	check(t, true, "", func(t testing.TB) bool { return AssertContains(t, content, "Paris", "France") })
+	check(t, false, `"Lyon"`, func(t testing.TB) bool { return AssertContains(t, content, "Paris", "Lyon") })
+}
+
+func TestAssertMatches(t *testing.T) {
+	check(t, true, "", func(t testing.TB) bool { return AssertMatches(t, "Order #1234 shipped", `#\d{4}\b`) })
+	check(t, false, "does not match", func(t testing.TB) bool { return AssertMatches(t, "Order shipped", `#\d+`) })
+	check(t, false, "invalid pattern", func(t testing.TB) bool { return AssertMatches(t, "Order", `(`) })
+}
+
+func TestAssertJSONPath(t *testing.T) {
+	content := "```json\n" + `{"city": "Paris", "items": [{"name": "croissant", "price": 1.5}], "open": true}` + "\n```"
+
+	tests := []struct {
+		name      string
+		content   string
+		path      string
+		want      any
+		wantOK    bool
+		wantError string
+	}{
+		{name: "string field", content: content, path: "$.city", want: "Paris", wantOK: true},
+		{name: "array index", content: content, path: "$.items[0].name", want: "croissant", wantOK: true},
+		{name: "dotted index", content: content, path: "items.0.price", want: 1.5, wantOK: true},
+		{name: "bool", content: content, path: "open", want: true, wantOK: true},
+		{name: "object", content: content, path: "items[0]", want: map[string]any{"name": "croissant", "price": 1.5}, wantOK: true},
+		{name: "wrong value", content: content, path: "city", want: "Lyon", wantError: `= "Paris", want "Lyon"`},
+		{name: "missing field", content: content, path: "country", want: "France", wantError: `no field "country"`},
+		{name: "index out of range", content: content, path: "items[3]", want: nil, wantError: "no element"},
+		{name: "not JSON", content: "Paris", path: "city", want: "Paris", wantError: "not JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check(t, tt.wantOK, tt.wantError, func(r testing.TB) bool {
+				return AssertJSONPath(r, tt.content, tt.path, tt.want)
+			})
+		})
+	}
+}
+
+func TestAssertMatchesSchema(t *testing.T) {
+	schema := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"name", "tags"},
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string", "minLength": 1},
+			"age":  map[string]any{"type": "integer", "minimum": 0, "maximum": 150},
+			"role": map[string]any{"enum": []string{"admin", "user"}},
+			"tags": map[string]any{"type": "array", "maxItems": 2, "items": map[string]any{"type": "string"}},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		content   string
+		wantOK    bool
+		wantError string
+	}{
+		{name: "valid", content: `{"name": "Ada", "age": 36, "role": "admin", "tags": ["math"]}`, wantOK: true},
+		{name: "integral number", content: `{"name": "Ada", "age": 36.0, "role": null, "tags": []}`, wantOK: true},
+		{name: "missing required", content: `{"name": "Ada"}`, wantError: "$.tags: missing required property"},
+		{name: "wrong type", content: `{"name": 42, "tags": []}`, wantError: "$.name: must be a string"},
+		{name: "not an integer", content: `{"name": "Ada", "age": 36.5, "tags": []}`, wantError: "$.age: must be an integer"},
+		{name: "out of range", content: `{"name": "Ada", "age": 200, "tags": []}`, wantError: "$.age: must be at most 150"},
+		{name: "enum", content: `{"name": "Ada", "role": "root", "tags": []}`, wantError: `$.role: must be one of "admin", "user"`},
+		{name: "array items", content: `{"name": "Ada", "tags": ["math", 1]}`, wantError: "$.tags[1]"},
+		{name: "too many items", content: `{"name": "Ada", "tags": ["a", "b", "c"]}`, wantError: "$.tags: must have at most 2 items"},
+		{name: "empty string", content: `{"name": "", "tags": []}`, wantError: "$.name: must be at least 1 characters long"},
+		{name: "additional property", content: `{"name": "Ada", "tags": [], "email": "ada@example.com"}`, wantError: "$.email: unknown property"},
+		{name: "not JSON", content: `name: Ada`, wantError: "not JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check(t, tt.wantOK, tt.wantError, func(r testing.TB) bool {
+				return AssertMatchesSchema(r, tt.content, schema)
+			})
+		})
+	}
+}
+
+func TestAssertJudgeScoreAtLeast(t *testing.T) {
+	tests := []struct {
+		name      string
+		reply     Response
+		wantOK    bool
+		wantError string
+	}{
+		{name: "passing score", reply: Response{Content: "8"}, wantOK: true},
+		{name: "score in a sentence", reply: Response{Content: "Score: 7.5/10"}, wantOK: true},
+		{name: "low score", reply: Response{Content: "3"}, wantError: "judge score 3 below 7"},
+		{name: "no score", reply: Response{Content: "Looks good!"}, wantError: "no score"},
+		{name: "judge error", reply: Response{StatusCode: 500, Error: "overloaded"}, wantError: "judge failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(tt.reply)
+			defer server.Close()
+			judge := server.Client().With(llm.WithDefaultModel("judge"))
+
+			check(t, tt.wantOK, tt.wantError, func(r testing.TB) bool {
+				return AssertJudgeScoreAtLeast(r, judge, "Paris is the capital of France.", "States the capital of France", 7)
+			})
+
+			req, ok := server.LastRequest()
+			if !ok {
+				t.Fatal("judge was not called")
+			}
+			if prompt := req.Messages[len(req.Messages)-1].Content; !strings.Contains(prompt, "States the capital of France") || !strings.Contains(prompt, "Paris is the capital") {
+				t.Errorf("judge prompt = %q, want criteria and response", prompt)
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+// Package llmtest provides an in-process fake of the OpenAI chat completions
+// API for testing code built on gollm without hand-writing HTTP handlers, and
+// assertions on model output.
+package llmtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/aiwizzard/gollm/llm"
+)
+
+// Response describes what the fake server answers to a single request
+type Response struct {
+	// Content is the assistant message text
+	Content string
+
+	// ToolCalls are the tool calls made by the assistant
+	ToolCalls []llm.ToolCall
+
+	// FinishReason defaults to "stop", or "tool_calls" when ToolCalls is set
+	FinishReason string
+
+	// Model defaults to the model of the request
+	Model string
+
+	// Chunks splits Content into the given stream deltas. By default the
+	// content is streamed word by word.
+	Chunks []string
+
+	// StatusCode makes the server fail the request with the given HTTP status
+	// and Error as the error message
+	StatusCode int
+	Error      string
+}
+
+// Message is a chat message received by the server
+type Message struct {
+	Role       string         `json:"role"`
+	Content    string         `json:"content"`
+	Name       string         `json:"name,omitempty"`
+	ToolCallID string         `json:"tool_call_id,omitempty"`
+	ToolCalls  []llm.ToolCall `json:"tool_calls,omitempty"`
+
+	// Images are the URLs of the images sent with the message
+	Images []string `json:"-"`
+}
+
+// UnmarshalJSON accepts content given as a string or as a list of parts, as
+// messages with images are sent. The text of the parts is joined into
+// Content and the URLs of the images are listed in Images.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	type plain Message
+	var msg struct {
+		plain
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	*m = Message(msg.plain)
+	if len(msg.Content) == 0 {
+		return nil
+	}
+
+	var parts []struct {
+		Type     string `json:"type"`
+		Text     string `json:"text"`
+		ImageURL struct {
+			URL string `json:"url"`
+		} `json:"image_url"`
+	}
+	if json.Unmarshal(msg.Content, &parts) != nil {
+		return json.Unmarshal(msg.Content, &m.Content)
+	}
+	for _, part := range parts {
+		if part.Type == "image_url" {
+			m.Images = append(m.Images, part.ImageURL.URL)
+			continue
+		}
+		m.Content += part.Text
+	}
+	return nil
+}
+
+// Request is a chat completion request received by the server
+type Request struct {
+	Model       string     `json:"model"`
+	Messages    []Message  `json:"messages"`
+	MaxTokens   int        `json:"max_tokens"`
+	Temperature float32    `json:"temperature"`
+	Stop        []string   `json:"stop"`
+	Stream      bool       `json:"stream"`
+	Tools       []llm.Tool `json:"tools"`
+	ToolChoice  any        `json:"tool_choice"`
+
+	// Header holds the HTTP headers of the request
+	Header http.Header `json:"-"`
+
+	// Body is the raw JSON request body
+	Body []byte `json:"-"`
+}
+
+// Server is a fake OpenAI-compatible server. Responses are served from a
+// queue in the order they were added; when the queue is empty, Handler is
+// consulted if set, otherwise the request fails with HTTP 500. Its methods
+// are safe for concurrent use.
+type Server struct {
+	// URL is the base URL of the server, suitable for OpenAIConfig.BaseURL
+	URL string
+
+	// Handler computes responses once the queue is exhausted (optional). It
+	// must be set before requests are sent and may be called concurrently.
+	Handler func(Request) Response
+
+	server *httptest.Server
+
+	mu       sync.Mutex
+	queue    []Response
+	requests []Request
+}
+
+// NewServer starts a fake server that answers with the given responses in order
+func NewServer(responses ...Response) *Server {
+	s := &Server{
+		queue: responses,
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+	return s
+}
+
+// Close shuts down the server
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns an OpenAI client pointed at the server with retries disabled
+func (s *Server) Client() *llm.OpenAIClient {
+	return llm.NewOpenAIClient(llm.OpenAIConfig{
+		APIKey:      "test-key",
+		BaseURL:     s.URL,
+		RetryConfig: &llm.RetryConfig{},
+	})
+}
+
+// Enqueue adds responses to the end of the queue
+func (s *Server) Enqueue(responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, responses...)
+}
+
+// Requests returns the requests received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// LastRequest returns the most recent request, or false if none was received
+func (s *Server) LastRequest() (Request, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requests) == 0 {
+		return Request{}, false
+	}
+	return s.requests[len(s.requests)-1], true
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/chat/completions") {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("llmtest: unexpected %s %s", r.Method, r.URL.Path))
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req Request
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "llmtest: invalid JSON body: "+err.Error())
+		return
+	}
+	req.Header = r.Header.Clone()
+	req.Body = body
+
+	resp, ok := s.next(req)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "llmtest: no response queued")
+		return
+	}
+
+	if resp.StatusCode != 0 && resp.StatusCode != http.StatusOK {
+		writeError(w, resp.StatusCode, resp.Error)
+		return
+	}
+
+	if resp.Model == "" {
+		resp.Model = req.Model
+	}
+	resp.ToolCalls = append([]llm.ToolCall(nil), resp.ToolCalls...)
+	for i := range resp.ToolCalls {
+		if resp.ToolCalls[i].ID == "" {
+			resp.ToolCalls[i].ID = fmt.Sprintf("call_%d", i)
+		}
+		if resp.ToolCalls[i].Type == "" {
+			resp.ToolCalls[i].Type = "function"
+		}
+	}
+	if resp.FinishReason == "" {
+		resp.FinishReason = "stop"
+		if len(resp.ToolCalls) > 0 {
+			resp.FinishReason = "tool_calls"
+		}
+	}
+
+	if req.Stream {
+		writeStream(w, resp)
+		return
+	}
+	writeCompletion(w, resp)
+}
+
+func (s *Server) next(req Request) (Response, bool) {
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	if len(s.queue) > 0 {
+		resp := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+		return resp, true
+	}
+	handler := s.Handler
+	s.mu.Unlock()
+
+	if handler == nil {
+		return Response{}, false
+	}
+	return handler(req), true
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]string{
+			"message": message,
+		},
+	})
+}
+
+func writeCompletion(w http.ResponseWriter, resp Response) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"id":     "chatcmpl-llmtest",
+		"object": "chat.completion",
+		"model":  resp.Model,
+		"choices": []map[string]any{
+			{
+				"index": 0,
+				"message": map[string]any{
+					"role":       "assistant",
+					"content":    resp.Content,
+					"tool_calls": resp.ToolCalls,
+				},
+				"finish_reason": resp.FinishReason,
+			},
+		},
+	})
+}
+
+func writeStream(w http.ResponseWriter, resp Response) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	flusher, _ := w.(http.Flusher)
+
+	send := func(delta map[string]any, finishReason any) {
+		chunk, _ := json.Marshal(map[string]any{
+			"id":     "chatcmpl-llmtest",
+			"object": "chat.completion.chunk",
+			"model":  resp.Model,
+			"choices": []map[string]any{
+				{
+					"index":         0,
+					"delta":         delta,
+					"finish_reason": finishReason,
+				},
+			},
+		})
+		fmt.Fprintf(w, "data: %s\n\n", chunk)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	chunks := resp.Chunks
+	if chunks == nil {
+		chunks = splitWords(resp.Content)
+	}
+	for _, chunk := range chunks {
+		send(map[string]any{"content": chunk}, nil)
+	}
+
+	for i, call := range resp.ToolCalls {
+		send(map[string]any{
+			"tool_calls": []map[string]any{
+				{
+					"index":    i,
+					"id":       call.ID,
+					"type":     call.Type,
+					"function": call.Function,
+				},
+			},
+		}, nil)
+	}
+
+	send(map[string]any{}, resp.FinishReason)
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// splitWords splits text into chunks that each start with the whitespace
+// preceding a word, so that concatenating them yields the original text
+func splitWords(text string) []string {
+	var chunks []string
+	start := 0
+	for i := 1; i < len(text); i++ {
+		if text[i] == ' ' && text[i-1] != ' ' {
+			chunks = append(chunks, text[start:i])
+			start = i
+		}
+	}
+	if start < len(text) {
+		chunks = append(chunks, text[start:])
+	}
+	return chunks
+}
@@ -0,0 +1,197 @@
+package llmtest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aiwizzard/gollm/llm"
+)
+
+func weatherCall(arguments string) llm.ToolCall {
+	var call llm.ToolCall
+	call.Function.Name = "get_weather"
+	call.Function.Arguments = arguments
+	return call
+}
+
+func TestServer_Complete(t *testing.T) {
+	server := NewServer(
+		Response{Content: "First answer"},
+		Response{ToolCalls: []llm.ToolCall{weatherCall(`{"location":"London"}`)}},
+	)
+	defer server.Close()
+
+	client := server.Client()
+
+	resp, err := client.Complete(context.Background(), &llm.CompletionRequest{
+		Model:  "gpt-4",
+		Prompt: "Hello",
+	})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Content != "First answer" {
+		t.Errorf("Content = %v, want 'First answer'", resp.Content)
+	}
+	if resp.Model != "gpt-4" {
+		t.Errorf("Model = %v, want gpt-4", resp.Model)
+	}
+	if resp.FinishReason != "stop" {
+		t.Errorf("FinishReason = %v, want stop", resp.FinishReason)
+	}
+
+	resp, err = client.Complete(context.Background(), &llm.CompletionRequest{
+		Model:  "gpt-4",
+		Prompt: "What's the weather in London?",
+	})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.FinishReason != "tool_calls" {
+		t.Errorf("FinishReason = %v, want tool_calls", resp.FinishReason)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "call_0" || resp.ToolCalls[0].Type != "function" {
+		t.Fatalf("ToolCalls = %+v, want one defaulted function call", resp.ToolCalls)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("Got %d requests, want 2", len(requests))
+	}
+	if requests[1].Messages[0].Content != "What's the weather in London?" {
+		t.Errorf("Message = %v, want the second prompt", requests[1].Messages[0].Content)
+	}
+	if requests[0].Header.Get("Authorization") != "Bearer test-key" {
+		t.Errorf("Authorization header = %v, want Bearer test-key", requests[0].Header.Get("Authorization"))
+	}
+}
+
+func TestServer_Images(t *testing.T) {
+	server := NewServer(Response{Content: "A cat."})
+	defer server.Close()
+
+	messages := llm.NewMessages().User("What is this?").Image("https://example.com/cat.jpg").Build()
+	if _, err := server.Client().Complete(context.Background(), &llm.CompletionRequest{Model: "gpt-4o", Messages: messages}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	msg := server.Requests()[0].Messages[0]
+	if msg.Content != "What is this?" || len(msg.Images) != 1 || msg.Images[0] != "https://example.com/cat.jpg" {
+		t.Errorf("Message = %+v, want the text and the image", msg)
+	}
+}
+
+func TestServer_CompleteStream(t *testing.T) {
+	server := NewServer(Response{Content: "Hello streaming world"})
+	defer server.Close()
+
+	stream, err := server.Client().CompleteStream(context.Background(), &llm.CompletionRequest{
+		Model:  "gpt-4",
+		Prompt: "Hello",
+	})
+	if err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	var chunks []string
+	var finishReason string
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Content != "" {
+			chunks = append(chunks, resp.Content)
+		}
+		if resp.FinishReason != "" {
+			finishReason = resp.FinishReason
+		}
+	}
+
+	if strings.Join(chunks, "") != "Hello streaming world" {
+		t.Errorf("Content = %v, want 'Hello streaming world'", strings.Join(chunks, ""))
+	}
+	if len(chunks) != 3 {
+		t.Errorf("Got %d chunks, want 3", len(chunks))
+	}
+	if finishReason != "stop" {
+		t.Errorf("FinishReason = %v, want stop", finishReason)
+	}
+
+	if req, ok := server.LastRequest(); !ok || !req.Stream {
+		t.Errorf("LastRequest() = %+v, want a streaming request", req)
+	}
+}
+
+func TestServer_StreamToolCalls(t *testing.T) {
+	server := NewServer(Response{ToolCalls: []llm.ToolCall{weatherCall(`{"location":"Paris"}`)}})
+	defer server.Close()
+
+	stream, err := server.Client().CompleteStream(context.Background(), &llm.CompletionRequest{
+		Model:  "gpt-4",
+		Prompt: "What's the weather in Paris?",
+	})
+	if err != nil {
+		t.Fatalf("CompleteStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	var calls []llm.ToolCall
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		calls = append(calls, resp.ToolCalls...)
+	}
+
+	if len(calls) != 1 || calls[0].Function.Arguments != `{"location":"Paris"}` {
+		t.Errorf("ToolCalls = %+v, want the Paris weather call", calls)
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	server := NewServer(Response{StatusCode: http.StatusUnauthorized, Error: "Invalid API key"})
+	defer server.Close()
+
+	client := server.Client()
+
+	_, err := client.Complete(context.Background(), &llm.CompletionRequest{Model: "gpt-4", Prompt: "Hello"})
+	var httpErr *llm.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Complete() error = %v, want HTTP 401", err)
+	}
+
+	// The queue is now empty and no handler is set
+	_, err = client.Complete(context.Background(), &llm.CompletionRequest{Model: "gpt-4", Prompt: "Hello"})
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Complete() error = %v, want HTTP 500", err)
+	}
+}
+
+func TestServer_Handler(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.Handler = func(req Request) Response {
+		return Response{Content: "echo: " + req.Messages[len(req.Messages)-1].Content}
+	}
+
+	resp, err := server.Client().Complete(context.Background(), &llm.CompletionRequest{Model: "gpt-4", Prompt: "ping"})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Content != "echo: ping" {
+		t.Errorf("Content = %v, want 'echo: ping'", resp.Content)
+	}
+}
@@ -0,0 +1,161 @@
+package llmtest
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aiwizzard/gollm/llm"
+	"github.com/aiwizzard/gollm/lmdiff"
+)
+
+// UpdateSnapshotsEnv is the environment variable that makes AssertSnapshot
+// write the current output to the snapshot files instead of comparing them:
+//
+//	LLMTEST_UPDATE=1 go test ./...
+const UpdateSnapshotsEnv = "LLMTEST_UPDATE"
+
+// SnapshotDir is the directory, relative to the package under test, that
+// holds the snapshot files
+var SnapshotDir = filepath.Join("testdata", "snapshots")
+
+// RenderPrompt renders the prompt req sends as readable text: the model,
+// the tools with their descriptions and parameter schemas, and every message
+// with its role and images, in the order the provider receives them. It is
+// the format AssertSnapshot stores.
+func RenderPrompt(req *llm.CompletionRequest) string {
+	var b strings.Builder
+	if req.Model != "" {
+		fmt.Fprintf(&b, "model: %s\n", req.Model)
+	}
+	for _, tool := range req.Tools {
+		fmt.Fprintf(&b, "tool: %s\n", tool.Function.Name)
+		if tool.Function.Description != "" {
+			fmt.Fprintf(&b, "  description: %s\n", indent(tool.Function.Description))
+		}
+		if tool.Function.Parameters != nil {
+			schema, err := json.MarshalIndent(tool.Function.Parameters, "  ", "  ")
+			if err != nil {
+				schema = []byte(err.Error())
+			}
+			fmt.Fprintf(&b, "  parameters: %s\n", schema)
+		}
+	}
+
+	messages := req.Messages
+	if req.SystemPrompt != "" {
+		messages = append([]llm.Message{{Role: llm.RoleSystem, Content: req.SystemPrompt}}, messages...)
+	}
+	if req.Prompt != "" || len(req.Messages) == 0 {
+		messages = append(messages, llm.Message{Role: llm.RoleUser, Content: req.Prompt})
+	}
+
+	for _, msg := range messages {
+		header := msg.Role
+		switch {
+		case msg.Name != "":
+			header += " " + msg.Name
+		case msg.ToolCallID != "":
+			header += " " + msg.ToolCallID
+		}
+		fmt.Fprintf(&b, "\n=== %s ===\n", header)
+		if msg.Content != "" {
+			b.WriteString(strings.TrimRight(msg.Content, "\n"))
+			b.WriteString("\n")
+		}
+		for _, part := range msg.Parts {
+			if part.Type == llm.PartImage {
+				fmt.Fprintf(&b, "[image %s]\n", imageName(part.ImageURL))
+				continue
+			}
+			b.WriteString(strings.TrimRight(part.Text, "\n"))
+			b.WriteString("\n")
+		}
+		for _, call := range msg.ToolCalls {
+			fmt.Fprintf(&b, "-> %s(%s)\n", call.Function.Name, call.Function.Arguments)
+		}
+	}
+	return b.String()
+}
+
+// imageName returns the URL of an image, with the data of data URLs replaced
+// by its hash to keep snapshots readable
+func imageName(url string) string {
+	header, data, ok := strings.Cut(url, ",")
+	if !ok || !strings.HasPrefix(header, "data:") {
+		return url
+	}
+	return fmt.Sprintf("%s sha256:%x", header, sha256.Sum256([]byte(data)))
+}
+
+// indent indents the lines of text after the first to nest them under a tool
+func indent(text string) string {
+	return strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", "\n    ")
+}
+
+// AssertSnapshot checks that the prompt req renders to, see RenderPrompt,
+// matches the snapshot file name.golden in SnapshotDir, reporting a line
+// diff when it does not. This catches changes to templates, few-shot
+// examples or context assembly that alter prompts. With UpdateSnapshotsEnv
+// set, or when the file does not exist yet, the snapshot is written instead
+// and should be committed with the change.
+func AssertSnapshot(t testing.TB, name string, req *llm.CompletionRequest) bool {
+	t.Helper()
+	return assertSnapshot(t, name, RenderPrompt(req))
+}
+
+func assertSnapshot(t testing.TB, name, got string) bool {
+	t.Helper()
+
+	path := filepath.Join(SnapshotDir, name+".golden")
+	want, err := os.ReadFile(path)
+	if os.Getenv(UpdateSnapshotsEnv) != "" || errors.Is(err, fs.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Errorf("cannot create snapshot directory: %v", err)
+			return false
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Errorf("cannot write snapshot: %v", err)
+			return false
+		}
+		t.Logf("wrote snapshot %s", path)
+		return true
+	}
+	if err != nil {
+		t.Errorf("cannot read snapshot: %v", err)
+		return false
+	}
+
+	if string(want) != got {
+		t.Errorf("prompt differs from snapshot %s (run with %s=1 to update):\n%s", path, UpdateSnapshotsEnv, lineDiff(string(want), got))
+		return false
+	}
+	return true
+}
+
+// lineDiff returns the lines of a and b prefixed with "  " when they are in
+// both, "- " when only in a and "+ " when only in b
+func lineDiff(a, b string) string {
+	var out strings.Builder
+	for _, edit := range lmdiff.Lines(a, b) {
+		prefix := "  "
+		switch edit.Op {
+		case lmdiff.Delete:
+			prefix = "- "
+		case lmdiff.Insert:
+			prefix = "+ "
+		}
+		for _, line := range strings.SplitAfter(edit.Text, "\n") {
+			if line != "" {
+				out.WriteString(prefix + line)
+			}
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,109 @@
+package llmtest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aiwizzard/gollm/llm"
+)
+
+func weatherPrompt(city string) *llm.CompletionRequest {
+	call := weatherCall(`{"location":"` + city + `"}`)
+	call.ID = "call_1"
+
+	return &llm.CompletionRequest{
+		Model:        "gpt-4o",
+		SystemPrompt: "You are a weather assistant.\nAnswer in one sentence.",
+		Tools: []llm.Tool{{Type: "function", Function: llm.Function{
+			Name:        "get_weather",
+			Description: "Get the current weather.\nUse metric units.",
+			Parameters: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"location": map[string]any{"type": "string"}},
+				"required":   []string{"location"},
+			},
+		}}},
+		Messages: []llm.Message{
+			{Role: llm.RoleUser, Content: "Weather in " + city + "?"},
+			{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{call}},
+			{Role: llm.RoleTool, ToolCallID: "call_1", Content: `{"temperature": 21}`},
+		},
+		Prompt: "Should I take an umbrella?",
+	}
+}
+
+func TestAssertSnapshot(t *testing.T) {
+	// testdata/snapshots/weather.golden is committed
+	check(t, true, "", func(r testing.TB) bool {
+		return AssertSnapshot(r, "weather", weatherPrompt("Paris"))
+	})
+	check(t, false, "- Weather in Paris?\n+ Weather in Rome?", func(r testing.TB) bool {
+		return AssertSnapshot(r, "weather", weatherPrompt("Rome"))
+	})
+}
+
+func TestAssertSnapshot_Update(t *testing.T) {
+	defer func(dir string) { SnapshotDir = dir }(SnapshotDir)
+	SnapshotDir = t.TempDir()
+	path := filepath.Join(SnapshotDir, "greeting.golden")
+
+	// A missing snapshot is written
+	check(t, true, "", func(r testing.TB) bool {
+		return AssertSnapshot(r, "greeting", &llm.CompletionRequest{Prompt: "Hello"})
+	})
+	if data, err := os.ReadFile(path); err != nil || string(data) != "\n=== user ===\nHello\n" {
+		t.Fatalf("snapshot = %q, %v", data, err)
+	}
+
+	check(t, false, "+ [image https://example.com/cat.jpg]", func(r testing.TB) bool {
+		return AssertSnapshot(r, "greeting", &llm.CompletionRequest{Messages: llm.NewMessages().User("Hello").Image("https://example.com/cat.jpg").Build()})
+	})
+
+	check(t, false, "+ Hi", func(r testing.TB) bool {
+		return AssertSnapshot(r, "greeting", &llm.CompletionRequest{Prompt: "Hi"})
+	})
+
+	t.Setenv(UpdateSnapshotsEnv, "1")
+	check(t, true, "", func(r testing.TB) bool {
+		return AssertSnapshot(r, "greeting", &llm.CompletionRequest{Prompt: "Hi"})
+	})
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "Hi") {
+		t.Errorf("snapshot not updated: %q", data)
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{name: "equal", a: "a\nb\n", b: "a\nb\n", want: "  a\n  b\n"},
+		{name: "changed line", a: "a\nb\nc\n", b: "a\nx\nc\n", want: "  a\n- b\n+ x\n  c\n"},
+		{name: "added lines", a: "a\n", b: "a\nb\nc\n", want: "  a\n+ b\n+ c\n"},
+		{name: "removed line", a: "a\nb\nc\n", b: "a\nc\n", want: "  a\n- b\n  c\n"},
+		{name: "no final line break", a: "a\nb", b: "a\nc", want: "  a\n- b\n+ c\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lineDiff(tt.a, tt.b); got != tt.want {
+				t.Errorf("lineDiff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderPrompt_Images(t *testing.T) {
+	req := &llm.CompletionRequest{Messages: llm.NewMessages().
+		User("Compare").Image("https://example.com/a.png").ImageData("image/png", []byte("png")).
+		Build()}
+
+	want := "\n=== user ===\nCompare\n[image https://example.com/a.png]\n" +
+		"[image data:image/png;base64 sha256:7bf413f7f540c7dcc07d31ad99c110e879f35c4718b7fd019a6384619da889e9]\n"
+	if got := RenderPrompt(req); got != want {
+		t.Errorf("RenderPrompt() = %q, want %q", got, want)
+	}
+}
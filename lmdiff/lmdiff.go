@@ -0,0 +1,265 @@
+// Package lmdiff compares two completions, for example the answers of a
+// primary and a candidate model, and quantifies how far they drift apart.
+package lmdiff
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aiwizzard/gollm/embeddings"
+)
+
+// Op is the kind of an edit
+type Op int
+
+const (
+	// Equal marks text present in both completions
+	Equal Op = iota
+
+	// Delete marks text only present in the first completion
+	Delete
+
+	// Insert marks text only present in the second completion
+	Insert
+)
+
+func (o Op) String() string {
+	switch o {
+	case Equal:
+		return "equal"
+	case Delete:
+		return "delete"
+	case Insert:
+		return "insert"
+	}
+	return fmt.Sprintf("Op(%d)", int(o))
+}
+
+// Edit is a run of consecutive units (tokens or sentences) with the same Op
+type Edit struct {
+	Op   Op
+	Text string
+}
+
+// Result describes how two completions differ
+type Result struct {
+	// Exact reports whether the completions are identical, ignoring leading
+	// and trailing whitespace
+	Exact bool
+
+	// TokenSimilarity and SentenceSimilarity are the share of tokens,
+	// respectively sentences, the completions have in common, from 0 for
+	// nothing in common to 1 for identical
+	TokenSimilarity    float64
+	SentenceSimilarity float64
+
+	// EmbeddingSimilarity is the cosine similarity of the embeddings of the
+	// completions. It is only set by CompareEmbeddings.
+	EmbeddingSimilarity float64
+
+	// Tokens and Sentences are the edits that turn the first completion into
+	// the second, at word and sentence granularity
+	Tokens    []Edit
+	Sentences []Edit
+}
+
+// Compare diffs completion a against completion b. Tokens are words and
+// punctuation marks; whitespace between them is kept in the edit text but
+// ignored when matching.
+func Compare(a, b string) Result {
+	tokens, tokenSimilarity := diff(splitTokens(a), splitTokens(b))
+	sentences, sentenceSimilarity := diff(splitSentences(a), splitSentences(b))
+
+	return Result{
+		Exact:              strings.TrimSpace(a) == strings.TrimSpace(b),
+		TokenSimilarity:    tokenSimilarity,
+		SentenceSimilarity: sentenceSimilarity,
+		Tokens:             tokens,
+		Sentences:          sentences,
+	}
+}
+
+// Lines diffs text a against text b line by line, for texts such as
+// rendered prompts whose lines are the unit of change. The text of each edit
+// is a run of whole lines, each ending with a line break.
+func Lines(a, b string) []Edit {
+	edits, _ := diff(splitLines(a), splitLines(b))
+	return edits
+}
+
+// EmbedFunc returns the embedding of text
+type EmbedFunc func(ctx context.Context, text string) ([]float32, error)
+
+// CompareEmbeddings is Compare with EmbeddingSimilarity computed from the
+// embeddings embed returns for both completions, which also catches
+// paraphrases that share few tokens
+func CompareEmbeddings(ctx context.Context, a, b string, embed EmbedFunc) (Result, error) {
+	result := Compare(a, b)
+
+	embeddingA, err := embed(ctx, a)
+	if err != nil {
+		return Result{}, fmt.Errorf("lmdiff: failed to embed first completion: %w", err)
+	}
+	embeddingB, err := embed(ctx, b)
+	if err != nil {
+		return Result{}, fmt.Errorf("lmdiff: failed to embed second completion: %w", err)
+	}
+
+	result.EmbeddingSimilarity, err = embeddings.CosineSimilarity(embeddingA, embeddingB)
+	if err != nil {
+		return Result{}, err
+	}
+	return result, nil
+}
+
+// unit is a token or sentence: key is compared, text is reported
+type unit struct {
+	key  string
+	text string
+}
+
+var tokenPattern = regexp.MustCompile(`([\p{L}\p{N}_]+|[^\p{L}\p{N}_\s])\s*`)
+
+func splitTokens(text string) []unit {
+	var units []unit
+	for _, match := range tokenPattern.FindAllStringSubmatch(text, -1) {
+		units = append(units, unit{key: match[1], text: match[0]})
+	}
+	return units
+}
+
+// splitLines splits text into lines, adding a line break to the last one if
+// it has none
+func splitLines(text string) []unit {
+	if text == "" {
+		return nil
+	}
+	var units []unit
+	for _, line := range strings.SplitAfter(strings.TrimSuffix(text, "\n"), "\n") {
+		key := strings.TrimSuffix(line, "\n")
+		units = append(units, unit{key: key, text: key + "\n"})
+	}
+	return units
+}
+
+// splitSentences splits text after sentence-ending punctuation followed by
+// whitespace, and at line breaks
+func splitSentences(text string) []unit {
+	var units []unit
+	add := func(sentence string) {
+		if key := strings.TrimSpace(sentence); key != "" {
+			units = append(units, unit{key: key, text: sentence})
+		}
+	}
+
+	start := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\n':
+			add(text[start : i+1])
+			start = i + 1
+		case '.', '!', '?':
+			if i+1 < len(text) && (text[i+1] == ' ' || text[i+1] == '\t') {
+				end := i + 1
+				for end < len(text) && (text[end] == ' ' || text[end] == '\t') {
+					end++
+				}
+				add(text[start:end])
+				start = end
+				i = end - 1
+			}
+		}
+	}
+	add(text[start:])
+	return units
+}
+
+// diff computes the shortest edit script from a to b with Myers' algorithm
+// and returns it with merged runs, along with the share of units in common
+func diff(a, b []unit) ([]Edit, float64) {
+	n, m := len(a), len(b)
+	if n+m == 0 {
+		return nil, 1
+	}
+
+	// trace[d] holds the furthest x reached on diagonals -d to d before
+	// step d, the only ones the walk back from step d reads, keeping the
+	// trace quadratic in the number of edits rather than in n+m
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	var trace [][]int
+
+search:
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x].key == b[y].key {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk the trace backwards, collecting edits in reverse
+	var reversed []Edit
+	equal := 0
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevX, prevY int
+		if d > 0 {
+			var prevK int
+			if k == -d || (k != d && v[d+k-1] < v[d+k+1]) {
+				prevK = k + 1
+			} else {
+				prevK = k - 1
+			}
+			prevX = v[d+prevK]
+			prevY = prevX - prevK
+		}
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, Edit{Op: Equal, Text: a[x-1].text})
+			equal++
+			x--
+			y--
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			reversed = append(reversed, Edit{Op: Insert, Text: b[y-1].text})
+			y--
+		} else {
+			reversed = append(reversed, Edit{Op: Delete, Text: a[x-1].text})
+			x--
+		}
+	}
+
+	var edits []Edit
+	for i := len(reversed) - 1; i >= 0; i-- {
+		edit := reversed[i]
+		if last := len(edits) - 1; last >= 0 && edits[last].Op == edit.Op {
+			edits[last].Text += edit.Text
+			continue
+		}
+		edits = append(edits, edit)
+	}
+
+	return edits, 2 * float64(equal) / float64(n+m)
+}
@@ -0,0 +1,266 @@
+package lmdiff
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name            string
+		a, b            string
+		wantExact       bool
+		wantTokenSim    float64
+		wantSentenceSim float64
+		wantTokens      []Edit
+	}{
+		{
+			name:            "both empty",
+			wantExact:       true,
+			wantTokenSim:    1,
+			wantSentenceSim: 1,
+		},
+		{
+			name:            "identical up to surrounding whitespace",
+			a:               "Paris is the capital.",
+			b:               "  Paris is the capital.\n",
+			wantExact:       true,
+			wantTokenSim:    1,
+			wantSentenceSim: 1,
+			wantTokens:      []Edit{{Op: Equal, Text: "Paris is the capital."}},
+		},
+		{
+			name:            "word replaced",
+			a:               "The answer is 42.",
+			b:               "The answer is 43.",
+			wantTokenSim:    0.8,
+			wantSentenceSim: 0,
+			wantTokens: []Edit{
+				{Op: Equal, Text: "The answer is "},
+				{Op: Delete, Text: "42"},
+				{Op: Insert, Text: "43"},
+				{Op: Equal, Text: "."},
+			},
+		},
+		{
+			name:            "sentence added",
+			a:               "Hello there. How are you?",
+			b:               "Hello there. Nice to meet you. How are you?",
+			wantTokenSim:    2 * 7.0 / 19,
+			wantSentenceSim: 0.8,
+			wantTokens: []Edit{
+				{Op: Equal, Text: "Hello there. "},
+				{Op: Insert, Text: "Nice to meet you. "},
+				{Op: Equal, Text: "How are you?"},
+			},
+		},
+		{
+			name:            "nothing in common",
+			a:               "yes",
+			b:               "no",
+			wantTokenSim:    0,
+			wantSentenceSim: 0,
+			wantTokens:      []Edit{{Op: Delete, Text: "yes"}, {Op: Insert, Text: "no"}},
+		},
+		{
+			name:            "whitespace differences ignored in tokens",
+			a:               "a  b\nc",
+			b:               "a b c",
+			wantTokenSim:    1,
+			wantSentenceSim: 0,
+			wantTokens:      []Edit{{Op: Equal, Text: "a  b\nc"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compare(tt.a, tt.b)
+			if got.Exact != tt.wantExact {
+				t.Errorf("Exact = %v, want %v", got.Exact, tt.wantExact)
+			}
+			if math.Abs(got.TokenSimilarity-tt.wantTokenSim) > 1e-9 {
+				t.Errorf("TokenSimilarity = %v, want %v", got.TokenSimilarity, tt.wantTokenSim)
+			}
+			if math.Abs(got.SentenceSimilarity-tt.wantSentenceSim) > 1e-9 {
+				t.Errorf("SentenceSimilarity = %v, want %v", got.SentenceSimilarity, tt.wantSentenceSim)
+			}
+			if !reflect.DeepEqual(got.Tokens, tt.wantTokens) {
+				t.Errorf("Tokens = %+v, want %+v", got.Tokens, tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestCompare_Reconstructs(t *testing.T) {
+	a := "First line.\nThe quick brown fox jumps over the lazy dog! Is it? Yes."
+	b := "First line.\nA quick red fox jumped over the dog! Is it? No, it is not."
+
+	for _, edits := range [][]Edit{Compare(a, b).Tokens, Compare(a, b).Sentences} {
+		var fromA, fromB strings.Builder
+		for _, edit := range edits {
+			if edit.Op != Insert {
+				fromA.WriteString(edit.Text)
+			}
+			if edit.Op != Delete {
+				fromB.WriteString(edit.Text)
+			}
+		}
+		if fromA.String() != a {
+			t.Errorf("edits rebuild a as %q", fromA.String())
+		}
+		// Equal text is taken from a, so b is only rebuilt up to whitespace
+		if strings.Join(strings.Fields(fromB.String()), " ") != strings.Join(strings.Fields(b), " ") {
+			t.Errorf("edits rebuild b as %q", fromB.String())
+		}
+	}
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Edit
+	}{
+		{name: "empty", want: nil},
+		{name: "equal", a: "a\nb\n", b: "a\nb", want: []Edit{{Equal, "a\nb\n"}}},
+		{
+			name: "changed line",
+			a:    "a\nb\nc\n",
+			b:    "a\nx\nc\n",
+			want: []Edit{{Equal, "a\n"}, {Delete, "b\n"}, {Insert, "x\n"}, {Equal, "c\n"}},
+		},
+		{name: "added lines", a: "a\n", b: "a\nb\nc\n", want: []Edit{{Equal, "a\n"}, {Insert, "b\nc\n"}}},
+		{name: "blank line", a: "a\n\nb\n", b: "a\nb\n", want: []Edit{{Equal, "a\n"}, {Delete, "\n"}, {Equal, "b\n"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lines(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	got := splitSentences("Smith arrived. Really?! Yes.\nNext line 3.5 percent")
+
+	var keys []string
+	for _, u := range got {
+		keys = append(keys, u.key)
+	}
+	want := []string{"Smith arrived.", "Really?!", "Yes.", "Next line 3.5 percent"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("sentences = %q, want %q", keys, want)
+	}
+}
+
+func TestCompareEmbeddings(t *testing.T) {
+	embed := func(ctx context.Context, text string) ([]float32, error) {
+		if strings.Contains(text, "fail") {
+			return nil, errors.New("embedding failed")
+		}
+		if strings.Contains(text, "Paris") {
+			return []float32{1, 0}, nil
+		}
+		return []float32{1, 1}, nil
+	}
+
+	got, err := CompareEmbeddings(context.Background(), "It is Paris.", "The capital is Paris.", embed)
+	if err != nil {
+		t.Fatalf("CompareEmbeddings() error = %v", err)
+	}
+	if got.EmbeddingSimilarity != 1 {
+		t.Errorf("EmbeddingSimilarity = %v, want 1", got.EmbeddingSimilarity)
+	}
+	if got.TokenSimilarity >= 1 {
+		t.Errorf("TokenSimilarity = %v, want < 1", got.TokenSimilarity)
+	}
+
+	got, err = CompareEmbeddings(context.Background(), "Paris", "London", embed)
+	if err != nil {
+		t.Fatalf("CompareEmbeddings() error = %v", err)
+	}
+	if want := 1 / math.Sqrt2; math.Abs(got.EmbeddingSimilarity-want) > 1e-6 {
+		t.Errorf("EmbeddingSimilarity = %v, want %v", got.EmbeddingSimilarity, want)
+	}
+
+	if _, err := CompareEmbeddings(context.Background(), "Paris", "fail", embed); err == nil {
+		t.Error("CompareEmbeddings() error = nil, want embedding error")
+	}
+}
+
+func TestOpString(t *testing.T) {
+	for op, want := range map[Op]string{Equal: "equal", Delete: "delete", Insert: "insert", Op(9): "Op(9)"} {
+		if got := op.String(); got != want {
+			t.Errorf("String() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestDiff_Shortest(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	units := func(n int) []unit {
+		u := make([]unit, n)
+		for i := range u {
+			key := string(rune('a' + rng.Intn(3)))
+			u[i] = unit{key: key, text: key}
+		}
+		return u
+	}
+
+	for i := 0; i < 200; i++ {
+		a, b := units(rng.Intn(30)), units(rng.Intn(30))
+		edits, _ := diff(a, b)
+
+		var fromA, fromB, common strings.Builder
+		for _, edit := range edits {
+			if edit.Op != Insert {
+				fromA.WriteString(edit.Text)
+			}
+			if edit.Op != Delete {
+				fromB.WriteString(edit.Text)
+			}
+			if edit.Op == Equal {
+				common.WriteString(edit.Text)
+			}
+		}
+		if fromA.String() != join(a) || fromB.String() != join(b) {
+			t.Fatalf("diff(%q, %q) = %v, does not rebuild the inputs", join(a), join(b), edits)
+		}
+		if got, want := common.Len(), lcs(a, b); got != want {
+			t.Fatalf("diff(%q, %q) keeps %d units in common, want %d", join(a), join(b), got, want)
+		}
+	}
+}
+
+func join(units []unit) string {
+	var s strings.Builder
+	for _, u := range units {
+		s.WriteString(u.text)
+	}
+	return s.String()
+}
+
+// lcs returns the length of the longest common subsequence of a and b
+func lcs(a, b []unit) int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].key == b[j].key {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+	return lengths[0][0]
+}
@@ -0,0 +1,304 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/aiwizzard/gollm/tools"
+)
+
+// ErrClosed is returned by the calls of a client whose connection is closed
+var ErrClosed = errors.New("mcp: connection closed")
+
+// ClientConfig configures a client
+type ClientConfig struct {
+	// Info identifies the client to servers (optional, defaults to gollm)
+	Info Implementation
+}
+
+// Client is a connection to an MCP server. It is safe for concurrent use.
+type Client struct {
+	transport Transport
+
+	// ServerInfo and Instructions are announced by the server when the
+	// connection is initialized
+	ServerInfo   Implementation
+	Instructions string
+
+	sendMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan *message
+	err     error
+	done    chan struct{}
+}
+
+// NewClient initializes a connection to an MCP server over transport. The
+// client owns the transport and closes it on Close or if initialization
+// fails.
+func NewClient(ctx context.Context, transport Transport, config ClientConfig) (*Client, error) {
+	if config.Info.Name == "" {
+		config.Info = Implementation{Name: "gollm", Version: "1.0.0"}
+	}
+
+	c := &Client{
+		transport: transport,
+		pending:   make(map[int64]chan *message),
+		done:      make(chan struct{}),
+	}
+	go c.readLoop()
+
+	var result initializeResult
+	err := c.call(ctx, "initialize", initializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]any{},
+		ClientInfo:      config.Info,
+	}, &result)
+	if err == nil {
+		err = c.notify(ctx, "notifications/initialized", nil)
+	}
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("mcp: failed to initialize: %w", err)
+	}
+
+	c.ServerInfo = result.ServerInfo
+	c.Instructions = result.Instructions
+	return c, nil
+}
+
+// ListTools returns the tools offered by the server
+func (c *Client) ListTools(ctx context.Context) ([]ToolInfo, error) {
+	var infos []ToolInfo
+	params := listToolsParams{}
+	for {
+		var result listToolsResult
+		if err := c.call(ctx, "tools/list", params, &result); err != nil {
+			return nil, err
+		}
+		infos = append(infos, result.Tools...)
+		if result.NextCursor == "" {
+			return infos, nil
+		}
+		params.Cursor = result.NextCursor
+	}
+}
+
+// CallTool calls a tool of the server with JSON object arguments (optional).
+// A failure of the tool itself is reported by CallToolResult.IsError rather
+// than an error.
+func (c *Client) CallTool(ctx context.Context, name string, arguments json.RawMessage) (*CallToolResult, error) {
+	var result CallToolResult
+	if err := c.call(ctx, "tools/call", callToolParams{Name: name, Arguments: arguments}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Tools returns the tools of the server as tools.Tool values, ready to
+// register, whose handlers call the server and return the text of the
+// result. A result marked as an error fails the call with its text. The
+// input schemas are kept as sent, in RawParameters, and arguments are only
+// checked against the keywords package jsonschema knows, leaving the rest to
+// the server.
+func (c *Client) Tools(ctx context.Context) ([]*tools.Tool, error) {
+	infos, err := c.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*tools.Tool, len(infos))
+	for i, info := range infos {
+		var schema json.RawMessage
+		if len(info.InputSchema) > 0 && string(info.InputSchema) != "null" {
+			schema = info.InputSchema
+		}
+
+		name := info.Name
+		list[i] = &tools.Tool{
+			Name:          name,
+			Description:   info.Description,
+			RawParameters: schema,
+			Handler: func(ctx context.Context, arguments string) (string, error) {
+				var args json.RawMessage
+				if arguments != "" {
+					args = json.RawMessage(arguments)
+				}
+				result, err := c.CallTool(ctx, name, args)
+				if err != nil {
+					return "", err
+				}
+				if result.IsError {
+					return "", fmt.Errorf("mcp: tool %s failed: %s", name, result.Text())
+				}
+				return result.Text(), nil
+			},
+		}
+	}
+	return list, nil
+}
+
+// Close closes the connection, failing pending calls with ErrClosed
+func (c *Client) Close() error {
+	err := c.transport.Close()
+	<-c.done
+	return err
+}
+
+// call sends a request and decodes the result of its response into result
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.nextID++
+	id := c.nextID
+	response := make(chan *message, 1)
+	c.pending[id] = response
+	c.mu.Unlock()
+
+	forget := func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}
+
+	rawID := json.RawMessage(strconv.FormatInt(id, 10))
+	if err := c.send(ctx, &message{ID: rawID, Method: method}, params); err != nil {
+		forget()
+		return err
+	}
+
+	select {
+	case msg := <-response:
+		if msg == nil {
+			return c.closedErr()
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal(msg.Result, result); err != nil {
+			return fmt.Errorf("mcp: invalid %s result: %w", method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		forget()
+		// Let the server stop working on the request
+		c.notify(context.Background(), "notifications/cancelled", map[string]any{
+			"requestId": id,
+			"reason":    ctx.Err().Error(),
+		})
+		return ctx.Err()
+	}
+}
+
+// notify sends a notification
+func (c *Client) notify(ctx context.Context, method string, params any) error {
+	return c.send(ctx, &message{Method: method}, params)
+}
+
+func (c *Client) send(ctx context.Context, msg *message, params any) error {
+	msg.JSONRPC = "2.0"
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		msg.Params = data
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	return c.transport.Send(ctx, data)
+}
+
+// readLoop dispatches the messages of the server until the connection ends
+func (c *Client) readLoop() {
+	defer close(c.done)
+	for {
+		data, err := c.transport.Receive()
+		if err != nil {
+			c.fail(err)
+			return
+		}
+
+		var msg message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			// Not JSON-RPC, such as log output of a misbehaving server
+			continue
+		}
+
+		switch {
+		case msg.isRequest():
+			c.answer(&msg)
+		case msg.isNotification():
+			// Progress, logging and list changes are not used
+		default:
+			c.deliver(&msg)
+		}
+	}
+}
+
+// answer responds to a request of the server: pings are answered and other
+// requests, for capabilities the client does not declare, are refused
+func (c *Client) answer(req *message) {
+	resp := &message{ID: req.ID}
+	if req.Method == "ping" {
+		resp.Result = json.RawMessage("{}")
+	} else {
+		resp.Error = &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+	// Answered in the background, as the transport may block until the
+	// response is read
+	go c.send(context.Background(), resp, nil)
+}
+
+// deliver hands a response to the call waiting for it
+func (c *Client) deliver(msg *message) {
+	id, err := strconv.ParseInt(string(msg.ID), 10, 64)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	response, ok := c.pending[id]
+	delete(c.pending, id)
+	c.mu.Unlock()
+	if ok {
+		response <- msg
+	}
+}
+
+// fail ends all pending calls once the connection is lost
+func (c *Client) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == io.EOF {
+		c.err = ErrClosed
+	} else {
+		c.err = fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+	for id, response := range c.pending {
+		close(response)
+		delete(c.pending, id)
+	}
+}
+
+func (c *Client) closedErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
@@ -0,0 +1,313 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aiwizzard/gollm/llm"
+	"github.com/aiwizzard/gollm/tools"
+)
+
+// fakeServer answers client messages on transport like an MCP server with
+// the tools echo, fail and hang, the latter never answering. Notifications
+// it receives are recorded.
+type fakeServer struct {
+	transport Transport
+
+	mu            sync.Mutex
+	notifications []string
+	pong          chan *message
+}
+
+func newFakeServer(transport Transport) *fakeServer {
+	s := &fakeServer{transport: transport, pong: make(chan *message, 1)}
+	go s.serve()
+	return s
+}
+
+func (s *fakeServer) serve() {
+	for {
+		data, err := s.transport.Receive()
+		if err != nil {
+			s.transport.Close()
+			return
+		}
+		var msg message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		if msg.isNotification() {
+			s.mu.Lock()
+			s.notifications = append(s.notifications, msg.Method)
+			s.mu.Unlock()
+			continue
+		}
+		if !msg.isRequest() {
+			s.pong <- &msg
+			continue
+		}
+
+		result, rpcErr := s.handle(&msg)
+		if result == nil && rpcErr == nil {
+			continue
+		}
+		resp := &message{JSONRPC: "2.0", ID: msg.ID, Error: rpcErr}
+		if result != nil {
+			resp.Result, _ = json.Marshal(result)
+		}
+		data, _ = json.Marshal(resp)
+		s.transport.Send(context.Background(), data)
+	}
+}
+
+func (s *fakeServer) handle(msg *message) (any, *RPCError) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "fake", "version": "0.1"},
+			"instructions":    "Use echo to repeat text.",
+		}, nil
+	case "tools/list":
+		var params listToolsParams
+		json.Unmarshal(msg.Params, &params)
+		if params.Cursor == "" {
+			return map[string]any{
+				"tools": []any{map[string]any{
+					"name":        "echo",
+					"description": "Repeat text",
+					"inputSchema": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"text": map[string]any{"type": "string"},
+							"lang": map[string]any{"type": []string{"string", "null"}},
+							"repeat": map[string]any{"anyOf": []any{
+								map[string]any{"type": "integer"},
+								map[string]any{"$ref": "#/definitions/count"},
+							}},
+						},
+						"required": []string{"text"},
+					},
+				}},
+				"nextCursor": "page2",
+			}, nil
+		}
+		return map[string]any{"tools": []any{
+			map[string]any{"name": "fail", "inputSchema": map[string]any{"type": "object"}},
+			map[string]any{"name": "hang"},
+		}}, nil
+	case "tools/call":
+		var params struct {
+			Name      string
+			Arguments map[string]any
+		}
+		json.Unmarshal(msg.Params, &params)
+		switch params.Name {
+		case "echo":
+			return CallToolResult{Content: []Content{
+				{Type: "text", Text: fmt.Sprint(params.Arguments["text"])},
+				{Type: "image", Data: "iVBORw0KGgo=", MimeType: "image/png"},
+			}}, nil
+		case "fail":
+			return CallToolResult{Content: []Content{{Type: "text", Text: "disk full"}}, IsError: true}, nil
+		case "hang":
+			return nil, nil
+		}
+		return nil, &RPCError{Code: CodeInvalidParams, Message: "unknown tool " + params.Name}
+	}
+	return nil, &RPCError{Code: CodeMethodNotFound, Message: "method not found"}
+}
+
+func (s *fakeServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.notifications...)
+}
+
+// pipeTransports returns the two ends of an in-memory stdio connection
+func pipeTransports() (client, server Transport) {
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	return NewStreamTransport(clientReader, clientWriter), NewStreamTransport(serverReader, serverWriter)
+}
+
+func newTestClient(t *testing.T) (*Client, *fakeServer) {
+	t.Helper()
+	clientTransport, serverTransport := pipeTransports()
+	server := newFakeServer(serverTransport)
+
+	client, err := NewClient(context.Background(), clientTransport, ClientConfig{})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, server
+}
+
+func TestClient(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+
+	if client.ServerInfo != (Implementation{Name: "fake", Version: "0.1"}) || client.Instructions != "Use echo to repeat text." {
+		t.Errorf("ServerInfo = %+v, Instructions = %q", client.ServerInfo, client.Instructions)
+	}
+
+	infos, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools() error = %v", err)
+	}
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"echo", "fail", "hang"}) {
+		t.Errorf("ListTools() names = %v, want all pages", names)
+	}
+
+	result, err := client.CallTool(ctx, "echo", json.RawMessage(`{"text": "hi"}`))
+	if err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	if result.IsError || result.Text() != "hi\n[image image/png]" {
+		t.Errorf("CallTool() = %+v, Text() = %q", result, result.Text())
+	}
+
+	_, err = client.CallTool(ctx, "missing", nil)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidParams {
+		t.Errorf("CallTool(missing) error = %v, want an invalid params error", err)
+	}
+
+	if got := server.received(); len(got) == 0 || got[0] != "notifications/initialized" {
+		t.Errorf("notifications = %v, want notifications/initialized first", got)
+	}
+}
+
+func TestClient_Tools(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	list, err := client.Tools(ctx)
+	if err != nil {
+		t.Fatalf("Tools() error = %v", err)
+	}
+	registry := tools.NewRegistry(list...)
+
+	// The schemas are passed on as the server sent them
+	definitions := registry.Definitions()
+	if len(definitions) != 3 {
+		t.Fatalf("Definitions() = %+v", definitions)
+	}
+	schema, err := json.Marshal(definitions[0].Function.Parameters)
+	if err != nil || !strings.Contains(string(schema), `"lang":{"type":["string","null"]}`) || !strings.Contains(string(schema), `"$ref"`) {
+		t.Errorf("echo parameters = %s, %v", schema, err)
+	}
+
+	call := func(name, arguments string) llm.ToolCall {
+		var call llm.ToolCall
+		call.Function.Name = name
+		call.Function.Arguments = arguments
+		return call
+	}
+
+	got, err := registry.Call(ctx, call("echo", `{"text": "hello"}`))
+	if err != nil || !strings.HasPrefix(got, "hello") {
+		t.Errorf("Call(echo) = %q, %v", got, err)
+	}
+	if _, err := registry.Call(ctx, call("echo", `{"text": "hi", "lang": null, "repeat": "twice"}`)); err != nil {
+		t.Errorf("Call(echo) with arguments left to the server error = %v", err)
+	}
+	if _, err := registry.Call(ctx, call("echo", `{}`)); !errors.Is(err, tools.ErrInvalidArguments) {
+		t.Errorf("Call(echo) without text error = %v, want %v", err, tools.ErrInvalidArguments)
+	}
+	if _, err := registry.Call(ctx, call("fail", "")); err == nil || !strings.Contains(err.Error(), "tool fail failed: disk full") {
+		t.Errorf("Call(fail) error = %v", err)
+	}
+}
+
+func TestClient_Cancel(t *testing.T) {
+	client, server := newTestClient(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.CallTool(ctx, "hang", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("CallTool(hang) error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !contains(server.received(), "notifications/cancelled") {
+		if time.Now().After(deadline) {
+			t.Fatalf("notifications = %v, want notifications/cancelled", server.received())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestClient_Ping(t *testing.T) {
+	_, server := newTestClient(t)
+
+	for _, method := range []string{"ping", "sampling/createMessage"} {
+		data, _ := json.Marshal(&message{JSONRPC: "2.0", ID: json.RawMessage(`"srv-1"`), Method: method})
+		if err := server.transport.Send(context.Background(), data); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case resp := <-server.pong:
+			if string(resp.ID) != `"srv-1"` {
+				t.Errorf("%s: response ID = %s", method, resp.ID)
+			}
+			if method == "ping" && (resp.Error != nil || string(resp.Result) != "{}") {
+				t.Errorf("ping response = %+v", resp)
+			}
+			if method != "ping" && (resp.Error == nil || resp.Error.Code != CodeMethodNotFound) {
+				t.Errorf("%s response = %+v, want method not found", method, resp)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s not answered", method)
+		}
+	}
+}
+
+func TestClient_ConnectionLost(t *testing.T) {
+	client, server := newTestClient(t)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.CallTool(context.Background(), "hang", nil)
+		done <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	server.transport.Close()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("CallTool() error = %v, want %v", err, ErrClosed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("pending call not failed when the connection was lost")
+	}
+	if _, err := client.ListTools(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("ListTools() error = %v, want %v", err, ErrClosed)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
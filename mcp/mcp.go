@@ -0,0 +1,125 @@
+// Package mcp connects gollm tools with the Model Context Protocol. A Client
+// consumes the tools of an MCP server, over stdio or HTTP with server-sent
+// events, as tools.Tool values whose calls are proxied back to the server,
+// and a Server serves the tools of a registry to MCP hosts over the same
+// transports.
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ProtocolVersion is the MCP version spoken by this package
+const ProtocolVersion = "2024-11-05"
+
+// JSON-RPC error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// message is a JSON-RPC 2.0 request, notification or response. Requests have
+// a Method and an ID, notifications only a Method, and responses an ID with
+// either a Result or an Error.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+func (m *message) isRequest() bool {
+	return m.Method != "" && len(m.ID) > 0
+}
+
+func (m *message) isNotification() bool {
+	return m.Method != "" && len(m.ID) == 0
+}
+
+// RPCError is a JSON-RPC error returned by the other side of a connection
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("mcp: %s (code %d)", e.Message, e.Code)
+}
+
+// Implementation identifies a client or server
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type initializeParams struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ClientInfo      Implementation `json:"clientInfo"`
+}
+
+type initializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      Implementation `json:"serverInfo"`
+	Instructions    string         `json:"instructions,omitempty"`
+}
+
+// ToolInfo describes a tool offered by an MCP server
+type ToolInfo struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+}
+
+type listToolsParams struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type listToolsResult struct {
+	Tools      []ToolInfo `json:"tools"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+type callToolParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// Content is an item of the content returned by a tool. Text items carry
+// Text; image and audio items carry base64 Data of type MimeType.
+type Content struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Data     string `json:"data,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+}
+
+// CallToolResult is the result of a tool call. IsError reports a failure of
+// the tool itself, described by Content.
+type CallToolResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Text returns the text items of the content, one per line, with
+// placeholders for other items
+func (r *CallToolResult) Text() string {
+	parts := make([]string, len(r.Content))
+	for i, content := range r.Content {
+		if content.Type == "text" {
+			parts[i] = content.Text
+		} else {
+			parts[i] = fmt.Sprintf("[%s %s]", content.Type, content.MimeType)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
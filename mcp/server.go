@@ -0,0 +1,229 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/aiwizzard/gollm/llm"
+	"github.com/aiwizzard/gollm/tools"
+)
+
+// ServerConfig configures a server
+type ServerConfig struct {
+	// Info identifies the server to clients (optional, defaults to gollm)
+	Info Implementation
+
+	// Instructions tell clients how to use the server's tools (optional)
+	Instructions string
+}
+
+// Server serves the tools of a registry to MCP hosts, such as Claude Desktop,
+// so that tools written in Go can be used outside gollm. Tools registered
+// after the server starts are served too.
+type Server struct {
+	registry *tools.Registry
+	config   ServerConfig
+}
+
+// NewServer creates a server for the tools of registry
+func NewServer(registry *tools.Registry, config ServerConfig) *Server {
+	if config.Info.Name == "" {
+		config.Info = Implementation{Name: "gollm", Version: "1.0.0"}
+	}
+	return &Server{registry: registry, config: config}
+}
+
+// ServeStdio serves a single client over the standard input and output of
+// the process, as MCP hosts expect from servers they launch. It returns when
+// the client closes standard input or ctx is done.
+func (s *Server) ServeStdio(ctx context.Context) error {
+	return s.Serve(ctx, NewStreamTransport(os.Stdin, os.Stdout))
+}
+
+// Serve serves a single client connected over transport until the client
+// disconnects, returning nil, or ctx is done. Requests are handled
+// concurrently, and the transport is closed on return.
+func (s *Server) Serve(ctx context.Context, transport Transport) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() { transport.Close() })
+	defer stop()
+
+	conn := &serverConn{server: s, transport: transport, inFlight: make(map[string]context.CancelFunc)}
+	defer func() {
+		// Stop the requests still being handled before waiting for them
+		cancel()
+		conn.wait.Wait()
+	}()
+
+	for {
+		data, err := transport.Receive()
+		if err != nil {
+			transport.Close()
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		conn.dispatch(ctx, data)
+	}
+}
+
+// serverConn is a connection served by a Server
+type serverConn struct {
+	server    *Server
+	transport Transport
+	sendMu    sync.Mutex
+	wait      sync.WaitGroup
+
+	// inFlight holds the cancel functions of the requests being handled, by
+	// ID, for notifications/cancelled
+	mu       sync.Mutex
+	inFlight map[string]context.CancelFunc
+}
+
+// dispatch handles a message of the client; requests are handled in their
+// own goroutine
+func (c *serverConn) dispatch(ctx context.Context, data []byte) {
+	var msg message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.reply(&message{ID: json.RawMessage("null"), Error: &RPCError{Code: CodeParseError, Message: "parse error"}})
+		return
+	}
+
+	switch {
+	case msg.isNotification():
+		if msg.Method == "notifications/cancelled" {
+			var params struct {
+				RequestID json.RawMessage `json:"requestId"`
+			}
+			if json.Unmarshal(msg.Params, &params) == nil {
+				c.cancel(string(params.RequestID))
+			}
+		}
+	case msg.isRequest():
+		reqCtx, cancel := context.WithCancel(ctx)
+		c.mu.Lock()
+		c.inFlight[string(msg.ID)] = cancel
+		c.mu.Unlock()
+
+		c.wait.Add(1)
+		go func() {
+			defer c.wait.Done()
+			defer c.cancel(string(msg.ID))
+			c.reply(c.handle(reqCtx, &msg))
+		}()
+	case msg.Method == "" && len(msg.ID) == 0:
+		c.reply(&message{ID: json.RawMessage("null"), Error: &RPCError{Code: CodeInvalidRequest, Message: "invalid request"}})
+	}
+	// Responses are ignored, as the server sends no requests
+}
+
+func (c *serverConn) cancel(id string) {
+	c.mu.Lock()
+	cancel, ok := c.inFlight[id]
+	delete(c.inFlight, id)
+	c.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// handle returns the response to a request
+func (c *serverConn) handle(ctx context.Context, req *message) *message {
+	result, rpcErr := c.result(ctx, req)
+	resp := &message{ID: req.ID, Error: rpcErr}
+	if rpcErr == nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			resp.Error = &RPCError{Code: CodeInternalError, Message: err.Error()}
+		} else {
+			resp.Result = data
+		}
+	}
+	return resp
+}
+
+func (c *serverConn) result(ctx context.Context, req *message) (any, *RPCError) {
+	switch req.Method {
+	case "initialize":
+		var params initializeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: "invalid params: " + err.Error()}
+		}
+		return initializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Capabilities:    map[string]any{"tools": map[string]any{}},
+			ServerInfo:      c.server.config.Info,
+			Instructions:    c.server.config.Instructions,
+		}, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return c.server.listTools(), nil
+	case "tools/call":
+		var params callToolParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: "invalid params: " + err.Error()}
+		}
+		return c.server.callTool(ctx, params)
+	}
+	return nil, &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + req.Method}
+}
+
+func (c *serverConn) reply(resp *message) {
+	resp.JSONRPC = "2.0"
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	c.transport.Send(context.Background(), data)
+}
+
+func (s *Server) listTools() listToolsResult {
+	result := listToolsResult{Tools: []ToolInfo{}}
+	for _, definition := range s.registry.Definitions() {
+		schema := json.RawMessage(`{"type":"object"}`)
+		if definition.Function.Parameters != nil {
+			if data, err := json.Marshal(definition.Function.Parameters); err == nil && string(data) != "null" {
+				schema = data
+			}
+		}
+		result.Tools = append(result.Tools, ToolInfo{
+			Name:        definition.Function.Name,
+			Description: definition.Function.Description,
+			InputSchema: schema,
+		})
+	}
+	return result
+}
+
+// callTool runs a tool. Failures of the tool, including invalid arguments,
+// are reported in the result so that the model sees them; calling an
+// unknown tool is a protocol error.
+func (s *Server) callTool(ctx context.Context, params callToolParams) (*CallToolResult, *RPCError) {
+	var call llm.ToolCall
+	call.Type = "function"
+	call.Function.Name = params.Name
+	if string(params.Arguments) != "null" {
+		call.Function.Arguments = string(params.Arguments)
+	}
+
+	content, err := s.registry.Call(ctx, call)
+	if errors.Is(err, tools.ErrUnknownTool) {
+		return nil, &RPCError{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	if err != nil {
+		return &CallToolResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+	}
+	return &CallToolResult{Content: []Content{{Type: "text", Text: content}}}, nil
+}
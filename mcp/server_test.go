@@ -0,0 +1,205 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aiwizzard/gollm/tools"
+)
+
+type addParams struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+// testRegistry returns tools adding numbers, panicking and blocking until
+// canceled, the latter reporting its cancellation on canceled
+func testRegistry(canceled chan<- error) *tools.Registry {
+	return tools.NewRegistry(
+		tools.New("add", "Add two numbers", func(ctx context.Context, params addParams) (string, error) {
+			return fmt.Sprint(params.A + params.B), nil
+		}),
+		&tools.Tool{Name: "boom", Handler: func(ctx context.Context, arguments string) (string, error) {
+			panic("kaboom")
+		}},
+		&tools.Tool{Name: "block", Handler: func(ctx context.Context, arguments string) (string, error) {
+			<-ctx.Done()
+			canceled <- ctx.Err()
+			return "", ctx.Err()
+		}},
+	)
+}
+
+func TestServer(t *testing.T) {
+	canceled := make(chan error, 1)
+	server := NewServer(testRegistry(canceled), ServerConfig{
+		Info:         Implementation{Name: "calc", Version: "2.0"},
+		Instructions: "Use add for sums.",
+	})
+
+	clientTransport, serverTransport := pipeTransports()
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(context.Background(), serverTransport) }()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, clientTransport, ClientConfig{})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if client.ServerInfo != (Implementation{Name: "calc", Version: "2.0"}) || client.Instructions != "Use add for sums." {
+		t.Errorf("ServerInfo = %+v, Instructions = %q", client.ServerInfo, client.Instructions)
+	}
+
+	infos, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools() error = %v", err)
+	}
+	if len(infos) != 3 || infos[0].Name != "add" || infos[0].Description != "Add two numbers" {
+		t.Fatalf("ListTools() = %+v", infos)
+	}
+	var schema map[string]any
+	json.Unmarshal(infos[0].InputSchema, &schema)
+	if !reflect.DeepEqual(schema["required"], []any{"a", "b"}) {
+		t.Errorf("add input schema = %s", infos[0].InputSchema)
+	}
+	if string(infos[1].InputSchema) != `{"type":"object"}` {
+		t.Errorf("block input schema = %s, want an object", infos[1].InputSchema)
+	}
+
+	tests := []struct {
+		name      string
+		tool      string
+		arguments string
+		want      string
+		wantError bool
+	}{
+		{name: "result", tool: "add", arguments: `{"a": 2, "b": 3}`, want: "5"},
+		{name: "invalid arguments", tool: "add", arguments: `{"a": "two"}`, want: "tools: invalid arguments for add: b: missing required property; a: must be an integer", wantError: true},
+		{name: "panic", tool: "boom", want: "tools: boom panicked: kaboom", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var arguments json.RawMessage
+			if tt.arguments != "" {
+				arguments = json.RawMessage(tt.arguments)
+			}
+			result, err := client.CallTool(ctx, tt.tool, arguments)
+			if err != nil {
+				t.Fatalf("CallTool() error = %v", err)
+			}
+			if result.IsError != tt.wantError || result.Text() != tt.want {
+				t.Errorf("CallTool() = %q (error %v), want %q (error %v)", result.Text(), result.IsError, tt.want, tt.wantError)
+			}
+		})
+	}
+
+	var rpcErr *RPCError
+	if _, err := client.CallTool(ctx, "missing", nil); !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidParams {
+		t.Errorf("CallTool(missing) error = %v, want an invalid params error", err)
+	}
+
+	// Canceling a call cancels the tool on the server
+	callCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.CallTool(callCtx, "block", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CallTool(block) error = %v", err)
+	}
+	select {
+	case err := <-canceled:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("tool context error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Error("tool not canceled")
+	}
+
+	client.Close()
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Serve() error = %v, want nil once the client disconnects", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Serve() did not return after the client disconnected")
+	}
+}
+
+func TestServer_Protocol(t *testing.T) {
+	clientTransport, serverTransport := pipeTransports()
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- NewServer(tools.NewRegistry(), ServerConfig{}).Serve(ctx, serverTransport) }()
+
+	tests := []struct {
+		request string
+		want    string
+	}{
+		{request: `{"jsonrpc":"2.0","id":"a","method":"ping"}`, want: `{"jsonrpc":"2.0","id":"a","result":{}}`},
+		{request: `{"jsonrpc":"2.0","id":7,"method":"tools/list"}`, want: `{"jsonrpc":"2.0","id":7,"result":{"tools":[]}}`},
+		{request: `{"jsonrpc":"2.0","id":8,"method":"resources/list"}`, want: `{"jsonrpc":"2.0","id":8,"error":{"code":-32601,"message":"method not found: resources/list"}}`},
+		{request: `{"jsonrpc":"2.0","id":9,"method":"tools/call","params":[]}`, want: `"code":-32602`},
+		{request: `{not json`, want: `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"parse error"}}`},
+		{request: `{"jsonrpc":"2.0"}`, want: `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"invalid request"}}`},
+	}
+	for _, tt := range tests {
+		if err := clientTransport.Send(ctx, []byte(tt.request)); err != nil {
+			t.Fatal(err)
+		}
+		got, err := clientTransport.Receive()
+		if err != nil {
+			t.Fatalf("Receive() error = %v", err)
+		}
+		if !strings.Contains(string(got), tt.want) {
+			t.Errorf("response to %s = %s, want %s", tt.request, got, tt.want)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-served:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Serve() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Serve() did not return when its context was canceled")
+	}
+}
+
+func TestServer_SSEHandler(t *testing.T) {
+	server := NewServer(testRegistry(make(chan error, 1)), ServerConfig{})
+	httpServer := httptest.NewServer(http.StripPrefix("/mcp", server.SSEHandler()))
+	defer httpServer.Close()
+
+	ctx := context.Background()
+	transport, err := NewSSETransport(ctx, httpServer.URL+"/mcp/", nil)
+	if err != nil {
+		t.Fatalf("NewSSETransport() error = %v", err)
+	}
+	client, err := NewClient(ctx, transport, ClientConfig{})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	result, err := client.CallTool(ctx, "add", json.RawMessage(`{"a": 40, "b": 2}`))
+	if err != nil || result.Text() != "42" {
+		t.Errorf("CallTool() = %+v, %v", result, err)
+	}
+
+	resp, err := http.Post(httpServer.URL+"/mcp/?sessionId=unknown", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("POST to an unknown session status = %d, want 404", resp.StatusCode)
+	}
+}
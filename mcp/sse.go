@@ -0,0 +1,300 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// sseTransport is the client side of the HTTP with server-sent events
+// transport: messages from the server arrive as events of a long-lived GET
+// request, and messages to it are POSTed to the endpoint it announces
+type sseTransport struct {
+	client   *http.Client
+	events   *bufio.Reader
+	body     io.Closer
+	endpoint string
+	cancel   context.CancelFunc
+}
+
+// NewSSETransport connects to the SSE endpoint of an MCP server at rawURL and
+// waits for the server to announce where messages are posted. The context
+// bounds the connection attempt only. client is used for all requests
+// (optional, defaults to http.DefaultClient); it must not time out the
+// event stream.
+func NewSSETransport(ctx context.Context, rawURL string, client *http.Client) (Transport, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: invalid URL: %w", err)
+	}
+
+	// The stream outlives ctx, which only bounds waiting for the endpoint
+	streamCtx, cancel := context.WithCancel(context.Background())
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("mcp: failed to connect: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("mcp: failed to connect: %s", resp.Status)
+	}
+
+	t := &sseTransport{client: client, events: bufio.NewReader(resp.Body), body: resp.Body, cancel: cancel}
+	event, data, err := t.nextEvent()
+	if err != nil {
+		t.Close()
+		return nil, fmt.Errorf("mcp: failed to read endpoint: %w", err)
+	}
+	if event != "endpoint" {
+		t.Close()
+		return nil, fmt.Errorf("mcp: expected an endpoint event, got %q", event)
+	}
+	endpoint, err := base.Parse(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Close()
+		return nil, fmt.Errorf("mcp: invalid endpoint: %w", err)
+	}
+	t.endpoint = endpoint.String()
+	return t, nil
+}
+
+// nextEvent reads the next event of the stream, skipping comments
+func (t *sseTransport) nextEvent() (event string, data []byte, err error) {
+	var lines [][]byte
+	for {
+		line, readErr := t.events.ReadBytes('\n')
+		if readErr != nil && len(line) == 0 {
+			return "", nil, readErr
+		}
+		line = bytes.TrimRight(line, "\r\n")
+
+		if len(line) == 0 {
+			if len(lines) == 0 && event == "" {
+				continue
+			}
+			if event == "" {
+				event = "message"
+			}
+			return event, bytes.Join(lines, []byte("\n")), nil
+		}
+
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+		switch string(field) {
+		case "event":
+			event = string(value)
+		case "data":
+			lines = append(lines, value)
+		}
+	}
+}
+
+func (t *sseTransport) Receive() ([]byte, error) {
+	for {
+		event, data, err := t.nextEvent()
+		if err != nil {
+			return nil, err
+		}
+		if event == "message" && len(data) > 0 {
+			return data, nil
+		}
+	}
+}
+
+func (t *sseTransport) Send(ctx context.Context, msg []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(msg))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("mcp: failed to send message: %s", resp.Status)
+	}
+	return nil
+}
+
+func (t *sseTransport) Close() error {
+	t.cancel()
+	err := t.body.Close()
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// maxMessageSize caps the messages posted to an SSE handler
+const maxMessageSize = 4 << 20
+
+// SSEHandler returns an HTTP handler serving s over HTTP with server-sent
+// events. A GET request opens a session: its response streams the messages
+// of the server, starting with an endpoint event giving the URL where the
+// client POSTs its messages: the URL it connected to with a session ID, as a
+// relative reference so that the handler can be mounted under any prefix.
+// Each session is served until its GET request ends.
+func (s *Server) SSEHandler() http.Handler {
+	return &sseHandler{server: s, sessions: make(map[string]*sseSession)}
+}
+
+type sseHandler struct {
+	server *Server
+
+	mu       sync.Mutex
+	sessions map[string]*sseSession
+}
+
+// sseSession is the server side transport of a session: POSTed messages
+// arrive on in, and messages sent to the client leave on out
+type sseSession struct {
+	in, out   chan []byte
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (t *sseSession) Send(ctx context.Context, msg []byte) error {
+	select {
+	case t.out <- msg:
+		return nil
+	case <-t.closed:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *sseSession) Receive() ([]byte, error) {
+	select {
+	case msg := <-t.in:
+		return msg, nil
+	case <-t.closed:
+		return nil, io.EOF
+	}
+}
+
+func (t *sseSession) Close() error {
+	t.closeOnce.Do(func() { close(t.closed) })
+	return nil
+}
+
+func (h *sseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.stream(w, r)
+	case http.MethodPost:
+		h.post(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// stream opens a session and streams its messages until the request ends
+func (h *sseHandler) stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		http.Error(w, "failed to create session", http.StatusInternalServerError)
+		return
+	}
+	session := &sseSession{in: make(chan []byte), out: make(chan []byte), closed: make(chan struct{})}
+	h.mu.Lock()
+	h.sessions[id] = session
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.sessions, id)
+		h.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	endpoint := "?" + url.Values{"sessionId": {id}}.Encode()
+	fmt.Fprintf(w, "event: endpoint\ndata: %s\n\n", endpoint)
+	flusher.Flush()
+
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		h.server.Serve(r.Context(), session)
+	}()
+
+	for {
+		select {
+		case msg := <-session.out:
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg)
+			flusher.Flush()
+		case <-session.closed:
+			<-served
+			return
+		}
+	}
+}
+
+// post delivers a message of the client to its session
+func (h *sseHandler) post(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	session, ok := h.sessions[r.URL.Query().Get("sessionId")]
+	h.mu.Unlock()
+	if !ok {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageSize))
+	if err != nil {
+		http.Error(w, "invalid message", http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case session.in <- body:
+		w.WriteHeader(http.StatusAccepted)
+	case <-session.closed:
+		http.Error(w, "session closed", http.StatusNotFound)
+	case <-r.Context().Done():
+	}
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,130 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// chanTransport is the server end of a connection whose messages are moved
+// by an HTTP handler
+type chanTransport struct {
+	in, out chan []byte
+	closed  chan struct{}
+}
+
+func (t *chanTransport) Send(ctx context.Context, msg []byte) error {
+	select {
+	case t.out <- msg:
+		return nil
+	case <-t.closed:
+		return io.ErrClosedPipe
+	}
+}
+
+func (t *chanTransport) Receive() ([]byte, error) {
+	select {
+	case msg := <-t.in:
+		return msg, nil
+	case <-t.closed:
+		return nil, io.EOF
+	}
+}
+
+func (t *chanTransport) Close() error { return nil }
+
+// sseServer serves a fake MCP server over HTTP with server-sent events
+func sseServer(t *testing.T) *httptest.Server {
+	transport := &chanTransport{in: make(chan []byte), out: make(chan []byte), closed: make(chan struct{})}
+	newFakeServer(transport)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/event-stream" {
+			t.Errorf("Accept = %q", r.Header.Get("Accept"))
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": connected\n\nevent: endpoint\ndata: /messages?session=1\n\n")
+		w.(http.Flusher).Flush()
+
+		for {
+			select {
+			case msg := <-transport.out:
+				fmt.Fprintf(w, "event: message\r\ndata: %s\r\n\r\n", msg)
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	mux.HandleFunc("/messages", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Query().Get("session") != "1" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+		go func() { transport.in <- body }()
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(func() {
+		server.CloseClientConnections()
+		server.Close()
+		close(transport.closed)
+	})
+	return server
+}
+
+func TestSSETransport(t *testing.T) {
+	server := sseServer(t)
+	ctx := context.Background()
+
+	transport, err := NewSSETransport(ctx, server.URL+"/sse", nil)
+	if err != nil {
+		t.Fatalf("NewSSETransport() error = %v", err)
+	}
+	client, err := NewClient(ctx, transport, ClientConfig{Info: Implementation{Name: "test", Version: "1"}})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	if client.ServerInfo.Name != "fake" {
+		t.Errorf("ServerInfo = %+v", client.ServerInfo)
+	}
+	result, err := client.CallTool(ctx, "echo", []byte(`{"text": "over sse"}`))
+	if err != nil || !strings.HasPrefix(result.Text(), "over sse") {
+		t.Errorf("CallTool() = %+v, %v", result, err)
+	}
+}
+
+func TestSSETransport_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/no-endpoint":
+			fmt.Fprint(w, "event: message\ndata: {}\n\n")
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{path: "/missing", wantErr: "404 Not Found"},
+		{path: "/no-endpoint", wantErr: `expected an endpoint event, got "message"`},
+	}
+	for _, tt := range tests {
+		_, err := NewSSETransport(context.Background(), server.URL+tt.path, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("NewSSETransport(%s) error = %v, want %q", tt.path, err, tt.wantErr)
+		}
+	}
+}
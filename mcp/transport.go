@@ -0,0 +1,135 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Transport carries the JSON-RPC messages of a connection. Receive is called
+// from a single goroutine, concurrently with Send; Send is never called
+// concurrently with itself.
+type Transport interface {
+	// Send sends a message
+	Send(ctx context.Context, msg []byte) error
+
+	// Receive blocks until a message arrives, returning io.EOF once the
+	// connection is closed by the other side
+	Receive() ([]byte, error)
+
+	// Close closes the connection
+	Close() error
+}
+
+// streamTransport exchanges newline-delimited messages over a reader and a
+// writer, as the stdio transport does
+type streamTransport struct {
+	reader  *bufio.Reader
+	writer  io.Writer
+	closers []io.Closer
+}
+
+// NewStreamTransport returns a transport reading newline-delimited messages
+// from r and writing them to w, such as the standard input and output of a
+// process. Closing it closes w and r if they are io.Closers.
+func NewStreamTransport(r io.Reader, w io.Writer) Transport {
+	t := &streamTransport{reader: bufio.NewReader(r), writer: w}
+	for _, v := range []any{w, r} {
+		if closer, ok := v.(io.Closer); ok {
+			t.closers = append(t.closers, closer)
+		}
+	}
+	return t
+}
+
+func (t *streamTransport) Send(ctx context.Context, msg []byte) error {
+	if bytes.ContainsAny(msg, "\r\n") {
+		return errors.New("mcp: message contains a line break")
+	}
+	_, err := t.writer.Write(append(msg, '\n'))
+	return err
+}
+
+func (t *streamTransport) Receive() ([]byte, error) {
+	for {
+		line, err := t.reader.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			// A final message without a line break is still delivered
+			return line, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *streamTransport) Close() error {
+	var errs []error
+	for _, closer := range t.closers {
+		errs = append(errs, closer.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// commandTransport is the stdio transport of a server running as a
+// subprocess
+type commandTransport struct {
+	Transport
+	cmd *exec.Cmd
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// commandShutdownTimeout is how long Close waits for a server process to exit
+// after closing its standard input before killing it
+var commandShutdownTimeout = 5 * time.Second
+
+// NewCommandTransport starts cmd and returns a transport talking to it over
+// its standard input and output. The server's standard error goes to
+// cmd.Stderr if set. Closing the transport closes the server's standard
+// input and waits for it to exit, killing it if it does not exit in time.
+func NewCommandTransport(cmd *exec.Cmd) (Transport, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("mcp: failed to start server: %w", err)
+	}
+	// Only standard input is closed, telling the server to exit; standard
+	// output is closed by Wait
+	stream := &streamTransport{reader: bufio.NewReader(stdout), writer: stdin, closers: []io.Closer{stdin}}
+	return &commandTransport{Transport: stream, cmd: cmd}, nil
+}
+
+func (t *commandTransport) Close() error {
+	t.closeOnce.Do(func() {
+		t.Transport.Close()
+
+		exited := make(chan error, 1)
+		go func() { exited <- t.cmd.Wait() }()
+		select {
+		case err := <-exited:
+			var exitErr *exec.ExitError
+			if err != nil && !errors.As(err, &exitErr) {
+				t.closeErr = err
+			}
+		case <-time.After(commandShutdownTimeout):
+			t.cmd.Process.Kill()
+			<-exited
+		}
+	})
+	return t.closeErr
+}
@@ -0,0 +1,74 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestStreamTransport(t *testing.T) {
+	var out bytes.Buffer
+	transport := NewStreamTransport(strings.NewReader("{\"id\":1}\n\n  \r\n{\"id\":2}\r\n{\"id\":3}"), &out)
+
+	for _, want := range []string{`{"id":1}`, `{"id":2}`, `{"id":3}`} {
+		got, err := transport.Receive()
+		if err != nil || string(got) != want {
+			t.Errorf("Receive() = %s, %v, want %s", got, err, want)
+		}
+	}
+	if _, err := transport.Receive(); err != io.EOF {
+		t.Errorf("Receive() error = %v, want EOF", err)
+	}
+
+	if err := transport.Send(context.Background(), []byte(`{"a":1}`)); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if err := transport.Send(context.Background(), []byte("{\n}")); err == nil {
+		t.Error("Send() accepted a message with a line break")
+	}
+	if out.String() != "{\"a\":1}\n" {
+		t.Errorf("written = %q", out.String())
+	}
+}
+
+// TestHelperServer is not a test: it runs the fake server over standard input
+// and output when started by TestCommandTransport
+func TestHelperServer(t *testing.T) {
+	if os.Getenv("MCP_HELPER_SERVER") != "1" {
+		t.Skip("only run as the server of TestCommandTransport")
+	}
+	server := &fakeServer{transport: NewStreamTransport(os.Stdin, os.Stdout), pong: make(chan *message, 1)}
+	server.serve()
+	os.Exit(0)
+}
+
+func TestCommandTransport(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperServer$")
+	cmd.Env = append(os.Environ(), "MCP_HELPER_SERVER=1")
+	cmd.Stderr = os.Stderr
+
+	transport, err := NewCommandTransport(cmd)
+	if err != nil {
+		t.Fatalf("NewCommandTransport() error = %v", err)
+	}
+	client, err := NewClient(context.Background(), transport, ClientConfig{})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	result, err := client.CallTool(context.Background(), "echo", []byte(`{"text": "over stdio"}`))
+	if err != nil || !strings.HasPrefix(result.Text(), "over stdio") {
+		t.Errorf("CallTool() = %+v, %v", result, err)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if !cmd.ProcessState.Exited() || !cmd.ProcessState.Success() {
+		t.Errorf("server state = %v, want a clean exit", cmd.ProcessState)
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import "github.com/aiwizzard/gollm/llm"
+
+// builtin are the models known without registration
+var builtin = []Model{
+	// OpenAI
+	{Name: "gpt-4o", Provider: "openai", ContextWindow: 128000, MaxOutputTokens: 16384, Tools: true, Vision: true, Price: llm.Price{Input: 2.50, Output: 10.00}},
+	{Name: "gpt-4o-mini", Provider: "openai", ContextWindow: 128000, MaxOutputTokens: 16384, Tools: true, Vision: true, Price: llm.Price{Input: 0.15, Output: 0.60}},
+	{Name: "gpt-4.1", Provider: "openai", ContextWindow: 1047576, MaxOutputTokens: 32768, Tools: true, Vision: true, Price: llm.Price{Input: 2.00, Output: 8.00}},
+	{Name: "gpt-4.1-mini", Provider: "openai", ContextWindow: 1047576, MaxOutputTokens: 32768, Tools: true, Vision: true, Price: llm.Price{Input: 0.40, Output: 1.60}},
+	{Name: "gpt-4.1-nano", Provider: "openai", ContextWindow: 1047576, MaxOutputTokens: 32768, Tools: true, Vision: true, Price: llm.Price{Input: 0.10, Output: 0.40}},
+	{Name: "gpt-4-turbo", Provider: "openai", ContextWindow: 128000, MaxOutputTokens: 4096, Tools: true, Vision: true, Price: llm.Price{Input: 10.00, Output: 30.00}},
+	{Name: "gpt-3.5-turbo", Provider: "openai", ContextWindow: 16385, MaxOutputTokens: 4096, Tools: true, Price: llm.Price{Input: 0.50, Output: 1.50}},
+	{Name: "o1", Provider: "openai", ContextWindow: 200000, MaxOutputTokens: 100000, Tools: true, Vision: true, Price: llm.Price{Input: 15.00, Output: 60.00}},
+	{Name: "o3", Provider: "openai", ContextWindow: 200000, MaxOutputTokens: 100000, Tools: true, Vision: true, Price: llm.Price{Input: 2.00, Output: 8.00}},
+	{Name: "o3-mini", Provider: "openai", ContextWindow: 200000, MaxOutputTokens: 100000, Tools: true, Price: llm.Price{Input: 1.10, Output: 4.40}},
+	{Name: "o4-mini", Provider: "openai", ContextWindow: 200000, MaxOutputTokens: 100000, Tools: true, Vision: true, Price: llm.Price{Input: 1.10, Output: 4.40}},
+
+	// Anthropic
+	{Name: "claude-opus-4", Provider: "anthropic", ContextWindow: 200000, MaxOutputTokens: 32000, Tools: true, Vision: true, Price: llm.Price{Input: 15.00, Output: 75.00}},
+	{Name: "claude-sonnet-4", Provider: "anthropic", ContextWindow: 200000, MaxOutputTokens: 64000, Tools: true, Vision: true, Price: llm.Price{Input: 3.00, Output: 15.00}},
+	{Name: "claude-3-7-sonnet", Provider: "anthropic", ContextWindow: 200000, MaxOutputTokens: 64000, Tools: true, Vision: true, Price: llm.Price{Input: 3.00, Output: 15.00}},
+	{Name: "claude-3-5-sonnet", Provider: "anthropic", ContextWindow: 200000, MaxOutputTokens: 8192, Tools: true, Vision: true, Price: llm.Price{Input: 3.00, Output: 15.00}},
+	{Name: "claude-3-5-haiku", Provider: "anthropic", ContextWindow: 200000, MaxOutputTokens: 8192, Tools: true, Price: llm.Price{Input: 0.80, Output: 4.00}},
+	{Name: "claude-3-opus", Provider: "anthropic", ContextWindow: 200000, MaxOutputTokens: 4096, Tools: true, Vision: true, Price: llm.Price{Input: 15.00, Output: 75.00}},
+	{Name: "claude-3-haiku", Provider: "anthropic", ContextWindow: 200000, MaxOutputTokens: 4096, Tools: true, Vision: true, Price: llm.Price{Input: 0.25, Output: 1.25}},
+
+	// Cohere
+	{Name: "command-r-plus", Provider: "cohere", ContextWindow: 128000, MaxOutputTokens: 4000, Tools: true, Price: llm.Price{Input: 2.50, Output: 10.00}},
+	{Name: "command-r", Provider: "cohere", ContextWindow: 128000, MaxOutputTokens: 4000, Tools: true, Price: llm.Price{Input: 0.15, Output: 0.60}},
+
+	// Groq
+	{Name: "llama-3.3-70b-versatile", Provider: "groq", ContextWindow: 131072, MaxOutputTokens: 32768, Tools: true, Price: llm.Price{Input: 0.59, Output: 0.79}},
+	{Name: "llama-3.1-8b-instant", Provider: "groq", ContextWindow: 131072, MaxOutputTokens: 8192, Tools: true, Price: llm.Price{Input: 0.05, Output: 0.08}},
+
+	// DeepSeek
+	{Name: "deepseek-chat", Provider: "deepseek", ContextWindow: 64000, MaxOutputTokens: 8192, Tools: true, Price: llm.Price{Input: 0.27, Output: 1.10}},
+	{Name: "deepseek-reasoner", Provider: "deepseek", ContextWindow: 64000, MaxOutputTokens: 8192, Price: llm.Price{Input: 0.55, Output: 2.19}},
+
+	// DashScope
+	{Name: "qwen-max", Provider: "dashscope", ContextWindow: 32768, MaxOutputTokens: 8192, Tools: true, Price: llm.Price{Input: 1.60, Output: 6.40}},
+	{Name: "qwen-plus", Provider: "dashscope", ContextWindow: 131072, MaxOutputTokens: 8192, Tools: true, Price: llm.Price{Input: 0.40, Output: 1.20}},
+	{Name: "qwen-turbo", Provider: "dashscope", ContextWindow: 1000000, MaxOutputTokens: 8192, Tools: true, Price: llm.Price{Input: 0.05, Output: 0.20}},
+}
@@ -0,0 +1,128 @@
+// Package models is a catalog of known models: their context windows,
+// output limits, capabilities and prices. Budgeting features use it to trim
+// prompts to fit a model and to estimate what a request costs.
+//
+// The built-in entries reflect the providers' published figures at the time
+// of writing. Prices change, so Register corrected or custom entries where
+// accuracy matters.
+package models
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aiwizzard/gollm/llm"
+)
+
+// Model describes a model
+type Model struct {
+	// Name is the model name as passed in CompletionRequest.Model
+	Name string
+
+	// Provider is the name of the provider serving the model, as accepted
+	// by llm.New
+	Provider string
+
+	// ContextWindow is the number of tokens the prompt and the completion
+	// share
+	ContextWindow int
+
+	// MaxOutputTokens is the largest completion the model generates
+	MaxOutputTokens int
+
+	// Tools and Vision report whether the model supports tool calling and
+	// image inputs
+	Tools  bool
+	Vision bool
+
+	// Price is what the model costs per million tokens, in US dollars
+	Price llm.Price
+}
+
+// MaxInputTokens returns how many prompt tokens fit in the context window
+// when up to maxTokens are reserved for the completion. A maxTokens of zero
+// reserves MaxOutputTokens.
+func (m Model) MaxInputTokens(maxTokens int) int {
+	if maxTokens <= 0 {
+		maxTokens = m.MaxOutputTokens
+	}
+	return max(m.ContextWindow-maxTokens, 0)
+}
+
+// Cost returns the cost in US dollars of a request with the given token
+// counts
+func (m Model) Cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*m.Price.Input + float64(outputTokens)*m.Price.Output) / 1e6
+}
+
+var (
+	mu      sync.RWMutex
+	catalog = make(map[string]Model)
+)
+
+func init() {
+	for _, m := range builtin {
+		catalog[m.Name] = m
+	}
+}
+
+// Register adds m to the catalog, replacing any entry with the same name
+func Register(m Model) {
+	mu.Lock()
+	defer mu.Unlock()
+	catalog[m.Name] = m
+}
+
+// Lookup returns the model called name. Versioned names such as
+// "gpt-4o-2024-08-06" or "claude-3-5-sonnet-latest" resolve to the entry
+// with the longest name they extend, so snapshots share the metadata of
+// their model.
+func Lookup(name string) (Model, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if m, ok := catalog[name]; ok {
+		return m, true
+	}
+
+	var best Model
+	var found bool
+	for key, m := range catalog {
+		if strings.HasPrefix(name, key+"-") && len(key) > len(best.Name) {
+			best, found = m, true
+		}
+	}
+	return best, found
+}
+
+// All returns every model in the catalog, sorted by name
+func All() []Model {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	all := make([]Model, 0, len(catalog))
+	for _, m := range catalog {
+		all = append(all, m)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// Prices returns the prices of every model in the catalog, with their
+// MaxOutputTokens, for use as llm.CostCeilingConfig.Prices. CostCeiling
+// resolves versioned names to these entries the way Lookup does.
+func Prices() map[string]llm.Price {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	prices := make(map[string]llm.Price, len(catalog))
+	for name, m := range catalog {
+		price := m.Price
+		if price.MaxOutputTokens == 0 {
+			price.MaxOutputTokens = m.MaxOutputTokens
+		}
+		prices[name] = price
+	}
+	return prices
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/aiwizzard/gollm/llm"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{name: "gpt-4o", want: "gpt-4o", wantOK: true},
+		{name: "gpt-4o-2024-08-06", want: "gpt-4o", wantOK: true},
+		{name: "gpt-4o-mini-2024-07-18", want: "gpt-4o-mini", wantOK: true},
+		{name: "claude-3-5-sonnet-latest", want: "claude-3-5-sonnet", wantOK: true},
+		{name: "claude-sonnet-4-20250514", want: "claude-sonnet-4", wantOK: true},
+		{name: "gpt-4oo"},
+		{name: "unknown-model"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Lookup(tt.name)
+			if ok != tt.wantOK || got.Name != tt.want {
+				t.Errorf("Lookup() = %q, %v, want %q, %v", got.Name, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	t.Cleanup(func() {
+		mu.Lock()
+		delete(catalog, "my-model")
+		catalog["gpt-4o"] = builtin[0]
+		mu.Unlock()
+	})
+
+	Register(Model{Name: "my-model", Provider: "compatible", ContextWindow: 8192, MaxOutputTokens: 1024})
+	if m, ok := Lookup("my-model-q4"); !ok || m.ContextWindow != 8192 {
+		t.Errorf("Lookup() = %+v, %v, want registered model", m, ok)
+	}
+
+	Register(Model{Name: "gpt-4o", Price: llm.Price{Input: 1, Output: 2}})
+	if m, _ := Lookup("gpt-4o"); m.Price.Input != 1 {
+		t.Errorf("Price.Input = %v, want overridden 1", m.Price.Input)
+	}
+}
+
+func TestModel_MaxInputTokens(t *testing.T) {
+	m := Model{ContextWindow: 8000, MaxOutputTokens: 2000}
+
+	tests := []struct {
+		maxTokens int
+		want      int
+	}{
+		{maxTokens: 0, want: 6000},
+		{maxTokens: 500, want: 7500},
+		{maxTokens: 9000, want: 0},
+	}
+	for _, tt := range tests {
+		if got := m.MaxInputTokens(tt.maxTokens); got != tt.want {
+			t.Errorf("MaxInputTokens(%d) = %d, want %d", tt.maxTokens, got, tt.want)
+		}
+	}
+}
+
+func TestModel_Cost(t *testing.T) {
+	m := Model{Price: llm.Price{Input: 2.50, Output: 10.00}}
+	if got, want := m.Cost(1000, 500), 0.0075; math.Abs(got-want) > 1e-12 {
+		t.Errorf("Cost() = %v, want %v", got, want)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	all := All()
+	if len(all) != len(builtin) {
+		t.Errorf("All() returned %d models, want %d", len(all), len(builtin))
+	}
+	if !sort.SliceIsSorted(all, func(i, j int) bool { return all[i].Name < all[j].Name }) {
+		t.Error("All() is not sorted by name")
+	}
+
+	for _, m := range all {
+		if m.Provider == "" || m.ContextWindow <= 0 || m.MaxOutputTokens <= 0 || m.MaxOutputTokens > m.ContextWindow {
+			t.Errorf("%s: incomplete or inconsistent entry %+v", m.Name, m)
+		}
+		if _, err := llm.New(m.Provider, llm.Config{APIKey: "key"}); err != nil {
+			t.Errorf("%s: unknown provider: %v", m.Name, err)
+		}
+	}
+}
+
+func TestPrices_CostCeiling(t *testing.T) {
+	var called bool
+	next := completeFunc(func(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+		called = true
+		return &llm.CompletionResponse{}, nil
+	})
+	provider := llm.Chain(next, llm.CostCeiling(llm.CostCeilingConfig{Prices: Prices(), MaxCost: 0.01}))
+
+	if _, err := provider.Complete(context.Background(), &llm.CompletionRequest{Model: "gpt-4o-mini", Prompt: "Hi", MaxTokens: 100}); err != nil || !called {
+		t.Errorf("Complete() error = %v, called = %v", err, called)
+	}
+
+	// Snapshot names resolve to their model as in Lookup
+	called = false
+	if _, err := provider.Complete(context.Background(), &llm.CompletionRequest{Model: "gpt-4o-mini-2024-07-18", Prompt: "Hi", MaxTokens: 100}); err != nil || !called {
+		t.Errorf("Complete() with a snapshot name error = %v, called = %v", err, called)
+	}
+
+	var ceiling *llm.CostCeilingError
+	_, err := provider.Complete(context.Background(), &llm.CompletionRequest{Model: "claude-opus-4", Prompt: "Hi", MaxTokens: 1000})
+	if !errors.As(err, &ceiling) {
+		t.Errorf("Complete() error = %v, want *CostCeilingError", err)
+	}
+}
+
+func TestPrices(t *testing.T) {
+	prices := Prices()
+	mini, _ := Lookup("gpt-4o-mini")
+	if got := prices["gpt-4o-mini"]; got.Input != mini.Price.Input || got.MaxOutputTokens != mini.MaxOutputTokens {
+		t.Errorf(`Prices()["gpt-4o-mini"] = %+v, want the catalog price with MaxOutputTokens %d`, got, mini.MaxOutputTokens)
+	}
+}
+
+type completeFunc func(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error)
+
+func (f completeFunc) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	return f(ctx, req)
+}
+
+func (f completeFunc) CompleteStream(ctx context.Context, req *llm.CompletionRequest) (llm.CompletionStream, error) {
+	return nil, errors.New("not implemented")
+}
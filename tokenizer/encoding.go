@@ -0,0 +1,230 @@
+package tokenizer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Names of the supported encodings
+const (
+	Cl100kBase = "cl100k_base"
+	O200kBase  = "o200k_base"
+)
+
+// whitespace extends RE2's ASCII-only \s to Unicode white space, as matched
+// by \s in the original tiktoken patterns
+const whitespace = `\s\x0B\x{85}\p{Z}`
+
+// pretokenizers hold the splitting patterns of each encoding, without their
+// trailing `\s+(?!\S)|\s+` alternatives: RE2 has no lookahead, so runs of
+// white space are split by splitWhitespace instead
+var pretokenizers = map[string]*regexp.Regexp{
+	Cl100kBase: regexp.MustCompile(`^(?:` +
+		`(?i:'s|'t|'re|'ve|'m|'ll|'d)` +
+		`|[^\r\n\p{L}\p{N}]?\p{L}+` +
+		`|\p{N}{1,3}` +
+		`| ?[^` + whitespace + `\p{L}\p{N}]+[\r\n]*` +
+		`|[` + whitespace + `]*[\r\n]+)`),
+	O200kBase: regexp.MustCompile(`^(?:` +
+		`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|\p{N}{1,3}` +
+		`| ?[^` + whitespace + `\p{L}\p{N}]+[\r\n/]*` +
+		`|[` + whitespace + `]*[\r\n]+)`),
+}
+
+// Encoding is a byte-level BPE encoding compatible with tiktoken. It is safe
+// for concurrent use.
+type Encoding struct {
+	name    string
+	ranks   map[string]int
+	pattern *regexp.Regexp
+}
+
+// NewEncoding creates the encoding name from its BPE merge ranks, which must
+// include every single byte. Only cl100k_base and o200k_base are known by
+// name, since each encoding splits text with its own pattern; other
+// encodings are created with NewCustomEncoding.
+func NewEncoding(name string, ranks map[string]int) (*Encoding, error) {
+	pattern, ok := pretokenizers[name]
+	if !ok {
+		return nil, fmt.Errorf("tokenizer: unsupported encoding %q", name)
+	}
+	return newEncoding(name, ranks, pattern)
+}
+
+// NewCustomEncoding creates an encoding for tokenizers other than
+// cl100k_base and o200k_base, such as those of local models, from its BPE
+// merge ranks and the pattern that splits text into pieces before merging.
+// The pattern uses RE2 syntax, which has no lookahead; white space that no
+// alternative matches becomes a piece of its own, except for its last
+// character when other text follows.
+func NewCustomEncoding(name string, ranks map[string]int, pattern string) (*Encoding, error) {
+	re, err := regexp.Compile(`^(?:` + pattern + `)`)
+	if err != nil {
+		return nil, fmt.Errorf("tokenizer: invalid pattern for encoding %s: %w", name, err)
+	}
+	return newEncoding(name, ranks, re)
+}
+
+func newEncoding(name string, ranks map[string]int, pattern *regexp.Regexp) (*Encoding, error) {
+	for b := 0; b < 256; b++ {
+		if _, ok := ranks[string([]byte{byte(b)})]; !ok {
+			return nil, fmt.Errorf("tokenizer: encoding %s has no rank for byte %#x", name, b)
+		}
+	}
+
+	return &Encoding{name: name, ranks: ranks, pattern: pattern}, nil
+}
+
+// LoadEncoding reads the encoding name from a tiktoken ranks file, in which
+// every line holds a base64-encoded token and its rank
+func LoadEncoding(name string, r io.Reader) (*Encoding, error) {
+	ranks, err := ReadRanks(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewEncoding(name, ranks)
+}
+
+// ReadRanks reads the BPE merge ranks of a tiktoken ranks file, for use with
+// NewCustomEncoding
+func ReadRanks(r io.Reader) (map[string]int, error) {
+	ranks := make(map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		token, rank, ok := strings.Cut(text, " ")
+		if !ok {
+			return nil, fmt.Errorf("tokenizer: line %d: expected token and rank", line)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return nil, fmt.Errorf("tokenizer: line %d: invalid token: %w", line, err)
+		}
+		value, err := strconv.Atoi(rank)
+		if err != nil {
+			return nil, fmt.Errorf("tokenizer: line %d: invalid rank: %w", line, err)
+		}
+		ranks[string(decoded)] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("tokenizer: failed to read ranks: %w", err)
+	}
+	return ranks, nil
+}
+
+// Name returns the name of the encoding
+func (e *Encoding) Name() string {
+	return e.name
+}
+
+// Encode returns the tokens of text. Special tokens such as <|endoftext|>
+// are encoded as ordinary text.
+func (e *Encoding) Encode(text string) []int {
+	var tokens []int
+	for _, piece := range e.split(text) {
+		if rank, ok := e.ranks[piece]; ok {
+			tokens = append(tokens, rank)
+			continue
+		}
+		for _, part := range e.merge(piece) {
+			tokens = append(tokens, e.ranks[part])
+		}
+	}
+	return tokens
+}
+
+// Count returns the number of tokens of text
+func (e *Encoding) Count(text string) int {
+	var count int
+	for _, piece := range e.split(text) {
+		if _, ok := e.ranks[piece]; ok {
+			count++
+			continue
+		}
+		count += len(e.merge(piece))
+	}
+	return count
+}
+
+// split breaks text into the pieces that are encoded independently
+func (e *Encoding) split(text string) []string {
+	var pieces []string
+	for len(text) > 0 {
+		var n int
+		if match := e.pattern.FindStringIndex(text); match != nil && match[1] > 0 {
+			n = match[1]
+		} else {
+			n = splitWhitespace(text)
+		}
+		pieces = append(pieces, text[:n])
+		text = text[n:]
+	}
+	return pieces
+}
+
+// splitWhitespace returns the length of the piece at the start of text when
+// no other alternative of the pattern matches, emulating `\s+(?!\S)|\s+`: a
+// run of white space followed by other text leaves its last character to
+// prefix the next piece
+func splitWhitespace(text string) int {
+	end, last := 0, 0
+	for end < len(text) {
+		r, size := utf8.DecodeRuneInString(text[end:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		last = end
+		end += size
+	}
+
+	switch {
+	case end == 0:
+		// Not white space; cannot happen with the supported patterns, but
+		// always make progress
+		_, size := utf8.DecodeRuneInString(text)
+		return size
+	case end == len(text) || last == 0:
+		return end
+	default:
+		return last
+	}
+}
+
+// merge applies byte pair merges to piece, always merging the adjacent pair
+// with the lowest rank first, and returns the resulting parts
+func (e *Encoding) merge(piece string) []string {
+	parts := make([]string, len(piece))
+	for i := 0; i < len(piece); i++ {
+		parts[i] = piece[i : i+1]
+	}
+
+	for len(parts) > 1 {
+		best, bestRank := -1, 0
+		for i := 0; i < len(parts)-1; i++ {
+			if rank, ok := e.ranks[parts[i]+parts[i+1]]; ok && (best < 0 || rank < bestRank) {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		parts[best] += parts[best+1]
+		parts = append(parts[:best+1], parts[best+2:]...)
+	}
+	return parts
+}
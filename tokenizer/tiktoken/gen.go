@@ -0,0 +1,53 @@
+//go:build ignore
+
+// gen fetches the tiktoken rank files embedded by the package into the ranks
+// directory, verifying them against the hashes tiktoken pins
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+const baseURL = "https://openaipublic.blob.core.windows.net/encodings/"
+
+// hashes are the SHA-256 hashes of the rank files, as pinned by tiktoken
+var hashes = map[string]string{
+	"cl100k_base": "223921b76ee99bde995b7ff738513eef100fb51d18c93597a113bcffe865b2a7",
+	"o200k_base":  "446a9538cb6c348e3516120d7c08b09f57c36495e2acfffe59a5bf8b0cfb1a2d",
+}
+
+func main() {
+	for name, hash := range hashes {
+		if err := fetch(name, hash); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func fetch(name, hash string) error {
+	resp, err := http.Get(baseURL + name + ".tiktoken")
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %s", name, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != hash {
+		return fmt.Errorf("%s: hash %s, want %s", name, got, hash)
+	}
+	return os.WriteFile(filepath.Join("ranks", name+".tiktoken"), data, 0o644)
+}
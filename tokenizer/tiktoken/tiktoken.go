@@ -0,0 +1,41 @@
+// Package tiktoken embeds the BPE ranks of the cl100k_base and o200k_base
+// encodings and registers them with the tokenizer package, so counts for
+// OpenAI models are exact without downloading anything at runtime. Import it
+// for its side effect:
+//
+//	import _ "github.com/aiwizzard/gollm/tokenizer/tiktoken"
+//
+// Each encoding is parsed the first time a count needs it. The rank files in
+// the ranks directory are those published for tiktoken; go generate fetches
+// them again and verifies their hashes.
+package tiktoken
+
+import (
+	"embed"
+
+	"github.com/aiwizzard/gollm/tokenizer"
+)
+
+//go:generate go run gen.go
+
+//go:embed ranks
+var ranks embed.FS
+
+func init() {
+	for _, name := range []string{tokenizer.Cl100kBase, tokenizer.O200kBase} {
+		name := name
+		tokenizer.RegisterEncodingLoader(name, func() (*tokenizer.Encoding, error) {
+			return load(name)
+		})
+	}
+}
+
+// load reads the encoding name from its embedded rank file
+func load(name string) (*tokenizer.Encoding, error) {
+	f, err := ranks.Open("ranks/" + name + ".tiktoken")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return tokenizer.LoadEncoding(name, f)
+}
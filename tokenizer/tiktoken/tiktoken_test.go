@@ -0,0 +1,70 @@
+package tiktoken
+
+import (
+	"io/fs"
+	"reflect"
+	"testing"
+
+	"github.com/aiwizzard/gollm/tokenizer"
+)
+
+// requireRanks skips the test when the rank file of encoding has not been
+// generated
+func requireRanks(t *testing.T, encoding string) {
+	t.Helper()
+	if _, err := fs.Stat(ranks, "ranks/"+encoding+".tiktoken"); err != nil {
+		t.Skipf("no rank file for %s, run go generate: %v", encoding, err)
+	}
+}
+
+// TestCountTokens compares counts with those of the Python tiktoken library
+func TestCountTokens(t *testing.T) {
+	tests := []struct {
+		text   string
+		cl100k int
+		o200k  int
+	}{
+		{text: "hallo world!", cl100k: 4, o200k: 4},
+		{text: "Hallo Welt!", cl100k: 3, o200k: 3},
+		{text: "Hallo verden!", cl100k: 4, o200k: 3},
+		{text: "Hej världen!", cl100k: 7, o200k: 3},
+		{text: "¡Hola mundo!", cl100k: 4, o200k: 4},
+		{text: "Bonjour le monde!", cl100k: 4, o200k: 4},
+		{text: "Привет мир!", cl100k: 6, o200k: 4},
+		{text: "你好世界！", cl100k: 6, o200k: 3},
+		{text: "こんにちは世界！", cl100k: 5, o200k: 3},
+		{text: "안녕하세요 세계!", cl100k: 10, o200k: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			requireRanks(t, tokenizer.Cl100kBase)
+			requireRanks(t, tokenizer.O200kBase)
+
+			if got := tokenizer.CountTokens("gpt-4", tt.text); got != tt.cl100k {
+				t.Errorf("CountTokens(gpt-4) = %d, want %d", got, tt.cl100k)
+			}
+			if got := tokenizer.CountTokens("gpt-4o", tt.text); got != tt.o200k {
+				t.Errorf("CountTokens(gpt-4o) = %d, want %d", got, tt.o200k)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	requireRanks(t, tokenizer.Cl100kBase)
+
+	enc, err := load(tokenizer.Cl100kBase)
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if !tokenizer.Exact("gpt-4") {
+		t.Error("Exact(gpt-4) = false, want true")
+	}
+
+	got := enc.Encode("hello world!你好，世界！")
+	want := []int{15339, 1917, 0, 57668, 53901, 3922, 3574, 244, 98220, 6447}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Encode() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,209 @@
+// Package tokenizer counts tokens the way OpenAI models do, so prompts can be
+// budgeted before they are sent.
+//
+// Exact counts need the BPE ranks of an encoding. Importing the tiktoken
+// subpackage embeds the ranks of cl100k_base and o200k_base and registers
+// them, to be loaded on first use:
+//
+//	import _ "github.com/aiwizzard/gollm/tokenizer/tiktoken"
+//
+// The ranks are kept out of this package so that only programs that count
+// exactly pay for their size. Other tiktoken-format files are loaded with
+// LoadEncoding and registered with RegisterEncoding or RegisterEncodingLoader.
+// Encodings of other tokenizers are created with NewCustomEncoding and
+// assigned to models with RegisterModel. For models without a registered
+// encoding, counts fall back to an approximation.
+//
+// The package is pure Go and builds on every platform Go supports.
+package tokenizer
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/aiwizzard/gollm/llm"
+)
+
+const (
+	// tokensPerMessage and tokensPerName are the formatting overhead of the
+	// chat format, and replyPriming the tokens that start the reply
+	tokensPerMessage = 3
+	tokensPerName    = 1
+	replyPriming     = 3
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]*Encoding{}
+
+	// loaders holds the encodings registered with RegisterEncodingLoader
+	// until they are first used
+	loaders = map[string]*encodingLoader{}
+
+	// modelEncodings maps model name prefixes registered with RegisterModel
+	// to encoding names
+	modelEncodings = map[string]string{}
+)
+
+// RegisterEncoding makes enc available to CountTokens and
+// CountMessageTokens for every model that uses an encoding of its name,
+// replacing any encoding registered before under that name
+func RegisterEncoding(enc *Encoding) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[enc.name] = enc
+	delete(loaders, enc.name)
+}
+
+// RegisterEncodingLoader registers the encoding name like RegisterEncoding,
+// but defers creating it until a count first needs it, so registering large
+// encodings from init functions costs nothing for programs that never count
+// with them. load is called at most once; if it fails, counts with the
+// encoding fall back to the approximation.
+func RegisterEncodingLoader(name string, load func() (*Encoding, error)) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	loaders[name] = &encodingLoader{load: load}
+	delete(registry, name)
+}
+
+// encodingLoader creates a lazily registered encoding once
+type encodingLoader struct {
+	once sync.Once
+	load func() (*Encoding, error)
+	enc  *Encoding
+}
+
+func (l *encodingLoader) get() *Encoding {
+	l.once.Do(func() {
+		enc, err := l.load()
+		if err == nil {
+			l.enc = enc
+		}
+	})
+	return l.enc
+}
+
+// RegisterModel makes every model whose name starts with prefix use the
+// encoding called encoding, for fine-tuned or local models the built-in
+// mapping does not know. Registered prefixes take precedence over the
+// built-in ones, and the longest registered prefix wins.
+func RegisterModel(prefix, encoding string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	modelEncodings[strings.ToLower(prefix)] = encoding
+}
+
+// EncodingForModel returns the name of the encoding model uses, or false if
+// the model is neither registered with RegisterModel nor a known OpenAI
+// model. OpenAI fine-tunes such as "ft:gpt-4o-mini-2024-07-18:org::id" use
+// the encoding of their base model.
+func EncodingForModel(model string) (string, bool) {
+	model = strings.ToLower(model)
+	if name, ok := registeredEncoding(model); ok {
+		return name, true
+	}
+
+	model = strings.TrimPrefix(model, "ft:")
+	for _, prefix := range []string{"gpt-4o", "chatgpt-4o", "gpt-4.1", "gpt-4.5", "gpt-5", "o1", "o3", "o4"} {
+		if strings.HasPrefix(model, prefix) {
+			return O200kBase, true
+		}
+	}
+	for _, prefix := range []string{"gpt-4", "gpt-3.5", "gpt-35", "text-embedding-3", "text-embedding-ada-002"} {
+		if strings.HasPrefix(model, prefix) {
+			return Cl100kBase, true
+		}
+	}
+	return "", false
+}
+
+// Exact reports whether counts for model come from its registered encoding
+// rather than the approximation
+func Exact(model string) bool {
+	return encodingForModel(model) != nil
+}
+
+// CountTokens returns the number of tokens text encodes to for model
+func CountTokens(model, text string) int {
+	if enc := encodingForModel(model); enc != nil {
+		return enc.Count(text)
+	}
+	return Approximate(text)
+}
+
+// CountMessageTokens returns the number of prompt tokens messages take up
+// for model, including the overhead of the chat format and the tokens that
+// prime the reply
+func CountMessageTokens(model string, messages []llm.Message) int {
+	count := func(text string) int { return CountTokens(model, text) }
+
+	tokens := replyPriming
+	for _, msg := range messages {
+		tokens += tokensPerMessage + count(msg.Role) + count(msg.Content)
+		for _, part := range msg.Parts {
+			if part.Type == llm.PartText {
+				tokens += count(part.Text)
+			}
+		}
+		if msg.Name != "" {
+			tokens += tokensPerName + count(msg.Name)
+		}
+		if msg.ToolCallID != "" {
+			tokens += count(msg.ToolCallID)
+		}
+		for _, call := range msg.ToolCalls {
+			tokens += count(call.Function.Name) + count(call.Function.Arguments)
+		}
+	}
+	return tokens
+}
+
+// Approximate estimates the token count of text at one token per four bytes
+// of ASCII and one per two bytes of other characters. It matches BPE
+// tokenizers closely for English prose and stays on the safe side for
+// scripts such as Cyrillic, Chinese, Japanese and Korean, which cl100k_base
+// encodes at up to about one token per two bytes.
+func Approximate(text string) int {
+	quarters := len(text)
+	for rest := text; len(rest) > 0; {
+		_, size := utf8.DecodeRuneInString(rest)
+		if size > 1 {
+			quarters += size
+		}
+		rest = rest[size:]
+	}
+	return (quarters + 3) / 4
+}
+
+// registeredEncoding returns the encoding registered for the longest prefix
+// of model
+func registeredEncoding(model string) (string, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	var name, longest string
+	var found bool
+	for prefix, encoding := range modelEncodings {
+		if strings.HasPrefix(model, prefix) && (!found || len(prefix) > len(longest)) {
+			name, longest, found = encoding, prefix, true
+		}
+	}
+	return name, found
+}
+
+func encodingForModel(model string) *Encoding {
+	name, ok := EncodingForModel(model)
+	if !ok {
+		return nil
+	}
+
+	registryMu.RLock()
+	enc, loader := registry[name], loaders[name]
+	registryMu.RUnlock()
+	if enc == nil && loader != nil {
+		enc = loader.get()
+	}
+	return enc
+}
@@ -0,0 +1,312 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aiwizzard/gollm/llm"
+)
+
+// testRanks returns every single byte at the rank of its value, followed by
+// a few merges
+func testRanks() map[string]int {
+	ranks := make(map[string]int)
+	for b := 0; b < 256; b++ {
+		ranks[string([]byte{byte(b)})] = b
+	}
+	for i, merge := range []string{"he", "ll", "hell", " w", "or", " wor"} {
+		ranks[merge] = 256 + i
+	}
+	return ranks
+}
+
+func testEncoding(t *testing.T, name string) *Encoding {
+	t.Helper()
+	enc, err := NewEncoding(name, testRanks())
+	if err != nil {
+		t.Fatalf("NewEncoding() error = %v", err)
+	}
+	return enc
+}
+
+func TestEncoding_Split(t *testing.T) {
+	tests := []struct {
+		encoding string
+		text     string
+		want     []string
+	}{
+		{encoding: Cl100kBase, text: "Hello world", want: []string{"Hello", " world"}},
+		{encoding: Cl100kBase, text: "  hello", want: []string{" ", " hello"}},
+		{encoding: Cl100kBase, text: "hi  ", want: []string{"hi", "  "}},
+		{encoding: Cl100kBase, text: "I'm", want: []string{"I", "'m"}},
+		{encoding: Cl100kBase, text: "don't", want: []string{"don", "'t"}},
+		{encoding: Cl100kBase, text: "12345", want: []string{"123", "45"}},
+		{encoding: Cl100kBase, text: "a\n\nb", want: []string{"a", "\n\n", "b"}},
+		{encoding: Cl100kBase, text: "x = 1;", want: []string{"x", " =", " ", "1", ";"}},
+		{encoding: Cl100kBase, text: "a  b", want: []string{"a", " ", " b"}},
+		{encoding: O200kBase, text: "don't", want: []string{"don't"}},
+		{encoding: O200kBase, text: "HelloWorld", want: []string{"Hello", "World"}},
+		{encoding: O200kBase, text: "a/b\n", want: []string{"a", "/b", "\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%q", tt.encoding, tt.text), func(t *testing.T) {
+			got := testEncoding(t, tt.encoding).split(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("split() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncoding_Encode(t *testing.T) {
+	enc := testEncoding(t, Cl100kBase)
+
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{text: "", want: nil},
+		{text: "hello", want: []int{258, 'o'}},
+		{text: "hello world", want: []int{258, 'o', 261, 'l', 'd'}},
+		{text: "he", want: []int{256}},
+		{text: "é", want: []int{0xc3, 0xa9}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := enc.Encode(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Encode() = %v, want %v", got, tt.want)
+			}
+			if got := enc.Count(tt.text); got != len(tt.want) {
+				t.Errorf("Count() = %d, want %d", got, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestNewEncoding_Errors(t *testing.T) {
+	if _, err := NewEncoding("p50k_base", testRanks()); err == nil {
+		t.Error("NewEncoding() with unsupported encoding succeeded")
+	}
+
+	ranks := testRanks()
+	delete(ranks, "a")
+	if _, err := NewEncoding(Cl100kBase, ranks); err == nil {
+		t.Error("NewEncoding() with missing byte succeeded")
+	}
+}
+
+func TestLoadEncoding(t *testing.T) {
+	var file strings.Builder
+	for token, rank := range testRanks() {
+		fmt.Fprintf(&file, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank)
+	}
+
+	enc, err := LoadEncoding(O200kBase, strings.NewReader(file.String()))
+	if err != nil {
+		t.Fatalf("LoadEncoding() error = %v", err)
+	}
+	if enc.Name() != O200kBase {
+		t.Errorf("Name() = %q, want %q", enc.Name(), O200kBase)
+	}
+	if got, want := enc.Encode("hello"), []int{258, 'o'}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Encode() = %v, want %v", got, want)
+	}
+
+	for _, invalid := range []string{"aGU=\n", "!!! 1\n", "aGU= x\n"} {
+		if _, err := LoadEncoding(O200kBase, strings.NewReader(invalid)); err == nil {
+			t.Errorf("LoadEncoding(%q) succeeded", invalid)
+		}
+	}
+}
+
+func TestEncodingForModel(t *testing.T) {
+	tests := []struct {
+		model  string
+		want   string
+		wantOK bool
+	}{
+		{model: "gpt-4o-mini", want: O200kBase, wantOK: true},
+		{model: "o3-mini", want: O200kBase, wantOK: true},
+		{model: "GPT-4.1", want: O200kBase, wantOK: true},
+		{model: "gpt-4-turbo", want: Cl100kBase, wantOK: true},
+		{model: "gpt-3.5-turbo", want: Cl100kBase, wantOK: true},
+		{model: "text-embedding-3-small", want: Cl100kBase, wantOK: true},
+		{model: "ft:gpt-4o-mini-2024-07-18:acme::abc123", want: O200kBase, wantOK: true},
+		{model: "ft:gpt-3.5-turbo-0125:acme::abc123", want: Cl100kBase, wantOK: true},
+		{model: "claude-3-5-sonnet-latest"},
+		{model: "llama3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			got, ok := EncodingForModel(tt.model)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("EncodingForModel() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewCustomEncoding(t *testing.T) {
+	enc, err := NewCustomEncoding("words", testRanks(), `\p{L}+|\p{N}|[^\s\p{L}\p{N}]+`)
+	if err != nil {
+		t.Fatalf("NewCustomEncoding() error = %v", err)
+	}
+	if got, want := enc.split("hello  world 42"), []string{"hello", " ", " ", "world", " ", "4", "2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("split() = %q, want %q", got, want)
+	}
+
+	if _, err := NewCustomEncoding("bad", testRanks(), `(?!x)`); err == nil {
+		t.Error("NewCustomEncoding() with invalid pattern succeeded")
+	}
+}
+
+func TestRegisterModel(t *testing.T) {
+	enc, err := NewCustomEncoding("words", testRanks(), `\p{L}+`)
+	if err != nil {
+		t.Fatalf("NewCustomEncoding() error = %v", err)
+	}
+	RegisterEncoding(enc)
+	RegisterModel("my-llama", "words")
+	RegisterModel("gpt-4o-custom", "words")
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, "words")
+		delete(modelEncodings, "my-llama")
+		delete(modelEncodings, "gpt-4o-custom")
+		registryMu.Unlock()
+	})
+
+	for model, want := range map[string]string{"my-llama-3-8b": "words", "GPT-4o-custom": "words", "gpt-4o": O200kBase} {
+		if got, _ := EncodingForModel(model); got != want {
+			t.Errorf("EncodingForModel(%q) = %q, want %q", model, got, want)
+		}
+	}
+
+	if !Exact("my-llama-3-8b") {
+		t.Error("Exact() = false for model with registered encoding")
+	}
+	if got := CountTokens("my-llama-3-8b", "hello"); got != 2 {
+		t.Errorf("CountTokens() = %d, want 2", got)
+	}
+}
+
+func TestCountTokens(t *testing.T) {
+	RegisterEncoding(testEncoding(t, Cl100kBase))
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, Cl100kBase)
+		registryMu.Unlock()
+	})
+
+	if !Exact("gpt-4") {
+		t.Error("Exact(gpt-4) = false, want true")
+	}
+	if Exact("gpt-4o") || Exact("llama3") {
+		t.Error("Exact() = true for model without registered encoding")
+	}
+
+	if got := CountTokens("gpt-4", "hello world"); got != 5 {
+		t.Errorf("CountTokens(gpt-4) = %d, want 5", got)
+	}
+	if got := CountTokens("llama3", "hello world"); got != 3 {
+		t.Errorf("CountTokens(llama3) = %d, want 3", got)
+	}
+}
+
+func TestRegisterEncodingLoader(t *testing.T) {
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(loaders, Cl100kBase)
+		delete(loaders, O200kBase)
+		registryMu.Unlock()
+	})
+
+	var loads int
+	RegisterEncodingLoader(Cl100kBase, func() (*Encoding, error) {
+		loads++
+		return NewEncoding(Cl100kBase, testRanks())
+	})
+	RegisterEncodingLoader(O200kBase, func() (*Encoding, error) {
+		return nil, errors.New("no ranks")
+	})
+	if loads != 0 {
+		t.Errorf("loader called %d times on registration, want 0", loads)
+	}
+
+	for i := 0; i < 2; i++ {
+		if got := CountTokens("gpt-4", "hello world"); got != 5 {
+			t.Errorf("CountTokens(gpt-4) = %d, want 5", got)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("loader called %d times, want 1", loads)
+	}
+
+	if Exact("gpt-4o") {
+		t.Error("Exact(gpt-4o) = true with a failing loader")
+	}
+	if got := CountTokens("gpt-4o", "hello world"); got != 3 {
+		t.Errorf("CountTokens(gpt-4o) = %d, want the approximation 3", got)
+	}
+}
+
+func TestApproximate(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{text: "", want: 0},
+		{text: "hello world", want: 3},
+		{text: "Привет мир!", want: 10},
+		{text: "你好世界！", want: 8},
+		{text: "안녕하세요 세계!", want: 11},
+		{text: "\xff\xfe", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := Approximate(tt.text); got != tt.want {
+				t.Errorf("Approximate() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountMessageTokens(t *testing.T) {
+	call := llm.ToolCall{ID: "call_1", Type: "function"}
+	call.Function.Name = "get"
+	call.Function.Arguments = `{"a":1}`
+
+	messages := []llm.Message{
+		{Role: "user", Content: "abcd", Name: "bob"},
+		{Role: "assistant", ToolCalls: []llm.ToolCall{call}},
+		{Role: "tool", Content: "ok", ToolCallID: "call_1"},
+	}
+
+	// Approximated per part: user 1 + abcd 1 + name 1+1, assistant 3 + get 1
+	// + arguments 2, tool 1 + ok 1 + call_1 2, plus 3 per message and 3 to
+	// prime the reply
+	want := 4 + 6 + 4 + 3*3 + 3
+	if got := CountMessageTokens("llama3", messages); got != want {
+		t.Errorf("CountMessageTokens() = %d, want %d", got, want)
+	}
+
+	parts := []llm.Message{{
+		Role:  "user",
+		Parts: []llm.ContentPart{llm.TextPart("abcd"), llm.ImagePart("https://example.com/a.png")},
+	}}
+	if got, want := CountMessageTokens("llama3", parts), 3+1+1+replyPriming; got != want {
+		t.Errorf("CountMessageTokens(parts) = %d, want %d", got, want)
+	}
+
+	if got := CountMessageTokens("llama3", nil); got != replyPriming {
+		t.Errorf("CountMessageTokens(nil) = %d, want %d", got, replyPriming)
+	}
+}
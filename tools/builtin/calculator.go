@@ -0,0 +1,322 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/aiwizzard/gollm/tools"
+)
+
+// CalculatorParams are the parameters of the calculator tool
+type CalculatorParams struct {
+	Expression string `json:"expression" description:"An arithmetic expression, e.g. (2 + 3) * sqrt(16) / 2^3"`
+}
+
+// Calculator returns the calculator tool, which evaluates arithmetic
+// expressions so the model does not have to compute them itself. It supports
+// + - * / % and ^ (power), parentheses, the constants pi and e, and the
+// functions abs, ceil, cos, exp, floor, ln, log, log10, max, min, pow, round,
+// sin, sqrt and tan.
+func Calculator() *tools.Tool {
+	return tools.New("calculator", "Evaluate an arithmetic expression and return the result",
+		func(ctx context.Context, params CalculatorParams) (string, error) {
+			value, err := Evaluate(params.Expression)
+			if err != nil {
+				return "", err
+			}
+			return strconv.FormatFloat(value, 'g', 15, 64), nil
+		})
+}
+
+// Evaluate evaluates an arithmetic expression as the calculator tool does
+func Evaluate(expression string) (float64, error) {
+	p := &parser{input: expression}
+	p.next()
+	value, err := p.expression()
+	if err != nil {
+		return 0, err
+	}
+	if p.token != "" {
+		return 0, fmt.Errorf("unexpected %q at position %d", p.token, p.start)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, errors.New("result is not a finite number")
+	}
+	return value, nil
+}
+
+var constants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+var functions = map[string]func(args []float64) (float64, error){
+	"abs":   unary(math.Abs),
+	"ceil":  unary(math.Ceil),
+	"cos":   unary(math.Cos),
+	"exp":   unary(math.Exp),
+	"floor": unary(math.Floor),
+	"ln":    unary(math.Log),
+	"log":   unary(math.Log),
+	"log10": unary(math.Log10),
+	"round": unary(math.Round),
+	"sin":   unary(math.Sin),
+	"sqrt":  unary(math.Sqrt),
+	"tan":   unary(math.Tan),
+	"pow": func(args []float64) (float64, error) {
+		if len(args) != 2 {
+			return 0, fmt.Errorf("takes 2 arguments, got %d", len(args))
+		}
+		return math.Pow(args[0], args[1]), nil
+	},
+	"min": variadic(math.Min),
+	"max": variadic(math.Max),
+}
+
+func unary(fn func(float64) float64) func([]float64) (float64, error) {
+	return func(args []float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("takes 1 argument, got %d", len(args))
+		}
+		return fn(args[0]), nil
+	}
+}
+
+func variadic(fn func(a, b float64) float64) func([]float64) (float64, error) {
+	return func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, errors.New("takes at least 1 argument")
+		}
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = fn(result, arg)
+		}
+		return result, nil
+	}
+}
+
+// parser evaluates an expression by recursive descent, token by token
+type parser struct {
+	input string
+	pos   int
+
+	// token is the current token, empty at the end of the input, and start
+	// its position
+	token string
+	start int
+}
+
+// next moves to the next token: a number, a name or an operator
+func (p *parser) next() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+	p.start = p.pos
+	if p.pos == len(p.input) {
+		p.token = ""
+		return
+	}
+
+	c := p.input[p.pos]
+	switch {
+	case isDigit(c) || c == '.':
+		for p.pos < len(p.input) && (isDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		// Exponent, as in 1.5e-3
+		if p.pos < len(p.input) && (p.input[p.pos] == 'e' || p.input[p.pos] == 'E') {
+			end := p.pos + 1
+			if end < len(p.input) && (p.input[end] == '+' || p.input[end] == '-') {
+				end++
+			}
+			if end < len(p.input) && isDigit(p.input[end]) {
+				for end < len(p.input) && isDigit(p.input[end]) {
+					end++
+				}
+				p.pos = end
+			}
+		}
+	case isLetter(c):
+		for p.pos < len(p.input) && (isLetter(p.input[p.pos]) || isDigit(p.input[p.pos])) {
+			p.pos++
+		}
+	case c == '*' && strings.HasPrefix(p.input[p.pos:], "**"):
+		// Python-style power
+		p.pos += 2
+		p.token = "^"
+		return
+	default:
+		p.pos++
+	}
+	p.token = p.input[p.start:p.pos]
+}
+
+// expression := term (("+" | "-") term)*
+func (p *parser) expression() (float64, error) {
+	value, err := p.term()
+	if err != nil {
+		return 0, err
+	}
+	for p.token == "+" || p.token == "-" {
+		op := p.token
+		p.next()
+		right, err := p.term()
+		if err != nil {
+			return 0, err
+		}
+		if op == "+" {
+			value += right
+		} else {
+			value -= right
+		}
+	}
+	return value, nil
+}
+
+// term := unary (("*" | "/" | "%") unary)*
+func (p *parser) term() (float64, error) {
+	value, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	for p.token == "*" || p.token == "/" || p.token == "%" {
+		op := p.token
+		p.next()
+		right, err := p.unary()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case op == "*":
+			value *= right
+		case right == 0:
+			return 0, errors.New("division by zero")
+		case op == "/":
+			value /= right
+		default:
+			value = math.Mod(value, right)
+		}
+	}
+	return value, nil
+}
+
+// unary := ("-" | "+") unary | power
+func (p *parser) unary() (float64, error) {
+	if p.token == "-" || p.token == "+" {
+		negative := p.token == "-"
+		p.next()
+		value, err := p.unary()
+		if negative {
+			value = -value
+		}
+		return value, err
+	}
+	return p.power()
+}
+
+// power := primary ("^" unary)?, so that 2^3^2 is 2^(3^2) and -2^2 is -4
+func (p *parser) power() (float64, error) {
+	base, err := p.primary()
+	if err != nil {
+		return 0, err
+	}
+	if p.token != "^" {
+		return base, nil
+	}
+	p.next()
+	exponent, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	return math.Pow(base, exponent), nil
+}
+
+// primary := number | "(" expression ")" | constant | function "(" arguments ")"
+func (p *parser) primary() (float64, error) {
+	token, start := p.token, p.start
+	switch {
+	case token == "":
+		return 0, errors.New("unexpected end of expression")
+	case token == "(":
+		p.next()
+		value, err := p.expression()
+		if err != nil {
+			return 0, err
+		}
+		if p.token != ")" {
+			return 0, fmt.Errorf("missing ) at position %d", p.start)
+		}
+		p.next()
+		return value, nil
+	case isDigit(token[0]) || token[0] == '.':
+		value, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q at position %d", token, start)
+		}
+		p.next()
+		return value, nil
+	case isLetter(token[0]):
+		p.next()
+		name := strings.ToLower(token)
+		if p.token != "(" {
+			if value, ok := constants[name]; ok {
+				return value, nil
+			}
+			return 0, fmt.Errorf("unknown constant %q", token)
+		}
+		fn, ok := functions[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown function %q", token)
+		}
+		args, err := p.arguments()
+		if err != nil {
+			return 0, err
+		}
+		value, err := fn(args)
+		if err != nil {
+			return 0, fmt.Errorf("%s %w", name, err)
+		}
+		return value, nil
+	}
+	return 0, fmt.Errorf("unexpected %q at position %d", token, start)
+}
+
+// arguments parses the parenthesized, comma-separated arguments of a call
+func (p *parser) arguments() ([]float64, error) {
+	p.next() // (
+	var args []float64
+	if p.token == ")" {
+		p.next()
+		return args, nil
+	}
+	for {
+		arg, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		switch p.token {
+		case ",":
+			p.next()
+		case ")":
+			p.next()
+			return args, nil
+		default:
+			return nil, fmt.Errorf("missing ) at position %d", p.start)
+		}
+	}
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_'
+}
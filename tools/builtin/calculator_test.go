@@ -0,0 +1,78 @@
+package builtin
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       float64
+		wantErr    string
+	}{
+		{expression: "1 + 2 * 3", want: 7},
+		{expression: "(1 + 2) * 3", want: 9},
+		{expression: "10 / 4 - 1", want: 1.5},
+		{expression: "7 % 3", want: 1},
+		{expression: "2^3^2", want: 512},
+		{expression: "2 ** 10", want: 1024},
+		{expression: "-2^2", want: -4},
+		{expression: "2^-1", want: 0.5},
+		{expression: "--3 + +1", want: 4},
+		{expression: "1.5e3 + .5", want: 1500.5},
+		{expression: "sqrt(16) + abs(-2) + round(2.5)", want: 9},
+		{expression: "max(1, 5, 3) - min(4, 2)", want: 3},
+		{expression: "pow(2, 8)", want: 256},
+		{expression: "floor(PI * 100)", want: 314},
+		{expression: "1 / 0", wantErr: "division by zero"},
+		{expression: "5 % 0", wantErr: "division by zero"},
+		{expression: "sqrt(-1)", wantErr: "not a finite number"},
+		{expression: "(1 + 2", wantErr: "missing )"},
+		{expression: "1 +", wantErr: "unexpected end of expression"},
+		{expression: "2 3", wantErr: `unexpected "3" at position 2`},
+		{expression: "foo(1)", wantErr: `unknown function "foo"`},
+		{expression: "x + 1", wantErr: `unknown constant "x"`},
+		{expression: "pow(2)", wantErr: "pow takes 2 arguments, got 1"},
+		{expression: "1..2", wantErr: `invalid number "1..2"`},
+		{expression: "", wantErr: "unexpected end of expression"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := Evaluate(tt.expression)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Evaluate() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculator(t *testing.T) {
+	tests := []struct {
+		arguments string
+		want      string
+	}{
+		{arguments: `{"expression": "0.1 + 0.2"}`, want: "0.3"},
+		{arguments: `{"expression": "10^6"}`, want: "1000000"},
+		{arguments: `{"expression": "1 / 3"}`, want: "0.333333333333333"},
+	}
+
+	tool := Calculator()
+	for _, tt := range tests {
+		got, err := tool.Call(context.Background(), tt.arguments)
+		if err != nil || got != tt.want {
+			t.Errorf("Call(%s) = %q, %v, want %q", tt.arguments, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aiwizzard/gollm/tools"
+)
+
+// TimeParams are the parameters of the current time tool
+type TimeParams struct {
+	Timezone string `json:"timezone,omitempty" description:"IANA time zone, e.g. Europe/Paris (defaults to UTC)"`
+}
+
+// CurrentTime returns the current_time tool, which tells the date and time in
+// a time zone, something a model cannot know on its own
+func CurrentTime() *tools.Tool {
+	return currentTime(time.Now)
+}
+
+func currentTime(now func() time.Time) *tools.Tool {
+	return tools.New("current_time", "Get the current date and time", func(ctx context.Context, params TimeParams) (string, error) {
+		location := time.UTC
+		if params.Timezone != "" {
+			var err error
+			if location, err = time.LoadLocation(params.Timezone); err != nil {
+				return "", fmt.Errorf("unknown time zone %q", params.Timezone)
+			}
+		}
+		t := now().In(location)
+		return fmt.Sprintf("%s (%s)", t.Format(time.RFC3339), t.Format("Monday")), nil
+	})
+}
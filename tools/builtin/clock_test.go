@@ -0,0 +1,40 @@
+package builtin
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCurrentTime(t *testing.T) {
+	now := func() time.Time { return time.Date(2024, 3, 15, 22, 30, 0, 0, time.UTC) }
+	tool := currentTime(now)
+
+	if _, err := time.LoadLocation("Asia/Tokyo"); err != nil {
+		t.Skip("no time zone database")
+	}
+
+	tests := []struct {
+		arguments string
+		want      string
+		wantErr   string
+	}{
+		{arguments: `{}`, want: "2024-03-15T22:30:00Z (Friday)"},
+		{arguments: `{"timezone": "Asia/Tokyo"}`, want: "2024-03-16T07:30:00+09:00 (Saturday)"},
+		{arguments: `{"timezone": "Mars/Olympus"}`, wantErr: `unknown time zone "Mars/Olympus"`},
+	}
+
+	for _, tt := range tests {
+		got, err := tool.Call(context.Background(), tt.arguments)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Call(%s) error = %v, want %q", tt.arguments, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Call(%s) = %q, %v, want %q", tt.arguments, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Package builtin provides common tools that are safe to hand to a model:
+// fetching web pages from allowed hosts, evaluating arithmetic, telling the
+// current time, reading files below a sandbox root and searching the web
+// through a pluggable search API. Each constructor returns a *tools.Tool
+// ready to register:
+//
+//	registry := tools.NewRegistry(
+//		builtin.Calculator(),
+//		builtin.CurrentTime(),
+//		builtin.HTTPFetch(builtin.FetchConfig{AllowedHosts: []string{"*.wikipedia.org"}}),
+//	)
+package builtin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aiwizzard/gollm/tools"
+)
+
+const (
+	defaultFetchTimeout = 15 * time.Second
+	defaultMaxBytes     = 64 << 10
+)
+
+// ErrHostNotAllowed is returned when a tool is asked to fetch a URL whose
+// host is not in the allowlist
+var ErrHostNotAllowed = errors.New("builtin: host not allowed")
+
+// FetchConfig configures the HTTP fetch tool
+type FetchConfig struct {
+	// AllowedHosts lists the hosts that may be fetched. An entry starting
+	// with "*." also allows the subdomains of the domain that follows. Ports
+	// are ignored. Nothing can be fetched if it is empty.
+	AllowedHosts []string
+
+	// MaxBytes caps the length of the returned body (optional, defaults to
+	// 64 KiB); longer bodies are truncated
+	MaxBytes int
+
+	// Timeout bounds each request (optional, defaults to 15s)
+	Timeout time.Duration
+
+	// Client sends the requests (optional, defaults to http.DefaultClient).
+	// Redirects are followed only to allowed hosts.
+	Client *http.Client
+}
+
+// FetchParams are the parameters of the HTTP fetch tool
+type FetchParams struct {
+	URL string `json:"url" description:"The http or https URL to fetch"`
+}
+
+// HTTPFetch returns the http_fetch tool, which GETs a URL from one of the
+// allowed hosts and returns its status and body as text
+func HTTPFetch(config FetchConfig) *tools.Tool {
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = defaultMaxBytes
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultFetchTimeout
+	}
+
+	client := http.DefaultClient
+	if config.Client != nil {
+		client = config.Client
+	}
+	// Copy the client so that redirects can be checked against the allowlist
+	checked := *client
+	checked.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !hostAllowed(config.AllowedHosts, req.URL.Hostname()) {
+			return fmt.Errorf("%w: redirect to %s", ErrHostNotAllowed, req.URL.Hostname())
+		}
+		if client.CheckRedirect != nil {
+			return client.CheckRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+
+	description := "Fetch a web page and return its content. Allowed hosts: " + strings.Join(config.AllowedHosts, ", ")
+	return tools.New("http_fetch", description, func(ctx context.Context, params FetchParams) (string, error) {
+		return fetch(ctx, &checked, config, params.URL)
+	})
+}
+
+func fetch(ctx context.Context, client *http.Client, config FetchConfig, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	if !hostAllowed(config.AllowedHosts, u.Hostname()) {
+		return "", fmt.Errorf("%w: %s", ErrHostNotAllowed, u.Hostname())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(config.MaxBytes)+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Status: %s\n", resp.Status)
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		fmt.Fprintf(&b, "Content-Type: %s\n", contentType)
+	}
+	b.WriteString("\n")
+	b.WriteString(truncate(body, config.MaxBytes))
+	return b.String(), nil
+}
+
+// hostAllowed reports whether host matches one of the allowed hosts
+func hostAllowed(allowed []string, host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if domain, ok := strings.CutPrefix(pattern, "*."); ok {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// truncate returns data as text, cut to max bytes on a rune boundary with a
+// note if it is longer
+func truncate(data []byte, max int) string {
+	if len(data) <= max {
+		return string(data)
+	}
+	data = data[:max]
+	// Drop a rune cut in the middle
+	if start := lastRuneStart(data); !utf8.FullRune(data[start:]) {
+		data = data[:start]
+	}
+	return string(data) + "\n[truncated]"
+}
+
+// lastRuneStart returns the index of the first byte of the last rune of data
+func lastRuneStart(data []byte) int {
+	i := len(data) - 1
+	for i > 0 && len(data)-i < utf8.UTFMax && !utf8.RuneStart(data[i]) {
+		i--
+	}
+	return i
+}
@@ -0,0 +1,100 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("héllo wörld"))
+		case "/redirect":
+			http.Redirect(w, r, "http://localhost.invalid/", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tool := HTTPFetch(FetchConfig{AllowedHosts: []string{"127.0.0.1"}, MaxBytes: 8})
+
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr error
+		errText string
+	}{
+		{name: "truncated", url: server.URL + "/page", want: "Status: 200 OK\nContent-Type: text/plain\n\nhéllo w\n[truncated]"},
+		{name: "not found", url: server.URL + "/missing", want: "Status: 404 Not Found\nContent-Type: text/plain; charset=utf-8\n\n404 page\n[truncated]"},
+		{name: "host not allowed", url: "http://example.com/", wantErr: ErrHostNotAllowed},
+		{name: "redirect not allowed", url: server.URL + "/redirect", wantErr: ErrHostNotAllowed},
+		{name: "scheme", url: "file:///etc/passwd", errText: `unsupported URL scheme "file"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tool.Call(context.Background(), `{"url": "`+tt.url+`"}`)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Call() error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.errText != "":
+				if err == nil || !strings.Contains(err.Error(), tt.errText) {
+					t.Errorf("Call() error = %v, want %q", err, tt.errText)
+				}
+			case err != nil:
+				t.Errorf("Call() error = %v", err)
+			case got != tt.want:
+				t.Errorf("Call() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostAllowed(t *testing.T) {
+	allowed := []string{"example.com", "*.wikipedia.org"}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{host: "example.com", want: true},
+		{host: "EXAMPLE.com.", want: true},
+		{host: "www.example.com", want: false},
+		{host: "wikipedia.org", want: true},
+		{host: "en.wikipedia.org", want: true},
+		{host: "evilwikipedia.org", want: false},
+		{host: "wikipedia.org.evil.com", want: false},
+	}
+	for _, tt := range tests {
+		if got := hostAllowed(allowed, tt.host); got != tt.want {
+			t.Errorf("hostAllowed(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		data string
+		max  int
+		want string
+	}{
+		{data: "wörld", max: 6, want: "wörld"},
+		{data: "wörld", max: 3, want: "wö\n[truncated]"},
+		{data: "wörld", max: 2, want: "w\n[truncated]"},
+		{data: "日本", max: 5, want: "日\n[truncated]"},
+	}
+	for _, tt := range tests {
+		if got := truncate([]byte(tt.data), tt.max); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.data, tt.max, got, tt.want)
+		}
+	}
+}
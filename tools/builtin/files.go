@@ -0,0 +1,114 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aiwizzard/gollm/tools"
+)
+
+// ErrOutsideRoot is returned when a tool is asked for a path outside its
+// sandbox root
+var ErrOutsideRoot = errors.New("builtin: path outside the sandbox root")
+
+// FileConfig configures the file reading tool
+type FileConfig struct {
+	// Root is the directory the tool may read below. Paths are resolved
+	// relative to it, and symbolic links leading out of it are refused.
+	Root string
+
+	// MaxBytes caps the length of the returned content (optional, defaults
+	// to 64 KiB); longer files are truncated
+	MaxBytes int
+}
+
+// FileParams are the parameters of the file reading tool
+type FileParams struct {
+	Path string `json:"path" description:"Path of the file, relative to the sandbox root; a directory is listed"`
+}
+
+// ReadFile returns the read_file tool, which reads a text file or lists a
+// directory below config.Root
+func ReadFile(config FileConfig) *tools.Tool {
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = defaultMaxBytes
+	}
+	return tools.New("read_file", "Read a file or list a directory", func(ctx context.Context, params FileParams) (string, error) {
+		path, err := sandboxPath(config.Root, params.Path)
+		if err != nil {
+			return "", err
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("cannot read %s: %w", params.Path, errors.Unwrap(err))
+		}
+		if info.IsDir() {
+			return listDir(path)
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return "", fmt.Errorf("cannot read %s: %w", params.Path, errors.Unwrap(err))
+		}
+		defer f.Close()
+
+		data, err := io.ReadAll(io.LimitReader(f, int64(config.MaxBytes)+1))
+		if err != nil {
+			return "", fmt.Errorf("cannot read %s: %w", params.Path, err)
+		}
+		return truncate(data, config.MaxBytes), nil
+	})
+}
+
+// sandboxPath resolves path below root, following symbolic links, and fails
+// with ErrOutsideRoot if the result is not below root
+func sandboxPath(root, path string) (string, error) {
+	if root == "" {
+		return "", errors.New("builtin: no sandbox root configured")
+	}
+	rel := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(path, "/")))
+	if !filepath.IsLocal(rel) && rel != "." {
+		return "", fmt.Errorf("%w: %s", ErrOutsideRoot, path)
+	}
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("builtin: invalid sandbox root: %w", err)
+	}
+	real, err := filepath.EvalSymlinks(filepath.Join(realRoot, rel))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("%s does not exist", path)
+		}
+		return "", err
+	}
+
+	if inside, err := filepath.Rel(realRoot, real); err != nil || (!filepath.IsLocal(inside) && inside != ".") {
+		return "", fmt.Errorf("%w: %s", ErrOutsideRoot, path)
+	}
+	return real, nil
+}
+
+// listDir lists the entries of a directory, one per line, marking
+// directories with a trailing slash
+func listDir(path string) (string, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, entry := range entries {
+		b.WriteString(entry.Name())
+		if entry.IsDir() {
+			b.WriteString("/")
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,77 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	mustWrite(t, filepath.Join(root, "notes.txt"), "hello")
+	mustWrite(t, filepath.Join(root, "docs", "long.txt"), strings.Repeat("a", 20))
+	mustWrite(t, filepath.Join(dir, "secret.txt"), "password")
+	if err := os.Symlink(filepath.Join(dir, "secret.txt"), filepath.Join(root, "escape.txt")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "notes.txt"), filepath.Join(root, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	tool := ReadFile(FileConfig{Root: root, MaxBytes: 10})
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr error
+		errText string
+	}{
+		{path: "notes.txt", want: "hello"},
+		{path: "/notes.txt", want: "hello"},
+		{path: "docs/../notes.txt", want: "hello"},
+		{path: "link.txt", want: "hello"},
+		{path: "docs/long.txt", want: "aaaaaaaaaa\n[truncated]"},
+		{path: ".", want: "docs/\nescape.txt\nlink.txt\nnotes.txt\n"},
+		{path: "../secret.txt", wantErr: ErrOutsideRoot},
+		{path: "docs/../../secret.txt", wantErr: ErrOutsideRoot},
+		{path: "escape.txt", wantErr: ErrOutsideRoot},
+		{path: "missing.txt", errText: "missing.txt does not exist"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := tool.Call(context.Background(), `{"path": "`+tt.path+`"}`)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Call() error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.errText != "":
+				if err == nil || !strings.Contains(err.Error(), tt.errText) {
+					t.Errorf("Call() error = %v, want %q", err, tt.errText)
+				}
+			case err != nil:
+				t.Errorf("Call() error = %v", err)
+			case got != tt.want:
+				t.Errorf("Call() = %q, want %q", got, tt.want)
+			}
+			if strings.Contains(got, "password") || (err != nil && strings.Contains(err.Error(), dir)) {
+				t.Errorf("Call() leaked outside the root: %q, %v", got, err)
+			}
+		})
+	}
+}
+
+func mustWrite(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,85 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aiwizzard/gollm/tools"
+)
+
+const defaultMaxResults = 5
+
+// SearchResult is a result of a web search
+type SearchResult struct {
+	Title   string
+	URL     string
+	Snippet string
+}
+
+// Searcher is a web search API. Implementations wrap the search provider of
+// choice, so the web search tool does not depend on any of them.
+type Searcher interface {
+	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
+}
+
+// SearchFunc adapts a function to the Searcher interface
+type SearchFunc func(ctx context.Context, query string, limit int) ([]SearchResult, error)
+
+// Search implements Searcher
+func (f SearchFunc) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	return f(ctx, query, limit)
+}
+
+// SearchConfig configures the web search tool
+type SearchConfig struct {
+	// Searcher runs the searches (required)
+	Searcher Searcher
+
+	// MaxResults caps the number of results (optional, defaults to 5)
+	MaxResults int
+}
+
+// SearchParams are the parameters of the web search tool
+type SearchParams struct {
+	Query string `json:"query" description:"The search query"`
+	Limit int    `json:"limit,omitempty" description:"Maximum number of results"`
+}
+
+// WebSearch returns the web_search tool, which searches the web through
+// config.Searcher and returns the results as a numbered list
+func WebSearch(config SearchConfig) *tools.Tool {
+	if config.MaxResults <= 0 {
+		config.MaxResults = defaultMaxResults
+	}
+	return tools.New("web_search", "Search the web and return the top results", func(ctx context.Context, params SearchParams) (string, error) {
+		if config.Searcher == nil {
+			return "", errors.New("builtin: no searcher configured")
+		}
+		if strings.TrimSpace(params.Query) == "" {
+			return "", errors.New("query is empty")
+		}
+
+		limit := config.MaxResults
+		if params.Limit > 0 {
+			limit = min(params.Limit, limit)
+		}
+		results, err := config.Searcher.Search(ctx, params.Query, limit)
+		if err != nil {
+			return "", fmt.Errorf("search failed: %w", err)
+		}
+		if len(results) == 0 {
+			return "No results found.", nil
+		}
+
+		var b strings.Builder
+		for i, result := range results[:min(len(results), limit)] {
+			fmt.Fprintf(&b, "%d. %s\n   %s\n", i+1, result.Title, result.URL)
+			if result.Snippet != "" {
+				fmt.Fprintf(&b, "   %s\n", result.Snippet)
+			}
+		}
+		return b.String(), nil
+	})
+}
@@ -0,0 +1,78 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWebSearch(t *testing.T) {
+	var gotQuery string
+	var gotLimit int
+	searcher := SearchFunc(func(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+		gotQuery, gotLimit = query, limit
+		switch query {
+		case "nothing":
+			return nil, nil
+		case "broken":
+			return nil, errors.New("quota exceeded")
+		}
+		return []SearchResult{
+			{Title: "Go", URL: "https://go.dev", Snippet: "The Go programming language"},
+			{Title: "Tour", URL: "https://go.dev/tour"},
+			{Title: "Extra", URL: "https://example.com"},
+		}, nil
+	})
+	tool := WebSearch(SearchConfig{Searcher: searcher, MaxResults: 2})
+
+	tests := []struct {
+		name      string
+		arguments string
+		want      string
+		wantLimit int
+		wantErr   string
+	}{
+		{
+			name:      "results capped",
+			arguments: `{"query": "golang"}`,
+			want:      "1. Go\n   https://go.dev\n   The Go programming language\n2. Tour\n   https://go.dev/tour\n",
+			wantLimit: 2,
+		},
+		{
+			name:      "lower limit",
+			arguments: `{"query": "golang", "limit": 1}`,
+			want:      "1. Go\n   https://go.dev\n   The Go programming language\n",
+			wantLimit: 1,
+		},
+		{name: "no results", arguments: `{"query": "nothing"}`, want: "No results found.", wantLimit: 2},
+		{name: "search error", arguments: `{"query": "broken"}`, wantErr: "search failed: quota exceeded"},
+		{name: "empty query", arguments: `{"query": " "}`, wantErr: "query is empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotLimit = 0
+			got, err := tool.Call(context.Background(), tt.arguments)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Call() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Call() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Call() = %q, want %q", got, tt.want)
+			}
+			if gotLimit != tt.wantLimit || gotQuery == "" {
+				t.Errorf("Search(%q, %d), want limit %d", gotQuery, gotLimit, tt.wantLimit)
+			}
+		})
+	}
+
+	if _, err := WebSearch(SearchConfig{}).Call(context.Background(), `{"query": "golang"}`); err == nil {
+		t.Error("Call() without a searcher succeeded")
+	}
+}
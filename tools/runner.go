@@ -0,0 +1,247 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aiwizzard/gollm/llm"
+)
+
+const defaultMaxIterations = 10
+
+// ErrNotApproved is returned for tool calls denied by RunnerConfig.Approve
+var ErrNotApproved = errors.New("tools: tool call not approved")
+
+// ApprovalError reports a tool call denied by RunnerConfig.Approve. Its
+// message holds the reason given, as it is reported to the model.
+type ApprovalError struct {
+	Tool   string
+	Reason string
+}
+
+func (e *ApprovalError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("tools: call to %s not approved", e.Tool)
+	}
+	return fmt.Sprintf("tools: call to %s not approved: %s", e.Tool, e.Reason)
+}
+
+// Is makes errors.Is(err, ErrNotApproved) match approval errors
+func (e *ApprovalError) Is(target error) bool {
+	return target == ErrNotApproved
+}
+
+// ApprovalFunc decides whether a tool call may run, giving the reason of a
+// denial. It can ask a user interactively or apply a policy, such as
+// requiring confirmation for payments or deletions.
+type ApprovalFunc func(ctx context.Context, call llm.ToolCall) (approve bool, reason string)
+
+// StopReason tells why a run stopped
+type StopReason string
+
+const (
+	// StopCompleted means the model replied without calling tools
+	StopCompleted StopReason = "completed"
+
+	// StopMaxIterations means the run reached RunnerConfig.MaxIterations
+	StopMaxIterations StopReason = "max_iterations"
+
+	// StopMaxToolCalls means the model asked for more tool calls than
+	// RunnerConfig.MaxToolCalls allows; the calls of the last turn are not
+	// run
+	StopMaxToolCalls StopReason = "max_tool_calls"
+
+	// StopMaxDuration means the run exceeded RunnerConfig.MaxDuration
+	StopMaxDuration StopReason = "max_duration"
+
+	// StopConditionMet means RunnerConfig.StopCondition returned true
+	StopConditionMet StopReason = "stop_condition"
+)
+
+// RunState is the state of a run, passed to StopCondition
+type RunState struct {
+	// Iterations is the number of model turns so far
+	Iterations int
+
+	// ToolCalls is the number of tool calls run or denied so far
+	ToolCalls int
+
+	// Elapsed is the time since the run started
+	Elapsed time.Duration
+
+	// Messages is the transcript so far
+	Messages []llm.Message
+
+	// Last is the last response of the model
+	Last *llm.CompletionResponse
+}
+
+// StopCondition decides whether a run should stop after a turn
+type StopCondition func(state RunState) bool
+
+// RunResult is the outcome of a run
+type RunResult struct {
+	RunState
+
+	// StopReason tells why the run stopped
+	StopReason StopReason
+}
+
+// RunnerConfig configures a Runner
+type RunnerConfig struct {
+	// MaxIterations caps the number of model turns (optional, defaults to
+	// 10)
+	MaxIterations int
+
+	// MaxToolCalls caps the number of tool calls over the run (optional, no
+	// limit if zero)
+	MaxToolCalls int
+
+	// MaxDuration is the wall-clock budget of a run (optional, no limit if
+	// zero). A model or tool call still running when it expires is canceled.
+	MaxDuration time.Duration
+
+	// StopCondition is checked after each turn once its tool calls have run
+	// (optional)
+	StopCondition StopCondition
+
+	// Concurrency caps the tool calls of a turn running at once (optional,
+	// no limit if zero), see Registry.CallAll
+	Concurrency int
+
+	// Approve is asked for each tool call before it runs (optional, all
+	// calls run if nil). The calls of a turn are submitted one at a time, in
+	// order, and the approved ones run once all have been decided. A denied
+	// call fails with an *ApprovalError, so the model learns why.
+	Approve ApprovalFunc
+}
+
+// Runner runs the tool-execution loop: it completes a conversation, runs the
+// tools the model calls, sends their results back and repeats until the
+// model answers without calling tools or a limit is reached
+type Runner struct {
+	provider llm.LLMProvider
+	registry *Registry
+	config   RunnerConfig
+}
+
+// NewRunner creates a runner completing conversations with provider and
+// running the tools of registry
+func NewRunner(provider llm.LLMProvider, registry *Registry, config RunnerConfig) *Runner {
+	if config.MaxIterations <= 0 {
+		config.MaxIterations = defaultMaxIterations
+	}
+	return &Runner{provider: provider, registry: registry, config: config}
+}
+
+// Run runs the loop on req, offering the tools of the registry unless
+// req.Tools is set. Reaching a limit is not an error: the result tells which
+// one stopped the run, with the transcript up to that point. An error is
+// returned with the partial result if a model call fails or ctx is done.
+func (r *Runner) Run(ctx context.Context, req llm.CompletionRequest) (*RunResult, error) {
+	start := time.Now()
+	runCtx := ctx
+	if r.config.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, r.config.MaxDuration)
+		defer cancel()
+	}
+
+	result := &RunResult{}
+	result.Messages = append([]llm.Message(nil), req.Messages...)
+	if req.Prompt != "" {
+		result.Messages = append(result.Messages, llm.Message{Role: llm.RoleUser, Content: req.Prompt})
+	}
+	req.Prompt = ""
+	if len(req.Tools) == 0 {
+		req.Tools = r.registry.Definitions()
+	}
+
+	stop := func(reason StopReason) (*RunResult, error) {
+		result.StopReason = reason
+		result.Elapsed = time.Since(start)
+		return result, nil
+	}
+	// overBudget reports whether runCtx ended because of MaxDuration rather
+	// than ctx
+	overBudget := func() bool {
+		return ctx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded)
+	}
+
+	for {
+		if result.Iterations >= r.config.MaxIterations {
+			return stop(StopMaxIterations)
+		}
+
+		req.Messages = result.Messages
+		resp, err := r.provider.Complete(runCtx, &req)
+		if err != nil {
+			if overBudget() {
+				return stop(StopMaxDuration)
+			}
+			result.Elapsed = time.Since(start)
+			return result, err
+		}
+		result.Iterations++
+		result.Last = resp
+		result.Messages = append(result.Messages, llm.Message{
+			Role:      llm.RoleAssistant,
+			Content:   resp.Content,
+			ToolCalls: resp.ToolCalls,
+			Thinking:  resp.Thinking,
+		})
+
+		if len(resp.ToolCalls) == 0 {
+			return stop(StopCompleted)
+		}
+		if r.config.MaxToolCalls > 0 && result.ToolCalls+len(resp.ToolCalls) > r.config.MaxToolCalls {
+			return stop(StopMaxToolCalls)
+		}
+
+		results := r.callTools(runCtx, resp.ToolCalls)
+		result.ToolCalls += len(results)
+		result.Messages = append(result.Messages, Messages(results)...)
+
+		if overBudget() {
+			return stop(StopMaxDuration)
+		}
+		if err := ctx.Err(); err != nil {
+			result.Elapsed = time.Since(start)
+			return result, err
+		}
+		if r.config.StopCondition != nil {
+			result.Elapsed = time.Since(start)
+			if r.config.StopCondition(result.RunState) {
+				return stop(StopConditionMet)
+			}
+		}
+	}
+}
+
+// callTools runs the approved calls among calls and returns the results of
+// all of them, in order
+func (r *Runner) callTools(ctx context.Context, calls []llm.ToolCall) []Result {
+	if r.config.Approve == nil {
+		return r.registry.CallAll(ctx, calls, r.config.Concurrency)
+	}
+
+	results := make([]Result, len(calls))
+	var approved []llm.ToolCall
+	var indexes []int
+	for i, call := range calls {
+		results[i].Call = call
+		if ok, reason := r.config.Approve(ctx, call); !ok {
+			results[i].Err = &ApprovalError{Tool: call.Function.Name, Reason: reason}
+			continue
+		}
+		approved = append(approved, call)
+		indexes = append(indexes, i)
+	}
+
+	for i, result := range r.registry.CallAll(ctx, approved, r.config.Concurrency) {
+		results[indexes[i]] = result
+	}
+	return results
+}
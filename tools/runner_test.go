@@ -0,0 +1,273 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aiwizzard/gollm/llm"
+)
+
+// scriptedProvider answers each Complete with the next response of its
+// script, calling the weather tool once it runs out, and records the requests
+type scriptedProvider struct {
+	mu        sync.Mutex
+	responses []*llm.CompletionResponse
+	requests  []llm.CompletionRequest
+	delay     time.Duration
+}
+
+func (p *scriptedProvider) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	if p.delay > 0 {
+		select {
+		case <-time.After(p.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	recorded := *req
+	recorded.Messages = append([]llm.Message(nil), req.Messages...)
+	p.requests = append(p.requests, recorded)
+	if len(p.responses) == 0 {
+		return callResponse(toolCall("get_weather", `{"location": "Paris"}`)), nil
+	}
+	resp := p.responses[0]
+	p.responses = p.responses[1:]
+	return resp, nil
+}
+
+func (p *scriptedProvider) CompleteStream(ctx context.Context, req *llm.CompletionRequest) (llm.CompletionStream, error) {
+	return nil, errors.New("not supported")
+}
+
+func callResponse(calls ...llm.ToolCall) *llm.CompletionResponse {
+	return &llm.CompletionResponse{FinishReason: "tool_calls", ToolCalls: calls}
+}
+
+func TestRunner(t *testing.T) {
+	thinking := []llm.ThinkingBlock{{Thinking: "Look it up.", Signature: "sig"}}
+	first := callResponse(toolCall("get_weather", `{"location": "Paris", "unit": "C"}`))
+	first.Thinking = thinking
+	provider := &scriptedProvider{responses: []*llm.CompletionResponse{first, {Content: "It is 22°C in Paris."}}}
+	runner := NewRunner(provider, NewRegistry(weatherTool()), RunnerConfig{})
+
+	result, err := runner.Run(context.Background(), llm.CompletionRequest{
+		SystemPrompt: "Be brief.",
+		Prompt:       "Weather in Paris?",
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.StopReason != StopCompleted || result.Iterations != 2 || result.ToolCalls != 1 {
+		t.Errorf("Run() = %+v", result)
+	}
+	if result.Last.Content != "It is 22°C in Paris." {
+		t.Errorf("Last = %+v", result.Last)
+	}
+
+	wantRoles := []string{llm.RoleUser, llm.RoleAssistant, llm.RoleTool, llm.RoleAssistant}
+	if len(result.Messages) != len(wantRoles) {
+		t.Fatalf("Messages = %+v, want %d messages", result.Messages, len(wantRoles))
+	}
+	for i, role := range wantRoles {
+		if result.Messages[i].Role != role {
+			t.Errorf("Messages[%d].Role = %s, want %s", i, result.Messages[i].Role, role)
+		}
+	}
+	if got := result.Messages[1]; !reflect.DeepEqual(got.Thinking, thinking) {
+		t.Errorf("assistant message = %+v, want the thinking blocks kept", got)
+	}
+	if got := result.Messages[2]; got.Content != "22°C in Paris" || got.ToolCallID != "call_1" {
+		t.Errorf("tool message = %+v", got)
+	}
+
+	second := provider.requests[1]
+	if second.Prompt != "" || second.SystemPrompt != "Be brief." || len(second.Messages) != 3 {
+		t.Errorf("second request = %+v, want the transcript without the prompt", second)
+	}
+	if len(second.Tools) != 1 || second.Tools[0].Function.Name != "get_weather" {
+		t.Errorf("second request tools = %+v, want the registry's", second.Tools)
+	}
+}
+
+func TestRunner_Limits(t *testing.T) {
+	twoCalls := callResponse(toolCall("get_weather", `{"location": "Paris"}`), toolCall("get_weather", `{"location": "Rome"}`))
+
+	tests := []struct {
+		name           string
+		config         RunnerConfig
+		responses      []*llm.CompletionResponse
+		wantReason     StopReason
+		wantIterations int
+		wantToolCalls  int
+	}{
+		{
+			name:           "default max iterations",
+			wantReason:     StopMaxIterations,
+			wantIterations: defaultMaxIterations,
+			wantToolCalls:  defaultMaxIterations,
+		},
+		{
+			name:           "max iterations",
+			config:         RunnerConfig{MaxIterations: 3},
+			wantReason:     StopMaxIterations,
+			wantIterations: 3,
+			wantToolCalls:  3,
+		},
+		{
+			name:           "max tool calls",
+			config:         RunnerConfig{MaxToolCalls: 3},
+			responses:      []*llm.CompletionResponse{twoCalls, twoCalls},
+			wantReason:     StopMaxToolCalls,
+			wantIterations: 2,
+			wantToolCalls:  2,
+		},
+		{
+			name: "stop condition",
+			config: RunnerConfig{StopCondition: func(state RunState) bool {
+				return state.ToolCalls >= 4
+			}},
+			responses:      []*llm.CompletionResponse{twoCalls, twoCalls},
+			wantReason:     StopConditionMet,
+			wantIterations: 2,
+			wantToolCalls:  4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &scriptedProvider{responses: tt.responses}
+			runner := NewRunner(provider, NewRegistry(weatherTool()), tt.config)
+
+			result, err := runner.Run(context.Background(), llm.CompletionRequest{Prompt: "Weather?"})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if result.StopReason != tt.wantReason || result.Iterations != tt.wantIterations || result.ToolCalls != tt.wantToolCalls {
+				t.Errorf("Run() = %s after %d iterations and %d tool calls, want %s after %d and %d",
+					result.StopReason, result.Iterations, result.ToolCalls, tt.wantReason, tt.wantIterations, tt.wantToolCalls)
+			}
+			// The transcript ends with the last turn, even when its calls
+			// were not run
+			if last := result.Messages[len(result.Messages)-1]; tt.wantReason == StopMaxToolCalls && last.Role != llm.RoleAssistant {
+				t.Errorf("last message = %+v, want the assistant turn", last)
+			}
+		})
+	}
+}
+
+func TestRunner_MaxDuration(t *testing.T) {
+	provider := &scriptedProvider{delay: 20 * time.Millisecond}
+	runner := NewRunner(provider, NewRegistry(weatherTool()), RunnerConfig{MaxDuration: 50 * time.Millisecond})
+
+	result, err := runner.Run(context.Background(), llm.CompletionRequest{Prompt: "Weather?"})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.StopReason != StopMaxDuration || result.Iterations == 0 || result.Iterations >= defaultMaxIterations {
+		t.Errorf("Run() = %s after %d iterations", result.StopReason, result.Iterations)
+	}
+	if result.Elapsed < 50*time.Millisecond || len(result.Messages) == 0 {
+		t.Errorf("Elapsed = %v, Messages = %d", result.Elapsed, len(result.Messages))
+	}
+}
+
+func TestRunner_Errors(t *testing.T) {
+	failing := &failingProvider{err: errors.New("rate limited")}
+	runner := NewRunner(failing, NewRegistry(weatherTool()), RunnerConfig{})
+	result, err := runner.Run(context.Background(), llm.CompletionRequest{Prompt: "Weather?"})
+	if err == nil || err.Error() != "rate limited" {
+		t.Errorf("Run() error = %v, want the provider's", err)
+	}
+	if result == nil || len(result.Messages) != 1 {
+		t.Errorf("Run() result = %+v, want the partial transcript", result)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	runner = NewRunner(&scriptedProvider{delay: time.Second}, NewRegistry(weatherTool()), RunnerConfig{MaxDuration: time.Minute})
+	if _, err := runner.Run(ctx, llm.CompletionRequest{Prompt: "Weather?"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() with a canceled context error = %v, want %v", err, context.Canceled)
+	}
+}
+
+type failingProvider struct {
+	err error
+}
+
+func (p *failingProvider) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	return nil, p.err
+}
+
+func (p *failingProvider) CompleteStream(ctx context.Context, req *llm.CompletionRequest) (llm.CompletionStream, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestRunner_Approve(t *testing.T) {
+	var mu sync.Mutex
+	var ran []string
+	transfer := New("transfer", "Transfer money", func(ctx context.Context, params struct {
+		To string `json:"to"`
+	}) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		ran = append(ran, params.To)
+		return "sent to " + params.To, nil
+	})
+
+	first, second := toolCall("transfer", `{"to": "alice"}`), toolCall("transfer", `{"to": "mallory"}`)
+	second.ID = "call_2"
+	provider := &scriptedProvider{responses: []*llm.CompletionResponse{
+		callResponse(first, second),
+		{Content: "Sent to alice only."},
+	}}
+
+	var asked []string
+	runner := NewRunner(provider, NewRegistry(transfer), RunnerConfig{
+		Approve: func(ctx context.Context, call llm.ToolCall) (bool, string) {
+			asked = append(asked, call.ID)
+			if call.Function.Arguments == `{"to": "mallory"}` {
+				return false, "recipient not on the allowlist"
+			}
+			return true, ""
+		},
+	})
+
+	result, err := runner.Run(context.Background(), llm.CompletionRequest{Prompt: "Pay alice and mallory"})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.StopReason != StopCompleted || result.ToolCalls != 2 {
+		t.Errorf("Run() = %s after %d tool calls", result.StopReason, result.ToolCalls)
+	}
+	if len(asked) != 2 || asked[0] != "call_1" || asked[1] != "call_2" {
+		t.Errorf("Approve asked for %v, want both calls in order", asked)
+	}
+	if len(ran) != 1 || ran[0] != "alice" {
+		t.Errorf("ran transfers to %v, want alice only", ran)
+	}
+
+	if got := result.Messages[2]; got.ToolCallID != "call_1" || got.Content != "sent to alice" {
+		t.Errorf("approved call message = %+v", got)
+	}
+	want := "Error: tools: call to transfer not approved: recipient not on the allowlist"
+	if got := result.Messages[3]; got.ToolCallID != "call_2" || got.Content != want {
+		t.Errorf("denied call message = %+v, want %q", got, want)
+	}
+}
+
+func TestApprovalError(t *testing.T) {
+	err := error(&ApprovalError{Tool: "delete_file"})
+	if !errors.Is(err, ErrNotApproved) {
+		t.Errorf("errors.Is(%v, ErrNotApproved) = false", err)
+	}
+	if got, want := err.Error(), "tools: call to delete_file not approved"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
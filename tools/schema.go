@@ -0,0 +1,215 @@
+// Package tools helps define the tools (functions) a model can call: JSON
+// schemas for their parameters are generated from Go structs, and typed
+// handlers receive the arguments of tool calls already decoded.
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON schema describing tool parameters. It encodes to the
+// value expected in llm.Function.Parameters.
+type Schema struct {
+	Type        string             `json:"type,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Enum        []any              `json:"enum,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+
+	// AdditionalProperties is false for structs, which have a fixed set of
+	// fields, and the schema of the values for maps
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
+}
+
+// SchemaFor returns the schema of the parameters struct T, see SchemaOf. It
+// panics if the schema cannot be generated, so it is meant for package-level
+// tool definitions:
+//
+//	type WeatherParams struct {
+//		Location string `json:"location" description:"The city, e.g. Paris"`
+//		Unit     string `json:"unit,omitempty" enum:"celsius,fahrenheit"`
+//	}
+//
+//	var weatherSchema = tools.SchemaFor[WeatherParams]()
+func SchemaFor[T any]() *Schema {
+	schema, err := SchemaOf(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+// SchemaOf returns the schema of the parameters struct type t, or a pointer
+// to it. Fields are named and skipped as encoding/json does, and embedded
+// structs are flattened. Fields are required unless their json tag has
+// omitempty or they are pointers. The struct tags description and enum add
+// a description and a comma-separated list of allowed values; enum values
+// of numeric fields are parsed as numbers.
+func SchemaOf(t reflect.Type) (*Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("tools: parameters must be a struct, got %s", t)
+	}
+	return schemaOf(t, make(map[reflect.Type]bool))
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// schemaOf returns the schema of t; visiting holds the struct types being
+// generated, to reject recursive types
+func schemaOf(t reflect.Type, visiting map[reflect.Type]bool) (*Schema, error) {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	case rawMessageType:
+		return &Schema{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOf(t.Elem(), visiting)
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json encodes byte slices as base64 strings
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := schemaOf(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("tools: unsupported map key type %s", t.Key())
+		}
+		values, err := schemaOf(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		return structSchema(t, visiting)
+	}
+	return nil, fmt.Errorf("tools: unsupported type %s", t)
+}
+
+func structSchema(t reflect.Type, visiting map[reflect.Type]bool) (*Schema, error) {
+	if visiting[t] {
+		return nil, fmt.Errorf("tools: recursive type %s", t)
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema), AdditionalProperties: false}
+	if err := addFields(schema, t, visiting); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// addFields adds the fields of struct type t, including those of embedded
+// structs, to schema
+func addFields(schema *Schema, t reflect.Type, visiting map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := addFields(schema, embedded, visiting); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property, err := schemaOf(field.Type, visiting)
+		if err != nil {
+			return fmt.Errorf("%w (field %s.%s)", err, t.Name(), field.Name)
+		}
+		property.Description = field.Tag.Get("description")
+		if enum := field.Tag.Get("enum"); enum != "" {
+			if property.Enum, err = enumValues(enum, property.Type); err != nil {
+				return fmt.Errorf("%w (field %s.%s)", err, t.Name(), field.Name)
+			}
+		}
+
+		schema.Properties[name] = property
+		if !hasOption(options, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return nil
+}
+
+// enumValues parses the comma-separated values of an enum tag for a
+// property of type typ
+func enumValues(tag, typ string) ([]any, error) {
+	var values []any
+	for _, value := range strings.Split(tag, ",") {
+		value = strings.TrimSpace(value)
+		switch typ {
+		case "integer":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("tools: invalid integer enum value %q", value)
+			}
+			values = append(values, n)
+		case "number":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("tools: invalid number enum value %q", value)
+			}
+			values = append(values, f)
+		default:
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+func hasOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Address struct {
+	City    string `json:"city" description:"City name"`
+	Country string `json:"country,omitempty"`
+}
+
+type Base struct {
+	RequestID string `json:"request_id,omitempty"`
+}
+
+type BookingParams struct {
+	Base
+	Guest    string            `json:"guest" description:"Full name of the guest"`
+	Nights   int               `json:"nights"`
+	Rooms    *int              `json:"rooms"`
+	Class    string            `json:"class" enum:"economy, business"`
+	Floor    int               `json:"floor,omitempty" enum:"1,2,3"`
+	Rate     float64           `json:"rate,omitempty"`
+	Smoking  bool              `json:"smoking"`
+	Arrival  time.Time         `json:"arrival"`
+	Tags     []string          `json:"tags,omitempty"`
+	Address  Address           `json:"address"`
+	Extras   map[string]int    `json:"extras,omitempty"`
+	Notes    json.RawMessage   `json:"notes,omitempty"`
+	Photo    []byte            `json:"photo,omitempty"`
+	Internal string            `json:"-"`
+	Default  string            // no tag: named after the field
+	private  string            // unexported: skipped
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+func TestSchemaFor(t *testing.T) {
+	got, err := json.Marshal(SchemaFor[BookingParams]())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := `{
+		"type": "object",
+		"properties": {
+			"request_id": {"type": "string"},
+			"guest": {"type": "string", "description": "Full name of the guest"},
+			"nights": {"type": "integer"},
+			"rooms": {"type": "integer"},
+			"class": {"type": "string", "enum": ["economy", "business"]},
+			"floor": {"type": "integer", "enum": [1, 2, 3]},
+			"rate": {"type": "number"},
+			"smoking": {"type": "boolean"},
+			"arrival": {"type": "string", "format": "date-time"},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"address": {
+				"type": "object",
+				"properties": {
+					"city": {"type": "string", "description": "City name"},
+					"country": {"type": "string"}
+				},
+				"required": ["city"],
+				"additionalProperties": false
+			},
+			"extras": {"type": "object", "additionalProperties": {"type": "integer"}},
+			"notes": {},
+			"photo": {"type": "string", "format": "byte"},
+			"Default": {"type": "string"},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}}
+		},
+		"required": ["guest", "nights", "class", "smoking", "arrival", "address", "Default"],
+		"additionalProperties": false
+	}`
+
+	var gotValue, wantValue any
+	json.Unmarshal(got, &gotValue)
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("invalid want: %v", err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("SchemaFor() = %s", got)
+	}
+}
+
+func TestSchemaOf_Errors(t *testing.T) {
+	type node struct {
+		Children []*node `json:"children"`
+	}
+	type badEnum struct {
+		Level int `json:"level" enum:"low,high"`
+	}
+	type badMap struct {
+		Scores map[int]string `json:"scores"`
+	}
+	type badField struct {
+		Done chan bool `json:"done"`
+	}
+
+	tests := []struct {
+		name    string
+		typ     reflect.Type
+		wantErr string
+	}{
+		{name: "not a struct", typ: reflect.TypeOf(""), wantErr: "must be a struct"},
+		{name: "recursive", typ: reflect.TypeOf(node{}), wantErr: "recursive type"},
+		{name: "invalid enum", typ: reflect.TypeOf(badEnum{}), wantErr: `invalid integer enum value "low"`},
+		{name: "map key", typ: reflect.TypeOf(badMap{}), wantErr: "unsupported map key type int"},
+		{name: "channel", typ: reflect.TypeOf(badField{}), wantErr: "unsupported type chan bool (field badField.Done)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SchemaOf(tt.typ)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("SchemaOf() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSchemaFor_Pointer(t *testing.T) {
+	if !reflect.DeepEqual(SchemaFor[*Address](), SchemaFor[Address]()) {
+		t.Error("SchemaFor[*Address]() differs from SchemaFor[Address]()")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("SchemaFor[int]() did not panic")
+		}
+	}()
+	SchemaFor[int]()
+}
@@ -0,0 +1,283 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aiwizzard/gollm/llm"
+)
+
+var (
+	// ErrUnknownTool is returned when the model calls a tool that is not
+	// registered
+	ErrUnknownTool = errors.New("tools: unknown tool")
+
+	// ErrTimeout is returned when a tool runs longer than its Timeout
+	ErrTimeout = errors.New("tools: tool timed out")
+
+	// ErrPanic is returned when a tool handler panics, see PanicError
+	ErrPanic = errors.New("tools: tool panicked")
+)
+
+// PanicError reports a panic recovered from a tool handler. Its message only
+// holds the panic value, as it is reported to the model; Stack is meant for
+// logs.
+type PanicError struct {
+	Tool  string
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("tools: %s panicked: %v", e.Tool, e.Value)
+}
+
+// Is makes errors.Is(err, ErrPanic) match panic errors
+func (e *PanicError) Is(target error) bool {
+	return target == ErrPanic
+}
+
+// Handler runs a tool with the JSON arguments the model passed and returns
+// the result to send back to it
+type Handler func(ctx context.Context, arguments string) (string, error)
+
+// Tool is a tool the model can call together with the handler that runs it
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  *Schema
+	Handler     Handler
+
+	// RawParameters is the JSON schema of the parameters, used instead of
+	// Parameters when set (optional). It carries schemas from other sources,
+	// such as MCP servers, with keywords Schema does not model.
+	RawParameters json.RawMessage
+
+	// Timeout bounds each call of the tool (optional, no limit if zero). The
+	// handler's context is canceled when it expires, and the call fails with
+	// ErrTimeout even if the handler ignores the cancellation.
+	Timeout time.Duration
+}
+
+// New returns a tool whose parameter schema is derived from T, see
+// SchemaFor, and whose arguments are decoded into a T before fn is called,
+// so handlers receive typed parameters. It panics if no schema can be
+// generated for T.
+func New[T any](name, description string, fn func(ctx context.Context, params T) (string, error)) *Tool {
+	return &Tool{
+		Name:        name,
+		Description: description,
+		Parameters:  SchemaFor[T](),
+		Handler: func(ctx context.Context, arguments string) (string, error) {
+			var params T
+			if strings.TrimSpace(arguments) != "" {
+				if err := json.Unmarshal([]byte(arguments), &params); err != nil {
+					return "", &ArgumentError{Tool: name, Problems: []string{err.Error()}}
+				}
+			}
+			return fn(ctx, params)
+		},
+	}
+}
+
+// Definition returns the tool definition to send in CompletionRequest.Tools
+func (t *Tool) Definition() llm.Tool {
+	var parameters any = t.Parameters
+	if len(t.RawParameters) > 0 {
+		parameters = t.RawParameters
+	}
+	return llm.Tool{
+		Type: "function",
+		Function: llm.Function{
+			Name:        t.Name,
+			Description: t.Description,
+			Parameters:  parameters,
+		},
+	}
+}
+
+// Call validates the JSON arguments of a tool call, see Validate, and runs
+// the tool with them. Invalid arguments fail with an *ArgumentError without
+// running the handler. A panic in the handler is recovered and returned as a
+// *PanicError, and a call exceeding Timeout fails with ErrTimeout.
+func (t *Tool) Call(ctx context.Context, arguments string) (string, error) {
+	if err := t.Validate(arguments); err != nil {
+		return "", err
+	}
+	if t.Timeout <= 0 {
+		return t.run(ctx, arguments)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+
+	type outcome struct {
+		content string
+		err     error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		content, err := t.run(callCtx, arguments)
+		done <- outcome{content, err}
+	}()
+
+	select {
+	case o := <-done:
+		if o.err != nil && ctx.Err() == nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+			return "", t.timeoutError()
+		}
+		return o.content, o.err
+	case <-callCtx.Done():
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		// The handler is left to return on its own
+		return "", t.timeoutError()
+	}
+}
+
+func (t *Tool) timeoutError() error {
+	return fmt.Errorf("%w: %s did not finish within %s", ErrTimeout, t.Name, t.Timeout)
+}
+
+// run calls the handler, recovering a panic into a *PanicError
+func (t *Tool) run(ctx context.Context, arguments string) (content string, err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			content, err = "", &PanicError{Tool: t.Name, Value: value, Stack: debug.Stack()}
+		}
+	}()
+	return t.Handler(ctx, arguments)
+}
+
+// Registry holds the tools available to a model. It is safe for concurrent
+// use.
+type Registry struct {
+	mu    sync.RWMutex
+	tools map[string]*Tool
+}
+
+// NewRegistry creates a registry holding tools
+func NewRegistry(tools ...*Tool) *Registry {
+	r := &Registry{tools: make(map[string]*Tool)}
+	r.Register(tools...)
+	return r
+}
+
+// Register adds tools to the registry, replacing tools of the same name
+func (r *Registry) Register(tools ...*Tool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, tool := range tools {
+		r.tools[tool.Name] = tool
+	}
+}
+
+// Lookup returns the tool registered under name
+func (r *Registry) Lookup(name string) (*Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+// Definitions returns the definitions of all tools, sorted by name, to send
+// in CompletionRequest.Tools
+func (r *Registry) Definitions() []llm.Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	definitions := make([]llm.Tool, 0, len(r.tools))
+	for _, tool := range r.tools {
+		definitions = append(definitions, tool.Definition())
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Function.Name < definitions[j].Function.Name
+	})
+	return definitions
+}
+
+// Call runs the tool a tool call asks for, failing with ErrUnknownTool if it
+// is not registered
+func (r *Registry) Call(ctx context.Context, call llm.ToolCall) (string, error) {
+	tool, ok := r.Lookup(call.Function.Name)
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownTool, call.Function.Name)
+	}
+	return tool.Call(ctx, call.Function.Arguments)
+}
+
+// Result is the outcome of a tool call
+type Result struct {
+	Call    llm.ToolCall
+	Content string
+	Err     error
+}
+
+// Message returns the tool message reporting the result to the model. A
+// failed call is reported as its error, so the model can react to it: calls
+// with invalid arguments are rejected this way with the list of problems,
+// and timeouts and panics do not stop the conversation.
+func (r Result) Message() llm.Message {
+	content := r.Content
+	if r.Err != nil {
+		content = "Error: " + r.Err.Error()
+	}
+	return llm.Message{Role: llm.RoleTool, ToolCallID: r.Call.ID, Content: content}
+}
+
+// Messages returns the tool messages of results, in order, to send back to
+// the model in a single follow-up turn
+func Messages(results []Result) []llm.Message {
+	messages := make([]llm.Message, len(results))
+	for i, result := range results {
+		messages[i] = result.Message()
+	}
+	return messages
+}
+
+// CallAll runs the tool calls a model made in one turn concurrently, at most
+// limit at a time (no limit if limit <= 0), and returns their results in the
+// order of calls regardless of which finished first. Calls not started when
+// ctx is done fail with its error.
+func (r *Registry) CallAll(ctx context.Context, calls []llm.ToolCall, limit int) []Result {
+	if limit <= 0 || limit > len(calls) {
+		limit = len(calls)
+	}
+
+	results := make([]Result, len(calls))
+	slots := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+
+	for i, call := range calls {
+		results[i].Call = call
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *Result) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			result.Content, result.Err = r.Call(ctx, result.Call)
+		}(&results[i])
+	}
+
+	wg.Wait()
+	return results
+}
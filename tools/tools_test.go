@@ -0,0 +1,289 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aiwizzard/gollm/llm"
+)
+
+type WeatherParams struct {
+	Location string `json:"location" description:"The city"`
+	Unit     string `json:"unit,omitempty" enum:"C,F"`
+}
+
+func weatherTool() *Tool {
+	return New("get_weather", "Get the weather", func(ctx context.Context, params WeatherParams) (string, error) {
+		if params.Location == "" {
+			return "", errors.New("location is required")
+		}
+		return fmt.Sprintf("22°%s in %s", params.Unit, params.Location), nil
+	})
+}
+
+func toolCall(name, arguments string) llm.ToolCall {
+	var call llm.ToolCall
+	call.ID = "call_1"
+	call.Function.Name = name
+	call.Function.Arguments = arguments
+	return call
+}
+
+func TestNew(t *testing.T) {
+	tool := weatherTool()
+
+	definition := tool.Definition()
+	if definition.Type != "function" || definition.Function.Name != "get_weather" || definition.Function.Description != "Get the weather" {
+		t.Errorf("Definition() = %+v", definition)
+	}
+	if !reflect.DeepEqual(definition.Function.Parameters, SchemaFor[WeatherParams]()) {
+		t.Errorf("Parameters = %+v, want the schema of WeatherParams", definition.Function.Parameters)
+	}
+
+	tests := []struct {
+		name      string
+		arguments string
+		want      string
+		wantErr   string
+	}{
+		{name: "decoded", arguments: `{"location": "Paris", "unit": "C"}`, want: "22°C in Paris"},
+		{name: "handler error", arguments: `{"location": ""}`, wantErr: "location is required"},
+		{name: "no arguments", arguments: "", wantErr: "location: missing required property"},
+		{name: "invalid JSON", arguments: `{"location": `, wantErr: "tools: invalid arguments for get_weather"},
+		{name: "wrong type", arguments: `{"location": 42}`, wantErr: "location: must be a string"},
+		{name: "not in enum", arguments: `{"location": "Paris", "unit": "K"}`, wantErr: `unit: must be one of "C", "F"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tool.Call(context.Background(), tt.arguments)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Call() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Call() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Call() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	clock := New("get_time", "Get the time", func(ctx context.Context, params struct{}) (string, error) {
+		return "noon", nil
+	})
+	registry := NewRegistry(weatherTool(), clock)
+
+	var names []string
+	for _, definition := range registry.Definitions() {
+		names = append(names, definition.Function.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"get_time", "get_weather"}) {
+		t.Errorf("Definitions() names = %v", names)
+	}
+
+	got, err := registry.Call(context.Background(), toolCall("get_weather", `{"location": "Rome", "unit": "F"}`))
+	if err != nil || got != "22°F in Rome" {
+		t.Errorf("Call() = %q, %v", got, err)
+	}
+
+	_, err = registry.Call(context.Background(), toolCall("get_stock", `{}`))
+	if !errors.Is(err, ErrUnknownTool) || !strings.Contains(err.Error(), "get_stock") {
+		t.Errorf("Call() error = %v, want %v", err, ErrUnknownTool)
+	}
+
+	// Registering a tool with the same name replaces it
+	registry.Register(New("get_time", "Get the time", func(ctx context.Context, params struct{}) (string, error) {
+		return "midnight", nil
+	}))
+	if got, _ := registry.Call(context.Background(), toolCall("get_time", "")); got != "midnight" {
+		t.Errorf("Call() after Register = %q, want midnight", got)
+	}
+	if _, ok := registry.Lookup("get_weather"); !ok {
+		t.Error("Lookup(get_weather) failed")
+	}
+}
+
+func TestRegistry_CallAll(t *testing.T) {
+	var (
+		mu               sync.Mutex
+		running, maxSeen int
+	)
+	slow := New("slow", "Sleep", func(ctx context.Context, params struct {
+		Millis int `json:"millis"`
+	}) (string, error) {
+		mu.Lock()
+		running++
+		maxSeen = max(maxSeen, running)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+
+		time.Sleep(time.Duration(params.Millis) * time.Millisecond)
+		return fmt.Sprintf("slept %d", params.Millis), nil
+	})
+	registry := NewRegistry(slow)
+
+	var calls []llm.ToolCall
+	for i, millis := range []int{40, 30, 20, 10, 1} {
+		call := toolCall("slow", fmt.Sprintf(`{"millis": %d}`, millis))
+		call.ID = fmt.Sprintf("call_%d", i)
+		calls = append(calls, call)
+	}
+	calls = append(calls, toolCall("missing", `{}`))
+	calls[len(calls)-1].ID = "call_missing"
+
+	tests := []struct {
+		limit   int
+		wantMax int
+	}{
+		{limit: 2, wantMax: 2},
+		{limit: 0, wantMax: 5},
+	}
+
+	for _, tt := range tests {
+		maxSeen = 0
+		results := registry.CallAll(context.Background(), calls, tt.limit)
+
+		if maxSeen > tt.wantMax || (tt.limit > 0 && maxSeen < 2) {
+			t.Errorf("limit %d: %d calls ran concurrently, want at most %d", tt.limit, maxSeen, tt.wantMax)
+		}
+		for i, result := range results[:5] {
+			if result.Call.ID != calls[i].ID || result.Err != nil || !strings.HasPrefix(result.Content, "slept ") {
+				t.Errorf("limit %d: result %d = %+v", tt.limit, i, result)
+			}
+		}
+		if !errors.Is(results[5].Err, ErrUnknownTool) {
+			t.Errorf("limit %d: missing tool error = %v", tt.limit, results[5].Err)
+		}
+	}
+}
+
+func TestRegistry_CallAllCanceled(t *testing.T) {
+	registry := NewRegistry(weatherTool())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := registry.CallAll(ctx, []llm.ToolCall{toolCall("get_weather", `{"location": "Paris"}`)}, 1)
+	if len(results) != 1 || !errors.Is(results[0].Err, context.Canceled) {
+		t.Errorf("results = %+v, want the call canceled", results)
+	}
+}
+
+func TestMessages(t *testing.T) {
+	results := []Result{
+		{Call: toolCall("get_weather", ""), Content: "22°C"},
+		{Call: toolCall("get_stock", ""), Err: errors.New("market closed")},
+	}
+	results[1].Call.ID = "call_2"
+
+	want := []llm.Message{
+		{Role: llm.RoleTool, ToolCallID: "call_1", Content: "22°C"},
+		{Role: llm.RoleTool, ToolCallID: "call_2", Content: "Error: market closed"},
+	}
+	if got := Messages(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("Messages() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTool_CallTimeoutAndPanic(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		handler Handler
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "within timeout",
+			timeout: time.Second,
+			handler: func(ctx context.Context, arguments string) (string, error) { return "done", nil },
+			want:    "done",
+		},
+		{
+			name:    "honors cancellation",
+			timeout: 10 * time.Millisecond,
+			handler: func(ctx context.Context, arguments string) (string, error) {
+				<-ctx.Done()
+				return "", ctx.Err()
+			},
+			wantErr: ErrTimeout,
+		},
+		{
+			name:    "ignores cancellation",
+			timeout: 10 * time.Millisecond,
+			handler: func(ctx context.Context, arguments string) (string, error) {
+				<-release
+				return "late", nil
+			},
+			wantErr: ErrTimeout,
+		},
+		{
+			name: "panic",
+			handler: func(ctx context.Context, arguments string) (string, error) {
+				var m map[string]int
+				m["boom"]++
+				return "", nil
+			},
+			wantErr: ErrPanic,
+		},
+		{
+			name:    "panic with timeout",
+			timeout: time.Second,
+			handler: func(ctx context.Context, arguments string) (string, error) { panic("boom") },
+			wantErr: ErrPanic,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &Tool{Name: "work", Handler: tt.handler, Timeout: tt.timeout}
+			got, err := tool.Call(context.Background(), "{}")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Call() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Call() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// A panic is reported to the model without its stack
+	tool := &Tool{Name: "work", Handler: func(ctx context.Context, arguments string) (string, error) { panic("boom") }}
+	result := NewRegistry(tool).CallAll(context.Background(), []llm.ToolCall{toolCall("work", "")}, 0)[0]
+	var panicErr *PanicError
+	if !errors.As(result.Err, &panicErr) || panicErr.Value != "boom" || len(panicErr.Stack) == 0 {
+		t.Fatalf("Err = %#v, want a *PanicError", result.Err)
+	}
+	if got := result.Message().Content; got != "Error: tools: work panicked: boom" {
+		t.Errorf("Message().Content = %q", got)
+	}
+
+	// Canceling the caller's context is not a timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tool = &Tool{Name: "work", Timeout: time.Second, Handler: func(ctx context.Context, arguments string) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}}
+	if _, err := tool.Call(ctx, ""); !errors.Is(err, context.Canceled) || errors.Is(err, ErrTimeout) {
+		t.Errorf("Call() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aiwizzard/gollm/jsonschema"
+)
+
+// ErrInvalidArguments is returned when the arguments of a tool call do not
+// match the parameters of the tool
+var ErrInvalidArguments = errors.New("tools: invalid arguments")
+
+// ArgumentError describes the arguments of a tool call that do not match the
+// parameters of the tool. Its message lists the problems for the model, so
+// that a call rejected through Result.Message can be retried with fixed
+// arguments.
+type ArgumentError struct {
+	Tool     string
+	Problems []string
+}
+
+func (e *ArgumentError) Error() string {
+	return fmt.Sprintf("tools: invalid arguments for %s: %s", e.Tool, strings.Join(e.Problems, "; "))
+}
+
+// Is makes errors.Is(err, ErrInvalidArguments) match argument errors
+func (e *ArgumentError) Is(target error) bool {
+	return target == ErrInvalidArguments
+}
+
+// Validate checks the JSON arguments of a tool call against the parameters
+// of the tool, RawParameters if set, returning an *ArgumentError listing
+// every problem found. Empty arguments are checked as an empty object. Tools
+// without parameters accept any arguments.
+func (t *Tool) Validate(arguments string) error {
+	var schema any
+	switch {
+	case len(t.RawParameters) > 0:
+		schema = t.RawParameters
+	case t.Parameters != nil:
+		schema = t.Parameters
+	default:
+		return nil
+	}
+	if strings.TrimSpace(arguments) == "" {
+		arguments = "{}"
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(arguments))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return &ArgumentError{Tool: t.Name, Problems: []string{"arguments are not valid JSON: " + err.Error()}}
+	}
+	if decoder.More() {
+		return &ArgumentError{Tool: t.Name, Problems: []string{"arguments are not a single JSON value"}}
+	}
+
+	if problems := validate(schema, value); len(problems) > 0 {
+		return &ArgumentError{Tool: t.Name, Problems: problems}
+	}
+	return nil
+}
+
+// Validate checks a JSON value decoded with json.Decoder.UseNumber against
+// the schema and returns the problems found, each prefixed with the path of
+// the offending value. See package jsonschema for the keywords checked.
+func (s *Schema) Validate(value any) []string {
+	return validate(s, value)
+}
+
+// validate checks value against schema, a *Schema or raw JSON
+func validate(schema, value any) []string {
+	found, err := jsonschema.Validate(schema, value)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	problems := make([]string, len(found))
+	for i, problem := range found {
+		problems[i] = at(problem.Path, problem.Message)
+	}
+	return problems
+}
+
+// at prefixes problem with path, the arguments themselves if empty
+func at(path, problem string) string {
+	if path == "" {
+		path = "arguments"
+	}
+	return path + ": " + problem
+}
@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aiwizzard/gollm/llm"
+)
+
+func TestTool_Validate(t *testing.T) {
+	tool := &Tool{Name: "book", Parameters: SchemaFor[BookingParams]()}
+	valid := `"guest": "Ada", "nights": 2, "class": "business", "smoking": false,
+		"arrival": "2024-05-01T15:00:00Z", "address": {"city": "Paris"}, "Default": ""`
+
+	tests := []struct {
+		name      string
+		arguments string
+		want      []string
+	}{
+		{name: "valid", arguments: `{` + valid + `}`},
+		{
+			name:      "optional properties",
+			arguments: `{` + valid + `, "rooms": 1, "floor": 2, "rate": 99.5, "tags": ["quiet"], "extras": {"breakfast": 2}, "notes": [1, "a"]}`,
+		},
+		{
+			name:      "null optional properties",
+			arguments: `{` + valid + `, "rooms": null, "tags": null, "nights": 2.0}`,
+		},
+		{
+			name:      "missing properties",
+			arguments: `{"guest": "Ada"}`,
+			want: []string{
+				"nights: missing required property",
+				"class: missing required property",
+				"smoking: missing required property",
+				"arrival: missing required property",
+				"address: missing required property",
+				"Default: missing required property",
+			},
+		},
+		{
+			name:      "wrong values",
+			arguments: `{` + valid + `, "nights": 2.5, "floor": 4, "rate": "high", "tags": ["a", 1], "extras": {"spa": true}, "guest": null, "view": "sea"}`,
+			want: []string{
+				`extras.spa: must be an integer`,
+				`floor: must be one of 1, 2, 3`,
+				`guest: must be a string, got null`,
+				`nights: must be an integer`,
+				`rate: must be a number`,
+				`tags[1]: must be a string`,
+				`view: unknown property`,
+			},
+		},
+		{
+			name:      "nested object",
+			arguments: `{` + valid + `, "address": {"country": "FR", "zip": 75001}, "class": "first", "arrival": "tomorrow"}`,
+			want: []string{
+				"address.city: missing required property",
+				"address.zip: unknown property",
+				"arrival: must be an RFC 3339 date-time",
+				`class: must be one of "economy", "business"`,
+			},
+		},
+		{name: "not an object", arguments: `["Ada"]`, want: []string{"arguments: must be an object"}},
+		{name: "invalid JSON", arguments: `{"guest": `, want: []string{"arguments are not valid JSON: unexpected EOF"}},
+		{name: "trailing data", arguments: `{` + valid + `} {}`, want: []string{"arguments are not a single JSON value"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tool.Validate(tt.arguments)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+
+			var argErr *ArgumentError
+			if !errors.As(err, &argErr) || !errors.Is(err, ErrInvalidArguments) {
+				t.Fatalf("Validate() error = %v, want an *ArgumentError", err)
+			}
+			if argErr.Tool != "book" || !reflect.DeepEqual(argErr.Problems, tt.want) {
+				t.Errorf("Problems = %q, want %q", argErr.Problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestTool_CallRejectsInvalidArguments(t *testing.T) {
+	called := false
+	tool := New("get_weather", "Get the weather", func(ctx context.Context, params WeatherParams) (string, error) {
+		called = true
+		return "sunny", nil
+	})
+	registry := NewRegistry(tool)
+
+	results := registry.CallAll(context.Background(), []llm.ToolCall{toolCall("get_weather", `{"unit": "C"}`)}, 1)
+	if called {
+		t.Error("handler called with invalid arguments")
+	}
+	if !errors.Is(results[0].Err, ErrInvalidArguments) {
+		t.Fatalf("Err = %v, want %v", results[0].Err, ErrInvalidArguments)
+	}
+
+	want := "Error: tools: invalid arguments for get_weather: location: missing required property"
+	if got := results[0].Message().Content; got != want {
+		t.Errorf("Message().Content = %q, want %q", got, want)
+	}
+
+	// Tools without parameters accept anything
+	free := &Tool{Name: "echo", Handler: func(ctx context.Context, arguments string) (string, error) {
+		return arguments, nil
+	}}
+	if got, err := free.Call(context.Background(), "not json"); err != nil || got != "not json" {
+		t.Errorf("Call() = %q, %v", got, err)
+	}
+}
+
+func TestTool_ValidateRawParameters(t *testing.T) {
+	tool := &Tool{
+		Name: "paint",
+		RawParameters: []byte(`{
+			"type": "object",
+			"required": ["color"],
+			"properties": {
+				"color": {"enum": [{"rgb": [255, 0, 0]}, "red"]},
+				"note": {"type": ["string", "null"]},
+				"size": {"anyOf": [{"type": "integer"}, {"$ref": "#/definitions/size"}]}
+			}
+		}`),
+		Handler: func(ctx context.Context, arguments string) (string, error) {
+			return "painted", nil
+		},
+	}
+
+	if got := tool.Definition().Function.Parameters; !reflect.DeepEqual(got, tool.RawParameters) {
+		t.Errorf("Definition() parameters = %v, want the raw schema", got)
+	}
+	if got, err := tool.Call(context.Background(), `{"color": {"rgb": [255, 0, 0]}, "note": null, "size": "large"}`); err != nil || got != "painted" {
+		t.Errorf("Call() = %q, %v", got, err)
+	}
+
+	// Objects in enums are compared by value rather than panicking
+	_, err := tool.Call(context.Background(), `{"color": {"rgb": [0, 0, 255]}}`)
+	want := `tools: invalid arguments for paint: color: must be one of {"rgb":[255,0,0]}, "red"`
+	if err == nil || err.Error() != want {
+		t.Errorf("Call() error = %v, want %q", err, want)
+	}
+}